@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -19,8 +21,13 @@ type DbConfig struct {
 	Driver     string
 }
 
+// ApiConfig holds the HTTP settings. Forwarded client addresses are only
+// believed from TrustedProxies; with none, the connecting address is the
+// client's.
 type ApiConfig struct {
-	ApiPort string
+	ApiPort        string
+	BaseURL        string
+	TrustedProxies []string
 }
 
 type TokenConfig struct {
@@ -28,12 +35,94 @@ type TokenConfig struct {
 	SignatureKey  []byte
 	SigningMethod *jwt.SigningMethodHMAC
 	ExpiresTime   time.Duration
+	// LinkExpiresTime is how long the signed links sent by email, such as
+	// guest donation and email verification links, stay valid.
+	LinkExpiresTime time.Duration
+}
+
+type MailConfig struct {
+	SmtpHost     string
+	SmtpPort     string
+	SmtpUsername string
+	SmtpPassword string
+	MailFrom     string
+}
+
+type MidtransConfig struct {
+	ServerKey   string
+	ClientKey   string
+	Environment string
+}
+
+type PaymentConfig struct {
+	Provider      string
+	FakeServerKey string
+}
+
+// FeeConfig holds the fee rules. The platform fee is in basis points and the
+// gateway fees are fixed amounts in minor units of a currency, keyed by the
+// currency code and then by the gateway's payment type.
+type FeeConfig struct {
+	PlatformFeeBasisPoints int
+	GatewayFees            map[string]map[string]int
+	DefaultGatewayFees     map[string]int
+	DonorCoversFees        bool
+}
+
+// StorageConfig is where generated documents such as receipts are stored.
+type StorageConfig struct {
+	BlobDir string
+}
+
+// ReceiptConfig controls donation receipts. Receipt numbers start again at 1
+// in every fiscal year, which begins in FiscalYearStart.
+type ReceiptConfig struct {
+	Issuer          string
+	FiscalYearStart time.Month
+}
+
+// RiskConfig limits how fast donations can be made and when they are held
+// for review. Donors and IP addresses may start at most MaxPerDonor and
+// MaxPerIP donations within VelocityWindow. A donation scoring ReviewScore or
+// more is held until an admin approves it.
+type RiskConfig struct {
+	VelocityWindow time.Duration
+	MaxPerDonor    int
+	MaxPerIP       int
+	ReviewScore    int
+}
+
+type SchedulerConfig struct {
+	TrendingInterval     time.Duration
+	PurgeInterval        time.Duration
+	SoftDeleteRetention  time.Duration
+	IdempotencyRetention time.Duration
+	LedgerCheckInterval  time.Duration
+	RecurringInterval    time.Duration
+	// RecurringRetryDelays are the waits before each retry of a failed
+	// recurring charge. The plan is cancelled once they are used up.
+	RecurringRetryDelays []time.Duration
+	ReconcileInterval    time.Duration
+	// ReconcileAfter is how long a transaction stays pending before the
+	// reconciler asks the gateway for its status. A pending transaction the
+	// gateway has never heard of expires once PaymentWindow has passed.
+	ReconcileAfter  time.Duration
+	PaymentWindow   time.Duration
+	ReceiptInterval time.Duration
 }
 
 type Config struct {
 	DbConfig
 	ApiConfig
 	TokenConfig
+	MailConfig
+	MidtransConfig
+	PaymentConfig
+	FeeConfig
+	StorageConfig
+	ReceiptConfig
+	RiskConfig
+	SchedulerConfig
 }
 
 func (c *Config) Configuration() error {
@@ -50,15 +139,210 @@ func (c *Config) Configuration() error {
 		Driver:     os.Getenv("DB_DRIVER"),
 	}
 
-	c.ApiConfig = ApiConfig{ApiPort: os.Getenv("API_PORT")}
+	c.ApiConfig = ApiConfig{
+		ApiPort: os.Getenv("API_PORT"),
+		BaseURL: os.Getenv("API_BASE_URL"),
+	}
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			c.TrustedProxies = append(c.TrustedProxies, proxy)
+		}
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = "http://localhost:" + c.ApiPort
+	}
 
 	tokenExpire, _ := strconv.Atoi(os.Getenv("TOKEN_EXPIRE"))
 
+	linkExpireHours, err := strconv.Atoi(os.Getenv("LINK_TOKEN_EXPIRE_HOURS"))
+	if err != nil || linkExpireHours <= 0 {
+		linkExpireHours = 30 * 24
+	}
+
 	c.TokenConfig = TokenConfig{
-		IssuerName:    os.Getenv("TOKEN_ISSUE"),
-		SignatureKey:  []byte(os.Getenv("TOKEN_SECRET")),
-		SigningMethod: jwt.SigningMethodHS256,
-		ExpiresTime:   time.Duration(tokenExpire) * time.Minute,
+		IssuerName:      os.Getenv("TOKEN_ISSUE"),
+		SignatureKey:    []byte(os.Getenv("TOKEN_SECRET")),
+		SigningMethod:   jwt.SigningMethodHS256,
+		ExpiresTime:     time.Duration(tokenExpire) * time.Minute,
+		LinkExpiresTime: time.Duration(linkExpireHours) * time.Hour,
+	}
+
+	c.MailConfig = MailConfig{
+		SmtpHost:     os.Getenv("MAIL_HOST"),
+		SmtpPort:     os.Getenv("MAIL_PORT"),
+		SmtpUsername: os.Getenv("MAIL_USERNAME"),
+		SmtpPassword: os.Getenv("MAIL_PASSWORD"),
+		MailFrom:     os.Getenv("MAIL_FROM"),
+	}
+
+	c.MidtransConfig = MidtransConfig{
+		ServerKey:   os.Getenv("MIDTRANS_SERVER_KEY"),
+		ClientKey:   os.Getenv("MIDTRANS_CLIENT_KEY"),
+		Environment: os.Getenv("MIDTRANS_ENV"),
+	}
+	if c.Environment == "" {
+		c.Environment = "sandbox"
+	}
+
+	c.PaymentConfig = PaymentConfig{
+		Provider:      os.Getenv("PAYMENT_PROVIDER"),
+		FakeServerKey: os.Getenv("FAKE_GATEWAY_SERVER_KEY"),
+	}
+	if c.Provider == "" {
+		c.Provider = "midtrans"
+	}
+	if c.FakeServerKey == "" {
+		c.FakeServerKey = "fake-gateway-server-key"
+	}
+
+	platformFeePercent, err := strconv.ParseFloat(os.Getenv("PLATFORM_FEE_PERCENT"), 64)
+	if err != nil || platformFeePercent < 0 || platformFeePercent > 100 {
+		platformFeePercent = 0
+	}
+
+	// GATEWAY_FEES and GATEWAY_FEE_DEFAULT are in rupiah. Fees charged in
+	// other currencies are set with the currency code appended, such as
+	// GATEWAY_FEES_USD.
+	gatewayFees := map[string]map[string]int{}
+	defaultGatewayFees := map[string]int{}
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		switch {
+		case name == "GATEWAY_FEES" || strings.HasPrefix(name, "GATEWAY_FEES_"):
+			fees, err := parseGatewayFees(name, value)
+			if err != nil {
+				return err
+			}
+			gatewayFees[feeCurrency(name, "GATEWAY_FEES")] = fees
+		case name == "GATEWAY_FEE_DEFAULT" || strings.HasPrefix(name, "GATEWAY_FEE_DEFAULT_"):
+			fee, err := strconv.Atoi(value)
+			if err != nil || fee < 0 {
+				fee = 0
+			}
+			defaultGatewayFees[feeCurrency(name, "GATEWAY_FEE_DEFAULT")] = fee
+		}
+	}
+
+	donorCoversFees, _ := strconv.ParseBool(os.Getenv("DONOR_COVERS_FEES"))
+
+	c.FeeConfig = FeeConfig{
+		PlatformFeeBasisPoints: int(math.Round(platformFeePercent * 100)),
+		GatewayFees:            gatewayFees,
+		DefaultGatewayFees:     defaultGatewayFees,
+		DonorCoversFees:        donorCoversFees,
+	}
+
+	c.StorageConfig = StorageConfig{BlobDir: os.Getenv("BLOB_STORAGE_DIR")}
+	if c.BlobDir == "" {
+		c.BlobDir = "storage"
+	}
+
+	fiscalYearStart, err := strconv.Atoi(os.Getenv("FISCAL_YEAR_START_MONTH"))
+	if err != nil || fiscalYearStart < 1 || fiscalYearStart > 12 {
+		fiscalYearStart = 1
+	}
+	c.ReceiptConfig = ReceiptConfig{
+		Issuer:          os.Getenv("RECEIPT_ISSUER"),
+		FiscalYearStart: time.Month(fiscalYearStart),
+	}
+	if c.Issuer == "" {
+		c.Issuer = "Eternal Fund"
+	}
+
+	velocityWindow, err := strconv.Atoi(os.Getenv("VELOCITY_WINDOW_MINUTES"))
+	if err != nil || velocityWindow <= 0 {
+		velocityWindow = 60
+	}
+	maxPerDonor, err := strconv.Atoi(os.Getenv("VELOCITY_MAX_PER_DONOR"))
+	if err != nil || maxPerDonor <= 0 {
+		maxPerDonor = 5
+	}
+	maxPerIP, err := strconv.Atoi(os.Getenv("VELOCITY_MAX_PER_IP"))
+	if err != nil || maxPerIP <= 0 {
+		maxPerIP = 20
+	}
+	reviewScore, err := strconv.Atoi(os.Getenv("RISK_REVIEW_SCORE"))
+	if err != nil || reviewScore <= 0 {
+		reviewScore = 60
+	}
+	c.RiskConfig = RiskConfig{
+		VelocityWindow: time.Duration(velocityWindow) * time.Minute,
+		MaxPerDonor:    maxPerDonor,
+		MaxPerIP:       maxPerIP,
+		ReviewScore:    reviewScore,
+	}
+
+	trendingInterval, err := strconv.Atoi(os.Getenv("TRENDING_REFRESH_MINUTES"))
+	if err != nil {
+		trendingInterval = 10
+	}
+
+	purgeInterval, err := strconv.Atoi(os.Getenv("PURGE_INTERVAL_MINUTES"))
+	if err != nil {
+		purgeInterval = 60
+	}
+
+	retentionDays, err := strconv.Atoi(os.Getenv("SOFT_DELETE_RETENTION_DAYS"))
+	if err != nil || retentionDays <= 0 {
+		retentionDays = 30
+	}
+
+	idempotencyHours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_RETENTION_HOURS"))
+	if err != nil || idempotencyHours <= 0 {
+		idempotencyHours = 24
+	}
+
+	ledgerCheckInterval, err := strconv.Atoi(os.Getenv("LEDGER_CHECK_MINUTES"))
+	if err != nil {
+		ledgerCheckInterval = 60
+	}
+
+	recurringInterval, err := strconv.Atoi(os.Getenv("RECURRING_BILLING_MINUTES"))
+	if err != nil {
+		recurringInterval = 15
+	}
+
+	reconcileInterval, err := strconv.Atoi(os.Getenv("RECONCILE_MINUTES"))
+	if err != nil {
+		reconcileInterval = 30
+	}
+
+	reconcileAfter, err := strconv.Atoi(os.Getenv("RECONCILE_AFTER_MINUTES"))
+	if err != nil || reconcileAfter <= 0 {
+		reconcileAfter = 30
+	}
+
+	paymentWindowHours, err := strconv.Atoi(os.Getenv("PAYMENT_WINDOW_HOURS"))
+	if err != nil || paymentWindowHours <= 0 {
+		paymentWindowHours = 24
+	}
+
+	receiptInterval, err := strconv.Atoi(os.Getenv("RECEIPT_MINUTES"))
+	if err != nil {
+		receiptInterval = 5
+	}
+
+	retryDays := os.Getenv("RECURRING_RETRY_DAYS")
+	if retryDays == "" {
+		retryDays = "1,3,7"
+	}
+	recurringRetryDelays, err := parseRetryDays(retryDays)
+	if err != nil {
+		return err
+	}
+
+	c.SchedulerConfig = SchedulerConfig{
+		TrendingInterval:     time.Duration(trendingInterval) * time.Minute,
+		PurgeInterval:        time.Duration(purgeInterval) * time.Minute,
+		SoftDeleteRetention:  time.Duration(retentionDays) * 24 * time.Hour,
+		IdempotencyRetention: time.Duration(idempotencyHours) * time.Hour,
+		LedgerCheckInterval:  time.Duration(ledgerCheckInterval) * time.Minute,
+		RecurringInterval:    time.Duration(recurringInterval) * time.Minute,
+		RecurringRetryDelays: recurringRetryDelays,
+		ReconcileInterval:    time.Duration(reconcileInterval) * time.Minute,
+		ReconcileAfter:       time.Duration(reconcileAfter) * time.Minute,
+		PaymentWindow:        time.Duration(paymentWindowHours) * time.Hour,
+		ReceiptInterval:      time.Duration(receiptInterval) * time.Minute,
 	}
 
 	if c.Host == "" || c.DbPort == "" || c.DbUser == "" ||
@@ -69,6 +353,46 @@ func (c *Config) Configuration() error {
 	return nil
 }
 
+// feeCurrency is the currency code a fee variable is for: what follows name's
+// prefix, or IDR when nothing does.
+func feeCurrency(name string, prefix string) string {
+	if code := strings.TrimPrefix(strings.TrimPrefix(name, prefix), "_"); code != "" {
+		return strings.ToUpper(code)
+	}
+	return "IDR"
+}
+
+// parseGatewayFees reads a list such as "bank_transfer:4000,gopay:1500" from
+// the variable called name.
+func parseGatewayFees(name string, value string) (map[string]int, error) {
+	fees := map[string]int{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		method, amount, found := strings.Cut(pair, ":")
+		fee, err := strconv.Atoi(strings.TrimSpace(amount))
+		if !found || err != nil || fee < 0 {
+			return nil, fmt.Errorf("invalid %s entry %q", name, pair)
+		}
+		fees[strings.TrimSpace(method)] = fee
+	}
+	return fees, nil
+}
+
+// parseRetryDays reads a list of days such as "1,3,7".
+func parseRetryDays(value string) ([]time.Duration, error) {
+	var delays []time.Duration
+	for _, item := range strings.Split(value, ",") {
+		days, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid RECURRING_RETRY_DAYS entry %q", item)
+		}
+		delays = append(delays, time.Duration(days)*24*time.Hour)
+	}
+	return delays, nil
+}
+
 func NewConfig() (*Config, error) {
 	config := &Config{}
 
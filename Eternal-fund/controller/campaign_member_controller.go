@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"eternal-fund/middleware"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type campaignMemberController struct {
+	memberUseCase  usecase.CampaignMemberUseCase
+	router         *gin.RouterGroup
+	authMiddleware middleware.AuthMiddleware
+}
+
+func memberErrorCode(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrCampaignForbidden), errors.Is(err, usecase.ErrInvitationEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrInvitationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrAlreadyMember), errors.Is(err, usecase.ErrLastOwner):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (mc *campaignMemberController) inviteMemberHandler(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	var input model.InviteMemberInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.CampaignID = campaignID
+	input.User = model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+
+	member, err := mc.memberUseCase.InviteMember(input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, memberErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, member, "Invitation sent successfully")
+}
+
+func (mc *campaignMemberController) getMembersHandler(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	user := model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+	members, err := mc.memberUseCase.ListMembers(campaignID, user)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, memberErrorCode(err), err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, member := range members {
+		data = append(data, member)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Campaign members retrieved successfully")
+}
+
+func (mc *campaignMemberController) removeMemberHandler(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+	memberID, err := strconv.Atoi(ctx.Param("member_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid member ID")
+		return
+	}
+
+	user := model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+	if err := mc.memberUseCase.RemoveMember(campaignID, memberID, user); err != nil {
+		commonresponse.SendErrorResponse(ctx, memberErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, nil, "Campaign member removed successfully")
+}
+
+func (mc *campaignMemberController) getInvitationsHandler(ctx *gin.Context) {
+	invitations, err := mc.memberUseCase.ListInvitations(ctx.GetInt("userID"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, invitation := range invitations {
+		data = append(data, invitation)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Invitations retrieved successfully")
+}
+
+func (mc *campaignMemberController) acceptInvitationHandler(ctx *gin.Context) {
+	invitationID, err := strconv.Atoi(ctx.Param("invitation_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	member, err := mc.memberUseCase.AcceptInvitation(invitationID, ctx.GetInt("userID"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, memberErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, member, "Invitation accepted successfully")
+}
+
+func (mc *campaignMemberController) declineInvitationHandler(ctx *gin.Context) {
+	invitationID, err := strconv.Atoi(ctx.Param("invitation_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	member, err := mc.memberUseCase.DeclineInvitation(invitationID, ctx.GetInt("userID"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, memberErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, member, "Invitation declined successfully")
+}
+
+func (mc *campaignMemberController) Routing() {
+	mc.router.POST("/campaigns/:campaign_id/members", mc.authMiddleware.CheckToken("user", "admin"), mc.inviteMemberHandler)
+	mc.router.GET("/campaigns/:campaign_id/members", mc.authMiddleware.CheckToken("user", "admin"), mc.getMembersHandler)
+	mc.router.DELETE("/campaigns/:campaign_id/members/:member_id", mc.authMiddleware.CheckToken("user", "admin"), mc.removeMemberHandler)
+	mc.router.GET("/invitations", mc.authMiddleware.CheckToken("user", "admin"), mc.getInvitationsHandler)
+	mc.router.POST("/invitations/:invitation_id/accept", mc.authMiddleware.CheckToken("user", "admin"), mc.acceptInvitationHandler)
+	mc.router.POST("/invitations/:invitation_id/decline", mc.authMiddleware.CheckToken("user", "admin"), mc.declineInvitationHandler)
+}
+
+func NewCampaignMemberController(memberUseCase usecase.CampaignMemberUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *campaignMemberController {
+	return &campaignMemberController{
+		memberUseCase:  memberUseCase,
+		router:         rg,
+		authMiddleware: authMiddleware,
+	}
+}
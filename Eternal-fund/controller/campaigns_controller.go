@@ -1,34 +1,60 @@
 package controller
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"eternal-fund/middleware"
 	"eternal-fund/model"
+	"eternal-fund/model/dto"
 	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/repository"
 	"eternal-fund/usecase"
+	"eternal-fund/usecase/service"
 
 	"github.com/gin-gonic/gin"
 )
 
+// campaignBySlugPath is where a campaign is found by slug, relative to the
+// router group. Old slugs redirect to it.
+const campaignBySlugPath = "/campaigns/by-slug/"
+
 type campaignController struct {
 	campaignUseCase usecase.CampaignsUseCase
 	router          *gin.RouterGroup
 	authMiddleware  middleware.AuthMiddleware
 }
 
+func campaignErrorCode(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrCampaignHasDonations), errors.Is(err, repository.ErrDuplicateSlug):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrUnsupportedCurrency), errors.Is(err, service.ErrUnsupportedCurrency), errors.Is(err, model.ErrInvalidDonationLimits):
+		return http.StatusBadRequest
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	default:
+		return memberErrorCode(err)
+	}
+}
+
 func (cc *campaignController) createCampaignHandler(ctx *gin.Context) {
 	var input model.Campaigns
 	if err := ctx.ShouldBindJSON(&input); err != nil {
 		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
+	if userID, exists := ctx.Get("userID"); exists {
+		input.User_id = userID.(int)
+	}
 
 	campaign, err := cc.campaignUseCase.CreateCampaigns(input)
 	if err != nil {
-		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		commonresponse.SendErrorResponse(ctx, campaignErrorCode(err), err.Error())
 		return
 	}
 
@@ -66,7 +92,7 @@ func (cc *campaignController) getCampaignsHandler(ctx *gin.Context) {
 }
 
 func (cc *campaignController) getCampaignByIdHandler(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Param("id"))
+	id, err := strconv.Atoi(ctx.Param("campaign_id"))
 	if err != nil {
 		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
 		return
@@ -85,15 +111,53 @@ func (cc *campaignController) getCampaignByIdHandler(ctx *gin.Context) {
 	commonresponse.SendSingleResponse(ctx, campaign, "Campaign retrieved successfully")
 }
 
+func (cc *campaignController) getCampaignBySlugHandler(ctx *gin.Context) {
+	campaignSlug := ctx.Param("slug")
+
+	campaign, err := cc.campaignUseCase.FindBySlug(campaignSlug)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if campaign.Slug != campaignSlug {
+		location := cc.router.BasePath() + campaignBySlugPath + url.PathEscape(campaign.Slug)
+		ctx.Header("Location", location)
+		ctx.JSON(http.StatusMovedPermanently, &dto.SingleResponse{
+			Status: dto.Status{
+				Code:    http.StatusMovedPermanently,
+				Message: "Campaign slug has changed",
+			},
+			Data: gin.H{"slug": campaign.Slug, "location": location},
+		})
+		return
+	}
+
+	campaign.User.PasswordHash = ""
+	campaign.User.CreatedAt = time.Time{}
+	campaign.User.UpdatedAt = time.Time{}
+
+	commonresponse.SendSingleResponse(ctx, campaign, "Campaign retrieved successfully")
+}
+
 func (cc *campaignController) updateCampaignHandler(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Param("id"))
+	id, err := strconv.Atoi(ctx.Param("campaign_id"))
 	if err != nil {
 		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
 		return
 	}
-	updatedCampaign, err := cc.campaignUseCase.UpdateCampaigns(id)
+
+	var input model.UpdateCampaignInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.User.ID = ctx.GetInt("userID")
+	input.User.Role = ctx.GetString("role")
+
+	updatedCampaign, err := cc.campaignUseCase.UpdateCampaigns(id, input)
 	if err != nil {
-		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		commonresponse.SendErrorResponse(ctx, memberErrorCode(err), err.Error())
 		return
 	}
 	updatedCampaign.User.PasswordHash = ""
@@ -103,24 +167,131 @@ func (cc *campaignController) updateCampaignHandler(ctx *gin.Context) {
 }
 
 func (cc *campaignController) deleteCampaignHandler(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Param("id"))
+	id, err := strconv.Atoi(ctx.Param("campaign_id"))
 	if err != nil {
 		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
 		return
 	}
 
-	err = cc.campaignUseCase.DeleteCampaigns(id)
+	user := model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+	err = cc.campaignUseCase.DeleteCampaigns(id, user)
 	if err != nil {
-		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		commonresponse.SendErrorResponse(ctx, campaignErrorCode(err), err.Error())
 		return
 	}
 
 	commonresponse.SendSingleResponse(ctx, nil, "Campaign deleted successfully")
 }
 
+func (cc *campaignController) getDeletedCampaignsHandler(ctx *gin.Context) {
+	campaigns, err := cc.campaignUseCase.FindDeletedCampaigns()
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, campaign := range campaigns {
+		data = append(data, campaign)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Deleted campaigns retrieved successfully")
+}
+
+func (cc *campaignController) restoreCampaignHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	campaign, err := cc.campaignUseCase.RestoreCampaigns(id)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, campaignErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, campaign, "Campaign restored successfully")
+}
+
+func (cc *campaignController) getCampaignHistoryHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	versions, err := cc.campaignUseCase.FindHistory(id)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, campaignErrorCode(err), err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, version := range versions {
+		data = append(data, version)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Campaign history retrieved successfully")
+}
+
+func (cc *campaignController) diffCampaignVersionsHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+	from, err := strconv.Atoi(ctx.Query("from"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid from version")
+		return
+	}
+	to, err := strconv.Atoi(ctx.Query("to"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid to version")
+		return
+	}
+
+	diff, err := cc.campaignUseCase.DiffVersions(id, from, to)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, campaignErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, diff, "Campaign versions compared successfully")
+}
+
+func (cc *campaignController) rollbackCampaignHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	user := model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+	campaign, err := cc.campaignUseCase.RollbackCampaign(id, version, user)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, campaignErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, campaign, "Campaign rolled back successfully")
+}
+
 func (cc *campaignController) Routing() {
 	cc.router.POST("/campaigns", cc.authMiddleware.CheckToken("user", "admin"), cc.createCampaignHandler)
 	cc.router.GET("/campaigns", cc.getCampaignsHandler)
+	cc.router.GET(campaignBySlugPath+":slug", cc.getCampaignBySlugHandler)
+	cc.router.GET("/campaigns/deleted", cc.authMiddleware.CheckToken("admin"), cc.getDeletedCampaignsHandler)
+	cc.router.POST("/campaigns/:campaign_id/restore", cc.authMiddleware.CheckToken("admin"), cc.restoreCampaignHandler)
+	cc.router.GET("/campaigns/:campaign_id/history", cc.getCampaignHistoryHandler)
+	cc.router.GET("/campaigns/:campaign_id/history/diff", cc.authMiddleware.CheckToken("admin"), cc.diffCampaignVersionsHandler)
+	cc.router.POST("/campaigns/:campaign_id/history/:version/rollback", cc.authMiddleware.CheckToken("admin"), cc.rollbackCampaignHandler)
 	cc.router.GET("/campaigns/:campaign_id", cc.authMiddleware.CheckToken("user", "admin"), cc.getCampaignByIdHandler)
 	cc.router.PUT("/campaigns/:campaign_id", cc.authMiddleware.CheckToken("user", "admin"), cc.updateCampaignHandler)
 	cc.router.DELETE("/campaigns/:campaign_id", cc.authMiddleware.CheckToken("user", "admin"), cc.deleteCampaignHandler)
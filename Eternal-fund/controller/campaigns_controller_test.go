@@ -92,7 +92,7 @@ func (suite *CampaignsControllerTestSuite) TestListHandler_fail() {
 
 func (suite *CampaignsControllerTestSuite) TestDeleteCampaigns_success() {
 	mockCampaignID := 1
-	suite.aum.On("DeleteCampaigns", mockCampaignID).Return(nil)
+	suite.aum.On("DeleteCampaigns", mockCampaignID, mock.AnythingOfType("model.User")).Return(nil)
 	campaignController := NewCampaignsController(suite.aum, suite.rg, suite.amm)
 	campaignController.Routing()
 	request, err := http.NewRequest(http.MethodDelete, "/api/v1/campaigns/1", nil)
@@ -100,7 +100,7 @@ func (suite *CampaignsControllerTestSuite) TestDeleteCampaigns_success() {
 	record := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(record)
 	ctx.Request = request
-	ctx.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+	ctx.Params = gin.Params{gin.Param{Key: "campaign_id", Value: "1"}}
 	campaignController.deleteCampaignHandler(ctx)
 	assert.Equal(suite.T(), http.StatusOK, record.Code)
 	var response dto.SingleResponse
@@ -135,7 +135,7 @@ func (suite *CampaignsControllerTestSuite) TestByIdCampaigns_success() {
 	ctx, _ := gin.CreateTestContext(record)
 	ctx.Request = request
 	ctx.Params = gin.Params{{
-		Key: "id", Value: "1"},
+		Key: "campaign_id", Value: "1"},
 	}
 	campaignController.getCampaignByIdHandler(ctx)
 	assert.Equal(suite.T(), http.StatusOK, record.Code)
@@ -162,7 +162,7 @@ func (suite *CampaignsControllerTestSuite) TestUpdateCampaignHandler_success() {
 			Role:         "user",
 		},
 	}
-	suite.aum.On("UpdateCampaigns", mock.AnythingOfType("int")).Return(mockUpdatedCampaign, nil)
+	suite.aum.On("UpdateCampaigns", mock.AnythingOfType("int"), mock.AnythingOfType("model.UpdateCampaignInput")).Return(mockUpdatedCampaign, nil)
 	campaignController := NewCampaignsController(suite.aum, suite.rg, suite.amm)
 	campaignController.Routing()
 	updatePayload := []byte(`{
@@ -180,7 +180,7 @@ func (suite *CampaignsControllerTestSuite) TestUpdateCampaignHandler_success() {
 	record := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(record)
 	ctx.Request = request
-	ctx.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+	ctx.Params = gin.Params{gin.Param{Key: "campaign_id", Value: "1"}}
 	campaignController.updateCampaignHandler(ctx)
 	assert.Equal(suite.T(), http.StatusOK, record.Code)
 	var response dto.SingleResponse
@@ -268,6 +268,20 @@ func (suite *CampaignsControllerTestSuite) TestCreateCampaignHandler_success() {
 	mockCampaignUseCase.AssertExpectations(suite.T())
 }
 
+func (suite *CampaignsControllerTestSuite) TestGetCampaignBySlug_oldSlugRedirects() {
+	suite.aum.On("FindBySlug", "old-name").Return(model.Campaigns{ID: 1, Slug: "new-name"}, nil)
+	campaignController := NewCampaignsController(suite.aum, suite.rg, suite.amm)
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/campaigns/by-slug/old-name", nil)
+	assert.NoError(suite.T(), err)
+	record := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(record)
+	ctx.Request = request
+	ctx.Params = gin.Params{gin.Param{Key: "slug", Value: "old-name"}}
+	campaignController.getCampaignBySlugHandler(ctx)
+	assert.Equal(suite.T(), http.StatusMovedPermanently, record.Code)
+	assert.Equal(suite.T(), "/api/v1/campaigns/by-slug/new-name", record.Header().Get("Location"))
+}
+
 func TestAuthoRepoTestSuite(t *testing.T) {
 	suite.Run(t, new(CampaignsControllerTestSuite))
 }
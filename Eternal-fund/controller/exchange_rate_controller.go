@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"eternal-fund/middleware"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type exchangeRateController struct {
+	exchangeRateUseCase usecase.ExchangeRateUseCase
+	router              *gin.RouterGroup
+	authMiddleware      middleware.AuthMiddleware
+}
+
+func exchangeRateErrorCode(err error) int {
+	switch {
+	case errors.Is(err, model.ErrUnsupportedCurrency), errors.Is(err, model.ErrInvalidExchangeRate),
+		errors.Is(err, usecase.ErrSameCurrency):
+		return http.StatusBadRequest
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (ec *exchangeRateController) getRatesHandler(ctx *gin.Context) {
+	rates, err := ec.exchangeRateUseCase.GetRates()
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, rate := range rates {
+		data = append(data, rate)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Exchange rates retrieved successfully")
+}
+
+func (ec *exchangeRateController) saveRateHandler(ctx *gin.Context) {
+	var input model.ExchangeRateInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.User = contextUser(ctx)
+
+	rate, err := ec.exchangeRateUseCase.SaveRate(input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, exchangeRateErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, rate, "Exchange rate saved successfully")
+}
+
+func (ec *exchangeRateController) deleteRateHandler(ctx *gin.Context) {
+	if err := ec.exchangeRateUseCase.DeleteRate(ctx.Param("base"), ctx.Param("quote")); err != nil {
+		commonresponse.SendErrorResponse(ctx, exchangeRateErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, nil, "Exchange rate deleted successfully")
+}
+
+func (ec *exchangeRateController) Routing() {
+	ec.router.GET("/exchange-rates", ec.getRatesHandler)
+	ec.router.PUT("/exchange-rates", ec.authMiddleware.CheckToken("admin"), ec.saveRateHandler)
+	ec.router.DELETE("/exchange-rates/:base/:quote", ec.authMiddleware.CheckToken("admin"), ec.deleteRateHandler)
+}
+
+func NewExchangeRateController(exchangeRateUseCase usecase.ExchangeRateUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *exchangeRateController {
+	return &exchangeRateController{
+		exchangeRateUseCase: exchangeRateUseCase,
+		router:              rg,
+		authMiddleware:      authMiddleware,
+	}
+}
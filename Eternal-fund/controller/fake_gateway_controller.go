@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"errors"
+	"eternal-fund/usecase/service"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var fakeCheckoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Fake gateway - order {{.Charge.OrderID}}</title></head>
+<body>
+<h1>Fake payment gateway</h1>
+<p>Order {{.Charge.OrderID}} for {{.Charge.Name}} &lt;{{.Charge.Email}}&gt;</p>
+<p>Amount: {{.Charge.Amount}}</p>
+<p>Status: {{.Charge.Status}}</p>
+{{if .Message}}<p><strong>{{.Message}}</strong></p>{{end}}
+{{if eq .Charge.Status "pending"}}
+<form method="POST">
+<button name="status" value="settlement">Pay</button>
+<button name="status" value="deny">Decline</button>
+<button name="status" value="expire">Expire</button>
+<button name="status" value="cancel">Cancel</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type fakeGatewayController struct {
+	gateway service.FakeGateway
+	router  *gin.RouterGroup
+}
+
+func (fc *fakeGatewayController) render(ctx *gin.Context, status int, orderID string, message string) {
+	charge, err := fc.gateway.FindCharge(orderID)
+	if errors.Is(err, service.ErrChargeNotFound) {
+		ctx.String(http.StatusNotFound, "charge not found")
+		return
+	}
+
+	ctx.Status(status)
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	if err := fakeCheckoutPage.Execute(ctx.Writer, gin.H{"Charge": charge, "Message": message}); err != nil {
+		log.Printf("Error rendering fake checkout page: %v", err)
+	}
+}
+
+func (fc *fakeGatewayController) checkoutHandler(ctx *gin.Context) {
+	fc.render(ctx, http.StatusOK, ctx.Param("order_id"), "")
+}
+
+func (fc *fakeGatewayController) completeHandler(ctx *gin.Context) {
+	orderID := ctx.Param("order_id")
+	if err := fc.gateway.Complete(orderID, ctx.PostForm("status")); err != nil {
+		fc.render(ctx, http.StatusBadRequest, orderID, err.Error())
+		return
+	}
+	fc.render(ctx, http.StatusOK, orderID, "Payment result sent to the webhook")
+}
+
+func (fc *fakeGatewayController) Routing() {
+	fc.router.GET("/checkout/:order_id", fc.checkoutHandler)
+	fc.router.POST("/checkout/:order_id", fc.completeHandler)
+}
+
+func NewFakeGatewayController(gateway service.FakeGateway, rg *gin.RouterGroup) *fakeGatewayController {
+	return &fakeGatewayController{
+		gateway: gateway,
+		router:  rg,
+	}
+}
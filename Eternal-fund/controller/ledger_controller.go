@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"eternal-fund/middleware"
+	"eternal-fund/model"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ledgerController struct {
+	ledgerUseCase  usecase.LedgerUseCase
+	router         *gin.RouterGroup
+	authMiddleware middleware.AuthMiddleware
+}
+
+func (lc *ledgerController) getBalanceHandler(ctx *gin.Context) {
+	balance, err := lc.ledgerUseCase.GetBalance(ctx.Param("account"), ctx.Query("currency"))
+	if errors.Is(err, model.ErrUnsupportedCurrency) {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, balance, "Ledger balance retrieved successfully")
+}
+
+func (lc *ledgerController) getEntriesHandler(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid page number")
+		return
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "10"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid size number")
+		return
+	}
+
+	entries, paging, err := lc.ledgerUseCase.GetEntries(ctx.Param("account"), page, size)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, entry := range entries {
+		data = append(data, entry)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, paging, "Ledger entries retrieved successfully")
+}
+
+func (lc *ledgerController) checkHandler(ctx *gin.Context) {
+	report, err := lc.ledgerUseCase.CheckInvariants()
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	message := "Ledger is consistent"
+	if !report.OK() {
+		message = "Ledger inconsistencies found"
+	}
+	commonresponse.SendSingleResponse(ctx, report, message)
+}
+
+func (lc *ledgerController) Routing() {
+	lc.router.GET("/ledger/accounts/:account", lc.authMiddleware.CheckToken("admin"), lc.getBalanceHandler)
+	lc.router.GET("/ledger/accounts/:account/entries", lc.authMiddleware.CheckToken("admin"), lc.getEntriesHandler)
+	lc.router.GET("/ledger/check", lc.authMiddleware.CheckToken("admin"), lc.checkHandler)
+}
+
+func NewLedgerController(ledgerUseCase usecase.LedgerUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *ledgerController {
+	return &ledgerController{
+		ledgerUseCase:  ledgerUseCase,
+		router:         rg,
+		authMiddleware: authMiddleware,
+	}
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"eternal-fund/middleware"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type matchingController struct {
+	matchingUseCase usecase.MatchingUseCase
+	router          *gin.RouterGroup
+	authMiddleware  middleware.AuthMiddleware
+}
+
+func matchingErrorCode(err error) int {
+	switch {
+	case errors.Is(err, model.ErrInvalidMatchingWindow):
+		return http.StatusBadRequest
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (mc *matchingController) createPledgeHandler(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	var input model.MatchingPledgeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.CampaignID = campaignID
+	input.User = contextUser(ctx)
+
+	pledge, err := mc.matchingUseCase.CreatePledge(input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, matchingErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, pledge, "Matching pledge created successfully")
+}
+
+func (mc *matchingController) getPledgesHandler(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	pledges, err := mc.matchingUseCase.GetPledges(campaignID)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, matchingErrorCode(err), err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, pledge := range pledges {
+		data = append(data, pledge)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Matching pledges retrieved successfully")
+}
+
+func (mc *matchingController) cancelPledgeHandler(ctx *gin.Context) {
+	pledgeID, err := strconv.Atoi(ctx.Param("pledge_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid pledge ID")
+		return
+	}
+
+	pledge, err := mc.matchingUseCase.CancelPledge(pledgeID)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, matchingErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, pledge, "Matching pledge cancelled successfully")
+}
+
+func (mc *matchingController) getContributionsHandler(ctx *gin.Context) {
+	pledgeID, err := strconv.Atoi(ctx.Param("pledge_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid pledge ID")
+		return
+	}
+
+	contributions, err := mc.matchingUseCase.GetContributions(pledgeID)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, matchingErrorCode(err), err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, contribution := range contributions {
+		data = append(data, contribution)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Matched contributions retrieved successfully")
+}
+
+func (mc *matchingController) Routing() {
+	mc.router.GET("/campaigns/:campaign_id/matching-pledges", mc.getPledgesHandler)
+	mc.router.POST("/campaigns/:campaign_id/matching-pledges", mc.authMiddleware.CheckToken("admin"), mc.createPledgeHandler)
+	mc.router.DELETE("/matching-pledges/:pledge_id", mc.authMiddleware.CheckToken("admin"), mc.cancelPledgeHandler)
+	mc.router.GET("/matching-pledges/:pledge_id/contributions", mc.authMiddleware.CheckToken("admin"), mc.getContributionsHandler)
+}
+
+func NewMatchingController(matchingUseCase usecase.MatchingUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *matchingController {
+	return &matchingController{
+		matchingUseCase: matchingUseCase,
+		router:          rg,
+		authMiddleware:  authMiddleware,
+	}
+}
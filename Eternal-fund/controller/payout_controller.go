@@ -0,0 +1,287 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"eternal-fund/middleware"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/repository"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type payoutController struct {
+	payoutUseCase  usecase.PayoutUseCase
+	router         *gin.RouterGroup
+	authMiddleware middleware.AuthMiddleware
+}
+
+func payoutErrorCode(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrBankAccountNotVerified):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrBankAccountReviewed), errors.Is(err, model.ErrIllegalWithdrawalTransition),
+		errors.Is(err, repository.ErrInsufficientBalance), errors.Is(err, repository.ErrEmptyPayoutBatch),
+		errors.Is(err, repository.ErrBatchNotExported):
+		return http.StatusConflict
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	default:
+		return memberErrorCode(err)
+	}
+}
+
+func contextUser(ctx *gin.Context) model.User {
+	return model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+}
+
+func (pc *payoutController) registerBankAccountHandler(ctx *gin.Context) {
+	var input model.BankAccountInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.User = contextUser(ctx)
+
+	account, err := pc.payoutUseCase.RegisterBankAccount(input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, account, "Bank account registered successfully")
+}
+
+func (pc *payoutController) listBankAccountsHandler(ctx *gin.Context) {
+	accounts, err := pc.payoutUseCase.ListBankAccounts(contextUser(ctx), ctx.Query("status"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, account := range accounts {
+		data = append(data, account)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Bank accounts retrieved successfully")
+}
+
+func (pc *payoutController) reviewBankAccountHandler(verified bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("bank_account_id"))
+		if err != nil {
+			commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid bank account ID")
+			return
+		}
+
+		account, err := pc.payoutUseCase.ReviewBankAccount(id, verified, contextUser(ctx))
+		if err != nil {
+			commonresponse.SendErrorResponse(ctx, payoutErrorCode(err), err.Error())
+			return
+		}
+
+		commonresponse.SendSingleResponse(ctx, account, "Bank account reviewed successfully")
+	}
+}
+
+func (pc *payoutController) getBalanceHandler(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	available, err := pc.payoutUseCase.GetAvailableBalance(campaignID, contextUser(ctx))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, payoutErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, gin.H{"campaign_id": campaignID, "available": available}, "Campaign balance retrieved successfully")
+}
+
+func (pc *payoutController) requestWithdrawalHandler(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	var input model.WithdrawalInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.CampaignID = campaignID
+	input.User = contextUser(ctx)
+
+	withdrawal, err := pc.payoutUseCase.RequestWithdrawal(input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, payoutErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, withdrawal, "Withdrawal requested successfully")
+}
+
+func (pc *payoutController) listCampaignWithdrawalsHandler(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	withdrawals, err := pc.payoutUseCase.ListCampaignWithdrawals(campaignID, contextUser(ctx))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, payoutErrorCode(err), err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, withdrawal := range withdrawals {
+		data = append(data, withdrawal)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Withdrawals retrieved successfully")
+}
+
+func (pc *payoutController) listWithdrawalsHandler(ctx *gin.Context) {
+	withdrawals, err := pc.payoutUseCase.ListWithdrawals(model.WithdrawalStatus(ctx.Query("status")))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, withdrawal := range withdrawals {
+		data = append(data, withdrawal)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Withdrawals retrieved successfully")
+}
+
+func (pc *payoutController) reviewWithdrawalHandler(review func(int, model.ReviewWithdrawalInput) (model.Withdrawal, error), message string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("withdrawal_id"))
+		if err != nil {
+			commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid withdrawal ID")
+			return
+		}
+
+		var input model.ReviewWithdrawalInput
+		if ctx.Request.ContentLength > 0 {
+			if err := ctx.ShouldBindJSON(&input); err != nil {
+				commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		input.User = contextUser(ctx)
+
+		withdrawal, err := review(id, input)
+		if err != nil {
+			commonresponse.SendErrorResponse(ctx, payoutErrorCode(err), err.Error())
+			return
+		}
+
+		commonresponse.SendSingleResponse(ctx, withdrawal, message)
+	}
+}
+
+func (pc *payoutController) createBatchHandler(ctx *gin.Context) {
+	batch, err := pc.payoutUseCase.CreatePayoutBatch(contextUser(ctx))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, payoutErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, batch, "Payout batch created successfully")
+}
+
+func (pc *payoutController) getBatchHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("batch_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid batch ID")
+		return
+	}
+
+	batch, err := pc.payoutUseCase.GetPayoutBatch(id)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, payoutErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, batch, "Payout batch retrieved successfully")
+}
+
+func (pc *payoutController) exportBatchHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("batch_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid batch ID")
+		return
+	}
+
+	file, err := pc.payoutUseCase.ExportPayoutBatch(id)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, payoutErrorCode(err), err.Error())
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=payout-batch-%d.csv", id))
+	ctx.Data(http.StatusOK, "text/csv", file)
+}
+
+func (pc *payoutController) completeBatchHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("batch_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid batch ID")
+		return
+	}
+
+	batch, err := pc.payoutUseCase.CompletePayoutBatch(id)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, payoutErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, batch, "Payout batch completed successfully")
+}
+
+func (pc *payoutController) Routing() {
+	pc.router.POST("/bank-accounts", pc.authMiddleware.CheckToken("user", "admin"), pc.registerBankAccountHandler)
+	pc.router.GET("/bank-accounts", pc.authMiddleware.CheckToken("user", "admin"), pc.listBankAccountsHandler)
+	pc.router.POST("/bank-accounts/:bank_account_id/verify", pc.authMiddleware.CheckToken("admin"), pc.reviewBankAccountHandler(true))
+	pc.router.POST("/bank-accounts/:bank_account_id/reject", pc.authMiddleware.CheckToken("admin"), pc.reviewBankAccountHandler(false))
+
+	pc.router.GET("/campaigns/:campaign_id/balance", pc.authMiddleware.CheckToken("user", "admin"), pc.getBalanceHandler)
+	pc.router.POST("/campaigns/:campaign_id/withdrawals", pc.authMiddleware.CheckToken("user", "admin"), pc.requestWithdrawalHandler)
+	pc.router.GET("/campaigns/:campaign_id/withdrawals", pc.authMiddleware.CheckToken("user", "admin"), pc.listCampaignWithdrawalsHandler)
+
+	pc.router.GET("/withdrawals", pc.authMiddleware.CheckToken("admin"), pc.listWithdrawalsHandler)
+	pc.router.POST("/withdrawals/:withdrawal_id/approve", pc.authMiddleware.CheckToken("admin"),
+		pc.reviewWithdrawalHandler(pc.payoutUseCase.ApproveWithdrawal, "Withdrawal approved successfully"))
+	pc.router.POST("/withdrawals/:withdrawal_id/reject", pc.authMiddleware.CheckToken("admin"),
+		pc.reviewWithdrawalHandler(pc.payoutUseCase.RejectWithdrawal, "Withdrawal rejected successfully"))
+	pc.router.POST("/withdrawals/:withdrawal_id/fail", pc.authMiddleware.CheckToken("admin"),
+		pc.reviewWithdrawalHandler(pc.payoutUseCase.FailWithdrawal, "Withdrawal marked as failed"))
+
+	pc.router.POST("/payout-batches", pc.authMiddleware.CheckToken("admin"), pc.createBatchHandler)
+	pc.router.GET("/payout-batches/:batch_id", pc.authMiddleware.CheckToken("admin"), pc.getBatchHandler)
+	pc.router.GET("/payout-batches/:batch_id/export", pc.authMiddleware.CheckToken("admin"), pc.exportBatchHandler)
+	pc.router.POST("/payout-batches/:batch_id/complete", pc.authMiddleware.CheckToken("admin"), pc.completeBatchHandler)
+}
+
+func NewPayoutController(payoutUseCase usecase.PayoutUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *payoutController {
+	return &payoutController{
+		payoutUseCase:  payoutUseCase,
+		router:         rg,
+		authMiddleware: authMiddleware,
+	}
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"eternal-fund/middleware"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type receiptController struct {
+	receiptUseCase usecase.ReceiptUseCase
+	router         *gin.RouterGroup
+	authMiddleware middleware.AuthMiddleware
+}
+
+func receiptErrorCode(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrReceiptForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrReceiptNotAvailable):
+		return http.StatusConflict
+	default:
+		return transactionErrorCode(err)
+	}
+}
+
+func (rc *receiptController) getReceiptHandler(ctx *gin.Context) {
+	transactionID, err := strconv.Atoi(ctx.Param("transaction_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	receipt, file, err := rc.receiptUseCase.GetReceipt(transactionID, contextUser(ctx))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, receiptErrorCode(err), err.Error())
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", receipt.Number))
+	ctx.Data(http.StatusOK, "application/pdf", file)
+}
+
+func (rc *receiptController) Routing() {
+	rc.router.GET("/transactions/:transaction_id/receipt", rc.authMiddleware.CheckToken("user", "admin"), rc.getReceiptHandler)
+}
+
+func NewReceiptController(receiptUseCase usecase.ReceiptUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *receiptController {
+	return &receiptController{
+		receiptUseCase: receiptUseCase,
+		router:         rg,
+		authMiddleware: authMiddleware,
+	}
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"eternal-fund/middleware"
+	"eternal-fund/model"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSettlementReportSize bounds the settlement reports read into memory.
+const maxSettlementReportSize = 10 << 20
+
+type reconciliationController struct {
+	reconciliationUseCase usecase.ReconciliationUseCase
+	router                *gin.RouterGroup
+	authMiddleware        middleware.AuthMiddleware
+}
+
+func reconciliationErrorCode(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidSettlementReport), errors.Is(err, usecase.ErrInvalidSettlementPeriod):
+		return http.StatusBadRequest
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (rc *reconciliationController) importSettlementHandler(ctx *gin.Context) {
+	periodStart, err := time.Parse("2006-01-02", ctx.PostForm("period_start"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid period_start, use YYYY-MM-DD")
+		return
+	}
+	periodEnd, err := time.Parse("2006-01-02", ctx.PostForm("period_end"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid period_end, use YYYY-MM-DD")
+		return
+	}
+
+	file, err := ctx.FormFile("report")
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if file.Size > maxSettlementReportSize {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Settlement report is too large")
+		return
+	}
+	opened, err := file.Open()
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer opened.Close()
+	report, err := io.ReadAll(opened)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	settlement, items, err := rc.reconciliationUseCase.ImportSettlement(model.SettlementImportInput{
+		FileName:    file.Filename,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Report:      report,
+		User:        contextUser(ctx),
+	})
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, reconciliationErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, gin.H{"import": settlement, "items": items}, "Settlement report imported successfully")
+}
+
+func (rc *reconciliationController) getItemsHandler(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid page number")
+		return
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "10"))
+	if err != nil || size < 1 {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid size number")
+		return
+	}
+
+	items, paging, err := rc.reconciliationUseCase.GetItems(ctx.Query("status") == "all", page, size)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, item := range items {
+		data = append(data, item)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, paging, "Reconciliation items retrieved successfully")
+}
+
+func (rc *reconciliationController) resolveItemHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("item_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid item ID")
+		return
+	}
+
+	var input model.ResolveReconciliationInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.User = contextUser(ctx)
+
+	item, err := rc.reconciliationUseCase.ResolveItem(id, input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, reconciliationErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, item, "Reconciliation item resolved successfully")
+}
+
+func (rc *reconciliationController) Routing() {
+	rc.router.POST("/reconciliation/settlements", rc.authMiddleware.CheckToken("admin"), rc.importSettlementHandler)
+	rc.router.GET("/reconciliation/items", rc.authMiddleware.CheckToken("admin"), rc.getItemsHandler)
+	rc.router.POST("/reconciliation/items/:item_id/resolve", rc.authMiddleware.CheckToken("admin"), rc.resolveItemHandler)
+}
+
+func NewReconciliationController(reconciliationUseCase usecase.ReconciliationUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *reconciliationController {
+	return &reconciliationController{
+		reconciliationUseCase: reconciliationUseCase,
+		router:                rg,
+		authMiddleware:        authMiddleware,
+	}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"eternal-fund/middleware"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/repository"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type recurringController struct {
+	recurringUseCase usecase.RecurringUseCase
+	router           *gin.RouterGroup
+	authMiddleware   middleware.AuthMiddleware
+}
+
+func recurringErrorCode(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidRecurringInterval):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrRecurringPlanForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, model.ErrIllegalRecurringTransition), errors.Is(err, repository.ErrRecurringPlanChanged):
+		return http.StatusConflict
+	default:
+		return transactionErrorCode(err)
+	}
+}
+
+func (rc *recurringController) createPlanHandler(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	var input model.CreateRecurringPlanInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.CampaignID = campaignID
+	input.User = contextUser(ctx)
+	input.ClientIP = ctx.ClientIP()
+
+	plan, transaction, err := rc.recurringUseCase.CreatePlan(input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, recurringErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, gin.H{"plan": plan, "transaction": transaction}, "Recurring donation created successfully")
+}
+
+func (rc *recurringController) listPlansHandler(ctx *gin.Context) {
+	plans, err := rc.recurringUseCase.ListPlans(contextUser(ctx))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, plan := range plans {
+		data = append(data, plan)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Recurring donations retrieved successfully")
+}
+
+func (rc *recurringController) getPlanHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("plan_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	plan, err := rc.recurringUseCase.GetPlan(id, contextUser(ctx))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, recurringErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, plan, "Recurring donation retrieved successfully")
+}
+
+func (rc *recurringController) changePlanHandler(change func(int, model.User) (model.RecurringPlan, error), message string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("plan_id"))
+		if err != nil {
+			commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid plan ID")
+			return
+		}
+
+		plan, err := change(id, contextUser(ctx))
+		if err != nil {
+			commonresponse.SendErrorResponse(ctx, recurringErrorCode(err), err.Error())
+			return
+		}
+
+		commonresponse.SendSingleResponse(ctx, plan, message)
+	}
+}
+
+func (rc *recurringController) Routing() {
+	rc.router.POST("/campaigns/:campaign_id/recurring-plans", rc.authMiddleware.CheckToken("user", "admin"), rc.createPlanHandler)
+	rc.router.GET("/recurring-plans", rc.authMiddleware.CheckToken("user", "admin"), rc.listPlansHandler)
+	rc.router.GET("/recurring-plans/:plan_id", rc.authMiddleware.CheckToken("user", "admin"), rc.getPlanHandler)
+	rc.router.POST("/recurring-plans/:plan_id/pause", rc.authMiddleware.CheckToken("user", "admin"),
+		rc.changePlanHandler(rc.recurringUseCase.PausePlan, "Recurring donation paused successfully"))
+	rc.router.POST("/recurring-plans/:plan_id/resume", rc.authMiddleware.CheckToken("user", "admin"),
+		rc.changePlanHandler(rc.recurringUseCase.ResumePlan, "Recurring donation resumed successfully"))
+	rc.router.POST("/recurring-plans/:plan_id/cancel", rc.authMiddleware.CheckToken("user", "admin"),
+		rc.changePlanHandler(rc.recurringUseCase.CancelPlan, "Recurring donation cancelled successfully"))
+}
+
+func NewRecurringController(recurringUseCase usecase.RecurringUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *recurringController {
+	return &recurringController{
+		recurringUseCase: recurringUseCase,
+		router:           rg,
+		authMiddleware:   authMiddleware,
+	}
+}
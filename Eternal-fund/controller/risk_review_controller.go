@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"eternal-fund/middleware"
+	"eternal-fund/model"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type riskReviewController struct {
+	riskReviewUseCase usecase.RiskReviewUseCase
+	router            *gin.RouterGroup
+	authMiddleware    middleware.AuthMiddleware
+}
+
+func riskReviewErrorCode(err error) int {
+	if errors.Is(err, usecase.ErrNotUnderReview) {
+		return http.StatusConflict
+	}
+	return transactionErrorCode(err)
+}
+
+func (rc *riskReviewController) getQueueHandler(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid page number")
+		return
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "10"))
+	if err != nil || size < 1 {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid size number")
+		return
+	}
+
+	transactions, paging, err := rc.riskReviewUseCase.GetQueue(page, size)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, transaction := range transactions {
+		data = append(data, transaction)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, paging, "Review queue retrieved successfully")
+}
+
+// bindReview reads the transaction and the optional note of a decision.
+func bindReview(ctx *gin.Context) (int, model.RiskReviewInput, bool) {
+	var input model.RiskReviewInput
+	transactionID, err := strconv.Atoi(ctx.Param("transaction_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid transaction ID")
+		return 0, input, false
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return 0, input, false
+	}
+	input.User = contextUser(ctx)
+	return transactionID, input, true
+}
+
+func (rc *riskReviewController) approveHandler(ctx *gin.Context) {
+	transactionID, input, ok := bindReview(ctx)
+	if !ok {
+		return
+	}
+
+	transaction, err := rc.riskReviewUseCase.Approve(transactionID, input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, riskReviewErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, transaction, "Transaction approved successfully")
+}
+
+func (rc *riskReviewController) rejectHandler(ctx *gin.Context) {
+	transactionID, input, ok := bindReview(ctx)
+	if !ok {
+		return
+	}
+
+	transaction, err := rc.riskReviewUseCase.Reject(transactionID, input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, riskReviewErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, transaction, "Transaction rejected successfully")
+}
+
+func (rc *riskReviewController) Routing() {
+	rc.router.GET("/risk-reviews", rc.authMiddleware.CheckToken("admin"), rc.getQueueHandler)
+	rc.router.POST("/risk-reviews/:transaction_id/approve", rc.authMiddleware.CheckToken("admin"), rc.approveHandler)
+	rc.router.POST("/risk-reviews/:transaction_id/reject", rc.authMiddleware.CheckToken("admin"), rc.rejectHandler)
+}
+
+func NewRiskReviewController(riskReviewUseCase usecase.RiskReviewUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *riskReviewController {
+	return &riskReviewController{
+		riskReviewUseCase: riskReviewUseCase,
+		router:            rg,
+		authMiddleware:    authMiddleware,
+	}
+}
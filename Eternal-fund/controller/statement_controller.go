@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"eternal-fund/middleware"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type statementController struct {
+	statementUseCase usecase.StatementUseCase
+	router           *gin.RouterGroup
+	authMiddleware   middleware.AuthMiddleware
+}
+
+func (sc *statementController) getStatementHandler(ctx *gin.Context) {
+	year, err := strconv.Atoi(ctx.Param("year"))
+	if err != nil || year < 1 {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid year")
+		return
+	}
+	format := ctx.DefaultQuery("format", usecase.StatementFormatPDF)
+
+	file, err := sc.statementUseCase.GetStatement(contextUser(ctx), year, format)
+	if err != nil {
+		code := transactionErrorCode(err)
+		if errors.Is(err, usecase.ErrInvalidStatementFormat) {
+			code = http.StatusBadRequest
+		}
+		commonresponse.SendErrorResponse(ctx, code, err.Error())
+		return
+	}
+
+	contentType := "application/pdf"
+	if format == usecase.StatementFormatCSV {
+		contentType = "text/csv"
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=donation-statement-%d.%s", year, format))
+	ctx.Data(http.StatusOK, contentType, file)
+}
+
+func (sc *statementController) Routing() {
+	sc.router.GET("/users/me/statements/:year", sc.authMiddleware.CheckToken("user", "admin"), sc.getStatementHandler)
+}
+
+func NewStatementController(statementUseCase usecase.StatementUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *statementController {
+	return &statementController{
+		statementUseCase: statementUseCase,
+		router:           rg,
+		authMiddleware:   authMiddleware,
+	}
+}
@@ -1,19 +1,23 @@
 package controller
 
 import (
-	"encoding/base64"
+	"database/sql"
+	"errors"
 	"eternal-fund/middleware"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
 	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/repository"
 	"eternal-fund/usecase"
+	"eternal-fund/usecase/service"
+	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-resty/resty/v2"
+	"github.com/gin-gonic/gin/binding"
 )
 
 type TransactionController struct {
@@ -22,6 +26,30 @@ type TransactionController struct {
 	authMiddleware middleware.AuthMiddleware
 }
 
+func transactionErrorCode(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidTransactionStatus), errors.Is(err, usecase.ErrCoverFeesDisabled),
+		errors.Is(err, service.ErrInvalidLinkToken), errors.Is(err, model.ErrUnsupportedCurrency),
+		errors.Is(err, usecase.ErrDonationTooSmall), errors.Is(err, service.ErrUnsupportedCurrency),
+		errors.Is(err, model.ErrDonationBelowMinimum), errors.Is(err, model.ErrDonationAboveMaximum):
+		return http.StatusBadRequest
+	case errors.Is(err, model.ErrVelocityExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, model.ErrIllegalTransition), errors.Is(err, usecase.ErrTransactionNotRefundable),
+		errors.Is(err, usecase.ErrRefundExceedsAmount), errors.Is(err, usecase.ErrExchangeRateNotFound),
+		errors.Is(err, repository.ErrRefundExceedsBalance):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrRefundFailed):
+		return http.StatusBadGateway
+	case errors.Is(err, usecase.ErrEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	default:
+		return memberErrorCode(err)
+	}
+}
+
 func (t *TransactionController) getCampaignTransactions(ctx *gin.Context) {
 	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
 	if err != nil {
@@ -29,9 +57,15 @@ func (t *TransactionController) getCampaignTransactions(ctx *gin.Context) {
 		return
 	}
 
-	transactions, err := t.transactionUC.GetTransactionsByCampaignID(campaignID)
+	transactions, err := t.transactionUC.GetTransactionsByCampaignID(campaignID, contextUser(ctx))
+	if errors.Is(err, usecase.ErrCampaignForbidden) {
+		// Outside the campaign's team donations are only shown the way
+		// the public supporters feed shows them.
+		t.getSupporters(ctx)
+		return
+	}
 	if err != nil {
-		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, "Failed to get transactions")
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), "Failed to get transactions")
 		return
 	}
 
@@ -51,9 +85,18 @@ func (t *TransactionController) getTransactionByID(ctx *gin.Context) {
 		return
 	}
 
-	transaction, err := t.transactionUC.GetTransactionByID(transactionID)
+	transaction, err := t.transactionUC.GetTransactionByID(transactionID, contextUser(ctx))
+	if errors.Is(err, usecase.ErrTransactionForbidden) {
+		supporter, err := t.transactionUC.GetSupporter(transactionID)
+		if err != nil {
+			commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
+			return
+		}
+		commonresponse.SendSingleResponse(ctx, supporter, "Transaction retrieved successfully")
+		return
+	}
 	if err != nil {
-		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
 		return
 	}
 
@@ -67,7 +110,20 @@ func (t *TransactionController) getUserTransactions(ctx *gin.Context) {
 		return
 	}
 
-	transactions, err := t.transactionUC.GetTransactionsByUserID(userID)
+	transactions, err := t.transactionUC.GetTransactionsByUserID(userID, contextUser(ctx))
+	if errors.Is(err, usecase.ErrTransactionForbidden) {
+		supporters, err := t.transactionUC.GetPublicDonations(userID)
+		if err != nil {
+			commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, "Failed to get transactions")
+			return
+		}
+		var data []interface{}
+		for _, supporter := range supporters {
+			data = append(data, supporter)
+		}
+		commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "User transactions retrieved successfully")
+		return
+	}
 	if err != nil {
 		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, "Failed to get transactions")
 		return
@@ -97,13 +153,14 @@ func (t *TransactionController) createTransaction(ctx *gin.Context) {
 
 	// Set userID in input
 	input.User.ID = userID.(int)
+	input.ClientIP = ctx.ClientIP()
 
 	// Debug log
 	log.Printf("Handler UserID: %d", input.User.ID)
 
 	transaction, err := t.transactionUC.CreateTransaction(input)
 	if err != nil {
-		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
 		return
 	}
 
@@ -117,6 +174,48 @@ func (t *TransactionController) createTransaction(ctx *gin.Context) {
 	commonresponse.SendSingleResponse(ctx, transaction, "Transaction created successfully")
 }
 
+func (t *TransactionController) createGuestTransaction(ctx *gin.Context) {
+	var input model.CreateGuestTransactionInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	input.ClientIP = ctx.ClientIP()
+	transaction, claimURL, err := t.transactionUC.CreateGuestTransaction(input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, gin.H{"transaction": transaction, "claim_url": claimURL}, "Transaction created successfully")
+}
+
+func (t *TransactionController) getGuestTransaction(ctx *gin.Context) {
+	transaction, err := t.transactionUC.GetGuestTransaction(ctx.Param("token"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, transaction, "Transaction retrieved successfully")
+}
+
+func (t *TransactionController) claimGuestTransactions(ctx *gin.Context) {
+	transactions, err := t.transactionUC.ClaimGuestTransactions(contextUser(ctx))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, tx := range transactions {
+		data = append(data, tx)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Guest donations claimed successfully")
+}
+
 func (t *TransactionController) UpdateTransaction(ctx *gin.Context) {
 	transactionID, err := strconv.Atoi(ctx.Param("transaction_id"))
 	if err != nil {
@@ -129,90 +228,264 @@ func (t *TransactionController) UpdateTransaction(ctx *gin.Context) {
 		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
+	input.User = model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+
 	transaction, err := t.transactionUC.UpdateTransaction(transactionID, input)
 	if err != nil {
-		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
 		return
 	}
 
 	commonresponse.SendSingleResponse(ctx, transaction, "Transaction updated successfully")
 }
 
-func (t *TransactionController) getNotification(ctx *gin.Context) {
-	var notificationPayload map[string]interface{}
-	if err := ctx.BindJSON(&notificationPayload); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+func (t *TransactionController) getStatusLog(ctx *gin.Context) {
+	transactionID, err := strconv.Atoi(ctx.Param("transaction_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	changes, err := t.transactionUC.GetStatusLog(transactionID)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
 		return
 	}
 
-	log.Println("Notification Payload:", notificationPayload)
+	var data []interface{}
+	for _, change := range changes {
+		data = append(data, change)
+	}
 
-	transactionStatus, exists := notificationPayload["transaction_status"].(string)
-	if !exists {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification payload: missing transaction_status"})
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Transaction status log retrieved successfully")
+}
+
+func (t *TransactionController) refundTransaction(ctx *gin.Context) {
+	transactionID, err := strconv.Atoi(ctx.Param("transaction_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid transaction ID")
 		return
 	}
 
-	orderID, exists := notificationPayload["order_id"].(string)
-	if !exists {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification payload: missing order_id"})
+	var input model.RefundInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.User = model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+
+	refund, err := t.transactionUC.RefundTransaction(transactionID, input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
 		return
 	}
 
-	log.Println("Order ID:", orderID)
-	log.Println("Transaction Status:", transactionStatus)
+	commonresponse.SendSingleResponse(ctx, refund, "Transaction refunded successfully")
+}
 
-	// Verifikasi notifikasi dari Midtrans
-	verified, err := t.verifyNotification(orderID, transactionStatus)
+func (t *TransactionController) getRefunds(ctx *gin.Context) {
+	transactionID, err := strconv.Atoi(ctx.Param("transaction_id"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify notification"})
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid transaction ID")
 		return
 	}
 
-	log.Println("Verified:", verified)
+	user := model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+	refunds, err := t.transactionUC.GetRefunds(transactionID, user)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
+		return
+	}
 
-	if verified {
-		updatedTransaction, err := t.transactionUC.UpdateTransactionStatus(orderID, transactionStatus)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction status"})
-			return
-		}
-		ctx.JSON(http.StatusOK, gin.H{"message": "Transaction status updated", "transaction": updatedTransaction})
-	} else {
+	var data []interface{}
+	for _, refund := range refunds {
+		data = append(data, refund)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Refunds retrieved successfully")
+}
+
+func (t *TransactionController) getCampaignReport(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	user := model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+	report, err := t.transactionUC.GetCampaignReport(campaignID, user)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, report, "Campaign report retrieved successfully")
+}
+
+// maxSupportersPageSize caps the public supporters feed.
+const maxSupportersPageSize = 50
+
+func (t *TransactionController) getSupporters(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid page number")
+		return
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "10"))
+	if err != nil || size < 1 {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid size number")
+		return
+	}
+
+	supporters, paging, err := t.transactionUC.GetSupporters(campaignID, page, min(size, maxSupportersPageSize))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, supporter := range supporters {
+		data = append(data, supporter)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, paging, "Supporters retrieved successfully")
+}
+
+func (t *TransactionController) exportDonations(ctx *gin.Context) {
+	campaignID, err := strconv.Atoi(ctx.Param("campaign_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid campaign ID")
+		return
+	}
+
+	user := model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+	file, err := t.transactionUC.ExportDonations(campaignID, user)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, transactionErrorCode(err), err.Error())
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=campaign-%d-donations.csv", campaignID))
+	ctx.Data(http.StatusOK, "text/csv", file)
+}
+
+func (t *TransactionController) getNotification(ctx *gin.Context) {
+	payload, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification payload: " + err.Error()})
+		return
+	}
+	var input model.TransactionNotificationInput
+	if err := binding.JSON.BindBody(payload, &input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification payload: " + err.Error()})
+		return
+	}
+
+	log.Printf("Notification for order %s: %s", input.OrderID, input.TransactionStatus)
+
+	headers := make(map[string]string, len(ctx.Request.Header))
+	for name, values := range ctx.Request.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	notification := model.PaymentNotification{
+		OrderID:           input.OrderID,
+		TransactionStatus: input.TransactionStatus,
+		SignatureKey:      input.SignatureKey,
+		Payload:           payload,
+		Headers:           headers,
+	}
+
+	updatedTransaction, err := t.transactionUC.HandleNotification(notification)
+	switch {
+	case errors.Is(err, usecase.ErrDuplicateNotification):
+		ctx.JSON(http.StatusOK, gin.H{"message": "Notification already processed"})
+		return
+	case errors.Is(err, usecase.ErrInvalidSignature):
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Notification verification failed"})
+		return
+	case errors.Is(err, usecase.ErrAmountMismatch):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction status"})
+		return
 	}
 
+	ctx.JSON(http.StatusOK, gin.H{"message": "Transaction status updated", "transaction": updatedTransaction})
 }
 
-func (t *TransactionController) verifyNotification(orderID string, transactionStatus string) (bool, error) {
-	client := resty.New()
-	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
-	response, err := client.R().
-		SetHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(serverKey+":"))).
-		Get("https://api.sandbox.midtrans.com/v2/" + orderID + "/status")
+func (t *TransactionController) getNotifications(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid page number")
+		return
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "10"))
+	if err != nil || size < 1 {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid size number")
+		return
+	}
 
+	notifications, paging, err := t.transactionUC.FindNotifications(page, size)
 	if err != nil {
-		log.Println("Error verifying notification:", err)
-		return false, err
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, notification := range notifications {
+		data = append(data, notification)
 	}
 
-	log.Println("Verification response status code:", response.StatusCode())
-	log.Println("Verification response body:", response.String())
+	commonresponse.SendManyResponse(ctx, data, paging, "Payment notifications retrieved successfully")
+}
+
+func (t *TransactionController) replayNotification(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("notification_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
 
-	if response.StatusCode() == http.StatusOK {
-		return true, nil
+	transaction, err := t.transactionUC.ReplayNotification(id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		commonresponse.SendErrorResponse(ctx, http.StatusNotFound, "Notification not found")
+		return
+	case errors.Is(err, usecase.ErrInvalidSignature), errors.Is(err, usecase.ErrAmountMismatch):
+		commonresponse.SendErrorResponse(ctx, http.StatusUnprocessableEntity, err.Error())
+		return
+	case err != nil:
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	return false, nil
+	commonresponse.SendSingleResponse(ctx, transaction, "Notification replayed successfully")
 }
 
 func (t *TransactionController) Routing() {
 	t.router.GET("/campaigns/:campaign_id/transactions", t.authMiddleware.CheckToken("user", "admin"), t.getCampaignTransactions)
+	t.router.GET("/campaigns/:campaign_id/report", t.authMiddleware.CheckToken("user", "admin"), t.getCampaignReport)
+	t.router.GET("/campaigns/:campaign_id/supporters", t.getSupporters)
+	t.router.GET("/campaigns/:campaign_id/donations/export", t.authMiddleware.CheckToken("user", "admin"), t.exportDonations)
 	t.router.GET("/transactions/:transaction_id", t.authMiddleware.CheckToken("user", "admin"), t.getTransactionByID)
 	t.router.GET("/users/:user_id/transactions", t.authMiddleware.CheckToken("user", "admin"), t.getUserTransactions)
 	t.router.POST("/transactions", t.authMiddleware.CheckToken("user", "admin"), t.createTransaction)
 	t.router.POST("/transactions/notification", t.getNotification)
-	t.router.PUT("/transactions/:transaction_id", t.authMiddleware.CheckToken("user", "admin"), t.UpdateTransaction)
+	t.router.POST("/guest-donations", t.createGuestTransaction)
+	t.router.GET("/guest-donations/:token", t.getGuestTransaction)
+	t.router.POST("/guest-donations/claim", t.authMiddleware.CheckToken("user", "admin"), t.claimGuestTransactions)
+	t.router.GET("/payment-notifications", t.authMiddleware.CheckToken("admin"), t.getNotifications)
+	t.router.POST("/payment-notifications/:notification_id/replay", t.authMiddleware.CheckToken("admin"), t.replayNotification)
+	t.router.PUT("/transactions/:transaction_id", t.authMiddleware.CheckToken("admin"), t.UpdateTransaction)
+	t.router.GET("/transactions/:transaction_id/status-log", t.authMiddleware.CheckToken("admin"), t.getStatusLog)
+	t.router.POST("/transactions/:transaction_id/refunds", t.authMiddleware.CheckToken("user", "admin"), t.refundTransaction)
+	t.router.GET("/transactions/:transaction_id/refunds", t.authMiddleware.CheckToken("user", "admin"), t.getRefunds)
 }
 
 func NewTransactionController(transactionUc usecase.TransactionUseCase, rg *gin.RouterGroup, authMiddle middleware.AuthMiddleware) *TransactionController {
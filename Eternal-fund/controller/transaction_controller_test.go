@@ -4,6 +4,9 @@ import (
     "encoding/json"
     "eternal-fund/mocking"
     "eternal-fund/model"
+    "eternal-fund/model/dto"
+    "eternal-fund/usecase"
+    "eternal-fund/usecase/service"
     "net/http"
     "net/http/httptest"
     "strings"
@@ -11,6 +14,7 @@ import (
 
     "github.com/gin-gonic/gin"
     "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
     "github.com/stretchr/testify/suite"
 )
 
@@ -39,7 +43,7 @@ func (suite *TransactionControllerTestSuite) TestGetCampaignTransactions() {
         {ID: 2, Amount: 2000, CampaignID: campaignID},
     }
 
-    suite.tuc.On("GetTransactionsByCampaignID", campaignID).Return(mockTransactions, nil)
+    suite.tuc.On("GetTransactionsByCampaignID", campaignID, model.User{}).Return(mockTransactions, nil)
 
     w := httptest.NewRecorder()
     req, _ := http.NewRequest("GET", "/api/v1/campaigns/1/transactions", nil)
@@ -49,17 +53,44 @@ func (suite *TransactionControllerTestSuite) TestGetCampaignTransactions() {
     suite.tuc.AssertExpectations(suite.T())
 }
 
+func (suite *TransactionControllerTestSuite) TestGetCampaignTransactions_NotMemberSeesSupporters() {
+    supporters := []model.Supporter{{CampaignID: 1, Name: model.AnonymousSupporterName, Anonymous: true, Amount: 1000}}
+    suite.tuc.On("GetTransactionsByCampaignID", 1, model.User{}).Return([]model.Transaction(nil), usecase.ErrCampaignForbidden)
+    suite.tuc.On("GetSupporters", 1, 1, 10).Return(supporters, dto.Paging{Page: 1, Size: 10, TotalRows: 1, TotalPages: 1}, nil)
+
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("GET", "/api/v1/campaigns/1/transactions", nil)
+    suite.router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusOK, w.Code)
+    assert.NotContains(suite.T(), w.Body.String(), "user_id")
+    suite.tuc.AssertExpectations(suite.T())
+}
+
 func (suite *TransactionControllerTestSuite) TestGetTransactionByID() {
     transactionID := 1
     mockTransaction := model.Transaction{ID: transactionID, Amount: 1000}
 
-    suite.tuc.On("GetTransactionByID", transactionID).Return(mockTransaction, nil)
+    suite.tuc.On("GetTransactionByID", transactionID, model.User{}).Return(mockTransaction, nil)
+
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("GET", "/api/v1/transactions/1", nil)
+    suite.router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusOK, w.Code)
+    suite.tuc.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestGetTransactionByID_OthersSeeSupporter() {
+    suite.tuc.On("GetTransactionByID", 1, model.User{}).Return(model.Transaction{}, usecase.ErrTransactionForbidden)
+    suite.tuc.On("GetSupporter", 1).Return(model.Supporter{CampaignID: 2, Name: model.AnonymousSupporterName, Anonymous: true, Amount: 1000}, nil)
 
     w := httptest.NewRecorder()
     req, _ := http.NewRequest("GET", "/api/v1/transactions/1", nil)
     suite.router.ServeHTTP(w, req)
 
     assert.Equal(suite.T(), http.StatusOK, w.Code)
+    assert.NotContains(suite.T(), w.Body.String(), "guest_name")
     suite.tuc.AssertExpectations(suite.T())
 }
 
@@ -70,7 +101,7 @@ func (suite *TransactionControllerTestSuite) TestGetUserTransactions() {
         {ID: 2, Amount: 2000, UserID: userID},
     }
 
-    suite.tuc.On("GetTransactionsByUserID", userID).Return(mockTransactions, nil)
+    suite.tuc.On("GetTransactionsByUserID", userID, model.User{}).Return(mockTransactions, nil)
 
     w := httptest.NewRecorder()
     req, _ := http.NewRequest("GET", "/api/v1/users/1/transactions", nil)
@@ -101,8 +132,8 @@ func (suite *TransactionControllerTestSuite) TestCreateTransaction() {
 
 func (suite *TransactionControllerTestSuite) TestUpdateTransaction() {
     transactionID := 1
-    input := model.UpdateTransactionInput{Amount: 2000}
-    transaction := model.Transaction{ID: transactionID, Amount: 2000}
+    input := model.UpdateTransactionInput{Status: model.TransactionStatusRefunded, Note: "donor request"}
+    transaction := model.Transaction{ID: transactionID, Amount: 2000, Status: model.TransactionStatusRefunded}
 
     suite.tuc.On("UpdateTransaction", transactionID, input).Return(transaction, nil)
 
@@ -116,14 +147,33 @@ func (suite *TransactionControllerTestSuite) TestUpdateTransaction() {
     suite.tuc.AssertExpectations(suite.T())
 }
 
+func (suite *TransactionControllerTestSuite) TestUpdateTransaction_IllegalTransition() {
+    input := model.UpdateTransactionInput{Status: model.TransactionStatusPending}
+    suite.tuc.On("UpdateTransaction", 1, input).Return(model.Transaction{}, model.ErrIllegalTransition)
+
+    body, _ := json.Marshal(input)
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("PUT", "/api/v1/transactions/1", strings.NewReader(string(body)))
+    req.Header.Set("Content-Type", "application/json")
+    suite.router.ServeHTTP(w, req)
+
+    assert.Contains(suite.T(), w.Body.String(), `"code":409`)
+}
+
 func (suite *TransactionControllerTestSuite) TestGetNotification() {
-    notificationPayload := map[string]interface{}{
-        "transaction_status": "settlement",
-        "order_id":           "order-123",
+    notification := model.TransactionNotificationInput{
+        TransactionStatus: "settlement",
+        OrderID:           "1",
+        StatusCode:        "200",
+        GrossAmount:       "1000.00",
+        SignatureKey:      "signature",
     }
-    body, _ := json.Marshal(notificationPayload)
+    body, _ := json.Marshal(notification)
 
-    suite.tuc.On("UpdateTransactionStatus", "order-123", "settlement").Return(model.Transaction{ID: 1, Status: "settlement"}, nil)
+    suite.tuc.On("HandleNotification", mock.MatchedBy(func(n model.PaymentNotification) bool {
+        return n.OrderID == "1" && n.SignatureKey == "signature" && string(n.Payload) == string(body) &&
+            n.Headers["Content-Type"] == "application/json"
+    })).Return(model.Transaction{ID: 1, Status: model.TransactionStatusPaid}, nil)
 
     w := httptest.NewRecorder()
     req, _ := http.NewRequest("POST", "/api/v1/transactions/notification", strings.NewReader(string(body)))
@@ -134,6 +184,97 @@ func (suite *TransactionControllerTestSuite) TestGetNotification() {
     suite.tuc.AssertExpectations(suite.T())
 }
 
+func (suite *TransactionControllerTestSuite) TestGetNotification_InvalidSignature() {
+    notification := model.TransactionNotificationInput{
+        TransactionStatus: "settlement",
+        OrderID:           "1",
+        StatusCode:        "200",
+        GrossAmount:       "1000.00",
+        SignatureKey:      "forged",
+    }
+    body, _ := json.Marshal(notification)
+
+    suite.tuc.On("HandleNotification", mock.AnythingOfType("model.PaymentNotification")).Return(model.Transaction{}, usecase.ErrInvalidSignature)
+
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("POST", "/api/v1/transactions/notification", strings.NewReader(string(body)))
+    req.Header.Set("Content-Type", "application/json")
+    suite.router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *TransactionControllerTestSuite) TestGetNotification_MissingSignature() {
+    body := `{"transaction_status":"settlement","order_id":"1","status_code":"200","gross_amount":"1000.00"}`
+
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("POST", "/api/v1/transactions/notification", strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    suite.router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+    suite.tuc.AssertNotCalled(suite.T(), "HandleNotification", mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestGetNotification_Duplicate() {
+    body := `{"transaction_status":"settlement","order_id":"1","status_code":"200","gross_amount":"1000.00","signature_key":"signature"}`
+
+    suite.tuc.On("HandleNotification", mock.AnythingOfType("model.PaymentNotification")).Return(model.Transaction{}, usecase.ErrDuplicateNotification)
+
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("POST", "/api/v1/transactions/notification", strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    suite.router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusOK, w.Code)
+    assert.Contains(suite.T(), w.Body.String(), "already processed")
+}
+
+func (suite *TransactionControllerTestSuite) TestReplayNotification() {
+    suite.tuc.On("ReplayNotification", 4).Return(model.Transaction{ID: 1, Status: model.TransactionStatusPaid}, nil)
+
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("POST", "/api/v1/payment-notifications/4/replay", nil)
+    suite.router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusOK, w.Code)
+    suite.tuc.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestGetNotifications_InvalidPaging() {
+    for _, query := range []string{"page=0", "size=0", "page=-1&size=10"} {
+        w := httptest.NewRecorder()
+        req, _ := http.NewRequest("GET", "/api/v1/payment-notifications?"+query, nil)
+        suite.router.ServeHTTP(w, req)
+
+        assert.Contains(suite.T(), w.Body.String(), `"code":400`, query)
+    }
+    suite.tuc.AssertNotCalled(suite.T(), "FindNotifications", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestGetSupporters_CapsPageSize() {
+    supporters := []model.Supporter{{Name: model.AnonymousSupporterName, Anonymous: true, Amount: 50000}}
+    suite.tuc.On("GetSupporters", 1, 2, maxSupportersPageSize).Return(supporters, dto.Paging{Page: 2, Size: maxSupportersPageSize}, nil)
+
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("GET", "/api/v1/campaigns/1/supporters?page=2&size=500", nil)
+    suite.router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusOK, w.Code)
+    assert.Contains(suite.T(), w.Body.String(), `"name":"Anonymous"`)
+    suite.tuc.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestGetGuestTransaction_InvalidLink() {
+    suite.tuc.On("GetGuestTransaction", "bad-token").Return(model.Transaction{}, service.ErrInvalidLinkToken)
+
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("GET", "/api/v1/guest-donations/bad-token", nil)
+    suite.router.ServeHTTP(w, req)
+
+    assert.Contains(suite.T(), w.Body.String(), `"code":400`)
+}
+
 func TestTransactionControllerTestSuite(t *testing.T) {
     suite.Run(t, new(TransactionControllerTestSuite))
 }
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"eternal-fund/middleware"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type trendingController struct {
+	trendingUseCase usecase.TrendingUseCase
+	router          *gin.RouterGroup
+	authMiddleware  middleware.AuthMiddleware
+}
+
+func (tc *trendingController) getTrendingHandler(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid limit number")
+		return
+	}
+
+	trending, err := tc.trendingUseCase.GetTrending(limit)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, trending, "Trending campaigns retrieved successfully")
+}
+
+func (tc *trendingController) refreshTrendingHandler(ctx *gin.Context) {
+	if err := tc.trendingUseCase.RefreshRankings(); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, nil, "Trending campaigns refreshed successfully")
+}
+
+func (tc *trendingController) getFeaturedHandler(ctx *gin.Context) {
+	featured, err := tc.trendingUseCase.ListFeatured()
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, f := range featured {
+		data = append(data, f)
+	}
+
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Featured campaigns retrieved successfully")
+}
+
+func (tc *trendingController) createFeaturedHandler(ctx *gin.Context) {
+	var input model.FeaturedCampaignInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.User.ID = ctx.GetInt("userID")
+
+	featured, err := tc.trendingUseCase.CreateFeatured(input)
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, featured, "Featured campaign created successfully")
+}
+
+func (tc *trendingController) deleteFeaturedHandler(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("featured_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid featured ID")
+		return
+	}
+
+	if err := tc.trendingUseCase.DeleteFeatured(id); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, nil, "Featured campaign deleted successfully")
+}
+
+func (tc *trendingController) Routing() {
+	tc.router.GET("/campaigns/trending", tc.getTrendingHandler)
+	tc.router.POST("/campaigns/trending/refresh", tc.authMiddleware.CheckToken("admin"), tc.refreshTrendingHandler)
+	tc.router.GET("/campaigns/featured", tc.authMiddleware.CheckToken("admin"), tc.getFeaturedHandler)
+	tc.router.POST("/campaigns/featured", tc.authMiddleware.CheckToken("admin"), tc.createFeaturedHandler)
+	tc.router.DELETE("/campaigns/featured/:featured_id", tc.authMiddleware.CheckToken("admin"), tc.deleteFeaturedHandler)
+}
+
+func NewTrendingController(trendingUseCase usecase.TrendingUseCase, rg *gin.RouterGroup, authMiddleware middleware.AuthMiddleware) *trendingController {
+	return &trendingController{
+		trendingUseCase: trendingUseCase,
+		router:          rg,
+		authMiddleware:  authMiddleware,
+	}
+}
@@ -1,11 +1,14 @@
 package controller
 
 import (
+	"database/sql"
+	"errors"
 	"eternal-fund/middleware"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
 	commonresponse "eternal-fund/model/dto/common_response"
 	"eternal-fund/usecase"
+	"eternal-fund/usecase/service"
 	"fmt"
 	"net/http"
 	"os"
@@ -109,7 +112,7 @@ func (u *userController) updateUserHandler(ctx *gin.Context) {
 }
 
 func (u *userController) saveAvatarHandler(ctx *gin.Context) {
-	userIdStr := ctx.Param("id")
+	userIdStr := ctx.Param("user_id")
 	userId, err := strconv.Atoi(userIdStr)
 	if err != nil {
 		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
@@ -167,13 +170,101 @@ func (u *userController) isEmailAvailableHandler(ctx *gin.Context) {
 	})
 }
 
+func (u *userController) deleteUserHandler(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	actor := model.User{ID: ctx.GetInt("userID"), Role: ctx.GetString("role")}
+	if err := u.userUseCase.DeleteUser(userId, actor); err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrUserForbidden) {
+			code = http.StatusForbidden
+		} else if errors.Is(err, sql.ErrNoRows) {
+			code = http.StatusNotFound
+		}
+		commonresponse.SendErrorResponse(ctx, code, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, nil, "User deleted successfully")
+}
+
+func (u *userController) getDeletedUsersHandler(ctx *gin.Context) {
+	users, err := u.userUseCase.FindDeleted()
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var data []interface{}
+	for _, user := range users {
+		data = append(data, user)
+	}
+	commonresponse.SendManyResponse(ctx, data, dto.Paging{}, "Deleted users retrieved successfully")
+}
+
+func (u *userController) restoreUserHandler(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := u.userUseCase.RestoreUser(userId)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, sql.ErrNoRows) {
+			code = http.StatusNotFound
+		}
+		commonresponse.SendErrorResponse(ctx, code, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, user, "User restored successfully")
+}
+
+func (u *userController) verifyEmailHandler(ctx *gin.Context) {
+	user, err := u.userUseCase.VerifyEmail(ctx.Query("token"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidLinkToken) {
+			code = http.StatusBadRequest
+		}
+		commonresponse.SendErrorResponse(ctx, code, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, user, "Email verified successfully")
+}
+
+func (u *userController) resendVerificationHandler(ctx *gin.Context) {
+	if err := u.userUseCase.SendVerificationEmail(ctx.GetInt("userID")); err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrEmailAlreadyVerified) {
+			code = http.StatusConflict
+		}
+		commonresponse.SendErrorResponse(ctx, code, err.Error())
+		return
+	}
+
+	commonresponse.SendSingleResponse(ctx, nil, "Verification email sent")
+}
+
 func (u *userController) Routing() {
 	u.router.GET("/users", u.authMiddleware.CheckToken("user"), u.listHandler)
 	u.router.GET("/users/:user_id", u.authMiddleware.CheckToken("user", "admin"), u.getByIdHandler)
 	u.router.POST("/register", u.registerHandler)
 	u.router.PUT("/users/:user_id", u.authMiddleware.CheckToken("user", "admin"), u.updateUserHandler)
-	u.router.POST("/users/:id/avatar", u.authMiddleware.CheckToken("user"), u.saveAvatarHandler)
+	u.router.DELETE("/users/:user_id", u.authMiddleware.CheckToken("user", "admin"), u.deleteUserHandler)
+	u.router.GET("/users/deleted", u.authMiddleware.CheckToken("admin"), u.getDeletedUsersHandler)
+	u.router.POST("/users/:user_id/restore", u.authMiddleware.CheckToken("admin"), u.restoreUserHandler)
+	u.router.POST("/users/:user_id/avatar", u.authMiddleware.CheckToken("user"), u.saveAvatarHandler)
 	u.router.POST("/users/check-email", u.isEmailAvailableHandler)
+	u.router.GET("/users/verify-email", u.verifyEmailHandler)
+	u.router.POST("/users/verify-email/resend", u.authMiddleware.CheckToken("user", "admin"), u.resendVerificationHandler)
 
 }
 
@@ -12,6 +12,7 @@ import (
 
 type AuthMiddleware interface {
 	CheckToken(roles ...string) gin.HandlerFunc
+	Identify() gin.HandlerFunc
 }
 type authMiddleware struct {
 	jwtService service.JwtService
@@ -65,12 +66,44 @@ func (a *authMiddleware) CheckToken(roles ...string) gin.HandlerFunc {
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
+		ctx.Set("role", claims["role"])
 		ctx.Next()
 
 	}
 
 }
 
+// Identify records who is calling when the request carries a valid access
+// token, without rejecting anyone. Routes still check access with CheckToken;
+// this lets middleware that runs before them, such as idempotency, key on the
+// authenticated user.
+func (a *authMiddleware) Identify() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		if header == "" {
+			ctx.Next()
+			return
+		}
+		claims, err := a.jwtService.ValidateToken(strings.Replace(header, "Bearer ", "", -1))
+		if err != nil {
+			ctx.Next()
+			return
+		}
+		userIdStr, ok := claims["userId"].(string)
+		if !ok {
+			ctx.Next()
+			return
+		}
+		userId, err := strconv.Atoi(userIdStr)
+		if err != nil {
+			ctx.Next()
+			return
+		}
+		ctx.Set("userID", userId)
+		ctx.Next()
+	}
+}
+
 func NewAuthMiddleware(jwtService service.JwtService) AuthMiddleware {
 	return &authMiddleware{jwtService: jwtService}
 }
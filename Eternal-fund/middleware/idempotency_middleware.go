@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	commonresponse "eternal-fund/model/dto/common_response"
+	"eternal-fund/usecase"
+	"eternal-fund/usecase/service"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	GuestTokenHeader         = "Guest-Token"
+	maxIdempotencyKeyLength  = 255
+)
+
+type IdempotencyMiddleware interface {
+	Check() gin.HandlerFunc
+}
+
+type idempotencyMiddleware struct {
+	idempotencyUC usecase.IdempotencyUseCase
+	jwtService    service.JwtService
+}
+
+// responseRecorder keeps a copy of everything written to the client so the
+// response can be stored against the idempotency key.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func hashHex(parts ...[]byte) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func sendStatus(ctx *gin.Context, code int, message string) {
+	ctx.AbortWithStatusJSON(code, &dto.SingleResponse{
+		Status: dto.Status{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
+
+func sendConflict(ctx *gin.Context, message string) {
+	sendStatus(ctx, http.StatusConflict, message)
+}
+
+// scopeOf names whose keys the request uses: the user authenticated by
+// AuthMiddleware.Identify, or a guest by the Guest-Token the server issued
+// them. A guest without one is sent a fresh token to retry with, since
+// guests must never share keys and see each other's responses.
+func (i *idempotencyMiddleware) scopeOf(ctx *gin.Context) (string, bool) {
+	if userID, ok := ctx.Get("userID"); ok {
+		return hashHex([]byte(fmt.Sprintf("user:%v", userID))), true
+	}
+	if ctx.GetHeader("Authorization") != "" {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return "", false
+	}
+
+	guestID, err := i.jwtService.ValidateLinkToken(service.LinkPurposeGuestSession, ctx.GetHeader(GuestTokenHeader))
+	if err == nil {
+		return hashHex([]byte("guest:" + guestID)), true
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return "", false
+	}
+	token, err := i.jwtService.CreateLinkToken(service.LinkPurposeGuestSession, hex.EncodeToString(buf))
+	if err != nil {
+		commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return "", false
+	}
+	ctx.Header(GuestTokenHeader, token)
+	sendStatus(ctx, http.StatusBadRequest, "Idempotency-Key needs the Guest-Token header from this response when not signed in")
+	return "", false
+}
+
+// Check makes mutating requests that carry an Idempotency-Key header safe to
+// retry. Keys are scoped to the authenticated user or the guest, so the same
+// key sent by two callers never collides.
+func (i *idempotencyMiddleware) Check() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutating(ctx.Request.Method) {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		scope, ok := i.scopeOf(ctx)
+		if !ok {
+			return
+		}
+
+		body, err := ctx.GetRawData()
+		if err != nil {
+			commonresponse.SendErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		path := ctx.Request.URL.RequestURI()
+		record := model.IdempotencyRecord{
+			Key:         key,
+			Scope:       scope,
+			Method:      ctx.Request.Method,
+			Path:        path,
+			Fingerprint: hashHex([]byte(ctx.Request.Method), []byte(path), body),
+		}
+
+		stored, replay, err := i.idempotencyUC.Begin(record)
+		if err != nil {
+			switch {
+			case errors.Is(err, usecase.ErrIdempotencyKeyReused), errors.Is(err, usecase.ErrIdempotencyInProgress):
+				sendConflict(ctx, err.Error())
+			default:
+				commonresponse.SendErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+		if replay {
+			ctx.Header(IdempotentReplayedHeader, "true")
+			ctx.Data(stored.StatusCode, stored.ContentType, stored.ResponseBody)
+			ctx.Abort()
+			return
+		}
+
+		// A handler that panics never answers, so free the key for a retry
+		// before the panic reaches the recovery middleware.
+		defer func() {
+			if r := recover(); r != nil {
+				if err := i.idempotencyUC.Abandon(stored); err != nil {
+					log.Println("Failed to release idempotency key:", err)
+				}
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		// Server errors are not stored so the client can retry with the same key.
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := i.idempotencyUC.Abandon(stored); err != nil {
+				log.Println("Failed to release idempotency key:", err)
+			}
+			return
+		}
+
+		stored.StatusCode = recorder.Status()
+		stored.ContentType = recorder.Header().Get("Content-Type")
+		stored.ResponseBody = recorder.body.Bytes()
+		if err := i.idempotencyUC.Finish(stored); err != nil {
+			log.Println("Failed to store idempotent response:", err)
+		}
+	}
+}
+
+func NewIdempotencyMiddleware(idempotencyUC usecase.IdempotencyUseCase, jwtService service.JwtService) IdempotencyMiddleware {
+	return &idempotencyMiddleware{idempotencyUC: idempotencyUC, jwtService: jwtService}
+}
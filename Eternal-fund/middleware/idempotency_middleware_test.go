@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"errors"
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"eternal-fund/usecase/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyMiddlewareTestSuite struct {
+	suite.Suite
+	router        *gin.Engine
+	idempotencyUC *mocking.IdempotencyUseCaseMock
+	jwtService    *mocking.JwtServiceMock
+}
+
+func (suite *IdempotencyMiddlewareTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.idempotencyUC = new(mocking.IdempotencyUseCaseMock)
+	suite.jwtService = new(mocking.JwtServiceMock)
+	suite.router = gin.New()
+	suite.router.Use(gin.Recovery(), func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "Bearer user-7" {
+			ctx.Set("userID", 7)
+		}
+	}, NewIdempotencyMiddleware(suite.idempotencyUC, suite.jwtService).Check())
+	suite.router.POST("/donations", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+	suite.router.POST("/panics", func(ctx *gin.Context) {
+		panic("boom")
+	})
+}
+
+func (suite *IdempotencyMiddlewareTestSuite) send(path string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(`{"amount":50000}`))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *IdempotencyMiddlewareTestSuite) TestScopesByUserAcrossTokens() {
+	var scopes []string
+	suite.idempotencyUC.On("Begin", mock.Anything).Run(func(args mock.Arguments) {
+		scopes = append(scopes, args.Get(0).(model.IdempotencyRecord).Scope)
+	}).Return(model.IdempotencyRecord{Key: "key-1"}, false, nil)
+	suite.idempotencyUC.On("Finish", mock.Anything).Return(nil)
+
+	suite.send("/donations", map[string]string{"Authorization": "Bearer user-7"})
+	suite.send("/donations", map[string]string{"Authorization": "Bearer user-7"})
+
+	assert.Len(suite.T(), scopes, 2)
+	assert.Equal(suite.T(), hashHex([]byte("user:7")), scopes[0])
+	assert.Equal(suite.T(), scopes[0], scopes[1])
+}
+
+func (suite *IdempotencyMiddlewareTestSuite) TestInvalidTokenRejected() {
+	w := suite.send("/donations", map[string]string{"Authorization": "Bearer expired"})
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	suite.idempotencyUC.AssertNotCalled(suite.T(), "Begin", mock.Anything)
+}
+
+func (suite *IdempotencyMiddlewareTestSuite) TestGuestWithoutTokenIsIssuedOne() {
+	suite.jwtService.On("ValidateLinkToken", service.LinkPurposeGuestSession, "").Return("", service.ErrInvalidLinkToken)
+	suite.jwtService.On("CreateLinkToken", service.LinkPurposeGuestSession, mock.Anything).Return("guest-token", nil)
+
+	w := suite.send("/donations", nil)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), "guest-token", w.Header().Get(GuestTokenHeader))
+	suite.idempotencyUC.AssertNotCalled(suite.T(), "Begin", mock.Anything)
+}
+
+func (suite *IdempotencyMiddlewareTestSuite) TestGuestsDoNotShareKeys() {
+	suite.jwtService.On("ValidateLinkToken", service.LinkPurposeGuestSession, "token-a").Return("a", nil)
+	suite.jwtService.On("ValidateLinkToken", service.LinkPurposeGuestSession, "token-b").Return("b", nil)
+	var scopes []string
+	suite.idempotencyUC.On("Begin", mock.Anything).Run(func(args mock.Arguments) {
+		scopes = append(scopes, args.Get(0).(model.IdempotencyRecord).Scope)
+	}).Return(model.IdempotencyRecord{Key: "key-1"}, false, nil)
+	suite.idempotencyUC.On("Finish", mock.Anything).Return(nil)
+
+	suite.send("/donations", map[string]string{GuestTokenHeader: "token-a"})
+	suite.send("/donations", map[string]string{GuestTokenHeader: "token-b"})
+
+	assert.Equal(suite.T(), []string{hashHex([]byte("guest:a")), hashHex([]byte("guest:b"))}, scopes)
+}
+
+func (suite *IdempotencyMiddlewareTestSuite) TestPanicReleasesKey() {
+	reserved := model.IdempotencyRecord{Key: "key-1", Scope: hashHex([]byte("user:7"))}
+	suite.idempotencyUC.On("Begin", mock.Anything).Return(reserved, false, nil)
+	suite.idempotencyUC.On("Abandon", reserved).Return(errors.New("ignored")).Once()
+
+	w := suite.send("/panics", map[string]string{"Authorization": "Bearer user-7"})
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+	suite.idempotencyUC.AssertExpectations(suite.T())
+	suite.idempotencyUC.AssertNotCalled(suite.T(), "Finish", mock.Anything)
+}
+
+func TestIdempotencyMiddlewareTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyMiddlewareTestSuite))
+}
@@ -13,3 +13,7 @@ func (a *AuthMiddlewareMock) CheckToken(roles ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {}
 
 }
+
+func (a *AuthMiddlewareMock) Identify() gin.HandlerFunc {
+	return func(ctx *gin.Context) {}
+}
@@ -0,0 +1,36 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type BankAccountRepoMock struct {
+	mock.Mock
+}
+
+func (m *BankAccountRepoMock) Save(account model.BankAccount) (model.BankAccount, error) {
+	args := m.Called(account)
+	return args.Get(0).(model.BankAccount), args.Error(1)
+}
+
+func (m *BankAccountRepoMock) FindByID(id int) (model.BankAccount, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.BankAccount), args.Error(1)
+}
+
+func (m *BankAccountRepoMock) FindByUserID(userID int) ([]model.BankAccount, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.BankAccount), args.Error(1)
+}
+
+func (m *BankAccountRepoMock) FindByStatus(status string) ([]model.BankAccount, error) {
+	args := m.Called(status)
+	return args.Get(0).([]model.BankAccount), args.Error(1)
+}
+
+func (m *BankAccountRepoMock) UpdateStatus(id int, status string, reviewedBy int) (model.BankAccount, error) {
+	args := m.Called(id, status, reviewedBy)
+	return args.Get(0).(model.BankAccount), args.Error(1)
+}
@@ -0,0 +1,17 @@
+package mocking
+
+import "github.com/stretchr/testify/mock"
+
+type BlobStorageMock struct {
+	mock.Mock
+}
+
+func (m *BlobStorageMock) Put(key string, data []byte) error {
+	args := m.Called(key, data)
+	return args.Error(0)
+}
+
+func (m *BlobStorageMock) Get(key string) ([]byte, error) {
+	args := m.Called(key)
+	return args.Get(0).([]byte), args.Error(1)
+}
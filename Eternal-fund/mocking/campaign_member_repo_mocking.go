@@ -0,0 +1,56 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type CampaignMemberRepoMock struct {
+	mock.Mock
+}
+
+func (m *CampaignMemberRepoMock) Save(member model.CampaignMember) (model.CampaignMember, error) {
+	args := m.Called(member)
+	return args.Get(0).(model.CampaignMember), args.Error(1)
+}
+
+func (m *CampaignMemberRepoMock) FindByID(id int) (model.CampaignMember, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.CampaignMember), args.Error(1)
+}
+
+func (m *CampaignMemberRepoMock) FindByCampaignID(campaignID int) ([]model.CampaignMember, error) {
+	args := m.Called(campaignID)
+	return args.Get(0).([]model.CampaignMember), args.Error(1)
+}
+
+func (m *CampaignMemberRepoMock) FindByCampaignAndEmail(campaignID int, email string) (model.CampaignMember, error) {
+	args := m.Called(campaignID, email)
+	return args.Get(0).(model.CampaignMember), args.Error(1)
+}
+
+func (m *CampaignMemberRepoMock) FindMembership(campaignID int, userID int) (model.CampaignMember, error) {
+	args := m.Called(campaignID, userID)
+	return args.Get(0).(model.CampaignMember), args.Error(1)
+}
+
+func (m *CampaignMemberRepoMock) FindPendingByEmail(email string) ([]model.CampaignMember, error) {
+	args := m.Called(email)
+	return args.Get(0).([]model.CampaignMember), args.Error(1)
+}
+
+func (m *CampaignMemberRepoMock) CountOwners(campaignID int) (int, error) {
+	args := m.Called(campaignID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *CampaignMemberRepoMock) Update(member model.CampaignMember) (model.CampaignMember, error) {
+	args := m.Called(member)
+	return args.Get(0).(model.CampaignMember), args.Error(1)
+}
+
+func (m *CampaignMemberRepoMock) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
@@ -0,0 +1,47 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type CampaignRankingRepoMock struct {
+	mock.Mock
+}
+
+func (m *CampaignRankingRepoMock) FindDonationVelocity(now time.Time, halfLife time.Duration) ([]model.CampaignRanking, error) {
+	args := m.Called(now, halfLife)
+	return args.Get(0).([]model.CampaignRanking), args.Error(1)
+}
+
+func (m *CampaignRankingRepoMock) SaveRankings(rankings []model.CampaignRanking) error {
+	args := m.Called(rankings)
+	return args.Error(0)
+}
+
+func (m *CampaignRankingRepoMock) FindRankings(limit int) ([]model.CampaignRanking, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]model.CampaignRanking), args.Error(1)
+}
+
+func (m *CampaignRankingRepoMock) CreateFeatured(featured model.FeaturedCampaign) (model.FeaturedCampaign, error) {
+	args := m.Called(featured)
+	return args.Get(0).(model.FeaturedCampaign), args.Error(1)
+}
+
+func (m *CampaignRankingRepoMock) CountOverlappingFeatured(slot int, startsAt time.Time, endsAt time.Time) (int, error) {
+	args := m.Called(slot, startsAt, endsAt)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *CampaignRankingRepoMock) FindUpcomingFeatured(now time.Time) ([]model.FeaturedCampaign, error) {
+	args := m.Called(now)
+	return args.Get(0).([]model.FeaturedCampaign), args.Error(1)
+}
+
+func (m *CampaignRankingRepoMock) DeleteFeatured(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
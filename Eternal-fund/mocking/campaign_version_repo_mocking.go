@@ -0,0 +1,26 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type CampaignVersionRepoMock struct {
+	mock.Mock
+}
+
+func (m *CampaignVersionRepoMock) Save(version model.CampaignVersion) (model.CampaignVersion, error) {
+	args := m.Called(version)
+	return args.Get(0).(model.CampaignVersion), args.Error(1)
+}
+
+func (m *CampaignVersionRepoMock) FindByCampaignID(campaignID int) ([]model.CampaignVersion, error) {
+	args := m.Called(campaignID)
+	return args.Get(0).([]model.CampaignVersion), args.Error(1)
+}
+
+func (m *CampaignVersionRepoMock) FindByVersion(campaignID int, version int) (model.CampaignVersion, error) {
+	args := m.Called(campaignID, version)
+	return args.Get(0).(model.CampaignVersion), args.Error(1)
+}
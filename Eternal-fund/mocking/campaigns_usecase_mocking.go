@@ -25,13 +25,18 @@ func (m *CampaignsUseCaseMock) FindByIdCampaigns(id int) (model.Campaigns, error
 	return args.Get(0).(model.Campaigns), args.Error(1)
 }
 
-func (m *CampaignsUseCaseMock) UpdateCampaigns(id int) (model.Campaigns, error) {
-	args := m.Called(id)
+func (m *CampaignsUseCaseMock) FindBySlug(campaignSlug string) (model.Campaigns, error) {
+	args := m.Called(campaignSlug)
 	return args.Get(0).(model.Campaigns), args.Error(1)
 }
 
-func (m *CampaignsUseCaseMock) DeleteCampaigns(id int) error {
-	args := m.Called(id)
+func (m *CampaignsUseCaseMock) UpdateCampaigns(id int, input model.UpdateCampaignInput) (model.Campaigns, error) {
+	args := m.Called(id, input)
+	return args.Get(0).(model.Campaigns), args.Error(1)
+}
+
+func (m *CampaignsUseCaseMock) DeleteCampaigns(id int, user model.User) error {
+	args := m.Called(id, user)
 	return args.Error(0)
 }
 
@@ -39,3 +44,28 @@ func (m *CampaignsUseCaseMock) SaveCampaignImage(input model.CampaignImage, file
 	args := m.Called(input, fileLocation)
 	return args.Get(0).(model.CampaignImage), args.Error(1)
 }
+
+func (m *CampaignsUseCaseMock) FindDeletedCampaigns() ([]model.Campaigns, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Campaigns), args.Error(1)
+}
+
+func (m *CampaignsUseCaseMock) RestoreCampaigns(id int) (model.Campaigns, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Campaigns), args.Error(1)
+}
+
+func (m *CampaignsUseCaseMock) FindHistory(id int) ([]model.CampaignVersion, error) {
+	args := m.Called(id)
+	return args.Get(0).([]model.CampaignVersion), args.Error(1)
+}
+
+func (m *CampaignsUseCaseMock) DiffVersions(id int, from int, to int) (model.CampaignVersionDiff, error) {
+	args := m.Called(id, from, to)
+	return args.Get(0).(model.CampaignVersionDiff), args.Error(1)
+}
+
+func (m *CampaignsUseCaseMock) RollbackCampaign(id int, version int, user model.User) (model.Campaigns, error) {
+	args := m.Called(id, version, user)
+	return args.Get(0).(model.Campaigns), args.Error(1)
+}
@@ -4,22 +4,28 @@ import (
 	"database/sql"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
 
 type CampaignRepoMock struct {
-    mock.Mock
+	mock.Mock
 }
 
 func (m *CampaignRepoMock) FindByIdCampaigns(id int) (model.Campaigns, error) {
-    args := m.Called(id)
-    return args.Get(0).(model.Campaigns), args.Error(1)
+	args := m.Called(id)
+	return args.Get(0).(model.Campaigns), args.Error(1)
 }
 
-func (m *CampaignRepoMock) UpdateCampaigns(id int) (model.Campaigns, error) {
-    args := m.Called(id)
-    return args.Get(0).(model.Campaigns), args.Error(1)
+func (m *CampaignRepoMock) FindByIDs(ids []int) ([]model.Campaigns, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]model.Campaigns), args.Error(1)
+}
+
+func (m *CampaignRepoMock) UpdateCampaigns(campaign model.Campaigns, version model.CampaignVersion) (model.Campaigns, error) {
+	args := m.Called(campaign, version)
+	return args.Get(0).(model.Campaigns), args.Error(1)
 }
 
 func (m *CampaignRepoMock) FindAllCampaigns(page int, size int) ([]model.Campaigns, dto.Paging, error) {
@@ -33,18 +39,18 @@ func (m *CampaignRepoMock) CreateCampaigns(campaigns model.Campaigns) (model.Cam
 }
 
 func (m *CampaignRepoMock) CreateImage(image model.CampaignImage) (model.CampaignImage, error) {
-    args := m.Called(image)
-    return args.Get(0).(model.CampaignImage), args.Error(1)
+	args := m.Called(image)
+	return args.Get(0).(model.CampaignImage), args.Error(1)
 }
 
 func (m *CampaignRepoMock) DeleteCampaigns(id int) error {
-    args := m.Called(id)
-    return args.Error(0)
+	args := m.Called(id)
+	return args.Error(0)
 }
 
 func (m *CampaignRepoMock) FindByUserID(userID int) ([]model.Campaigns, error) {
-    args := m.Called(userID)
-    return args.Get(0).([]model.Campaigns), args.Error(1)
+	args := m.Called(userID)
+	return args.Get(0).([]model.Campaigns), args.Error(1)
 }
 
 func (m *CampaignRepoMock) MarkAllImagesAsNonPrimary(campaignID int) (bool, error) {
@@ -52,9 +58,41 @@ func (m *CampaignRepoMock) MarkAllImagesAsNonPrimary(campaignID int) (bool, erro
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *CampaignRepoMock) FindBySlug(slug string) (model.Campaigns, error) {
+	args := m.Called(slug)
+	return args.Get(0).(model.Campaigns), args.Error(1)
+}
+
+func (m *CampaignRepoMock) FindSlugOwner(slug string) (int, error) {
+	args := m.Called(slug)
+	return args.Int(0), args.Error(1)
+}
 
+func (m *CampaignRepoMock) FindCampaignIDBySlugHistory(slug string) (int, error) {
+	args := m.Called(slug)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *CampaignRepoMock) CountDonations(id int) (int, error) {
+	args := m.Called(id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *CampaignRepoMock) FindDeletedCampaigns() ([]model.Campaigns, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Campaigns), args.Error(1)
+}
+
+func (m *CampaignRepoMock) RestoreCampaigns(id int) (model.Campaigns, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Campaigns), args.Error(1)
+}
+
+func (m *CampaignRepoMock) PurgeDeletedCampaigns(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
 
 func NewCampaignRepoMock(db *sql.DB) *CampaignRepoMock {
 	return &CampaignRepoMock{}
 }
-
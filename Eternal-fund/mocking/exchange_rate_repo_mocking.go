@@ -0,0 +1,31 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ExchangeRateRepoMock struct {
+	mock.Mock
+}
+
+func (m *ExchangeRateRepoMock) Save(rate model.ExchangeRate) (model.ExchangeRate, error) {
+	args := m.Called(rate)
+	return args.Get(0).(model.ExchangeRate), args.Error(1)
+}
+
+func (m *ExchangeRateRepoMock) Find(base model.Currency, quote model.Currency) (model.ExchangeRate, error) {
+	args := m.Called(base, quote)
+	return args.Get(0).(model.ExchangeRate), args.Error(1)
+}
+
+func (m *ExchangeRateRepoMock) FindAll() ([]model.ExchangeRate, error) {
+	args := m.Called()
+	return args.Get(0).([]model.ExchangeRate), args.Error(1)
+}
+
+func (m *ExchangeRateRepoMock) Delete(base model.Currency, quote model.Currency) error {
+	args := m.Called(base, quote)
+	return args.Error(0)
+}
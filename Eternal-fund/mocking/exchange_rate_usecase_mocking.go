@@ -0,0 +1,31 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ExchangeRateUseCaseMock struct {
+	mock.Mock
+}
+
+func (m *ExchangeRateUseCaseMock) SaveRate(input model.ExchangeRateInput) (model.ExchangeRate, error) {
+	args := m.Called(input)
+	return args.Get(0).(model.ExchangeRate), args.Error(1)
+}
+
+func (m *ExchangeRateUseCaseMock) GetRates() ([]model.ExchangeRate, error) {
+	args := m.Called()
+	return args.Get(0).([]model.ExchangeRate), args.Error(1)
+}
+
+func (m *ExchangeRateUseCaseMock) DeleteRate(base string, quote string) error {
+	args := m.Called(base, quote)
+	return args.Error(0)
+}
+
+func (m *ExchangeRateUseCaseMock) Convert(amount int, from model.Currency, to model.Currency) (int, string, error) {
+	args := m.Called(amount, from, to)
+	return args.Int(0), args.String(1), args.Error(2)
+}
@@ -0,0 +1,37 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type IdempotencyRepoMock struct {
+	mock.Mock
+}
+
+func (m *IdempotencyRepoMock) Reserve(record model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	args := m.Called(record)
+	return args.Get(0).(model.IdempotencyRecord), args.Bool(1), args.Error(2)
+}
+
+func (m *IdempotencyRepoMock) Find(scope string, key string) (model.IdempotencyRecord, error) {
+	args := m.Called(scope, key)
+	return args.Get(0).(model.IdempotencyRecord), args.Error(1)
+}
+
+func (m *IdempotencyRepoMock) Complete(record model.IdempotencyRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *IdempotencyRepoMock) Release(scope string, key string) error {
+	args := m.Called(scope, key)
+	return args.Error(0)
+}
+
+func (m *IdempotencyRepoMock) PurgeBefore(cutoff time.Time) (int64, error) {
+	args := m.Called(cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,36 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type IdempotencyUseCaseMock struct {
+	mock.Mock
+}
+
+func (m *IdempotencyUseCaseMock) Begin(record model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	args := m.Called(record)
+	return args.Get(0).(model.IdempotencyRecord), args.Bool(1), args.Error(2)
+}
+
+func (m *IdempotencyUseCaseMock) Finish(record model.IdempotencyRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *IdempotencyUseCaseMock) Abandon(record model.IdempotencyRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *IdempotencyUseCaseMock) PurgeExpired() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *IdempotencyUseCaseMock) StartPurger(interval time.Duration) {
+	m.Called(interval)
+}
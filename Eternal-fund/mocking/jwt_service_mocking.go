@@ -0,0 +1,33 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+)
+
+type JwtServiceMock struct {
+	mock.Mock
+}
+
+func (m *JwtServiceMock) CreateToken(user model.User) (dto.AuthResponDto, error) {
+	args := m.Called(user)
+	return args.Get(0).(dto.AuthResponDto), args.Error(1)
+}
+
+func (m *JwtServiceMock) ValidateToken(token string) (jwt.MapClaims, error) {
+	args := m.Called(token)
+	return args.Get(0).(jwt.MapClaims), args.Error(1)
+}
+
+func (m *JwtServiceMock) CreateLinkToken(purpose string, subject string) (string, error) {
+	args := m.Called(purpose, subject)
+	return args.String(0), args.Error(1)
+}
+
+func (m *JwtServiceMock) ValidateLinkToken(purpose string, token string) (string, error) {
+	args := m.Called(purpose, token)
+	return args.String(0), args.Error(1)
+}
@@ -0,0 +1,32 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type LedgerRepoMock struct {
+	mock.Mock
+}
+
+func (m *LedgerRepoMock) Balance(account string, currency model.Currency) (model.LedgerBalance, error) {
+	args := m.Called(account, currency)
+	return args.Get(0).(model.LedgerBalance), args.Error(1)
+}
+
+func (m *LedgerRepoMock) FindEntries(account string, page int, size int) ([]model.LedgerEntry, dto.Paging, error) {
+	args := m.Called(account, page, size)
+	return args.Get(0).([]model.LedgerEntry), args.Get(1).(dto.Paging), args.Error(2)
+}
+
+func (m *LedgerRepoMock) FindUnbalancedJournals() ([]int, error) {
+	args := m.Called()
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *LedgerRepoMock) FindCampaignDiscrepancies() ([]model.LedgerDiscrepancy, error) {
+	args := m.Called()
+	return args.Get(0).([]model.LedgerDiscrepancy), args.Error(1)
+}
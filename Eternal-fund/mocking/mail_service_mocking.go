@@ -0,0 +1,21 @@
+package mocking
+
+import (
+	"eternal-fund/usecase/service"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MailServiceMock struct {
+	mock.Mock
+}
+
+func (m *MailServiceMock) Send(to string, subject string, body string) error {
+	args := m.Called(to, subject, body)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendWithAttachment(to string, subject string, body string, attachment service.Attachment) error {
+	args := m.Called(to, subject, body, attachment)
+	return args.Error(0)
+}
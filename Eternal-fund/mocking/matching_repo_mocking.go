@@ -0,0 +1,41 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MatchingRepoMock struct {
+	mock.Mock
+}
+
+func (m *MatchingRepoMock) Save(pledge model.MatchingPledge) (model.MatchingPledge, error) {
+	args := m.Called(pledge)
+	return args.Get(0).(model.MatchingPledge), args.Error(1)
+}
+
+func (m *MatchingRepoMock) FindByID(id int) (model.MatchingPledge, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.MatchingPledge), args.Error(1)
+}
+
+func (m *MatchingRepoMock) FindByCampaign(campaignID int) ([]model.MatchingPledge, error) {
+	args := m.Called(campaignID)
+	return args.Get(0).([]model.MatchingPledge), args.Error(1)
+}
+
+func (m *MatchingRepoMock) FindByCampaigns(campaignIDs []int) ([]model.MatchingPledge, error) {
+	args := m.Called(campaignIDs)
+	return args.Get(0).([]model.MatchingPledge), args.Error(1)
+}
+
+func (m *MatchingRepoMock) Cancel(id int) (model.MatchingPledge, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.MatchingPledge), args.Error(1)
+}
+
+func (m *MatchingRepoMock) FindContributions(pledgeID int) ([]model.MatchedContribution, error) {
+	args := m.Called(pledgeID)
+	return args.Get(0).([]model.MatchedContribution), args.Error(1)
+}
@@ -0,0 +1,37 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PaymentNotificationRepoMock struct {
+	mock.Mock
+}
+
+func (m *PaymentNotificationRepoMock) Save(notification model.PaymentNotification) (model.PaymentNotification, error) {
+	args := m.Called(notification)
+	return args.Get(0).(model.PaymentNotification), args.Error(1)
+}
+
+func (m *PaymentNotificationRepoMock) FindByID(id int) (model.PaymentNotification, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.PaymentNotification), args.Error(1)
+}
+
+func (m *PaymentNotificationRepoMock) FindAll(page int, size int) ([]model.PaymentNotification, dto.Paging, error) {
+	args := m.Called(page, size)
+	return args.Get(0).([]model.PaymentNotification), args.Get(1).(dto.Paging), args.Error(2)
+}
+
+func (m *PaymentNotificationRepoMock) IsProcessed(notification model.PaymentNotification) (bool, error) {
+	args := m.Called(notification)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *PaymentNotificationRepoMock) UpdateResult(id int, result string, errMessage string) error {
+	args := m.Called(id, result, errMessage)
+	return args.Error(0)
+}
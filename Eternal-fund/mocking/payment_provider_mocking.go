@@ -0,0 +1,40 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type PaymentProviderMock struct {
+	mock.Mock
+}
+
+func (m *PaymentProviderMock) SupportsCurrency(currency model.Currency) bool {
+	args := m.Called(currency)
+	return args.Bool(0)
+}
+
+func (m *PaymentProviderMock) CreateCharge(transaction model.Transaction, user model.User) (model.PaymentCharge, error) {
+	args := m.Called(transaction, user)
+	return args.Get(0).(model.PaymentCharge), args.Error(1)
+}
+
+func (m *PaymentProviderMock) ChargeToken(transaction model.Transaction, user model.User, cardToken string) (model.PaymentCharge, error) {
+	args := m.Called(transaction, user, cardToken)
+	return args.Get(0).(model.PaymentCharge), args.Error(1)
+}
+
+func (m *PaymentProviderMock) FetchStatus(orderID string) (model.TransactionNotificationInput, error) {
+	args := m.Called(orderID)
+	return args.Get(0).(model.TransactionNotificationInput), args.Error(1)
+}
+
+func (m *PaymentProviderMock) Refund(orderID string, refundKey string, amount int, reason string) (model.PaymentRefund, error) {
+	args := m.Called(orderID, refundKey, amount, reason)
+	return args.Get(0).(model.PaymentRefund), args.Error(1)
+}
+
+func (m *PaymentProviderMock) VerifyWebhook(notification model.TransactionNotificationInput) bool {
+	args := m.Called(notification)
+	return args.Bool(0)
+}
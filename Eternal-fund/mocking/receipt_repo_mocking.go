@@ -0,0 +1,36 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ReceiptRepoMock struct {
+	mock.Mock
+}
+
+func (m *ReceiptRepoMock) FindUnissued(limit int) ([]model.Transaction, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]model.Transaction), args.Error(1)
+}
+
+func (m *ReceiptRepoMock) Create(receipt model.Receipt) (model.Receipt, error) {
+	args := m.Called(receipt)
+	return args.Get(0).(model.Receipt), args.Error(1)
+}
+
+func (m *ReceiptRepoMock) FindByTransactionID(transactionID int) (model.Receipt, error) {
+	args := m.Called(transactionID)
+	return args.Get(0).(model.Receipt), args.Error(1)
+}
+
+func (m *ReceiptRepoMock) FindUndelivered(limit int) ([]model.Receipt, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]model.Receipt), args.Error(1)
+}
+
+func (m *ReceiptRepoMock) MarkDelivered(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
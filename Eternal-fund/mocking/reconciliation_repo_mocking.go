@@ -0,0 +1,48 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ReconciliationRepoMock struct {
+	mock.Mock
+}
+
+func (m *ReconciliationRepoMock) FindPendingTransactions(before time.Time, limit int) ([]model.Transaction, error) {
+	args := m.Called(before, limit)
+	return args.Get(0).([]model.Transaction), args.Error(1)
+}
+
+func (m *ReconciliationRepoMock) FindTransactionsByCodes(codes []string) ([]model.Transaction, error) {
+	args := m.Called(codes)
+	return args.Get(0).([]model.Transaction), args.Error(1)
+}
+
+func (m *ReconciliationRepoMock) FindPaidTransactions(from time.Time, to time.Time) ([]model.Transaction, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]model.Transaction), args.Error(1)
+}
+
+func (m *ReconciliationRepoMock) SaveItem(item model.ReconciliationItem) (model.ReconciliationItem, bool, error) {
+	args := m.Called(item)
+	return args.Get(0).(model.ReconciliationItem), args.Bool(1), args.Error(2)
+}
+
+func (m *ReconciliationRepoMock) SaveImport(settlement model.SettlementImport, items []model.ReconciliationItem) (model.SettlementImport, []model.ReconciliationItem, error) {
+	args := m.Called(settlement, items)
+	return args.Get(0).(model.SettlementImport), args.Get(1).([]model.ReconciliationItem), args.Error(2)
+}
+
+func (m *ReconciliationRepoMock) FindItems(all bool, page int, size int) ([]model.ReconciliationItem, dto.Paging, error) {
+	args := m.Called(all, page, size)
+	return args.Get(0).([]model.ReconciliationItem), args.Get(1).(dto.Paging), args.Error(2)
+}
+
+func (m *ReconciliationRepoMock) Resolve(id int, userID int, note string) (model.ReconciliationItem, error) {
+	args := m.Called(id, userID, note)
+	return args.Get(0).(model.ReconciliationItem), args.Error(1)
+}
@@ -0,0 +1,47 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type RecurringRepoMock struct {
+	mock.Mock
+}
+
+func (m *RecurringRepoMock) Save(plan model.RecurringPlan) (model.RecurringPlan, error) {
+	args := m.Called(plan)
+	return args.Get(0).(model.RecurringPlan), args.Error(1)
+}
+
+func (m *RecurringRepoMock) FindByID(id int) (model.RecurringPlan, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.RecurringPlan), args.Error(1)
+}
+
+func (m *RecurringRepoMock) FindByUserID(userID int) ([]model.RecurringPlan, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.RecurringPlan), args.Error(1)
+}
+
+func (m *RecurringRepoMock) FindDue(now time.Time) ([]model.RecurringPlan, error) {
+	args := m.Called(now)
+	return args.Get(0).([]model.RecurringPlan), args.Error(1)
+}
+
+func (m *RecurringRepoMock) FindSettled() ([]model.RecurringPlan, error) {
+	args := m.Called()
+	return args.Get(0).([]model.RecurringPlan), args.Error(1)
+}
+
+func (m *RecurringRepoMock) Update(plan model.RecurringPlan) (model.RecurringPlan, error) {
+	args := m.Called(plan)
+	return args.Get(0).(model.RecurringPlan), args.Error(1)
+}
+
+func (m *RecurringRepoMock) RecordCharge(plan model.RecurringPlan) (model.RecurringPlan, error) {
+	args := m.Called(plan)
+	return args.Get(0).(model.RecurringPlan), args.Error(1)
+}
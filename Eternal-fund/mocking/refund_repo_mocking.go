@@ -0,0 +1,36 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type RefundRepoMock struct {
+	mock.Mock
+}
+
+func (m *RefundRepoMock) Save(refund model.Refund) (model.Refund, error) {
+	args := m.Called(refund)
+	return args.Get(0).(model.Refund), args.Error(1)
+}
+
+func (m *RefundRepoMock) FindByTransactionID(transactionID int) ([]model.Refund, error) {
+	args := m.Called(transactionID)
+	return args.Get(0).([]model.Refund), args.Error(1)
+}
+
+func (m *RefundRepoMock) SumRefunded(transactionID int) (int, error) {
+	args := m.Called(transactionID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *RefundRepoMock) MarkFailed(refund model.Refund) (model.Refund, error) {
+	args := m.Called(refund)
+	return args.Get(0).(model.Refund), args.Error(1)
+}
+
+func (m *RefundRepoMock) MarkSucceeded(refund model.Refund, transaction model.Transaction) (model.Refund, error) {
+	args := m.Called(refund, transaction)
+	return args.Get(0).(model.Refund), args.Error(1)
+}
@@ -0,0 +1,16 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type RiskEngineMock struct {
+	mock.Mock
+}
+
+func (m *RiskEngineMock) Assess(transaction model.Transaction, campaign model.Campaigns) (model.RiskAssessment, error) {
+	args := m.Called(transaction, campaign)
+	return args.Get(0).(model.RiskAssessment), args.Error(1)
+}
@@ -0,0 +1,28 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type RiskRepoMock struct {
+	mock.Mock
+}
+
+func (m *RiskRepoMock) CountRecentByDonor(userID int, email string, since time.Time) (int, error) {
+	args := m.Called(userID, email, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *RiskRepoMock) CountRecentByIP(ip string, since time.Time) (int, error) {
+	args := m.Called(ip, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *RiskRepoMock) FindReviewQueue(page int, size int) ([]model.Transaction, dto.Paging, error) {
+	args := m.Called(page, size)
+	return args.Get(0).([]model.Transaction), args.Get(1).(dto.Paging), args.Error(2)
+}
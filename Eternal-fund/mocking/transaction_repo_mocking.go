@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -32,11 +33,26 @@ func (m *TransactionRepoMock) Save(transaction model.Transaction) (model.Transac
 	return args.Get(0).(model.Transaction), args.Error(1)
 }
 
-func (m *TransactionRepoMock) Update(transaction model.Transaction) (model.Transaction, error) {
-	args := m.Called(transaction)
+func (m *TransactionRepoMock) SaveWithinLimit(transaction model.Transaction, limit model.VelocityLimit) (model.Transaction, error) {
+	args := m.Called(transaction, limit)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
+func (m *TransactionRepoMock) ApplyStatus(change model.TransactionStatusChange) (model.Transaction, error) {
+	args := m.Called(change)
 	return args.Get(0).(model.Transaction), args.Error(1)
 }
 
+func (m *TransactionRepoMock) FindStatusLog(transactionID int) ([]model.TransactionStatusChange, error) {
+	args := m.Called(transactionID)
+	return args.Get(0).([]model.TransactionStatusChange), args.Error(1)
+}
+
+func (m *TransactionRepoMock) FindPaidTimes(transactionIDs []int) (map[int]time.Time, error) {
+	args := m.Called(transactionIDs)
+	return args.Get(0).(map[int]time.Time), args.Error(1)
+}
+
 func (m *TransactionRepoMock) UpdatePaymentURL(transaction model.Transaction) (model.Transaction, error) {
 	args := m.Called(transaction)
 	return args.Get(0).(model.Transaction), args.Error(1)
@@ -47,6 +63,36 @@ func (m *TransactionRepoMock) FindAll(page int, size int) ([]model.Transaction,
 	return args.Get(0).([]model.Transaction), args.Get(1).(dto.Paging), args.Error(2)
 }
 
+func (m *TransactionRepoMock) FindSupporters(campaignID int, page int, size int) ([]model.Supporter, dto.Paging, error) {
+	args := m.Called(campaignID, page, size)
+	return args.Get(0).([]model.Supporter), args.Get(1).(dto.Paging), args.Error(2)
+}
+
+func (m *TransactionRepoMock) FindSupporter(transactionID int) (model.Supporter, error) {
+	args := m.Called(transactionID)
+	return args.Get(0).(model.Supporter), args.Error(1)
+}
+
+func (m *TransactionRepoMock) FindPublicDonations(userID int) ([]model.Supporter, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Supporter), args.Error(1)
+}
+
+func (m *TransactionRepoMock) FindDonationExport(campaignID int) ([]model.DonationExportRow, error) {
+	args := m.Called(campaignID)
+	return args.Get(0).([]model.DonationExportRow), args.Error(1)
+}
+
+func (m *TransactionRepoMock) ClaimGuestTransactions(userID int, email string) ([]model.Transaction, error) {
+	args := m.Called(userID, email)
+	return args.Get(0).([]model.Transaction), args.Error(1)
+}
+
+func (m *TransactionRepoMock) FindCampaignReport(campaignID int) (model.CampaignReport, error) {
+	args := m.Called(campaignID)
+	return args.Get(0).(model.CampaignReport), args.Error(1)
+}
+
 func (m *TransactionRepoMock) GetByCode(code string) (*model.Transaction, error) {
 	args := m.Called(code)
 	return args.Get(0).(*model.Transaction), args.Error(1)
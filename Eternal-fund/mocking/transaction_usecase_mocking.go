@@ -8,50 +8,115 @@ import (
 )
 
 type TransactionUseCaseMock struct {
-    mock.Mock
+	mock.Mock
 }
 
-func (m *TransactionUseCaseMock) GetTransactionsByCampaignID(campaignID int) ([]model.Transaction, error) {
-    args := m.Called(campaignID)
-    return args.Get(0).([]model.Transaction), args.Error(1)
+func (m *TransactionUseCaseMock) GetTransactionsByCampaignID(campaignID int, user model.User) ([]model.Transaction, error) {
+	args := m.Called(campaignID, user)
+	return args.Get(0).([]model.Transaction), args.Error(1)
 }
 
-func (m *TransactionUseCaseMock) GetTransactionByID(transactionID int) (model.Transaction, error) {
-    args := m.Called(transactionID)
-    return args.Get(0).(model.Transaction), args.Error(1)
+func (m *TransactionUseCaseMock) GetTransactionByID(transactionID int, user model.User) (model.Transaction, error) {
+	args := m.Called(transactionID, user)
+	return args.Get(0).(model.Transaction), args.Error(1)
 }
 
-func (m *TransactionUseCaseMock) GetTransactionsByUserID(userID int) ([]model.Transaction, error) {
-    args := m.Called(userID)
-    return args.Get(0).([]model.Transaction), args.Error(1)
+func (m *TransactionUseCaseMock) GetTransactionsByUserID(userID int, user model.User) ([]model.Transaction, error) {
+	args := m.Called(userID, user)
+	return args.Get(0).([]model.Transaction), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) GetSupporter(transactionID int) (model.Supporter, error) {
+	args := m.Called(transactionID)
+	return args.Get(0).(model.Supporter), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) GetPublicDonations(userID int) ([]model.Supporter, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Supporter), args.Error(1)
 }
 
 func (m *TransactionUseCaseMock) CreateTransaction(input model.CreateTransactionInput) (model.Transaction, error) {
-    args := m.Called(input)
-    return args.Get(0).(model.Transaction), args.Error(1)
+	args := m.Called(input)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) CreateGuestTransaction(input model.CreateGuestTransactionInput) (model.Transaction, string, error) {
+	args := m.Called(input)
+	return args.Get(0).(model.Transaction), args.String(1), args.Error(2)
+}
+
+func (m *TransactionUseCaseMock) GetGuestTransaction(token string) (model.Transaction, error) {
+	args := m.Called(token)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) ClaimGuestTransactions(user model.User) ([]model.Transaction, error) {
+	args := m.Called(user)
+	return args.Get(0).([]model.Transaction), args.Error(1)
 }
 
 func (m *TransactionUseCaseMock) UpdateTransaction(transactionID int, input model.UpdateTransactionInput) (model.Transaction, error) {
-    args := m.Called(transactionID, input)
-    return args.Get(0).(model.Transaction), args.Error(1)
+	args := m.Called(transactionID, input)
+	return args.Get(0).(model.Transaction), args.Error(1)
 }
 
-func (m *TransactionUseCaseMock) UpdateTransactionStatus(orderID string, transactionStatus string) (model.Transaction, error) {
-    args := m.Called(orderID, transactionStatus)
-    return args.Get(0).(model.Transaction), args.Error(1)
+func (m *TransactionUseCaseMock) GetStatusLog(transactionID int) ([]model.TransactionStatusChange, error) {
+	args := m.Called(transactionID)
+	return args.Get(0).([]model.TransactionStatusChange), args.Error(1)
 }
 
 func (m *TransactionUseCaseMock) GetAllTransactions(page, limit int) ([]model.Transaction, dto.Paging, error) {
-    args := m.Called(page, limit)
-    return args.Get(0).([]model.Transaction), args.Get(1).(dto.Paging), args.Error(2)
+	args := m.Called(page, limit)
+	return args.Get(0).([]model.Transaction), args.Get(1).(dto.Paging), args.Error(2)
 }
 
 func (m *TransactionUseCaseMock) GetPaymentURL(transaction model.Transaction, user model.User) (string, error) {
-    args := m.Called(transaction, user)
-    return args.String(0), args.Error(1)
+	args := m.Called(transaction, user)
+	return args.String(0), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) ProcessPayment(input model.TransactionNotificationInput) (model.Transaction, error) {
+	args := m.Called(input)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) HandleNotification(notification model.PaymentNotification) (model.Transaction, error) {
+	args := m.Called(notification)
+	return args.Get(0).(model.Transaction), args.Error(1)
 }
 
-func (m *TransactionUseCaseMock) ProcessPayment(input model.TransactionNotificationInput) error {
-    args := m.Called(input)
-    return args.Error(0)
-}
\ No newline at end of file
+func (m *TransactionUseCaseMock) ReplayNotification(id int) (model.Transaction, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) FindNotifications(page int, size int) ([]model.PaymentNotification, dto.Paging, error) {
+	args := m.Called(page, size)
+	return args.Get(0).([]model.PaymentNotification), args.Get(1).(dto.Paging), args.Error(2)
+}
+
+func (m *TransactionUseCaseMock) RefundTransaction(transactionID int, input model.RefundInput) (model.Refund, error) {
+	args := m.Called(transactionID, input)
+	return args.Get(0).(model.Refund), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) GetRefunds(transactionID int, user model.User) ([]model.Refund, error) {
+	args := m.Called(transactionID, user)
+	return args.Get(0).([]model.Refund), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) GetCampaignReport(campaignID int, user model.User) (model.CampaignReport, error) {
+	args := m.Called(campaignID, user)
+	return args.Get(0).(model.CampaignReport), args.Error(1)
+}
+
+func (m *TransactionUseCaseMock) GetSupporters(campaignID int, page int, size int) ([]model.Supporter, dto.Paging, error) {
+	args := m.Called(campaignID, page, size)
+	return args.Get(0).([]model.Supporter), args.Get(1).(dto.Paging), args.Error(2)
+}
+
+func (m *TransactionUseCaseMock) ExportDonations(campaignID int, user model.User) ([]byte, error) {
+	args := m.Called(campaignID, user)
+	return args.Get(0).([]byte), args.Error(1)
+}
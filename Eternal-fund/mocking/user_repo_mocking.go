@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -27,6 +28,11 @@ func (m *UserRepoMock) FindByEmail(email string) (model.User, error) {
 	return args.Get(0).(model.User), args.Error(1)
 }
 
+func (m *UserRepoMock) MarkEmailVerified(email string) (model.User, error) {
+	args := m.Called(email)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
 func (m *UserRepoMock) Save(user model.User) (model.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(model.User), args.Error(1)
@@ -47,6 +53,26 @@ func (m *UserRepoMock) IsEmailAvailable(input model.CheckEmailInput) (bool, erro
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *UserRepoMock) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *UserRepoMock) FindDeleted() ([]model.User, error) {
+	args := m.Called()
+	return args.Get(0).([]model.User), args.Error(1)
+}
+
+func (m *UserRepoMock) Restore(id int) (model.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *UserRepoMock) PurgeDeleted(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func NewUserRepoMock(db *sql.DB) *UserRepoMock {
 	return &UserRepoMock{}
 }
\ No newline at end of file
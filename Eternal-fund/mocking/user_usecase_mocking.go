@@ -3,6 +3,7 @@ package mocking
 import (
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -48,6 +49,52 @@ func (m *UserUseCaseMock) UpdateUser(id int, input model.User) (model.User, erro
 	args := m.Called(id, input)
 	return args.Get(0).(model.User), args.Error(1)
 }
+
+func (m *UserUseCaseMock) DeleteUser(id int, actor model.User) error {
+	args := m.Called(id, actor)
+	return args.Error(0)
+}
+
+func (m *UserUseCaseMock) FindDeleted() ([]model.User, error) {
+	args := m.Called()
+	return args.Get(0).([]model.User), args.Error(1)
+}
+
+func (m *UserUseCaseMock) RestoreUser(id int) (model.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *UserUseCaseMock) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *UserUseCaseMock) Restore(id int) (model.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *UserUseCaseMock) PurgeDeleted(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *UserUseCaseMock) MarkEmailVerified(email string) (model.User, error) {
+	args := m.Called(email)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *UserUseCaseMock) SendVerificationEmail(userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *UserUseCaseMock) VerifyEmail(token string) (model.User, error) {
+	args := m.Called(token)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
 func NewUserUseCaseMock() *UserUseCaseMock {
 	return &UserUseCaseMock{}
 }
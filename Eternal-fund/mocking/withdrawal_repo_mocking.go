@@ -0,0 +1,61 @@
+package mocking
+
+import (
+	"eternal-fund/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type WithdrawalRepoMock struct {
+	mock.Mock
+}
+
+func (m *WithdrawalRepoMock) AvailableBalance(campaignID int) (int, error) {
+	args := m.Called(campaignID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *WithdrawalRepoMock) Create(withdrawal model.Withdrawal) (model.Withdrawal, error) {
+	args := m.Called(withdrawal)
+	return args.Get(0).(model.Withdrawal), args.Error(1)
+}
+
+func (m *WithdrawalRepoMock) FindByID(id int) (model.Withdrawal, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Withdrawal), args.Error(1)
+}
+
+func (m *WithdrawalRepoMock) FindByCampaignID(campaignID int) ([]model.Withdrawal, error) {
+	args := m.Called(campaignID)
+	return args.Get(0).([]model.Withdrawal), args.Error(1)
+}
+
+func (m *WithdrawalRepoMock) FindByStatus(status model.WithdrawalStatus) ([]model.Withdrawal, error) {
+	args := m.Called(status)
+	return args.Get(0).([]model.Withdrawal), args.Error(1)
+}
+
+func (m *WithdrawalRepoMock) UpdateStatus(id int, status model.WithdrawalStatus, reviewedBy int, note string) (model.Withdrawal, error) {
+	args := m.Called(id, status, reviewedBy, note)
+	return args.Get(0).(model.Withdrawal), args.Error(1)
+}
+
+func (m *WithdrawalRepoMock) CreateBatch(createdBy int) (model.PayoutBatch, error) {
+	args := m.Called(createdBy)
+	return args.Get(0).(model.PayoutBatch), args.Error(1)
+}
+
+func (m *WithdrawalRepoMock) FindBatch(id int) (model.PayoutBatch, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.PayoutBatch), args.Error(1)
+}
+
+func (m *WithdrawalRepoMock) FindBatchLines(batchID int) ([]model.PayoutLine, error) {
+	args := m.Called(batchID)
+	return args.Get(0).([]model.PayoutLine), args.Error(1)
+}
+
+func (m *WithdrawalRepoMock) CompleteBatch(id int) (model.PayoutBatch, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.PayoutBatch), args.Error(1)
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+const (
+	CampaignRoleOwner  = "owner"
+	CampaignRoleEditor = "editor"
+	CampaignRoleViewer = "viewer"
+
+	MemberStatusInvited  = "invited"
+	MemberStatusAccepted = "accepted"
+	MemberStatusDeclined = "declined"
+)
+
+type CampaignMember struct {
+	ID         int       `json:"id"`
+	CampaignID int       `json:"campaign_id"`
+	UserID     int       `json:"user_id"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	Status     string    `json:"status"`
+	InvitedBy  int       `json:"invited_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,8 @@
+package model
+
+type InviteMemberInput struct {
+	CampaignID int    `json:"-"`
+	Email      string `json:"email" binding:"required,email"`
+	Role       string `json:"role" binding:"required,oneof=owner editor viewer"`
+	User       User
+}
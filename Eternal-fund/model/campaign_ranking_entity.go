@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// CampaignRanking is a campaign's paid donations over the last 30 days.
+// DecayedAmount and DecayedBackers count every donation at a weight that
+// halves with each half-life since it was paid.
+type CampaignRanking struct {
+	CampaignID       int       `json:"campaign_id"`
+	Rank             int       `json:"rank"`
+	Score            float64   `json:"score"`
+	Amount_24h       int       `json:"amount_24h"`
+	Backer_count_24h int       `json:"backer_count_24h"`
+	Amount_7d        int       `json:"amount_7d"`
+	Backer_count_7d  int       `json:"backer_count_7d"`
+	Amount_30d       int       `json:"amount_30d"`
+	Backer_count_30d int       `json:"backer_count_30d"`
+	DecayedAmount    float64   `json:"-"`
+	DecayedBackers   float64   `json:"-"`
+	Currency         Currency  `json:"-"`
+	ComputedAt       time.Time `json:"computed_at"`
+	Campaign         Campaigns `json:"campaign"`
+}
+
+type FeaturedCampaign struct {
+	ID         int       `json:"id"`
+	CampaignID int       `json:"campaign_id"`
+	Slot       int       `json:"slot"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	CreatedBy  int       `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Campaign   Campaigns `json:"campaign"`
+}
+
+// IsActive reports whether the featured slot is inside its schedule window at t.
+func (f FeaturedCampaign) IsActive(t time.Time) bool {
+	return !t.Before(f.StartsAt) && t.Before(f.EndsAt)
+}
+
+type TrendingCampaigns struct {
+	Featured   []FeaturedCampaign `json:"featured"`
+	Trending   []CampaignRanking  `json:"trending"`
+	ComputedAt time.Time          `json:"computed_at"`
+}
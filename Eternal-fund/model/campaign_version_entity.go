@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// CampaignSnapshot holds the donor facing fields of a campaign at one version.
+type CampaignSnapshot struct {
+	Name              string `json:"name"`
+	Short_description string `json:"short_description"`
+	Description       string `json:"description"`
+	Perks             string `json:"perks"`
+	Goal_amount       int    `json:"goal_amount"`
+	Min_donation      int    `json:"min_donation,omitempty"`
+	Max_donation      int    `json:"max_donation,omitempty"`
+	Slug              string `json:"slug"`
+}
+
+type CampaignFieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type CampaignVersion struct {
+	ID         int                   `json:"id"`
+	CampaignID int                   `json:"campaign_id"`
+	Version    int                   `json:"version"`
+	EditedBy   int                   `json:"edited_by"`
+	Note       string                `json:"note,omitempty"`
+	Snapshot   CampaignSnapshot      `json:"snapshot"`
+	Changes    []CampaignFieldChange `json:"changes"`
+	CreatedAt  time.Time             `json:"created_at"`
+}
+
+type CampaignVersionDiff struct {
+	CampaignID  int                   `json:"campaign_id"`
+	FromVersion int                   `json:"from_version"`
+	ToVersion   int                   `json:"to_version"`
+	Changes     []CampaignFieldChange `json:"changes"`
+}
+
+func (c Campaigns) Snapshot() CampaignSnapshot {
+	return CampaignSnapshot{
+		Name:              c.Name,
+		Short_description: c.Short_description,
+		Description:       c.Description,
+		Perks:             c.Perks,
+		Goal_amount:       c.Goal_amount,
+		Min_donation:      c.Min_donation,
+		Max_donation:      c.Max_donation,
+		Slug:              c.Slug,
+	}
+}
+
+// Diff lists the fields that differ between s and next.
+func (s CampaignSnapshot) Diff(next CampaignSnapshot) []CampaignFieldChange {
+	var changes []CampaignFieldChange
+	add := func(field string, old, new interface{}) {
+		if old != new {
+			changes = append(changes, CampaignFieldChange{Field: field, Old: old, New: new})
+		}
+	}
+	add("name", s.Name, next.Name)
+	add("short_description", s.Short_description, next.Short_description)
+	add("description", s.Description, next.Description)
+	add("perks", s.Perks, next.Perks)
+	add("goal_amount", s.Goal_amount, next.Goal_amount)
+	add("min_donation", s.Min_donation, next.Min_donation)
+	add("max_donation", s.Max_donation, next.Max_donation)
+	add("slug", s.Slug, next.Slug)
+	return changes
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"time"
 
 	"github.com/leekchan/accounting"
@@ -16,13 +17,53 @@ type Campaigns struct {
 	Backer_count      int             `json:"backer_count"`
 	Goal_amount       int             `json:"goal_amount"`
 	Current_amount    int             `json:"current_amount"`
+	Net_amount        int             `json:"net_amount"`
+	// Currency is the currency of the amounts above, in minor units.
+	Currency          Currency        `json:"currency"`
+	// Min_donation and Max_donation bound a single donation in Currency.
+	// Zero means no limit.
+	Min_donation      int             `json:"min_donation" binding:"min=0"`
+	Max_donation      int             `json:"max_donation" binding:"min=0"`
 	Slug              string          `json:"slug"`
 	Created_at        time.Time       `json:"created_at"`
 	Updated_at        time.Time       `json:"updated_at"`
+	Deleted_at        *time.Time      `json:"deleted_at,omitempty"`
 	CampaignImages    []CampaignImage `json:"campaign_images"`
+	// Matching is filled in from the campaign's matching pledges.
+	Matching          *CampaignMatching `json:"matching,omitempty"`
 	User              User       	  `json:"user"`
 }
 
+var (
+	ErrInvalidDonationLimits = errors.New("minimum donation must not exceed the maximum donation")
+	ErrDonationBelowMinimum  = errors.New("donation is below the campaign's minimum")
+	ErrDonationAboveMaximum  = errors.New("donation is above the campaign's maximum")
+)
+
+// ValidateDonationLimits checks that the minimum does not exceed the maximum
+// when both are set.
+func (c Campaigns) ValidateDonationLimits() error {
+	if c.Min_donation < 0 || c.Max_donation < 0 {
+		return ErrInvalidDonationLimits
+	}
+	if c.Min_donation > 0 && c.Max_donation > 0 && c.Min_donation > c.Max_donation {
+		return ErrInvalidDonationLimits
+	}
+	return nil
+}
+
+// CheckDonation reports whether amount, in the campaign's currency, is within
+// the campaign's donation limits.
+func (c Campaigns) CheckDonation(amount int) error {
+	if c.Min_donation > 0 && amount < c.Min_donation {
+		return ErrDonationBelowMinimum
+	}
+	if c.Max_donation > 0 && amount > c.Max_donation {
+		return ErrDonationAboveMaximum
+	}
+	return nil
+}
+
 func (c Campaigns) GoalAmountFormatIDR() string {
 	ac := accounting.Accounting{Symbol: "Rp", Precision: 2, Thousand: ".", Decimal: ","}
 	return ac.FormatMoney(c.Goal_amount)
@@ -31,4 +72,8 @@ func (c Campaigns) GoalAmountFormatIDR() string {
 func (c Campaigns) CurrentAmountFormatIDR() string {
 	ac := accounting.Accounting{Symbol: "Rp", Precision: 2, Thousand: ".", Decimal: ","}
 	return ac.FormatMoney(c.Current_amount)
-}
\ No newline at end of file
+}
+func (c Campaigns) NetAmountFormatIDR() string {
+	ac := accounting.Accounting{Symbol: "Rp", Precision: 2, Thousand: ".", Decimal: ","}
+	return ac.FormatMoney(c.Net_amount)
+}
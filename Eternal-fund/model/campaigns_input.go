@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+type FeaturedCampaignInput struct {
+	CampaignID int       `json:"campaign_id" binding:"required"`
+	Slot       int       `json:"slot" binding:"required,min=1"`
+	StartsAt   time.Time `json:"starts_at" binding:"required"`
+	EndsAt     time.Time `json:"ends_at" binding:"required"`
+	User       User
+}
+
+type UpdateCampaignInput struct {
+	Name              string `json:"name" binding:"required"`
+	Short_description string `json:"short_description"`
+	Description       string `json:"description"`
+	Perks             string `json:"perks"`
+	Goal_amount       int    `json:"goal_amount" binding:"required"`
+	Min_donation      int    `json:"min_donation" binding:"min=0"`
+	Max_donation      int    `json:"max_donation" binding:"min=0"`
+	User              User
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/leekchan/accounting"
+)
+
+// Currency is an ISO 4217 code. Amounts are always stored as integers in the
+// currency's minor unit, so they never go through floating point.
+type Currency string
+
+const (
+	CurrencyIDR Currency = "IDR"
+	CurrencyUSD Currency = "USD"
+	CurrencySGD Currency = "SGD"
+	CurrencyMYR Currency = "MYR"
+
+	// DefaultCurrency is used for campaigns and donations that do not
+	// name a currency, which includes everything created before
+	// currencies existed.
+	DefaultCurrency = CurrencyIDR
+)
+
+var ErrUnsupportedCurrency = errors.New("currency must be one of IDR, USD, SGD or MYR")
+
+// currencyFormat is how amounts of a currency are written in the locale it is
+// used in. Exponent is the number of minor units in a major unit as a power
+// of ten. Rupiah has no sub-unit in use, so its minor unit is the rupiah.
+type currencyFormat struct {
+	Exponent   int
+	Accounting accounting.Accounting
+}
+
+var currencyFormats = map[Currency]currencyFormat{
+	CurrencyIDR: {0, accounting.Accounting{Symbol: "Rp", Precision: 2, Thousand: ".", Decimal: ","}},
+	CurrencyUSD: {2, accounting.Accounting{Symbol: "$", Precision: 2, Thousand: ",", Decimal: "."}},
+	CurrencySGD: {2, accounting.Accounting{Symbol: "S$", Precision: 2, Thousand: ",", Decimal: "."}},
+	CurrencyMYR: {2, accounting.Accounting{Symbol: "RM", Precision: 2, Thousand: ",", Decimal: "."}},
+}
+
+// ParseCurrency reads a currency code. An empty code is the default currency.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if currency == "" {
+		return DefaultCurrency, nil
+	}
+	if _, ok := currencyFormats[currency]; !ok {
+		return "", ErrUnsupportedCurrency
+	}
+	return currency, nil
+}
+
+// OrDefault returns the currency, or the default one when it is not set.
+func (c Currency) OrDefault() Currency {
+	if c == "" {
+		return DefaultCurrency
+	}
+	return c
+}
+
+// Exponent is the number of decimal places between the major and the minor
+// unit of the currency.
+func (c Currency) Exponent() int {
+	return currencyFormats[c.OrDefault()].Exponent
+}
+
+// majorUnits is amount, given in minor units, as an exact number of major
+// units.
+func (c Currency) majorUnits(amount int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(c.Exponent())), nil)
+	return new(big.Rat).SetFrac(big.NewInt(int64(amount)), scale)
+}
+
+// FormatMoney writes an amount given in minor units of currency the way it is
+// written where the currency is used.
+func FormatMoney(amount int, currency Currency) string {
+	currency = currency.OrDefault()
+	ac := currencyFormats[currency].Accounting
+	return ac.FormatMoneyBigRat(currency.majorUnits(amount))
+}
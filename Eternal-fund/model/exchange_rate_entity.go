@@ -0,0 +1,61 @@
+package model
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrInvalidExchangeRate = errors.New("rate must be a positive decimal with at most 10 digits before and after the point")
+
+// rateFormat matches the rates the exchange_rates table can hold exactly.
+var rateFormat = regexp.MustCompile(`^\d{1,10}(\.\d{1,10})?$`)
+
+// ExchangeRate is the rate donations in Base are converted to Quote at: one
+// major unit of Base is worth Rate major units of Quote. Rates are kept as
+// decimal strings so they are never rounded on the way in or out.
+type ExchangeRate struct {
+	ID        int       `json:"id"`
+	Base      Currency  `json:"base"`
+	Quote     Currency  `json:"quote"`
+	Rate      string    `json:"rate"`
+	UpdatedBy int       `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ExchangeRateInput struct {
+	Base  string `json:"base" binding:"required"`
+	Quote string `json:"quote" binding:"required"`
+	Rate  string `json:"rate" binding:"required"`
+	User  User   `json:"-"`
+}
+
+// ParseRate reads a rate as an exact fraction.
+func ParseRate(rate string) (*big.Rat, error) {
+	rate = strings.TrimSpace(rate)
+	if !rateFormat.MatchString(rate) {
+		return nil, ErrInvalidExchangeRate
+	}
+	value, ok := new(big.Rat).SetString(rate)
+	if !ok || value.Sign() <= 0 {
+		return nil, ErrInvalidExchangeRate
+	}
+	return value, nil
+}
+
+// Convert turns amount, in minor units of from, into minor units of to at
+// rate. The result is rounded half away from zero to a whole minor unit,
+// which is the only rounding that happens.
+func Convert(amount int, from Currency, to Currency, rate *big.Rat) int {
+	value := from.majorUnits(amount)
+	value.Mul(value, rate)
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(to.Exponent())), nil)))
+
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	return int(quotient.Int64())
+}
@@ -0,0 +1,92 @@
+package model
+
+// FeeSchedule holds the fee rules applied to a donation when it is paid. The
+// platform fee is a percentage of the gross amount in basis points and the
+// gateway fee is fixed per payment method in each currency. A currency without
+// gateway fees only pays the platform fee.
+type FeeSchedule struct {
+	PlatformBasisPoints int
+	GatewayFees         map[Currency]GatewayFees
+	// DonorCoversFees lets donors add the fees on top of their donation so
+	// the campaign receives the amount they chose.
+	DonorCoversFees bool
+}
+
+// GatewayFees are the fixed fees of the payment methods in one currency, in
+// minor units of it. Default applies to methods that are not listed.
+type GatewayFees struct {
+	ByMethod map[string]int
+	Default  int
+}
+
+// TransactionFees splits the gross amount of a paid donation into the fees
+// taken from it and the net amount the campaign receives.
+type TransactionFees struct {
+	PaymentMethod string `json:"payment_method"`
+	GrossAmount   int    `json:"gross_amount"`
+	PlatformFee   int    `json:"platform_fee"`
+	GatewayFee    int    `json:"gateway_fee"`
+	FeeAmount     int    `json:"fee_amount"`
+	NetAmount     int    `json:"net_amount"`
+}
+
+// NoFees is the split of a donation that paid no fees, such as one paid
+// before fees were introduced.
+func NoFees(gross int) TransactionFees {
+	return TransactionFees{GrossAmount: gross, NetAmount: gross}
+}
+
+func (s FeeSchedule) GatewayFee(currency Currency, method string) int {
+	fees := s.GatewayFees[currency.OrDefault()]
+	if fee, ok := fees.ByMethod[method]; ok {
+		return fee
+	}
+	return fees.Default
+}
+
+// Calculate splits gross, in minor units of currency, for a payment made with
+// method. The platform fee is rounded half up and fees never take more than
+// the gross amount.
+func (s FeeSchedule) Calculate(gross int, currency Currency, method string) TransactionFees {
+	gatewayFee := min(s.GatewayFee(currency, method), gross)
+	platformFee := min((gross*s.PlatformBasisPoints+5000)/10000, gross-gatewayFee)
+
+	fees := TransactionFees{
+		PaymentMethod: method,
+		GrossAmount:   gross,
+		PlatformFee:   platformFee,
+		GatewayFee:    gatewayFee,
+		FeeAmount:     platformFee + gatewayFee,
+	}
+	fees.NetAmount = gross - fees.FeeAmount
+	return fees
+}
+
+// GrossUp returns the smallest amount to charge in currency so that amount is
+// left after fees. The payment method is not known yet, so the currency's
+// default gateway fee is assumed.
+func (s FeeSchedule) GrossUp(amount int, currency Currency) int {
+	if s.PlatformBasisPoints >= 10000 {
+		return amount
+	}
+	gatewayFee := s.GatewayFees[currency.OrDefault()].Default
+	gross := ((amount+gatewayFee)*10000 + 10000 - s.PlatformBasisPoints - 1) / (10000 - s.PlatformBasisPoints)
+	for gross > amount && s.Calculate(gross-1, currency, "").NetAmount >= amount {
+		gross--
+	}
+	for s.Calculate(gross, currency, "").NetAmount < amount {
+		gross++
+	}
+	return gross
+}
+
+// CampaignReport sums the money a campaign has raised. GrossRaised is the
+// campaign's current amount and NetRaised what is left of it after fees.
+type CampaignReport struct {
+	CampaignID   int `json:"campaign_id"`
+	Donations    int `json:"donations"`
+	GrossRaised  int `json:"gross_raised"`
+	PlatformFees int `json:"platform_fees"`
+	GatewayFees  int `json:"gateway_fees"`
+	NetRaised    int `json:"net_raised"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// IdempotencyRecord remembers the outcome of a mutating request sent with an
+// Idempotency-Key header so that retries receive the original response.
+type IdempotencyRecord struct {
+	Key          string     `json:"key"`
+	Scope        string     `json:"scope"`
+	Method       string     `json:"method"`
+	Path         string     `json:"path"`
+	Fingerprint  string     `json:"fingerprint"`
+	StatusCode   int        `json:"status_code"`
+	ContentType  string     `json:"content_type"`
+	ResponseBody []byte     `json:"response_body"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// IsCompleted reports whether the original request has finished and its
+// response was stored.
+func (r IdempotencyRecord) IsCompleted() bool {
+	return r.CompletedAt != nil
+}
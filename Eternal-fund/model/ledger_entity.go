@@ -0,0 +1,94 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ledger accounts. Campaign and donor accounts are per id, see CampaignAccount
+// and DonorAccount.
+const (
+	LedgerAccountGatewayClearing = "gateway_clearing"
+	LedgerAccountPlatformFees    = "platform_fees"
+	LedgerAccountGatewayFees     = "gateway_fees"
+	LedgerAccountPayouts         = "payouts"
+)
+
+const (
+	JournalKindPayment  = "payment"
+	JournalKindReversal = "reversal"
+	JournalKindRefund   = "refund"
+	JournalKindPayout   = "payout"
+	JournalKindFee      = "fee"
+)
+
+// RaisedJournalKinds are the journals that change how much a campaign has
+// raised, which is what campaigns.current_amount holds. Fees and payouts are
+// taken from what was raised and are not among them.
+var RaisedJournalKinds = []string{JournalKindPayment, JournalKindReversal, JournalKindRefund}
+
+var ErrUnbalancedJournal = errors.New("ledger journal debits and credits do not balance")
+
+func CampaignAccount(campaignID int) string {
+	return fmt.Sprintf("campaign:%d", campaignID)
+}
+
+func DonorAccount(userID int) string {
+	return fmt.Sprintf("donor:%d", userID)
+}
+
+// LedgerJournal groups the entries of one money movement. Entries are signed:
+// debits are positive and credits negative, so a journal balances when its
+// entries sum to zero. All entries of a journal are in its Currency. Journals
+// are never updated or deleted; mistakes are corrected with a new journal.
+type LedgerJournal struct {
+	ID          int           `json:"id"`
+	Kind        string        `json:"kind"`
+	Reference   string        `json:"reference"`
+	Description string        `json:"description"`
+	Currency    Currency      `json:"currency"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Entries     []LedgerEntry `json:"entries,omitempty"`
+}
+
+type LedgerEntry struct {
+	ID        int       `json:"id"`
+	JournalID int       `json:"journal_id"`
+	Account   string    `json:"account"`
+	Amount    int       `json:"amount"`
+	Currency  Currency  `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Transfer returns the pair of entries moving amount from the credited account
+// to the debited one.
+func Transfer(debit string, credit string, amount int) []LedgerEntry {
+	return []LedgerEntry{{Account: debit, Amount: amount}, {Account: credit, Amount: -amount}}
+}
+
+func (j LedgerJournal) IsBalanced() bool {
+	total := 0
+	for _, entry := range j.Entries {
+		total += entry.Amount
+	}
+	return len(j.Entries) > 0 && total == 0
+}
+
+// LedgerBalance sums the entries of an account in one currency. Balance is
+// credits minus debits, which is what a campaign or donor account is owed.
+type LedgerBalance struct {
+	Account  string   `json:"account"`
+	Currency Currency `json:"currency"`
+	Debit    int      `json:"debit"`
+	Credit   int      `json:"credit"`
+	Balance  int      `json:"balance"`
+}
+
+// LedgerDiscrepancy is a campaign whose stored current amount does not match
+// what its ledger account says it has raised.
+type LedgerDiscrepancy struct {
+	CampaignID    int `json:"campaign_id"`
+	CurrentAmount int `json:"current_amount"`
+	LedgerRaised  int `json:"ledger_raised"`
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidMatchingWindow = errors.New("a matching pledge must end after it starts")
+
+// MatchingPledge is a sponsor's promise to match the donations made to a
+// campaign between StartsAt and EndsAt. Every donation is matched at
+// RatioPercent of its amount until Cap has been matched. Amounts are in the
+// campaign's currency.
+type MatchingPledge struct {
+	ID            int        `json:"id"`
+	CampaignID    int        `json:"campaign_id"`
+	SponsorName   string     `json:"sponsor_name"`
+	RatioPercent  int        `json:"ratio_percent"`
+	Cap           int        `json:"cap"`
+	MatchedAmount int        `json:"matched_amount"`
+	StartsAt      time.Time  `json:"starts_at"`
+	EndsAt        time.Time  `json:"ends_at"`
+	CreatedBy     int        `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CancelledAt   *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// Remaining is the part of the cap not matched yet.
+func (p MatchingPledge) Remaining() int {
+	return max(p.Cap-p.MatchedAmount, 0)
+}
+
+// ActiveAt reports whether a donation made at t would be matched.
+func (p MatchingPledge) ActiveAt(t time.Time) bool {
+	return p.CancelledAt == nil && !t.Before(p.StartsAt) && t.Before(p.EndsAt) && p.Remaining() > 0
+}
+
+// MatchedContribution is what one pledge added to one paid donation. A
+// partial refund takes back the same share of it as RefundedAmount, and it is
+// reversed entirely when the donation is refunded or charged back.
+type MatchedContribution struct {
+	ID             int        `json:"id"`
+	PledgeID       int        `json:"pledge_id"`
+	TransactionID  int        `json:"transaction_id"`
+	CampaignID     int        `json:"campaign_id"`
+	Amount         int        `json:"amount"`
+	RefundedAmount int        `json:"refunded_amount"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReversedAt     *time.Time `json:"reversed_at,omitempty"`
+}
+
+// CampaignMatching is what donors see of a campaign's matching pledges. A
+// gift made now is multiplied by Multiplier while RemainingBudget lasts.
+type CampaignMatching struct {
+	Active          bool       `json:"active"`
+	Multiplier      string     `json:"multiplier,omitempty"`
+	RatioPercent    int        `json:"ratio_percent"`
+	RemainingBudget int        `json:"remaining_budget"`
+	MatchedAmount   int        `json:"matched_amount"`
+	Sponsors        []string   `json:"sponsors,omitempty"`
+	EndsAt          *time.Time `json:"ends_at,omitempty"`
+}
+
+// SummarizeMatching combines a campaign's pledges as of now. Pledges that are
+// active at the same time each match the full donation, so their ratios add
+// up. It returns nil for a campaign without pledges.
+func SummarizeMatching(pledges []MatchingPledge, now time.Time) *CampaignMatching {
+	if len(pledges) == 0 {
+		return nil
+	}
+
+	summary := &CampaignMatching{}
+	for _, pledge := range pledges {
+		summary.MatchedAmount += pledge.MatchedAmount
+		if !pledge.ActiveAt(now) {
+			continue
+		}
+		summary.Active = true
+		summary.RatioPercent += pledge.RatioPercent
+		summary.RemainingBudget += pledge.Remaining()
+		summary.Sponsors = append(summary.Sponsors, pledge.SponsorName)
+		if summary.EndsAt == nil || pledge.EndsAt.Before(*summary.EndsAt) {
+			endsAt := pledge.EndsAt
+			summary.EndsAt = &endsAt
+		}
+	}
+	if summary.Active {
+		summary.Multiplier = strconv.FormatFloat(float64(100+summary.RatioPercent)/100, 'f', -1, 64) + "x"
+	}
+	return summary
+}
+
+// MatchableAmount is the part of a paid donation that sponsors match: the
+// donation itself, without the fees a donor chose to cover.
+func (t Transaction) MatchableAmount() int {
+	if t.CoverFees {
+		return t.Amount - t.FeeAmount
+	}
+	return t.Amount
+}
+
+type MatchingPledgeInput struct {
+	CampaignID   int       `json:"-"`
+	SponsorName  string    `json:"sponsor_name" binding:"required,max=100"`
+	RatioPercent int       `json:"ratio_percent" binding:"required,min=1,max=1000"`
+	Cap          int       `json:"cap" binding:"required,min=1"`
+	StartsAt     time.Time `json:"starts_at" binding:"required"`
+	EndsAt       time.Time `json:"ends_at" binding:"required"`
+	User         User
+}
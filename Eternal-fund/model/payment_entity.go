@@ -0,0 +1,16 @@
+package model
+
+// PaymentCharge is a payment created at the gateway for a transaction. The
+// donor completes it by following RedirectURL.
+type PaymentCharge struct {
+	OrderID     string `json:"order_id"`
+	Token       string `json:"token"`
+	RedirectURL string `json:"redirect_url"`
+}
+
+type PaymentRefund struct {
+	OrderID   string `json:"order_id"`
+	RefundKey string `json:"refund_key"`
+	Amount    int    `json:"amount"`
+	Status    string `json:"status"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	NotificationResultReceived  = "received"
+	NotificationResultProcessed = "processed"
+	NotificationResultDuplicate = "duplicate"
+	NotificationResultRejected  = "rejected"
+	NotificationResultFailed    = "failed"
+)
+
+// PaymentNotification is a gateway notification as it was received, kept for
+// auditing, deduplication and replay.
+type PaymentNotification struct {
+	ID                int               `json:"id"`
+	OrderID           string            `json:"order_id"`
+	TransactionStatus string            `json:"transaction_status"`
+	SignatureKey      string            `json:"signature_key"`
+	Payload           json.RawMessage   `json:"payload"`
+	Headers           map[string]string `json:"headers"`
+	Result            string            `json:"result"`
+	Error             string            `json:"error,omitempty"`
+	ReceivedAt        time.Time         `json:"received_at"`
+	ProcessedAt       *time.Time        `json:"processed_at,omitempty"`
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	BankAccountStatusUnverified = "unverified"
+	BankAccountStatusVerified   = "verified"
+	BankAccountStatusRejected   = "rejected"
+)
+
+// BankAccount is where a creator receives payouts. Accounts are never edited;
+// a changed account is registered again and verified again.
+type BankAccount struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	BankCode      string     `json:"bank_code"`
+	AccountNumber string     `json:"account_number"`
+	AccountName   string     `json:"account_name"`
+	Status        string     `json:"status"`
+	ReviewedBy    int        `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type BankAccountInput struct {
+	BankCode      string `json:"bank_code" binding:"required"`
+	AccountNumber string `json:"account_number" binding:"required,numeric,min=5,max=34"`
+	AccountName   string `json:"account_name" binding:"required"`
+	User          User
+}
+
+// WithdrawalStatus is the lifecycle state of a withdrawal. Approving a
+// withdrawal moves its amount out of the campaign's ledger balance, so the
+// same funds cannot be approved twice.
+type WithdrawalStatus string
+
+const (
+	WithdrawalStatusRequested  WithdrawalStatus = "requested"
+	WithdrawalStatusApproved   WithdrawalStatus = "approved"
+	WithdrawalStatusRejected   WithdrawalStatus = "rejected"
+	WithdrawalStatusProcessing WithdrawalStatus = "processing"
+	WithdrawalStatusPaid       WithdrawalStatus = "paid"
+	WithdrawalStatusFailed     WithdrawalStatus = "failed"
+)
+
+var ErrIllegalWithdrawalTransition = errors.New("illegal withdrawal status transition")
+
+var withdrawalTransitions = map[WithdrawalStatus][]WithdrawalStatus{
+	WithdrawalStatusRequested:  {WithdrawalStatusApproved, WithdrawalStatusRejected},
+	WithdrawalStatusApproved:   {WithdrawalStatusProcessing, WithdrawalStatusFailed},
+	WithdrawalStatusProcessing: {WithdrawalStatusPaid, WithdrawalStatusFailed},
+}
+
+func (s WithdrawalStatus) CanTransitionTo(next WithdrawalStatus) bool {
+	for _, allowed := range withdrawalTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type Withdrawal struct {
+	ID            int              `json:"id"`
+	CampaignID    int              `json:"campaign_id"`
+	BankAccountID int              `json:"bank_account_id"`
+	Amount        int              `json:"amount"`
+	Status        WithdrawalStatus `json:"status"`
+	RequestedBy   int              `json:"requested_by"`
+	ReviewedBy    int              `json:"reviewed_by,omitempty"`
+	Note          string           `json:"note,omitempty"`
+	BatchID       int              `json:"batch_id,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+type WithdrawalInput struct {
+	CampaignID    int `json:"-"`
+	BankAccountID int `json:"bank_account_id" binding:"required"`
+	Amount        int `json:"amount" binding:"required,min=1"`
+	User          User
+}
+
+type ReviewWithdrawalInput struct {
+	Note string `json:"note"`
+	User User
+}
+
+const (
+	PayoutBatchStatusExported  = "exported"
+	PayoutBatchStatusCompleted = "completed"
+)
+
+// PayoutBatch groups approved withdrawals into one bank transfer file.
+type PayoutBatch struct {
+	ID          int          `json:"id"`
+	Status      string       `json:"status"`
+	Total       int          `json:"total"`
+	Count       int          `json:"count"`
+	CreatedBy   int          `json:"created_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	Withdrawals []Withdrawal `json:"withdrawals,omitempty"`
+}
+
+// PayoutLine is one transfer in a payout batch file.
+type PayoutLine struct {
+	WithdrawalID  int
+	BankCode      string
+	AccountNumber string
+	AccountName   string
+	Amount        int
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Receipt is the tax receipt of a paid donation. Receipts are numbered
+// without gaps within a fiscal year, so a number is never reused or skipped.
+type Receipt struct {
+	ID            int        `json:"id"`
+	TransactionID int        `json:"transaction_id"`
+	Number        string     `json:"number"`
+	FiscalYear    int        `json:"fiscal_year"`
+	Sequence      int        `json:"sequence"`
+	Amount        int        `json:"amount"`
+	DonatedAt     time.Time  `json:"donated_at"`
+	IssuedAt      time.Time  `json:"issued_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// ReceiptNumber formats the number of the sequence-th receipt of a fiscal year.
+func ReceiptNumber(fiscalYear int, sequence int) string {
+	return fmt.Sprintf("RCPT-%d-%06d", fiscalYear, sequence)
+}
+
+// BlobKey is where the receipt's PDF is stored.
+func (r Receipt) BlobKey() string {
+	return fmt.Sprintf("receipts/%d/%s.pdf", r.FiscalYear, r.Number)
+}
+
+// FiscalYear is the fiscal year t falls in when fiscal years begin in start.
+// A fiscal year is named after the calendar year it begins in.
+func FiscalYear(t time.Time, start time.Month) int {
+	if t.Month() < start {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+// FiscalYearBounds returns the first moment of fiscal year year and of the
+// year after it, in loc.
+func FiscalYearBounds(year int, start time.Month, loc *time.Location) (time.Time, time.Time) {
+	from := time.Date(year, start, 1, 0, 0, 0, 0, loc)
+	return from, from.AddDate(1, 0, 0)
+}
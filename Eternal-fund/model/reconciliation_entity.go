@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+// ReconciliationKind is the kind of difference found between a transaction
+// and what the payment gateway reports for it.
+type ReconciliationKind string
+
+const (
+	ReconciliationAmountMismatch ReconciliationKind = "amount_mismatch"
+	ReconciliationStatusMismatch ReconciliationKind = "status_mismatch"
+	// ReconciliationMissingTransaction is a gateway record with no
+	// transaction here.
+	ReconciliationMissingTransaction ReconciliationKind = "missing_transaction"
+	// ReconciliationMissingSettlement is a paid transaction left out of the
+	// gateway's settlement report.
+	ReconciliationMissingSettlement ReconciliationKind = "missing_settlement"
+)
+
+const (
+	ReconciliationSourceStatusCheck = "status_check"
+	ReconciliationSourceSettlement  = "settlement_report"
+)
+
+// ReconciliationItem is a difference for an admin to look into. It stays open
+// until it is resolved, and an order has at most one open item of each kind.
+type ReconciliationItem struct {
+	ID            int                `json:"id"`
+	ImportID      int                `json:"import_id,omitempty"`
+	TransactionID int                `json:"transaction_id,omitempty"`
+	OrderID       string             `json:"order_id"`
+	Kind          ReconciliationKind `json:"kind"`
+	Source        string             `json:"source"`
+	// The local values are ours, the gateway values what the gateway reported.
+	LocalAmount   int        `json:"local_amount"`
+	GatewayAmount int        `json:"gateway_amount"`
+	LocalStatus   string     `json:"local_status"`
+	GatewayStatus string     `json:"gateway_status"`
+	Note          string     `json:"note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy    int        `json:"resolved_by,omitempty"`
+}
+
+// SettlementImport records a settlement report uploaded by an admin.
+type SettlementImport struct {
+	ID            int       `json:"id"`
+	FileName      string    `json:"file_name"`
+	PeriodStart   time.Time `json:"period_start"`
+	PeriodEnd     time.Time `json:"period_end"`
+	RowCount      int       `json:"row_count"`
+	MismatchCount int       `json:"mismatch_count"`
+	ImportedBy    int       `json:"imported_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SettlementImportInput is a settlement report in CSV covering the days from
+// PeriodStart up to and including PeriodEnd.
+type SettlementImportInput struct {
+	FileName    string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Report      []byte
+	User        User
+}
+
+type ResolveReconciliationInput struct {
+	Note string `json:"note" binding:"required"`
+	User User
+}
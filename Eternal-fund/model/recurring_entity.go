@@ -0,0 +1,142 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// RecurringInterval is how often a recurring plan is billed.
+type RecurringInterval string
+
+const (
+	RecurringIntervalMonthly   RecurringInterval = "monthly"
+	RecurringIntervalQuarterly RecurringInterval = "quarterly"
+	RecurringIntervalYearly    RecurringInterval = "yearly"
+)
+
+func (i RecurringInterval) Months() int {
+	switch i {
+	case RecurringIntervalMonthly:
+		return 1
+	case RecurringIntervalQuarterly:
+		return 3
+	case RecurringIntervalYearly:
+		return 12
+	}
+	return 0
+}
+
+func (i RecurringInterval) IsValid() bool {
+	return i.Months() > 0
+}
+
+// ChargeDate is the date of the given billing cycle of a plan started at
+// anchor. Cycles always count from the anchor and the day is clamped to the
+// end of shorter months, so a plan started on 31 January is billed on
+// 28 February and again on 31 March.
+func (i RecurringInterval) ChargeDate(anchor time.Time, cycle int) time.Time {
+	year, month, day := anchor.Date()
+	hour, min, sec := anchor.Clock()
+	target := time.Date(year, month+time.Month(cycle*i.Months()), 1, hour, min, sec, anchor.Nanosecond(), anchor.Location())
+	lastDay := target.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return target.AddDate(0, 0, day-1)
+}
+
+// RecurringStatus is the state of a recurring plan. A plan is past due while
+// a failed charge is being retried.
+type RecurringStatus string
+
+const (
+	RecurringStatusActive    RecurringStatus = "active"
+	RecurringStatusPaused    RecurringStatus = "paused"
+	RecurringStatusPastDue   RecurringStatus = "past_due"
+	RecurringStatusCancelled RecurringStatus = "cancelled"
+)
+
+var ErrIllegalRecurringTransition = errors.New("illegal recurring plan status transition")
+
+// recurringTransitions are the changes a donor can make. Moving in and out of
+// past due is left to the biller.
+var recurringTransitions = map[RecurringStatus][]RecurringStatus{
+	RecurringStatusActive:  {RecurringStatusPaused, RecurringStatusCancelled},
+	RecurringStatusPastDue: {RecurringStatusPaused, RecurringStatusCancelled},
+	RecurringStatusPaused:  {RecurringStatusActive, RecurringStatusCancelled},
+}
+
+func (s RecurringStatus) CanTransitionTo(next RecurringStatus) bool {
+	for _, allowed := range recurringTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type RecurringPlan struct {
+	ID         int               `json:"id"`
+	CampaignID int               `json:"campaign_id"`
+	UserID     int               `json:"user_id"`
+	Amount     int               `json:"amount"`
+	Currency   Currency          `json:"currency"`
+	CoverFees  bool              `json:"cover_fees"`
+	Interval   RecurringInterval `json:"interval"`
+	Status     RecurringStatus   `json:"status"`
+	CardToken  string            `json:"-"`
+	// Anonymous, DisplayName and ContactOptOut are applied to every
+	// transaction the plan bills.
+	Anonymous     bool      `json:"anonymous"`
+	DisplayName   string    `json:"display_name,omitempty"`
+	ContactOptOut bool      `json:"contact_opt_out"`
+	StartedAt     time.Time `json:"started_at"`
+	// Cycle is the index of the next billing cycle, counted from StartedAt.
+	Cycle             int        `json:"cycle"`
+	NextChargeAt      time.Time  `json:"next_charge_at"`
+	RetryAt           *time.Time `json:"retry_at,omitempty"`
+	FailedAttempts    int        `json:"failed_attempts"`
+	LastTransactionID int        `json:"last_transaction_id,omitempty"`
+	// ChargePending is set while the last charge awaits its outcome.
+	ChargePending bool `json:"charge_pending"`
+	// LastTransactionStatus is the status of the last charge's transaction.
+	LastTransactionStatus TransactionStatus `json:"last_transaction_status,omitempty"`
+	CreatedAt             time.Time         `json:"created_at"`
+	UpdatedAt             time.Time         `json:"updated_at"`
+	CancelledAt           *time.Time        `json:"cancelled_at,omitempty"`
+}
+
+// AutoCharge reports whether the plan bills a saved card rather than sending
+// the donor a payment link each cycle.
+func (p RecurringPlan) AutoCharge() bool {
+	return p.CardToken != ""
+}
+
+// AdvanceCycle moves the plan to its next billing cycle.
+func (p *RecurringPlan) AdvanceCycle() {
+	p.Cycle++
+	p.NextChargeAt = p.Interval.ChargeDate(p.StartedAt, p.Cycle)
+}
+
+// SkipMissedCycles moves past cycles that fell due while the plan was paused
+// or past due, so the donor is not billed for them all at once.
+func (p *RecurringPlan) SkipMissedCycles(now time.Time) {
+	for p.NextChargeAt.Before(now) {
+		p.AdvanceCycle()
+	}
+}
+
+type CreateRecurringPlanInput struct {
+	CampaignID int               `json:"-"`
+	Amount     int               `json:"amount" binding:"required,min=1"`
+	Interval   RecurringInterval `json:"interval" binding:"required"`
+	CoverFees  bool              `json:"cover_fees"`
+	// CardToken is a saved card token from the gateway's card tokenization.
+	// Without one the donor is sent a payment link every cycle.
+	CardToken string `json:"card_token"`
+	// DonorChoices apply to every cycle; the message only to the first.
+	DonorChoices
+	User User
+	// ClientIP is the address the request came from.
+	ClientIP string `json:"-"`
+}
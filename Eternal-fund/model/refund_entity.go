@@ -0,0 +1,43 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+// Refund is a full or partial return of a paid donation through the payment
+// gateway. A transaction can have several refunds as long as together they do
+// not exceed its amount.
+type Refund struct {
+	ID            int       `json:"id"`
+	TransactionID int       `json:"transaction_id"`
+	Amount        int       `json:"amount"`
+	Reason        string    `json:"reason"`
+	Status        string    `json:"status"`
+	RefundKey     string    `json:"refund_key,omitempty"`
+	RequestedBy   int       `json:"requested_by"`
+	Error         string    `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// GatewayKey is the idempotency key the refund is sent to the gateway with.
+// It stays the same however often the refund is retried, so the gateway
+// never pays it out twice.
+func (r Refund) GatewayKey(orderID string) string {
+	return fmt.Sprintf("%s-refund-%d", orderID, r.ID)
+}
+
+// RefundInput requests a refund of Amount, or of everything not yet refunded
+// when Amount is left out.
+type RefundInput struct {
+	Amount int    `json:"amount" binding:"min=0"`
+	Reason string `json:"reason" binding:"required"`
+	User   User
+}
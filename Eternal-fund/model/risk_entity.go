@@ -0,0 +1,39 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrVelocityExceeded = errors.New("too many donations in a short time, try again later")
+
+// RiskAssessment is the outcome of the fraud rules for a new donation. Score
+// runs from 0 to 100 and Reasons names each rule that added to it. Review is
+// set when the score is high enough to hold the donation for an admin. Limit
+// is checked again when the donation is saved.
+type RiskAssessment struct {
+	Score   int            `json:"score"`
+	Reasons []string       `json:"reasons"`
+	Review  bool           `json:"review"`
+	Limit   *VelocityLimit `json:"-"`
+}
+
+// VelocityLimit caps the donations a donor and an address may start since
+// Since.
+type VelocityLimit struct {
+	Since       time.Time
+	MaxPerDonor int
+	MaxPerIP    int
+}
+
+// Add raises the score by weight for reason. The score never exceeds 100.
+func (a *RiskAssessment) Add(reason string, weight int) {
+	a.Score = min(a.Score+weight, 100)
+	a.Reasons = append(a.Reasons, reason)
+}
+
+// RiskReviewInput is an admin's decision on a donation held for review.
+type RiskReviewInput struct {
+	Note string `json:"note" binding:"max=500"`
+	User User
+}
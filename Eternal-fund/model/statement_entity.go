@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// DonationStatement sums up a donor's donations in one fiscal year, grouped
+// by campaign, with refunds netted out. Campaigns may raise money in
+// different currencies, so the statement has a total per currency.
+type DonationStatement struct {
+	Year        int                 `json:"year"`
+	PeriodStart time.Time           `json:"period_start"`
+	PeriodEnd   time.Time           `json:"period_end"`
+	DonorName   string              `json:"donor_name"`
+	DonorEmail  string              `json:"donor_email"`
+	Campaigns   []StatementCampaign `json:"campaigns"`
+	Totals      []StatementTotal    `json:"totals"`
+}
+
+type StatementTotal struct {
+	Currency Currency `json:"currency"`
+	Donated  int      `json:"donated"`
+	Refunded int      `json:"refunded"`
+	Net      int      `json:"net"`
+}
+
+type StatementCampaign struct {
+	CampaignID   int                 `json:"campaign_id"`
+	CampaignName string              `json:"campaign_name"`
+	Donations    []StatementDonation `json:"donations"`
+	StatementTotal
+}
+
+type StatementDonation struct {
+	TransactionID int       `json:"transaction_id"`
+	Code          string    `json:"code"`
+	DonatedAt     time.Time `json:"donated_at"`
+	Amount        int       `json:"amount"`
+	Refunded      int       `json:"refunded"`
+	Net           int       `json:"net"`
+}
+
+// add counts a donation towards the total.
+func (t *StatementTotal) add(donation StatementDonation) {
+	t.Donated += donation.Amount
+	t.Refunded += donation.Refunded
+	t.Net += donation.Net
+}
+
+// Add counts a donation towards its campaign and the total of the campaign's
+// currency. Campaigns and currencies are listed in the order of their first
+// donation.
+func (s *DonationStatement) Add(campaignID int, campaignName string, currency Currency, donation StatementDonation) {
+	currency = currency.OrDefault()
+	s.total(currency).add(donation)
+	for i := range s.Campaigns {
+		if s.Campaigns[i].CampaignID == campaignID {
+			s.Campaigns[i].Donations = append(s.Campaigns[i].Donations, donation)
+			s.Campaigns[i].add(donation)
+			return
+		}
+	}
+	entry := StatementCampaign{CampaignID: campaignID, CampaignName: campaignName, StatementTotal: StatementTotal{Currency: currency}}
+	entry.Donations = append(entry.Donations, donation)
+	entry.add(donation)
+	s.Campaigns = append(s.Campaigns, entry)
+}
+
+func (s *DonationStatement) total(currency Currency) *StatementTotal {
+	for i := range s.Totals {
+		if s.Totals[i].Currency == currency {
+			return &s.Totals[i]
+		}
+	}
+	s.Totals = append(s.Totals, StatementTotal{Currency: currency})
+	return &s.Totals[len(s.Totals)-1]
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// AnonymousSupporterName is shown in place of donors who chose to be anonymous.
+const AnonymousSupporterName = "Anonymous"
+
+// Supporter is a paid donation as shown in a campaign's public feed. It never
+// carries who an anonymous donor is.
+type Supporter struct {
+	CampaignID int       `json:"campaign_id"`
+	Name       string    `json:"name"`
+	Anonymous  bool      `json:"anonymous"`
+	Message    string    `json:"message,omitempty"`
+	Amount     int       `json:"amount"`
+	DonatedAt  time.Time `json:"donated_at"`
+}
+
+// DonationExportRow is a donation with the donor's account details, for the
+// campaign owner's export.
+type DonationExportRow struct {
+	Transaction
+	DonorName  string
+	DonorEmail string
+}
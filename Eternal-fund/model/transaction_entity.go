@@ -1,23 +1,124 @@
 package model
 
 import (
+	"errors"
 	"time"
 
 	"github.com/leekchan/accounting"
 )
 
+// TransactionStatus is the lifecycle state of a donation. Only the statuses
+// declared here are valid and they change along transactionTransitions.
+type TransactionStatus string
+
+const (
+	TransactionStatusPending       TransactionStatus = "pending"
+	TransactionStatusPaid          TransactionStatus = "paid"
+	TransactionStatusFailed        TransactionStatus = "failed"
+	TransactionStatusExpired       TransactionStatus = "expired"
+	TransactionStatusCancelled     TransactionStatus = "cancelled"
+	TransactionStatusRefundPending TransactionStatus = "refund_pending"
+	TransactionStatusRefunded      TransactionStatus = "refunded"
+	TransactionStatusChargeback    TransactionStatus = "chargeback"
+	// TransactionStatusReview holds a risky donation until an admin
+	// approves it, which issues its payment link, or rejects it.
+	TransactionStatusReview TransactionStatus = "review"
+)
+
+const (
+	StatusSourceGateway = "gateway"
+	StatusSourceAdmin   = "admin"
+	StatusSourceSystem  = "system"
+)
+
+var ErrIllegalTransition = errors.New("illegal transaction status transition")
+
+var transactionTransitions = map[TransactionStatus][]TransactionStatus{
+	TransactionStatusPending:       {TransactionStatusPaid, TransactionStatusFailed, TransactionStatusExpired, TransactionStatusCancelled},
+	TransactionStatusPaid:          {TransactionStatusRefundPending, TransactionStatusRefunded, TransactionStatusChargeback},
+	TransactionStatusRefundPending: {TransactionStatusRefunded, TransactionStatusPaid},
+	TransactionStatusReview:        {TransactionStatusPending, TransactionStatusCancelled},
+}
+
+func (s TransactionStatus) IsValid() bool {
+	switch s {
+	case TransactionStatusPending, TransactionStatusPaid, TransactionStatusFailed, TransactionStatusExpired,
+		TransactionStatusCancelled, TransactionStatusRefundPending, TransactionStatusRefunded, TransactionStatusChargeback,
+		TransactionStatusReview:
+		return true
+	}
+	return false
+}
+
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	for _, allowed := range transactionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CountedTransactionStatuses are the statuses included in the campaign's
+// current amount and backer count.
+var CountedTransactionStatuses = []string{string(TransactionStatusPaid), string(TransactionStatusRefundPending)}
+
+// CountsTowardsTotal reports whether a transaction in this status is included
+// in the campaign's current amount and backer count.
+func (s TransactionStatus) CountsTowardsTotal() bool {
+	return s == TransactionStatusPaid || s == TransactionStatusRefundPending
+}
+
 type Transaction struct {
 	ID         int `json:"id"`
 	CampaignID int `json:"campaign_id"`
 	UserID     int `json:"user_id"`
-	Amount     int `json:"amount"`
-	Status     string `json:"status"`
-	Code       string `json:"code"`
-	PaymentURL string `json:"payment_url"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	// User       User 	
-	// Campaigns  Campaigns  
+	// GuestName and GuestEmail identify a donor who gave without an account.
+	// UserID stays 0 until the donation is claimed by a verified account.
+	GuestName  string `json:"guest_name,omitempty"`
+	GuestEmail string `json:"-"`
+	// Amount is in minor units of Currency, which is the campaign's
+	// currency. DonorAmount is what the donor entered in DonorCurrency,
+	// before fees were added, and ExchangeRate is the rate it was
+	// converted at.
+	Amount        int               `json:"amount"`
+	Currency      Currency          `json:"currency"`
+	DonorAmount   int               `json:"donor_amount"`
+	DonorCurrency Currency          `json:"donor_currency"`
+	ExchangeRate  string            `json:"exchange_rate"`
+	Status        TransactionStatus `json:"status"`
+	Code          string            `json:"code"`
+	PaymentURL    string            `json:"payment_url"`
+	CoverFees     bool              `json:"cover_fees"`
+	// RecurringPlanID is set on the transactions billed for a recurring plan.
+	RecurringPlanID int `json:"recurring_plan_id,omitempty"`
+	// ClientIP is the address the donation was made from. RiskScore and
+	// RiskReasons are what the fraud rules found when it was made.
+	ClientIP    string   `json:"-"`
+	RiskScore   int      `json:"risk_score"`
+	RiskReasons []string `json:"risk_reasons,omitempty"`
+	DonorChoices
+	// TransactionFees is filled in when the transaction is paid.
+	TransactionFees
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// User       User
+	// Campaigns  Campaigns
+}
+
+// TransactionStatusChange is a single entry of a transaction's status log.
+type TransactionStatusChange struct {
+	ID            int               `json:"id"`
+	TransactionID int               `json:"transaction_id"`
+	FromStatus    TransactionStatus `json:"from_status"`
+	ToStatus      TransactionStatus `json:"to_status"`
+	Source        string            `json:"source"`
+	ActorID       int               `json:"actor_id,omitempty"`
+	Note          string            `json:"note,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	// Fees are stored on the transaction when the change makes it count
+	// towards the campaign total. They are not part of the log.
+	Fees TransactionFees `json:"-"`
 }
 
 func (t Transaction) AmountFormatIDR() string {
@@ -25,3 +126,7 @@ func (t Transaction) AmountFormatIDR() string {
 	return ac.FormatMoney(t.Amount)
 }
 
+// AmountFormat writes the amount in the transaction's currency.
+func (t Transaction) AmountFormat() string {
+	return FormatMoney(t.Amount, t.Currency)
+}
@@ -7,19 +7,90 @@ type GetCampaignTransactionsInput struct {
 
 type CreateTransactionInput struct {
 	CampaignID int `json:"campaign_id" binding:"required"`
-	Amount     int `json:"amount" binding:"required"`
-	User       User
+	Amount     int `json:"amount" binding:"required,min=1"`
+	// Currency is what Amount is given in, in minor units. It defaults to
+	// the campaign's currency.
+	Currency  string `json:"currency"`
+	CoverFees bool   `json:"cover_fees"`
+	DonorChoices
+	User User
+	// RecurringPlanID and CardToken are set by the recurring biller. With a
+	// card token the saved card is charged instead of creating a payment link.
+	// Renewal marks the charges after a plan's first one.
+	RecurringPlanID int    `json:"-"`
+	CardToken       string `json:"-"`
+	Renewal         bool   `json:"-"`
+	// ClientIP is the address the request came from.
+	ClientIP string `json:"-"`
+}
+
+// CreateGuestTransactionInput is a donation from someone without an account.
+// The email receives the link to follow the donation and later lets the donor
+// claim it once they register and verify that email.
+type CreateGuestTransactionInput struct {
+	CampaignID int    `json:"campaign_id" binding:"required"`
+	Amount     int    `json:"amount" binding:"required,min=1"`
+	Currency   string `json:"currency"`
+	CoverFees  bool   `json:"cover_fees"`
+	Name       string `json:"name" binding:"required,max=100"`
+	Email      string `json:"email" binding:"required,email,max=255"`
+	DonorChoices
+	ClientIP string `json:"-"`
+}
+
+// DonorChoices are what a donor decides about a donation's public face: a
+// message for the campaign, whether to appear anonymous or under another
+// name, and whether the campaign may contact them.
+type DonorChoices struct {
+	Message       string `json:"message" binding:"max=500"`
+	Anonymous     bool   `json:"anonymous"`
+	DisplayName   string `json:"display_name" binding:"max=100"`
+	ContactOptOut bool   `json:"contact_opt_out"`
 }
 
 type UpdateTransactionInput struct {
-	ID     int    `json:"id"`
-	Amount int    `json:"amount"`
-	Status string `json:"status"`
+	Status TransactionStatus `json:"status" binding:"required"`
+	Note   string            `json:"note"`
+	User   User
 }
 
 type TransactionNotificationInput struct {
-	TransactionStatus string `json:"transaction_status"`
-	OrderID           string `json:"order_id"`
+	TransactionStatus string `json:"transaction_status" binding:"required"`
+	OrderID           string `json:"order_id" binding:"required"`
 	PaymentType       string `json:"payment_type"`
 	FraudStatus       string `json:"fraud_status"`
+	StatusCode        string `json:"status_code" binding:"required"`
+	GrossAmount       string `json:"gross_amount" binding:"required"`
+	SignatureKey      string `json:"signature_key" binding:"required"`
+	TransactionID     string `json:"transaction_id"`
+	TransactionTime   string `json:"transaction_time"`
+}
+
+// InternalStatus maps the gateway transaction status to the status stored on
+// the transaction. It returns an empty string for statuses that should not
+// change the transaction, such as refunds.
+func (n TransactionNotificationInput) InternalStatus() TransactionStatus {
+	switch n.TransactionStatus {
+	case "settlement":
+		return TransactionStatusPaid
+	case "capture":
+		switch n.FraudStatus {
+		case "challenge":
+			return TransactionStatusPending
+		case "deny":
+			return TransactionStatusFailed
+		default:
+			return TransactionStatusPaid
+		}
+	case "pending":
+		return TransactionStatusPending
+	case "deny", "failure":
+		return TransactionStatusFailed
+	case "expire":
+		return TransactionStatusExpired
+	case "cancel":
+		return TransactionStatusCancelled
+	default:
+		return ""
+	}
 }
@@ -10,6 +10,10 @@ type User struct {
 	PasswordHash   string    `json:"password"`
 	AvatarFileName *string   `json:"avatar_file_name"`
 	Role           string    `json:"role"`
+	// EmailVerifiedAt is set once the user follows the link sent to their
+	// email and cleared again when the email changes.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
 }
@@ -9,8 +9,10 @@ CREATE TABLE users (
     role VARCHAR(255),  
     -- token VARCHAR(255),
     created_at TIMESTAMP,
-    updated_at TIMESTAMP
+    updated_at TIMESTAMP,
+    deleted_at TIMESTAMP
 );
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
 -- Table structure for table `campaigns`
 CREATE TABLE campaigns (
     id SERIAL PRIMARY KEY,
@@ -22,11 +24,62 @@ CREATE TABLE campaigns (
     backer_count INTEGER,
     goal_amount INTEGER,
     current_amount INTEGER,
-    slug VARCHAR(255),
+    slug VARCHAR(255) UNIQUE,
     created_at TIMESTAMP,
     updated_at TIMESTAMP,
+    deleted_at TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
 );
+CREATE INDEX idx_campaigns_deleted_at ON campaigns (deleted_at);
+-- Table structure for table `campaign_members`
+CREATE TABLE campaign_members (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER,
+    user_id INTEGER,
+    email VARCHAR(255),
+    role VARCHAR(20),
+    status VARCHAR(20),
+    invited_by INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    UNIQUE (campaign_id, email),
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL
+);
+-- Every existing campaign owner becomes the first team member
+INSERT INTO campaign_members (campaign_id, user_id, email, role, status, invited_by, created_at, updated_at)
+SELECT c.id, c.user_id, LOWER(u.email), 'owner', 'accepted', c.user_id, NOW(), NOW()
+FROM campaigns c JOIN users u ON u.id = c.user_id;
+-- Table structure for table `campaign_slug_histories`
+CREATE TABLE campaign_slug_histories (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER,
+    slug VARCHAR(255) UNIQUE,
+    created_at TIMESTAMP,
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE
+);
+-- Table structure for table `campaign_versions`
+CREATE TABLE campaign_versions (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER,
+    version INTEGER,
+    edited_by INTEGER,
+    note VARCHAR(255) DEFAULT '',
+    snapshot JSONB,
+    changes JSONB,
+    created_at TIMESTAMP,
+    UNIQUE (campaign_id, version),
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE,
+    FOREIGN KEY (edited_by) REFERENCES users(id) ON DELETE SET NULL
+);
+-- Existing campaigns start their history at version 1
+INSERT INTO campaign_versions (campaign_id, version, edited_by, note, snapshot, changes, created_at)
+SELECT id, 1, user_id, 'created',
+    jsonb_build_object('name', name, 'short_description', short_description, 'description', description,
+        'perks', perks, 'goal_amount', goal_amount, 'slug', slug),
+    '[]'::jsonb, created_at
+FROM campaigns;
 -- Table structure for table `campaign_images`
 CREATE TABLE campaign_images (
     id SERIAL PRIMARY KEY,
@@ -52,3 +105,400 @@ CREATE TABLE transactions (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
 );
 
+
+-- Table structure for table `campaign_rankings`
+CREATE TABLE campaign_rankings (
+    campaign_id INTEGER PRIMARY KEY,
+    rank INTEGER,
+    score DOUBLE PRECISION,
+    amount_24h INTEGER,
+    backer_count_24h INTEGER,
+    amount_7d INTEGER,
+    backer_count_7d INTEGER,
+    amount_30d INTEGER,
+    backer_count_30d INTEGER,
+    computed_at TIMESTAMP,
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE
+);
+-- Table structure for table `featured_campaigns`
+CREATE TABLE featured_campaigns (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER,
+    slot INTEGER,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    created_by INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_transactions_campaign_status_updated ON transactions (campaign_id, status, updated_at);
+
+-- Table structure for table `payment_notifications`
+CREATE TABLE payment_notifications (
+    id SERIAL PRIMARY KEY,
+    order_id VARCHAR(255) NOT NULL,
+    transaction_status VARCHAR(50) NOT NULL,
+    signature_key VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    result VARCHAR(20) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    received_at TIMESTAMP NOT NULL,
+    processed_at TIMESTAMP
+);
+CREATE INDEX idx_payment_notifications_dedupe ON payment_notifications (order_id, transaction_status, signature_key, result);
+
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_status
+    CHECK (status IN ('pending', 'paid', 'failed', 'expired', 'cancelled', 'refund_pending', 'refunded', 'chargeback'));
+
+-- Table structure for table `transaction_status_logs`
+CREATE TABLE transaction_status_logs (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    actor_id INTEGER,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_transaction_status_logs_transaction ON transaction_status_logs (transaction_id, id);
+
+ALTER TABLE transactions ADD CONSTRAINT uq_transactions_code UNIQUE (code);
+
+-- Table structure for table `idempotency_keys`
+CREATE TABLE idempotency_keys (
+    key VARCHAR(255) NOT NULL,
+    scope CHAR(64) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    status_code INTEGER,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    response_body BYTEA NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    PRIMARY KEY (scope, key)
+);
+CREATE INDEX idx_idempotency_keys_created ON idempotency_keys (created_at);
+
+-- Table structure for table `refunds`
+CREATE TABLE refunds (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+    refund_key VARCHAR(255) NOT NULL DEFAULT '',
+    requested_by INTEGER,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_refunds_transaction ON refunds (transaction_id, status);
+
+-- Table structure for table `ledger_journals`
+-- The ledger is append-only: journals and entries are never updated or deleted.
+CREATE TABLE ledger_journals (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    reference VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_ledger_journals_reference ON ledger_journals (reference);
+
+-- Table structure for table `ledger_entries`
+-- amount is signed: debits are positive and credits negative.
+CREATE TABLE ledger_entries (
+    id SERIAL PRIMARY KEY,
+    journal_id INTEGER NOT NULL,
+    account VARCHAR(100) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (journal_id) REFERENCES ledger_journals(id) ON DELETE RESTRICT
+);
+CREATE INDEX idx_ledger_entries_account ON ledger_entries (account, id);
+CREATE INDEX idx_ledger_entries_journal ON ledger_entries (journal_id);
+
+CREATE FUNCTION ledger_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger rows cannot be changed';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER trg_ledger_journals_append_only BEFORE UPDATE OR DELETE ON ledger_journals
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+CREATE TRIGGER trg_ledger_entries_append_only BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+-- Table structure for table `bank_accounts`
+CREATE TABLE bank_accounts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER,
+    bank_code VARCHAR(20) NOT NULL,
+    account_number VARCHAR(34) NOT NULL,
+    account_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('unverified', 'verified', 'rejected')),
+    reviewed_by INTEGER,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_bank_accounts_user ON bank_accounts (user_id);
+
+-- Table structure for table `payout_batches`
+CREATE TABLE payout_batches (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('exported', 'completed')),
+    total BIGINT NOT NULL,
+    count INTEGER NOT NULL,
+    created_by INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Table structure for table `withdrawals`
+CREATE TABLE withdrawals (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL,
+    bank_account_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('requested', 'approved', 'rejected', 'processing', 'paid', 'failed')),
+    requested_by INTEGER,
+    reviewed_by INTEGER,
+    note TEXT NOT NULL DEFAULT '',
+    batch_id INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE RESTRICT,
+    FOREIGN KEY (bank_account_id) REFERENCES bank_accounts(id) ON DELETE RESTRICT,
+    FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (batch_id) REFERENCES payout_batches(id) ON DELETE RESTRICT
+);
+CREATE INDEX idx_withdrawals_campaign ON withdrawals (campaign_id, status);
+CREATE INDEX idx_withdrawals_status ON withdrawals (status);
+
+-- Fees taken from paid donations
+ALTER TABLE transactions
+    ADD COLUMN cover_fees BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN payment_method VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN gross_amount INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN platform_fee INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN gateway_fee INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN fee_amount INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN net_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE campaigns ADD COLUMN net_amount INTEGER NOT NULL DEFAULT 0;
+-- Donations paid before fees were introduced paid none
+UPDATE transactions SET gross_amount = amount, net_amount = amount
+WHERE status IN ('paid', 'refund_pending', 'refunded', 'chargeback');
+UPDATE campaigns SET net_amount = COALESCE(current_amount, 0);
+
+-- Table structure for table `recurring_plans`
+CREATE TABLE recurring_plans (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    cover_fees BOOLEAN NOT NULL DEFAULT FALSE,
+    interval VARCHAR(20) NOT NULL CHECK (interval IN ('monthly', 'quarterly', 'yearly')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'paused', 'past_due', 'cancelled')),
+    card_token VARCHAR(255) NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL,
+    cycle INTEGER NOT NULL DEFAULT 0,
+    next_charge_at TIMESTAMP NOT NULL,
+    retry_at TIMESTAMP,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_transaction_id INTEGER,
+    charge_pending BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    cancelled_at TIMESTAMP,
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_recurring_plans_user ON recurring_plans (user_id);
+CREATE INDEX idx_recurring_plans_due ON recurring_plans (status, next_charge_at);
+
+ALTER TABLE transactions ADD COLUMN recurring_plan_id INTEGER REFERENCES recurring_plans(id) ON DELETE SET NULL;
+ALTER TABLE recurring_plans ADD FOREIGN KEY (last_transaction_id) REFERENCES transactions(id) ON DELETE SET NULL;
+
+-- Donor messages, anonymity and contact preferences
+ALTER TABLE transactions
+    ADD COLUMN message TEXT NOT NULL DEFAULT '',
+    ADD COLUMN anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN display_name VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN contact_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE recurring_plans
+    ADD COLUMN anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN display_name VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN contact_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX idx_transactions_campaign_status ON transactions (campaign_id, status, id);
+
+-- Guest checkout and email verification
+ALTER TABLE transactions
+    ADD COLUMN guest_name VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN guest_email VARCHAR(255) NOT NULL DEFAULT '';
+CREATE INDEX idx_transactions_guest_email ON transactions (LOWER(guest_email)) WHERE user_id IS NULL AND guest_email <> '';
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- Payment reconciliation
+CREATE TABLE settlement_imports (
+    id SERIAL PRIMARY KEY,
+    file_name VARCHAR(255) NOT NULL,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    row_count INTEGER NOT NULL,
+    mismatch_count INTEGER NOT NULL,
+    imported_by INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (imported_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE reconciliation_items (
+    id SERIAL PRIMARY KEY,
+    import_id INTEGER,
+    transaction_id INTEGER,
+    order_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('amount_mismatch', 'status_mismatch', 'missing_transaction', 'missing_settlement')),
+    source VARCHAR(30) NOT NULL,
+    local_amount INTEGER NOT NULL DEFAULT 0,
+    gateway_amount INTEGER NOT NULL DEFAULT 0,
+    local_status VARCHAR(20) NOT NULL DEFAULT '',
+    gateway_status VARCHAR(30) NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    resolved_by INTEGER,
+    FOREIGN KEY (import_id) REFERENCES settlement_imports(id) ON DELETE SET NULL,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE SET NULL,
+    FOREIGN KEY (resolved_by) REFERENCES users(id) ON DELETE SET NULL
+);
+-- An order has at most one open item of each kind
+CREATE UNIQUE INDEX uq_reconciliation_items_open ON reconciliation_items (order_id, kind) WHERE resolved_at IS NULL;
+CREATE INDEX idx_transactions_pending ON transactions (created_at) WHERE status = 'pending';
+-- When the reconciler last asked the gateway about a pending transaction, so
+-- each run checks the ones it has not looked at for longest.
+ALTER TABLE transactions ADD COLUMN last_reconciled_at TIMESTAMP;
+CREATE INDEX idx_transactions_pending_reconciled ON transactions (last_reconciled_at NULLS FIRST, id) WHERE status = 'pending';
+
+-- Donation receipts
+CREATE TABLE receipt_sequences (
+    fiscal_year INTEGER PRIMARY KEY,
+    last_sequence INTEGER NOT NULL
+);
+
+CREATE TABLE receipts (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL UNIQUE,
+    number VARCHAR(30) NOT NULL UNIQUE,
+    fiscal_year INTEGER NOT NULL,
+    sequence INTEGER NOT NULL,
+    amount INTEGER NOT NULL,
+    donated_at TIMESTAMP NOT NULL,
+    issued_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP,
+    UNIQUE (fiscal_year, sequence),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE RESTRICT
+);
+CREATE INDEX idx_receipts_undelivered ON receipts (id) WHERE delivered_at IS NULL;
+
+-- Currencies. Amounts are stored in minor units of the currency next to them;
+-- rupiah has no minor unit in use, so existing amounts stay as they are.
+ALTER TABLE campaigns ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'IDR';
+
+-- A donation is converted once, when it is made, into the campaign's currency.
+-- What the donor entered and the rate used are kept with it.
+ALTER TABLE transactions
+    ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    ADD COLUMN donor_amount INTEGER,
+    ADD COLUMN donor_currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    ADD COLUMN exchange_rate NUMERIC NOT NULL DEFAULT 1;
+UPDATE transactions SET donor_amount = amount;
+ALTER TABLE transactions ALTER COLUMN donor_amount SET NOT NULL;
+
+-- Ledger amounts are in minor units of the entry's currency. Shared accounts
+-- such as platform_fees hold several currencies, which are summed separately.
+ALTER TABLE ledger_entries ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'IDR';
+DROP INDEX idx_ledger_entries_account;
+CREATE INDEX idx_ledger_entries_account ON ledger_entries (account, currency, id);
+
+-- Admin maintained rates; one major unit of base is worth rate units of quote.
+-- NUMERIC without a scale keeps the rate exactly as it was entered.
+CREATE TABLE exchange_rates (
+    id SERIAL PRIMARY KEY,
+    base_currency VARCHAR(3) NOT NULL,
+    quote_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC NOT NULL CHECK (rate > 0),
+    updated_by INTEGER,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (base_currency, quote_currency),
+    CHECK (base_currency <> quote_currency),
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Donation limits per campaign, in the campaign's currency. 0 means no limit.
+ALTER TABLE campaigns
+    ADD COLUMN min_donation INTEGER NOT NULL DEFAULT 0 CHECK (min_donation >= 0),
+    ADD COLUMN max_donation INTEGER NOT NULL DEFAULT 0 CHECK (max_donation >= 0);
+
+-- Risky donations are held in review until an admin approves or rejects them.
+ALTER TABLE transactions DROP CONSTRAINT chk_transactions_status;
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_status
+    CHECK (status IN ('pending', 'paid', 'failed', 'expired', 'cancelled', 'refund_pending', 'refunded', 'chargeback', 'review'));
+
+-- Where each donation came from and what the fraud rules found.
+ALTER TABLE transactions
+    ADD COLUMN client_ip VARCHAR(45),
+    ADD COLUMN risk_score INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN risk_reasons TEXT[] NOT NULL DEFAULT '{}';
+CREATE INDEX idx_transactions_client_ip ON transactions (client_ip, created_at);
+CREATE INDEX idx_transactions_user_created ON transactions (user_id, created_at);
+CREATE INDEX idx_transactions_review ON transactions (risk_score DESC) WHERE status = 'review';
+
+-- Sponsors matching the donations made to a campaign. ratio_percent of every
+-- donation made between starts_at and ends_at is matched until cap is reached.
+-- Amounts are in the campaign's currency.
+CREATE TABLE matching_pledges (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL,
+    sponsor_name VARCHAR(100) NOT NULL,
+    ratio_percent INTEGER NOT NULL CHECK (ratio_percent > 0),
+    cap INTEGER NOT NULL CHECK (cap > 0),
+    matched_amount INTEGER NOT NULL DEFAULT 0 CHECK (matched_amount >= 0 AND matched_amount <= cap),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_by INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    cancelled_at TIMESTAMP,
+    CHECK (ends_at > starts_at),
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_matching_pledges_campaign ON matching_pledges (campaign_id);
+
+-- What each pledge added to a paid donation. Reversed when the donation is
+-- refunded or charged back, which gives the amount back to the pledge.
+CREATE TABLE matched_contributions (
+    id SERIAL PRIMARY KEY,
+    pledge_id INTEGER NOT NULL,
+    transaction_id INTEGER NOT NULL,
+    campaign_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    refunded_amount INTEGER NOT NULL DEFAULT 0 CHECK (refunded_amount BETWEEN 0 AND amount),
+    created_at TIMESTAMP NOT NULL,
+    reversed_at TIMESTAMP,
+    UNIQUE (pledge_id, transaction_id),
+    FOREIGN KEY (pledge_id) REFERENCES matching_pledges(id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_matched_contributions_transaction ON matched_contributions (transaction_id);
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+)
+
+type bankAccountRepo struct {
+	db *sql.DB
+}
+
+const bankAccountColumns = "id, COALESCE(user_id, 0), bank_code, account_number, account_name, status, COALESCE(reviewed_by, 0), reviewed_at, created_at, updated_at"
+
+func scanBankAccount(row interface{ Scan(dest ...any) error }) (model.BankAccount, error) {
+	var account model.BankAccount
+	err := row.Scan(&account.ID, &account.UserID, &account.BankCode, &account.AccountNumber, &account.AccountName, &account.Status,
+		&account.ReviewedBy, &account.ReviewedAt, &account.CreatedAt, &account.UpdatedAt)
+	return account, err
+}
+
+func (r *bankAccountRepo) Save(account model.BankAccount) (model.BankAccount, error) {
+	query := `INSERT INTO bank_accounts (user_id, bank_code, account_number, account_name, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW()) RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(query, account.UserID, account.BankCode, account.AccountNumber, account.AccountName, account.Status).
+		Scan(&account.ID, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		return model.BankAccount{}, err
+	}
+	return account, nil
+}
+
+func (r *bankAccountRepo) FindByID(id int) (model.BankAccount, error) {
+	row := r.db.QueryRow("SELECT "+bankAccountColumns+" FROM bank_accounts WHERE id = $1", id)
+	account, err := scanBankAccount(row)
+	if err != nil {
+		return model.BankAccount{}, err
+	}
+	return account, nil
+}
+
+func (r *bankAccountRepo) FindByUserID(userID int) ([]model.BankAccount, error) {
+	return r.findMany("SELECT "+bankAccountColumns+" FROM bank_accounts WHERE user_id = $1 ORDER BY id", userID)
+}
+
+func (r *bankAccountRepo) FindByStatus(status string) ([]model.BankAccount, error) {
+	return r.findMany("SELECT "+bankAccountColumns+" FROM bank_accounts WHERE status = $1 ORDER BY id", status)
+}
+
+func (r *bankAccountRepo) UpdateStatus(id int, status string, reviewedBy int) (model.BankAccount, error) {
+	row := r.db.QueryRow(`UPDATE bank_accounts SET status = $1, reviewed_by = NULLIF($2, 0), reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $3 RETURNING `+bankAccountColumns, status, reviewedBy, id)
+	account, err := scanBankAccount(row)
+	if err != nil {
+		return model.BankAccount{}, err
+	}
+	return account, nil
+}
+
+func (r *bankAccountRepo) findMany(query string, args ...any) ([]model.BankAccount, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []model.BankAccount
+	for rows.Next() {
+		account, err := scanBankAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+type BankAccountRepo interface {
+	Save(account model.BankAccount) (model.BankAccount, error)
+	FindByID(id int) (model.BankAccount, error)
+	FindByUserID(userID int) ([]model.BankAccount, error)
+	FindByStatus(status string) ([]model.BankAccount, error)
+	UpdateStatus(id int, status string, reviewedBy int) (model.BankAccount, error)
+}
+
+func NewBankAccountRepo(db *sql.DB) BankAccountRepo {
+	return &bankAccountRepo{db: db}
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+)
+
+type campaignMemberRepo struct {
+	db *sql.DB
+}
+
+const campaignMemberColumns = "id, campaign_id, COALESCE(user_id, 0), email, role, status, COALESCE(invited_by, 0), created_at, updated_at"
+
+func scanCampaignMember(row interface{ Scan(dest ...any) error }) (model.CampaignMember, error) {
+	var member model.CampaignMember
+	err := row.Scan(&member.ID, &member.CampaignID, &member.UserID, &member.Email, &member.Role, &member.Status,
+		&member.InvitedBy, &member.CreatedAt, &member.UpdatedAt)
+	return member, err
+}
+
+func (r *campaignMemberRepo) Save(member model.CampaignMember) (model.CampaignMember, error) {
+	query := `INSERT INTO campaign_members (campaign_id, user_id, email, role, status, invited_by, created_at, updated_at)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, NULLIF($6, 0), NOW(), NOW()) RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(query, member.CampaignID, member.UserID, member.Email, member.Role, member.Status, member.InvitedBy).
+		Scan(&member.ID, &member.CreatedAt, &member.UpdatedAt)
+	if err != nil {
+		return model.CampaignMember{}, err
+	}
+	return member, nil
+}
+
+func (r *campaignMemberRepo) FindByID(id int) (model.CampaignMember, error) {
+	row := r.db.QueryRow("SELECT "+campaignMemberColumns+" FROM campaign_members WHERE id = $1", id)
+	member, err := scanCampaignMember(row)
+	if err != nil {
+		return model.CampaignMember{}, err
+	}
+	return member, nil
+}
+
+func (r *campaignMemberRepo) FindByCampaignID(campaignID int) ([]model.CampaignMember, error) {
+	return r.findMany("SELECT "+campaignMemberColumns+" FROM campaign_members WHERE campaign_id = $1 ORDER BY id", campaignID)
+}
+
+func (r *campaignMemberRepo) FindByCampaignAndEmail(campaignID int, email string) (model.CampaignMember, error) {
+	row := r.db.QueryRow("SELECT "+campaignMemberColumns+" FROM campaign_members WHERE campaign_id = $1 AND LOWER(email) = LOWER($2)", campaignID, email)
+	member, err := scanCampaignMember(row)
+	if err != nil {
+		return model.CampaignMember{}, err
+	}
+	return member, nil
+}
+
+func (r *campaignMemberRepo) FindMembership(campaignID int, userID int) (model.CampaignMember, error) {
+	row := r.db.QueryRow("SELECT "+campaignMemberColumns+" FROM campaign_members WHERE campaign_id = $1 AND user_id = $2 AND status = $3",
+		campaignID, userID, model.MemberStatusAccepted)
+	member, err := scanCampaignMember(row)
+	if err != nil {
+		return model.CampaignMember{}, err
+	}
+	return member, nil
+}
+
+func (r *campaignMemberRepo) FindPendingByEmail(email string) ([]model.CampaignMember, error) {
+	return r.findMany("SELECT "+campaignMemberColumns+` FROM campaign_members WHERE LOWER(email) = LOWER($1) AND status = $2
+		AND campaign_id IN (SELECT id FROM campaigns WHERE deleted_at IS NULL) ORDER BY id`,
+		email, model.MemberStatusInvited)
+}
+
+func (r *campaignMemberRepo) CountOwners(campaignID int) (int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM campaign_members WHERE campaign_id = $1 AND role = $2 AND status = $3",
+		campaignID, model.CampaignRoleOwner, model.MemberStatusAccepted).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *campaignMemberRepo) Update(member model.CampaignMember) (model.CampaignMember, error) {
+	query := `UPDATE campaign_members SET user_id = NULLIF($1, 0), role = $2, status = $3, invited_by = NULLIF($4, 0), updated_at = NOW()
+		WHERE id = $5 RETURNING updated_at`
+	err := r.db.QueryRow(query, member.UserID, member.Role, member.Status, member.InvitedBy, member.ID).Scan(&member.UpdatedAt)
+	if err != nil {
+		return model.CampaignMember{}, err
+	}
+	return member, nil
+}
+
+func (r *campaignMemberRepo) Delete(id int) error {
+	_, err := r.db.Exec("DELETE FROM campaign_members WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *campaignMemberRepo) findMany(query string, args ...any) ([]model.CampaignMember, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []model.CampaignMember
+	for rows.Next() {
+		member, err := scanCampaignMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+type CampaignMemberRepo interface {
+	Save(member model.CampaignMember) (model.CampaignMember, error)
+	FindByID(id int) (model.CampaignMember, error)
+	FindByCampaignID(campaignID int) ([]model.CampaignMember, error)
+	FindByCampaignAndEmail(campaignID int, email string) (model.CampaignMember, error)
+	FindMembership(campaignID int, userID int) (model.CampaignMember, error)
+	FindPendingByEmail(email string) ([]model.CampaignMember, error)
+	CountOwners(campaignID int) (int, error)
+	Update(member model.CampaignMember) (model.CampaignMember, error)
+	Delete(id int) error
+}
+
+func NewCampaignMemberRepo(db *sql.DB) CampaignMemberRepo {
+	return &campaignMemberRepo{db: db}
+}
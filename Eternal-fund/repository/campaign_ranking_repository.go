@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"time"
+)
+
+type campaignRankingRepo struct {
+	db *sql.DB
+}
+
+// FindDonationVelocity sums the donations paid to each campaign over the last
+// 30 days. A donation counts from the moment the status log shows it paid,
+// and its decayed weight halves every halfLife from then. The sums are in the
+// campaign's currency, which every donation to it is converted into.
+func (r *campaignRankingRepo) FindDonationVelocity(now time.Time, halfLife time.Duration) ([]model.CampaignRanking, error) {
+	query := `
+		WITH paid AS (
+			SELECT t.id, t.campaign_id, t.amount, MAX(l.created_at) AS paid_at
+			FROM transactions t
+			JOIN transaction_status_logs l ON l.transaction_id = t.id AND l.to_status = 'paid' AND l.created_at >= $3
+			WHERE t.status = 'paid'
+			GROUP BY t.id
+		)
+		SELECT c.id, c.currency,
+			COALESCE(SUM(p.amount) FILTER (WHERE p.paid_at >= $1), 0),
+			COUNT(p.id) FILTER (WHERE p.paid_at >= $1),
+			COALESCE(SUM(p.amount) FILTER (WHERE p.paid_at >= $2), 0),
+			COUNT(p.id) FILTER (WHERE p.paid_at >= $2),
+			COALESCE(SUM(p.amount), 0),
+			COUNT(p.id),
+			COALESCE(SUM(p.amount * POWER(0.5, EXTRACT(EPOCH FROM ($4 - p.paid_at)) / $5)), 0),
+			COALESCE(SUM(POWER(0.5, EXTRACT(EPOCH FROM ($4 - p.paid_at)) / $5)), 0)
+		FROM campaigns c
+		JOIN paid p ON p.campaign_id = c.id
+		WHERE c.deleted_at IS NULL
+		GROUP BY c.id
+	`
+	rows, err := r.db.Query(query, now.Add(-24*time.Hour), now.AddDate(0, 0, -7), now.AddDate(0, 0, -30), now, halfLife.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rankings []model.CampaignRanking
+	for rows.Next() {
+		var ranking model.CampaignRanking
+		err := rows.Scan(&ranking.CampaignID, &ranking.Currency, &ranking.Amount_24h, &ranking.Backer_count_24h, &ranking.Amount_7d, &ranking.Backer_count_7d,
+			&ranking.Amount_30d, &ranking.Backer_count_30d, &ranking.DecayedAmount, &ranking.DecayedBackers)
+		if err != nil {
+			return nil, err
+		}
+		rankings = append(rankings, ranking)
+	}
+	return rankings, nil
+}
+
+func (r *campaignRankingRepo) SaveRankings(rankings []model.CampaignRanking) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM campaign_rankings"); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO campaign_rankings (campaign_id, rank, score, amount_24h, backer_count_24h, amount_7d, backer_count_7d,
+		amount_30d, backer_count_30d, computed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, ranking := range rankings {
+		_, err := stmt.Exec(ranking.CampaignID, ranking.Rank, ranking.Score, ranking.Amount_24h, ranking.Backer_count_24h,
+			ranking.Amount_7d, ranking.Backer_count_7d, ranking.Amount_30d, ranking.Backer_count_30d, ranking.ComputedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *campaignRankingRepo) FindRankings(limit int) ([]model.CampaignRanking, error) {
+	query := `SELECT campaign_id, rank, score, amount_24h, backer_count_24h, amount_7d, backer_count_7d, amount_30d, backer_count_30d, computed_at
+		FROM campaign_rankings ORDER BY rank LIMIT $1`
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rankings []model.CampaignRanking
+	for rows.Next() {
+		var ranking model.CampaignRanking
+		err := rows.Scan(&ranking.CampaignID, &ranking.Rank, &ranking.Score, &ranking.Amount_24h, &ranking.Backer_count_24h,
+			&ranking.Amount_7d, &ranking.Backer_count_7d, &ranking.Amount_30d, &ranking.Backer_count_30d, &ranking.ComputedAt)
+		if err != nil {
+			return nil, err
+		}
+		rankings = append(rankings, ranking)
+	}
+	return rankings, nil
+}
+
+func (r *campaignRankingRepo) CreateFeatured(featured model.FeaturedCampaign) (model.FeaturedCampaign, error) {
+	query := `INSERT INTO featured_campaigns (campaign_id, slot, starts_at, ends_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW()) RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(query, featured.CampaignID, featured.Slot, featured.StartsAt, featured.EndsAt, featured.CreatedBy).
+		Scan(&featured.ID, &featured.CreatedAt, &featured.UpdatedAt)
+	if err != nil {
+		return model.FeaturedCampaign{}, err
+	}
+	return featured, nil
+}
+
+func (r *campaignRankingRepo) CountOverlappingFeatured(slot int, startsAt time.Time, endsAt time.Time) (int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM featured_campaigns WHERE slot = $1 AND starts_at < $3 AND ends_at > $2", slot, startsAt, endsAt).
+		Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *campaignRankingRepo) FindUpcomingFeatured(now time.Time) ([]model.FeaturedCampaign, error) {
+	query := `SELECT id, campaign_id, slot, starts_at, ends_at, created_by, created_at, updated_at
+		FROM featured_campaigns WHERE ends_at > $1 ORDER BY slot, starts_at`
+	rows, err := r.db.Query(query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var featured []model.FeaturedCampaign
+	for rows.Next() {
+		var f model.FeaturedCampaign
+		err := rows.Scan(&f.ID, &f.CampaignID, &f.Slot, &f.StartsAt, &f.EndsAt, &f.CreatedBy, &f.CreatedAt, &f.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		featured = append(featured, f)
+	}
+	return featured, nil
+}
+
+func (r *campaignRankingRepo) DeleteFeatured(id int) error {
+	result, err := r.db.Exec("DELETE FROM featured_campaigns WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+type CampaignRankingRepo interface {
+	FindDonationVelocity(now time.Time, halfLife time.Duration) ([]model.CampaignRanking, error)
+	SaveRankings(rankings []model.CampaignRanking) error
+	FindRankings(limit int) ([]model.CampaignRanking, error)
+	CreateFeatured(featured model.FeaturedCampaign) (model.FeaturedCampaign, error)
+	CountOverlappingFeatured(slot int, startsAt time.Time, endsAt time.Time) (int, error)
+	FindUpcomingFeatured(now time.Time) ([]model.FeaturedCampaign, error)
+	DeleteFeatured(id int) error
+}
+
+func NewCampaignRankingRepo(db *sql.DB) CampaignRankingRepo {
+	return &campaignRankingRepo{db: db}
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"eternal-fund/model"
+)
+
+type campaignVersionRepo struct {
+	db *sql.DB
+}
+
+const campaignVersionColumns = "id, campaign_id, version, COALESCE(edited_by, 0), note, snapshot, changes, created_at"
+
+func scanCampaignVersion(row interface{ Scan(dest ...any) error }) (model.CampaignVersion, error) {
+	var version model.CampaignVersion
+	var snapshot, changes []byte
+	err := row.Scan(&version.ID, &version.CampaignID, &version.Version, &version.EditedBy, &version.Note, &snapshot, &changes, &version.CreatedAt)
+	if err != nil {
+		return model.CampaignVersion{}, err
+	}
+	if err := json.Unmarshal(snapshot, &version.Snapshot); err != nil {
+		return model.CampaignVersion{}, err
+	}
+	if err := json.Unmarshal(changes, &version.Changes); err != nil {
+		return model.CampaignVersion{}, err
+	}
+	return version, nil
+}
+
+// Save stores the next version number for the campaign.
+func (r *campaignVersionRepo) Save(version model.CampaignVersion) (model.CampaignVersion, error) {
+	return saveCampaignVersion(r.db, version)
+}
+
+// saveCampaignVersion inserts the version with q, which is the database
+// transaction that saved the campaign when the two are written together.
+func saveCampaignVersion(q rowQuerier, version model.CampaignVersion) (model.CampaignVersion, error) {
+	snapshot, err := json.Marshal(version.Snapshot)
+	if err != nil {
+		return model.CampaignVersion{}, err
+	}
+	if version.Changes == nil {
+		version.Changes = []model.CampaignFieldChange{}
+	}
+	changes, err := json.Marshal(version.Changes)
+	if err != nil {
+		return model.CampaignVersion{}, err
+	}
+
+	query := `INSERT INTO campaign_versions (campaign_id, version, edited_by, note, snapshot, changes, created_at)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, NULLIF($2, 0), $3, $4, $5, NOW() FROM campaign_versions WHERE campaign_id = $1
+		RETURNING id, version, created_at`
+	err = q.QueryRow(query, version.CampaignID, version.EditedBy, version.Note, snapshot, changes).
+		Scan(&version.ID, &version.Version, &version.CreatedAt)
+	if err != nil {
+		return model.CampaignVersion{}, err
+	}
+	return version, nil
+}
+
+func (r *campaignVersionRepo) FindByCampaignID(campaignID int) ([]model.CampaignVersion, error) {
+	rows, err := r.db.Query("SELECT "+campaignVersionColumns+" FROM campaign_versions WHERE campaign_id = $1 ORDER BY version DESC", campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []model.CampaignVersion
+	for rows.Next() {
+		version, err := scanCampaignVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func (r *campaignVersionRepo) FindByVersion(campaignID int, version int) (model.CampaignVersion, error) {
+	row := r.db.QueryRow("SELECT "+campaignVersionColumns+" FROM campaign_versions WHERE campaign_id = $1 AND version = $2", campaignID, version)
+	return scanCampaignVersion(row)
+}
+
+type CampaignVersionRepo interface {
+	Save(version model.CampaignVersion) (model.CampaignVersion, error)
+	FindByCampaignID(campaignID int) ([]model.CampaignVersion, error)
+	FindByVersion(campaignID int, version int) (model.CampaignVersion, error)
+}
+
+func NewCampaignVersionRepo(db *sql.DB) CampaignVersionRepo {
+	return &campaignVersionRepo{db: db}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CampaignVersionRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    CampaignVersionRepo
+}
+
+func (suite *CampaignVersionRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewCampaignVersionRepo(suite.mockDB)
+}
+
+func (suite *CampaignVersionRepoTestSuite) TestSave_Success() {
+	now := time.Now()
+	version := model.CampaignVersion{
+		CampaignID: 1,
+		EditedBy:   2,
+		Snapshot:   model.CampaignSnapshot{Name: "Water", Goal_amount: 1000, Slug: "water"},
+		Changes:    []model.CampaignFieldChange{{Field: "goal_amount", Old: 500, New: 1000}},
+	}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO campaign_versions")).
+		WithArgs(1, 2, "",
+			[]byte(`{"name":"Water","short_description":"","description":"","perks":"","goal_amount":1000,"slug":"water"}`),
+			[]byte(`[{"field":"goal_amount","old":500,"new":1000}]`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at"}).AddRow(7, 3, now))
+
+	saved, err := suite.repo.Save(version)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, saved.Version)
+	assert.Equal(suite.T(), 7, saved.ID)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *CampaignVersionRepoTestSuite) TestFindByVersion_Success() {
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "campaign_id", "version", "edited_by", "note", "snapshot", "changes", "created_at"}).
+		AddRow(7, 1, 3, 2, "", []byte(`{"name":"Water","goal_amount":1000}`), []byte(`[{"field":"name","old":"Wells","new":"Water"}]`), now)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM campaign_versions WHERE campaign_id = $1 AND version = $2")).
+		WithArgs(1, 3).
+		WillReturnRows(rows)
+
+	version, err := suite.repo.FindByVersion(1, 3)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Water", version.Snapshot.Name)
+	assert.Equal(suite.T(), 1000, version.Snapshot.Goal_amount)
+	assert.Equal(suite.T(), []model.CampaignFieldChange{{Field: "name", Old: "Wells", New: "Water"}}, version.Changes)
+}
+
+func TestCampaignVersionRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(CampaignVersionRepoTestSuite))
+}
@@ -2,20 +2,53 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
 	"log"
 	"math"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrDuplicateSlug is returned when another campaign took the slug first.
+var ErrDuplicateSlug = errors.New("campaign slug already exists")
+
+// slugError reports a unique violation as ErrDuplicateSlug. The slug is the
+// only unique column a campaign write can collide on.
+func slugError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateSlug
+	}
+	return err
+}
+
 type campaignsRepo struct {
 	db *sql.DB
 }
 
+const campaignColumns = "id, user_id, name, short_description, description, perks, backer_count, goal_amount, current_amount, net_amount, slug, created_at, updated_at, currency, min_donation, max_donation"
+
+func campaignFields(c *model.Campaigns) []any {
+	return []any{&c.ID, &c.User_id, &c.Name, &c.Short_description, &c.Description, &c.Perks, &c.Backer_count,
+		&c.Goal_amount, &c.Current_amount, &c.Net_amount, &c.Slug, &c.Created_at, &c.Updated_at, &c.Currency, &c.Min_donation, &c.Max_donation}
+}
+
+// donationStatus matches transactions that were paid at some point, including
+// those refunded or charged back since. They and the refunds, receipts and
+// ledger journals made for them must never lose their campaign.
+const donationStatus = "status NOT IN ('pending', 'failed', 'expired', 'cancelled', 'review')"
+
+// purgeableCampaigns selects campaigns soft deleted before $1 that hold no
+// donations.
+const purgeableCampaigns = `SELECT c.id FROM campaigns c WHERE c.deleted_at < $1
+	AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.campaign_id = c.id AND t.` + donationStatus + `)`
+
 func (a *campaignsRepo) CreateCampaigns(campaigns model.Campaigns) (model.Campaigns, error) {
 	stmt, err := a.db.Prepare(`INSERT INTO campaigns (user_id, name, short_description, description, perks,  backer_count, goal_amount,
-		 current_amount, slug, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8,$9, NOW(), NOW()) RETURNING id`)
+		 current_amount, slug, currency, min_donation, max_donation, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8,$9, $10, $11, $12, NOW(), NOW()) RETURNING id`)
 	if err != nil {
 		return model.Campaigns{}, err
 	}
@@ -23,9 +56,10 @@ func (a *campaignsRepo) CreateCampaigns(campaigns model.Campaigns) (model.Campai
 
 	var campaignsID int
 	err = stmt.QueryRow(campaigns.User_id, campaigns.Name, campaigns.Short_description, campaigns.Description, campaigns.Perks,
-		campaigns.Backer_count, campaigns.Goal_amount, campaigns.Current_amount, campaigns.Slug).Scan(&campaignsID)
+		campaigns.Backer_count, campaigns.Goal_amount, campaigns.Current_amount, campaigns.Slug, campaigns.Currency,
+		campaigns.Min_donation, campaigns.Max_donation).Scan(&campaignsID)
 	if err != nil {
-		return model.Campaigns{}, err
+		return model.Campaigns{}, slugError(err)
 	}
 
 	campaigns.ID = campaignsID
@@ -41,19 +75,18 @@ func (a *campaignsRepo) FindAllCampaigns(page int, size int) ([]model.Campaigns,
 	var row *sql.Rows
 	offset := (page - 1) * size
 	var err error
-	row, err = a.db.Query("SELECT  * FROM campaigns limit $1 offset $2", size, offset)
+	row, err = a.db.Query("SELECT "+campaignColumns+" FROM campaigns WHERE deleted_at IS NULL limit $1 offset $2", size, offset)
 	if err != nil {
 		return nil, dto.Paging{}, err
 	}
 	totalRows := 0
-	err = a.db.QueryRow("SELECT COUNT(*) FROM campaigns").Scan(&totalRows)
+	err = a.db.QueryRow("SELECT COUNT(*) FROM campaigns WHERE deleted_at IS NULL").Scan(&totalRows)
 	if err != nil {
 		return nil, dto.Paging{}, err
 	}
 	for row.Next() {
 		var campaigns model.Campaigns
-		err := row.Scan(&campaigns.ID, &campaigns.User_id, &campaigns.Name, &campaigns.Short_description, &campaigns.Description, &campaigns.Perks, &campaigns.Backer_count,
-			&campaigns.Goal_amount, &campaigns.Current_amount, &campaigns.Slug, &campaigns.Created_at, &campaigns.Updated_at)
+		err := row.Scan(campaignFields(&campaigns)...)
 		if err != nil {
 			log.Println(err.Error())
 		}
@@ -71,8 +104,8 @@ func (a *campaignsRepo) FindAllCampaigns(page int, size int) ([]model.Campaigns,
 
 func (a *campaignsRepo) FindByIdCampaigns(id int) (model.Campaigns, error) {
 	var camp model.Campaigns
-	err := a.db.QueryRow("SELECT * FROM campaigns where id=$1", id).
-		Scan(&camp.ID, &camp.User_id, &camp.Name, &camp.Short_description, &camp.Description, &camp.Perks, &camp.Backer_count, &camp.Goal_amount, &camp.Current_amount, &camp.Slug, &camp.Created_at, &camp.Updated_at)
+	err := a.db.QueryRow("SELECT "+campaignColumns+" FROM campaigns WHERE id=$1 AND deleted_at IS NULL", id).
+		Scan(campaignFields(&camp)...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return model.Campaigns{}, err
@@ -82,47 +115,198 @@ func (a *campaignsRepo) FindByIdCampaigns(id int) (model.Campaigns, error) {
 	return camp, nil
 }
 
+// FindByIDs loads the campaigns with the given ids in one query. Deleted
+// campaigns are left out, and the order is not kept.
+func (a *campaignsRepo) FindByIDs(ids []int) ([]model.Campaigns, error) {
+	rows, err := a.db.Query("SELECT "+campaignColumns+" FROM campaigns WHERE id = ANY($1) AND deleted_at IS NULL", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []model.Campaigns
+	for rows.Next() {
+		var campaign model.Campaigns
+		if err := rows.Scan(campaignFields(&campaign)...); err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+	return campaigns, rows.Err()
+}
+
+// DeleteCampaigns soft deletes a campaign. It returns sql.ErrNoRows when the
+// campaign does not exist, is already deleted or has donations.
 func (a *campaignsRepo) DeleteCampaigns(id int) error {
-	_, err := a.db.Exec("DELETE FROM campaigns WHERE id = $1", id)
+	result, err := a.db.Exec(`UPDATE campaigns SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM transactions WHERE campaign_id = $1 AND `+donationStatus+`)`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
 	return nil
 }
 
-func (a *campaignsRepo) UpdateCampaigns(id int) (model.Campaigns, error) {
-	campaign := model.Campaigns{}
-	stmt, err := a.db.Prepare(`
-		UPDATE campaigns 
-		SET user_id = $1, name = $2, short_description = $3, description = $4,
-		perks = $5, backer_count = $6, goal_amount = $7, current_amount = $8, slug = $9, updated_at = NOW()
-		WHERE id = $10
-		RETURNING id, user_id, name, short_description, description, perks, backer_count, goal_amount, current_amount, slug, created_at, updated_at
-	`)
+// CountDonations counts the campaign's transactions that were ever paid.
+func (a *campaignsRepo) CountDonations(id int) (int, error) {
+	var total int
+	err := a.db.QueryRow("SELECT COUNT(*) FROM transactions WHERE campaign_id = $1 AND "+donationStatus, id).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (a *campaignsRepo) FindDeletedCampaigns() ([]model.Campaigns, error) {
+	rows, err := a.db.Query("SELECT " + campaignColumns + ", deleted_at FROM campaigns WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []model.Campaigns
+	for rows.Next() {
+		var c model.Campaigns
+		if err := rows.Scan(append(campaignFields(&c), &c.Deleted_at)...); err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, c)
+	}
+	return campaigns, nil
+}
+
+func (a *campaignsRepo) RestoreCampaigns(id int) (model.Campaigns, error) {
+	var c model.Campaigns
+	err := a.db.QueryRow("UPDATE campaigns SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND deleted_at IS NOT NULL RETURNING "+campaignColumns, id).
+		Scan(campaignFields(&c)...)
 	if err != nil {
 		return model.Campaigns{}, err
 	}
-	defer stmt.Close()
+	return c, nil
+}
+
+// PurgeDeletedCampaigns permanently removes campaigns soft deleted before the
+// cutoff together with their images and the transactions that were never
+// paid.
+func (a *campaignsRepo) PurgeDeletedCampaigns(before time.Time) (int64, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM transactions WHERE campaign_id IN ("+purgeableCampaigns+")", before); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM campaign_images WHERE campaign_id IN ("+purgeableCampaigns+")", before); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec("DELETE FROM campaigns WHERE id IN ("+purgeableCampaigns+")", before)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, tx.Commit()
+}
+
+// UpdateCampaigns saves a campaign. When its slug changes, the old slug is
+// kept in the slug history in the same database transaction so links to it
+// keep working, and the new slug is taken out of the history in case the
+// campaign used it before. The owner and donation totals are left alone so an
+// edit never overwrites donations counted since the campaign was read. A
+// version with changes is stored in the same transaction, so the history
+// cannot miss an edit that was saved.
+func (a *campaignsRepo) UpdateCampaigns(campaign model.Campaigns, version model.CampaignVersion) (model.Campaigns, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return model.Campaigns{}, err
+	}
+	defer tx.Rollback()
+
+	var oldSlug string
+	err = tx.QueryRow("SELECT slug FROM campaigns WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", campaign.ID).Scan(&oldSlug)
+	if err != nil {
+		return model.Campaigns{}, err
+	}
+	if oldSlug != campaign.Slug {
+		if _, err := tx.Exec("DELETE FROM campaign_slug_histories WHERE slug = $1", campaign.Slug); err != nil {
+			return model.Campaigns{}, err
+		}
+		_, err = tx.Exec("INSERT INTO campaign_slug_histories (campaign_id, slug, created_at) VALUES ($1, $2, NOW())", campaign.ID, oldSlug)
+		if err != nil {
+			return model.Campaigns{}, slugError(err)
+		}
+	}
 
 	var updatedCampaign model.Campaigns
-	err = stmt.QueryRow(
-		campaign.User_id, campaign.Name, campaign.Short_description, campaign.Description,
-		campaign.Perks, campaign.Backer_count, campaign.Goal_amount, campaign.Current_amount, campaign.Slug, campaign.ID,
-	).Scan(
-		&updatedCampaign.ID, &updatedCampaign.User_id, &updatedCampaign.Name, &updatedCampaign.Short_description,
-		&updatedCampaign.Description, &updatedCampaign.Perks, &updatedCampaign.Backer_count, &updatedCampaign.Goal_amount,
-		&updatedCampaign.Current_amount, &updatedCampaign.Slug, &updatedCampaign.Created_at, &updatedCampaign.Updated_at,
-	)
+	err = tx.QueryRow(`
+		UPDATE campaigns 
+		SET name = $1, short_description = $2, description = $3, perks = $4,
+		goal_amount = $5, slug = $6, min_donation = $7, max_donation = $8, updated_at = NOW()
+		WHERE id = $9 AND deleted_at IS NULL
+		RETURNING `+campaignColumns,
+		campaign.Name, campaign.Short_description, campaign.Description, campaign.Perks,
+		campaign.Goal_amount, campaign.Slug, campaign.Min_donation, campaign.Max_donation, campaign.ID,
+	).Scan(campaignFields(&updatedCampaign)...)
+	if err != nil {
+		return model.Campaigns{}, slugError(err)
+	}
+	if len(version.Changes) > 0 {
+		if _, err := saveCampaignVersion(tx, version); err != nil {
+			return model.Campaigns{}, err
+		}
+	}
+
+	return updatedCampaign, tx.Commit()
+}
+
+func (a *campaignsRepo) FindBySlug(slug string) (model.Campaigns, error) {
+	var camp model.Campaigns
+	err := a.db.QueryRow("SELECT "+campaignColumns+" FROM campaigns WHERE slug=$1 AND deleted_at IS NULL", slug).
+		Scan(campaignFields(&camp)...)
 	if err != nil {
 		return model.Campaigns{}, err
 	}
+	return camp, nil
+}
 
-	return updatedCampaign, nil
+// FindSlugOwner returns the campaign that currently uses the slug or used it
+// before a rename. It returns sql.ErrNoRows when the slug is free.
+func (a *campaignsRepo) FindSlugOwner(slug string) (int, error) {
+	var campaignID int
+	err := a.db.QueryRow(`
+		SELECT id FROM campaigns WHERE slug = $1
+		UNION ALL
+		SELECT campaign_id FROM campaign_slug_histories WHERE slug = $1
+		LIMIT 1`, slug).Scan(&campaignID)
+	if err != nil {
+		return 0, err
+	}
+	return campaignID, nil
+}
+
+func (a *campaignsRepo) FindCampaignIDBySlugHistory(slug string) (int, error) {
+	var campaignID int
+	err := a.db.QueryRow("SELECT campaign_id FROM campaign_slug_histories WHERE slug = $1", slug).Scan(&campaignID)
+	if err != nil {
+		return 0, err
+	}
+	return campaignID, nil
 }
 
 func (a *campaignsRepo) FindByUserID(userID int) ([]model.Campaigns, error) {
 	var campaigns []model.Campaigns
-	rows, err := a.db.Query("SELECT * FROM campaigns WHERE user_id = $1", userID)
+	rows, err := a.db.Query("SELECT "+campaignColumns+" FROM campaigns WHERE user_id = $1 AND deleted_at IS NULL", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +315,7 @@ func (a *campaignsRepo) FindByUserID(userID int) ([]model.Campaigns, error) {
 
 	for rows.Next() {
 		var campaign model.Campaigns
-		if err := rows.Scan(&campaign.ID, &campaign.User_id, &campaign.Name, &campaign.Short_description, &campaign.Description, &campaign.Perks, &campaign.Backer_count, &campaign.Goal_amount, &campaign.Current_amount, &campaign.Slug, &campaign.Created_at, &campaign.Updated_at); err != nil {
+		if err := rows.Scan(campaignFields(&campaign)...); err != nil {
 			return nil, err
 		}
 		campaigns = append(campaigns, campaign)
@@ -159,11 +343,19 @@ type CampaignsRepo interface {
 	CreateCampaigns(campaigns model.Campaigns) (model.Campaigns, error)
 	FindAllCampaigns(page int, size int) ([]model.Campaigns, dto.Paging, error)
 	FindByIdCampaigns(id int) (model.Campaigns, error)
-	UpdateCampaigns(input int) (model.Campaigns, error)
+	FindByIDs(ids []int) ([]model.Campaigns, error)
+	UpdateCampaigns(campaign model.Campaigns, version model.CampaignVersion) (model.Campaigns, error)
 	DeleteCampaigns(id int) error
 	FindByUserID(userID int) ([]model.Campaigns, error)
 	CreateImage(campaignImage model.CampaignImage) (model.CampaignImage, error)
 	MarkAllImagesAsNonPrimary(campaignID int) (bool, error)
+	FindBySlug(slug string) (model.Campaigns, error)
+	FindSlugOwner(slug string) (int, error)
+	FindCampaignIDBySlugHistory(slug string) (int, error)
+	CountDonations(id int) (int, error)
+	FindDeletedCampaigns() ([]model.Campaigns, error)
+	RestoreCampaigns(id int) (model.Campaigns, error)
+	PurgeDeletedCampaigns(before time.Time) (int64, error)
 }
 
 func NewCampaignsRepo(database *sql.DB) CampaignsRepo {
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -38,9 +39,11 @@ var expectedCampaigns = []model.Campaigns{
 		Backer_count:      10,
 		Goal_amount:       1000,
 		Current_amount:    500,
+		Net_amount:        460,
 		Slug:              "campaign-1",
 		Created_at:        time.Now(),
 		Updated_at:        time.Now(),
+		Currency:          model.CurrencyIDR,
 	},
 	{
 		ID:                2,
@@ -55,6 +58,9 @@ var expectedCampaigns = []model.Campaigns{
 		Slug:              "campaign-2",
 		Created_at:        time.Now(),
 		Updated_at:        time.Now(),
+		Currency:          model.CurrencyUSD,
+		Min_donation:      500,
+		Max_donation:      100000,
 	},
 }
 
@@ -69,15 +75,15 @@ func (suite *CampaignsRepoTestSuite) TestGetAll_success() {
 		TotalRows:  5,
 		TotalPages: 3,
 	}
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "slug", "created_at", "updated_at"}).
-		AddRow(expectedCampaigns[0].ID, expectedCampaigns[0].User_id, expectedCampaigns[0].Name, expectedCampaigns[0].Short_description, expectedCampaigns[0].Description, expectedCampaigns[0].Perks, expectedCampaigns[0].Backer_count, expectedCampaigns[0].Goal_amount, expectedCampaigns[0].Current_amount, expectedCampaigns[0].Slug, expectedCampaigns[0].Created_at, expectedCampaigns[0].Updated_at).
-		AddRow(expectedCampaigns[1].ID, expectedCampaigns[1].User_id, expectedCampaigns[1].Name, expectedCampaigns[1].Short_description, expectedCampaigns[1].Description, expectedCampaigns[1].Perks, expectedCampaigns[1].Backer_count, expectedCampaigns[1].Goal_amount, expectedCampaigns[1].Current_amount, expectedCampaigns[1].Slug, expectedCampaigns[1].Created_at, expectedCampaigns[1].Updated_at)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "net_amount", "slug", "created_at", "updated_at", "currency", "min_donation", "max_donation"}).
+		AddRow(expectedCampaigns[0].ID, expectedCampaigns[0].User_id, expectedCampaigns[0].Name, expectedCampaigns[0].Short_description, expectedCampaigns[0].Description, expectedCampaigns[0].Perks, expectedCampaigns[0].Backer_count, expectedCampaigns[0].Goal_amount, expectedCampaigns[0].Current_amount, expectedCampaigns[0].Net_amount, expectedCampaigns[0].Slug, expectedCampaigns[0].Created_at, expectedCampaigns[0].Updated_at, string(expectedCampaigns[0].Currency), expectedCampaigns[0].Min_donation, expectedCampaigns[0].Max_donation).
+		AddRow(expectedCampaigns[1].ID, expectedCampaigns[1].User_id, expectedCampaigns[1].Name, expectedCampaigns[1].Short_description, expectedCampaigns[1].Description, expectedCampaigns[1].Perks, expectedCampaigns[1].Backer_count, expectedCampaigns[1].Goal_amount, expectedCampaigns[1].Current_amount, expectedCampaigns[1].Net_amount, expectedCampaigns[1].Slug, expectedCampaigns[1].Created_at, expectedCampaigns[1].Updated_at, string(expectedCampaigns[1].Currency), expectedCampaigns[1].Min_donation, expectedCampaigns[1].Max_donation)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM campaigns limit $1 offset $2`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT "+campaignColumns+" FROM campaigns WHERE deleted_at IS NULL limit $1 offset $2")).
 		WithArgs(size, offset).WillReturnRows(rows)
 
 	totalRows := sqlmock.NewRows([]string{"COUNT"}).AddRow(5)
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM campaigns")).WillReturnRows(totalRows)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM campaigns WHERE deleted_at IS NULL")).WillReturnRows(totalRows)
 
 	campaigns, paging, err := suite.repo.FindAllCampaigns(page, size)
 	assert.NoError(suite.T(), err)
@@ -88,11 +94,11 @@ func (suite *CampaignsRepoTestSuite) TestGetAll_success() {
 func (suite *CampaignsRepoTestSuite) TestFindById_Success() {
 	expectedCampaign := expectedCampaigns[0]
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "slug", "created_at", "updated_at"}).
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "net_amount", "slug", "created_at", "updated_at", "currency", "min_donation", "max_donation"}).
 		AddRow(expectedCampaign.ID, expectedCampaign.User_id, expectedCampaign.Name, expectedCampaign.Short_description,
 			expectedCampaign.Description, expectedCampaign.Perks, expectedCampaign.Backer_count, expectedCampaign.Goal_amount,
-			expectedCampaign.Current_amount, expectedCampaign.Slug, expectedCampaign.Created_at, expectedCampaign.Updated_at)
-	expectedQuery := `SELECT \* FROM campaigns where id=\$1`
+			expectedCampaign.Current_amount, expectedCampaign.Net_amount, expectedCampaign.Slug, expectedCampaign.Created_at, expectedCampaign.Updated_at, string(expectedCampaign.Currency), expectedCampaign.Min_donation, expectedCampaign.Max_donation)
+	expectedQuery := regexp.QuoteMeta("SELECT " + campaignColumns + " FROM campaigns WHERE id=$1 AND deleted_at IS NULL")
 
 	suite.mockSql.ExpectQuery(expectedQuery).
 		WithArgs(expectedCampaign.ID).
@@ -116,10 +122,25 @@ func (suite *CampaignsRepoTestSuite) TestFindById_Success() {
 	assert.NoError(suite.T(), err)
 }
 
+func (suite *CampaignsRepoTestSuite) TestFindByIDs_Success() {
+	campaign := expectedCampaigns[0]
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "net_amount", "slug", "created_at", "updated_at", "currency", "min_donation", "max_donation"}).
+		AddRow(campaign.ID, campaign.User_id, campaign.Name, campaign.Short_description, campaign.Description, campaign.Perks, campaign.Backer_count,
+			campaign.Goal_amount, campaign.Current_amount, campaign.Net_amount, campaign.Slug, campaign.Created_at, campaign.Updated_at,
+			string(campaign.Currency), campaign.Min_donation, campaign.Max_donation)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM campaigns WHERE id = ANY($1) AND deleted_at IS NULL")).
+		WithArgs(pq.Array([]int{campaign.ID, 99})).
+		WillReturnRows(rows)
+
+	campaigns, err := suite.repo.FindByIDs([]int{campaign.ID, 99})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.Campaigns{campaign}, campaigns)
+}
+
 func (suite *CampaignsRepoTestSuite) TestDelete_Success() {
 	id := 1
 
-	suite.mockSql.ExpectExec(regexp.QuoteMeta("DELETE FROM campaigns WHERE id = $1")).
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE campaigns SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL")).
 		WithArgs(id).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
@@ -128,6 +149,57 @@ func (suite *CampaignsRepoTestSuite) TestDelete_Success() {
 	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
+func (suite *CampaignsRepoTestSuite) TestDelete_HasPaidTransactions() {
+	id := 1
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE campaigns SET deleted_at = NOW()")).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := suite.repo.DeleteCampaigns(id)
+	assert.ErrorIs(suite.T(), err, sql.ErrNoRows)
+}
+
+func (suite *CampaignsRepoTestSuite) TestCountDonations_IncludesRefundedAndChargedBack() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM transactions WHERE campaign_id = $1 AND status NOT IN ('pending', 'failed', 'expired', 'cancelled', 'review')")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	total, err := suite.repo.CountDonations(1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, total)
+}
+
+func (suite *CampaignsRepoTestSuite) TestRestore_Success() {
+	campaign := expectedCampaigns[0]
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "net_amount", "slug", "created_at", "updated_at", "currency", "min_donation", "max_donation"}).
+		AddRow(campaign.ID, campaign.User_id, campaign.Name, campaign.Short_description, campaign.Description, campaign.Perks,
+			campaign.Backer_count, campaign.Goal_amount, campaign.Current_amount, campaign.Net_amount, campaign.Slug, campaign.Created_at, campaign.Updated_at, string(campaign.Currency), campaign.Min_donation, campaign.Max_donation)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE campaigns SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND deleted_at IS NOT NULL")).
+		WithArgs(campaign.ID).
+		WillReturnRows(rows)
+
+	restored, err := suite.repo.RestoreCampaigns(campaign.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), campaign, restored)
+}
+
+func (suite *CampaignsRepoTestSuite) TestPurgeDeleted_Success() {
+	before := time.Now()
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("DELETE FROM transactions WHERE campaign_id IN")).WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 3))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("DELETE FROM campaign_images WHERE campaign_id IN")).WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("DELETE FROM campaigns WHERE id IN")).WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 2))
+	suite.mockSql.ExpectCommit()
+
+	purged, err := suite.repo.PurgeDeletedCampaigns(before)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), purged)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
 func (suite *CampaignsRepoTestSuite) TestFindByUserID_Success() {
 	userID := 123
 
@@ -135,14 +207,14 @@ func (suite *CampaignsRepoTestSuite) TestFindByUserID_Success() {
 		{ID: 1, User_id: userID, Name: "Campaign 1" /* other fields */},
 		{ID: 2, User_id: userID, Name: "Campaign 2" /* other fields */},
 	}
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "slug", "created_at", "updated_at"})
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "net_amount", "slug", "created_at", "updated_at", "currency", "min_donation", "max_donation"})
 	for _, campaign := range expectedCampaigns {
 		rows.AddRow(campaign.ID, campaign.User_id, campaign.Name, campaign.Short_description,
 			campaign.Description, campaign.Perks, campaign.Backer_count, campaign.Goal_amount,
-			campaign.Current_amount, campaign.Slug, campaign.Created_at, campaign.Updated_at)
+			campaign.Current_amount, campaign.Net_amount, campaign.Slug, campaign.Created_at, campaign.Updated_at, string(campaign.Currency), campaign.Min_donation, campaign.Max_donation)
 	}
 
-	expectedQuery := `SELECT \* FROM campaigns WHERE user_id = \$1`
+	expectedQuery := regexp.QuoteMeta("SELECT " + campaignColumns + " FROM campaigns WHERE user_id = $1 AND deleted_at IS NULL")
 	suite.mockSql.ExpectQuery(expectedQuery).
 		WithArgs(userID).
 		WillReturnRows(rows)
@@ -192,7 +264,8 @@ func (suite *CampaignsRepoTestSuite) TestCreateCampaigns_Success() {
 	suite.mockSql.ExpectPrepare(expectedQuery)
 	suite.mockSql.ExpectQuery(expectedQuery).
 		WithArgs(mockCampaign.User_id, mockCampaign.Name, mockCampaign.Short_description, mockCampaign.Description, mockCampaign.Perks,
-			mockCampaign.Backer_count, mockCampaign.Goal_amount, mockCampaign.Current_amount, mockCampaign.Slug).
+			mockCampaign.Backer_count, mockCampaign.Goal_amount, mockCampaign.Current_amount, mockCampaign.Slug, mockCampaign.Currency,
+			mockCampaign.Min_donation, mockCampaign.Max_donation).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(expectedCampaignID))
 	createdCampaign, err := suite.repo.CreateCampaigns(mockCampaign)
 
@@ -213,6 +286,84 @@ func (suite *CampaignsRepoTestSuite) TestCreateCampaigns_Success() {
 	assert.NoError(suite.T(), err)
 }
 
+func (suite *CampaignsRepoTestSuite) TestCreateCampaigns_DuplicateSlug() {
+	suite.mockSql.ExpectPrepare("INSERT INTO campaigns")
+	suite.mockSql.ExpectQuery("INSERT INTO campaigns").WillReturnError(&pq.Error{Code: "23505"})
+
+	_, err := suite.repo.CreateCampaigns(model.Campaigns{Name: "Clean Water", Slug: "clean-water"})
+	assert.ErrorIs(suite.T(), err, ErrDuplicateSlug)
+}
+
+func (suite *CampaignsRepoTestSuite) TestUpdateCampaigns_RenameKeepsSlugHistory() {
+	renamed := expectedCampaigns[0]
+	renamed.Slug = "new-name"
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "net_amount", "slug", "created_at", "updated_at", "currency", "min_donation", "max_donation"}).
+		AddRow(renamed.ID, renamed.User_id, renamed.Name, renamed.Short_description, renamed.Description, renamed.Perks, renamed.Backer_count,
+			renamed.Goal_amount, renamed.Current_amount, renamed.Net_amount, renamed.Slug, renamed.Created_at, renamed.Updated_at,
+			string(renamed.Currency), renamed.Min_donation, renamed.Max_donation)
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT slug FROM campaigns WHERE id = $1 AND deleted_at IS NULL FOR UPDATE")).
+		WithArgs(renamed.ID).WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("old-name"))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("DELETE FROM campaign_slug_histories WHERE slug = $1")).
+		WithArgs("new-name").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO campaign_slug_histories")).
+		WithArgs(renamed.ID, "old-name").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SET name = $1, short_description = $2, description = $3, perks = $4,")).
+		WithArgs(renamed.Name, renamed.Short_description, renamed.Description, renamed.Perks,
+			renamed.Goal_amount, renamed.Slug, renamed.Min_donation, renamed.Max_donation, renamed.ID).
+		WillReturnRows(rows)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO campaign_versions")).
+		WithArgs(renamed.ID, 3, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at"}).AddRow(8, 2, time.Now()))
+	suite.mockSql.ExpectCommit()
+
+	version := model.CampaignVersion{CampaignID: renamed.ID, EditedBy: 3, Snapshot: renamed.Snapshot(),
+		Changes: []model.CampaignFieldChange{{Field: "slug", Old: "old-name", New: "new-name"}}}
+	updated, err := suite.repo.UpdateCampaigns(renamed, version)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "new-name", updated.Slug)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *CampaignsRepoTestSuite) TestUpdateCampaigns_SlugTaken() {
+	renamed := expectedCampaigns[0]
+	renamed.Slug = "new-name"
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT slug FROM campaigns")).
+		WithArgs(renamed.ID).WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("old-name"))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("DELETE FROM campaign_slug_histories")).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO campaign_slug_histories")).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectQuery("UPDATE campaigns").WillReturnError(&pq.Error{Code: "23505"})
+	suite.mockSql.ExpectRollback()
+
+	_, err := suite.repo.UpdateCampaigns(renamed, model.CampaignVersion{})
+	assert.ErrorIs(suite.T(), err, ErrDuplicateSlug)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *CampaignsRepoTestSuite) TestUpdateCampaigns_VersionFailsRollsBack() {
+	edited := expectedCampaigns[0]
+	edited.Goal_amount += 1000
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT slug FROM campaigns")).
+		WithArgs(edited.ID).WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow(edited.Slug))
+	suite.mockSql.ExpectQuery("UPDATE campaigns").WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "short_description", "description", "perks", "backer_count", "goal_amount", "current_amount", "net_amount", "slug", "created_at", "updated_at", "currency", "min_donation", "max_donation"}).
+		AddRow(edited.ID, edited.User_id, edited.Name, edited.Short_description, edited.Description, edited.Perks, edited.Backer_count,
+			edited.Goal_amount, edited.Current_amount, edited.Net_amount, edited.Slug, edited.Created_at, edited.Updated_at,
+			string(edited.Currency), edited.Min_donation, edited.Max_donation))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO campaign_versions")).WillReturnError(assert.AnError)
+	suite.mockSql.ExpectRollback()
+
+	version := model.CampaignVersion{CampaignID: edited.ID, Snapshot: edited.Snapshot(),
+		Changes: []model.CampaignFieldChange{{Field: "goal_amount", Old: edited.Goal_amount - 1000, New: edited.Goal_amount}}}
+	_, err := suite.repo.UpdateCampaigns(edited, version)
+	assert.ErrorIs(suite.T(), err, assert.AnError)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
 // func (suite *CampaignsRepoTestSuite) TestUpdate_Success() {
 // 	updatedCampaign := model.Campaigns{
 // 		ID:                70,
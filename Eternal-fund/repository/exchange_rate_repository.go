@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+)
+
+type exchangeRateRepo struct {
+	db *sql.DB
+}
+
+const exchangeRateColumns = "id, base_currency, quote_currency, rate, COALESCE(updated_by, 0), updated_at"
+
+func scanExchangeRate(row interface{ Scan(dest ...any) error }) (model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+	err := row.Scan(&rate.ID, &rate.Base, &rate.Quote, &rate.Rate, &rate.UpdatedBy, &rate.UpdatedAt)
+	return rate, err
+}
+
+// Save sets the rate of a currency pair, replacing the one it had before.
+func (r *exchangeRateRepo) Save(rate model.ExchangeRate) (model.ExchangeRate, error) {
+	return scanExchangeRate(r.db.QueryRow(`INSERT INTO exchange_rates (base_currency, quote_currency, rate, updated_by, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, 0), NOW())
+		ON CONFLICT (base_currency, quote_currency) DO UPDATE SET rate = EXCLUDED.rate, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING `+exchangeRateColumns, rate.Base, rate.Quote, rate.Rate, rate.UpdatedBy))
+}
+
+func (r *exchangeRateRepo) Find(base model.Currency, quote model.Currency) (model.ExchangeRate, error) {
+	return scanExchangeRate(r.db.QueryRow("SELECT "+exchangeRateColumns+" FROM exchange_rates WHERE base_currency = $1 AND quote_currency = $2",
+		base, quote))
+}
+
+func (r *exchangeRateRepo) FindAll() ([]model.ExchangeRate, error) {
+	rows, err := r.db.Query("SELECT " + exchangeRateColumns + " FROM exchange_rates ORDER BY base_currency, quote_currency")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []model.ExchangeRate
+	for rows.Next() {
+		rate, err := scanExchangeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// Delete removes the rate of a currency pair. It returns sql.ErrNoRows when
+// the pair has no rate.
+func (r *exchangeRateRepo) Delete(base model.Currency, quote model.Currency) error {
+	result, err := r.db.Exec("DELETE FROM exchange_rates WHERE base_currency = $1 AND quote_currency = $2", base, quote)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+type ExchangeRateRepo interface {
+	Save(rate model.ExchangeRate) (model.ExchangeRate, error)
+	Find(base model.Currency, quote model.Currency) (model.ExchangeRate, error)
+	FindAll() ([]model.ExchangeRate, error)
+	Delete(base model.Currency, quote model.Currency) error
+}
+
+func NewExchangeRateRepo(db *sql.DB) ExchangeRateRepo {
+	return &exchangeRateRepo{db: db}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExchangeRateRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    ExchangeRateRepo
+}
+
+func (suite *ExchangeRateRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewExchangeRateRepo(suite.mockDB)
+}
+
+func (suite *ExchangeRateRepoTestSuite) TestSave_ReplacesRate() {
+	updatedAt := time.Now()
+	rate := model.ExchangeRate{Base: model.CurrencyUSD, Quote: model.CurrencyIDR, Rate: "16250.5", UpdatedBy: 1}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("ON CONFLICT (base_currency, quote_currency) DO UPDATE")).
+		WithArgs(model.CurrencyUSD, model.CurrencyIDR, "16250.5", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "base_currency", "quote_currency", "rate", "updated_by", "updated_at"}).
+			AddRow(3, "USD", "IDR", "16250.5", 1, updatedAt))
+
+	saved, err := suite.repo.Save(rate)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.ExchangeRate{ID: 3, Base: model.CurrencyUSD, Quote: model.CurrencyIDR, Rate: "16250.5", UpdatedBy: 1,
+		UpdatedAt: updatedAt}, saved)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ExchangeRateRepoTestSuite) TestDelete_NotFound() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("DELETE FROM exchange_rates")).
+		WithArgs(model.CurrencySGD, model.CurrencyMYR).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := suite.repo.Delete(model.CurrencySGD, model.CurrencyMYR)
+	assert.ErrorIs(suite.T(), err, sql.ErrNoRows)
+}
+
+func TestExchangeRateRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(ExchangeRateRepoTestSuite))
+}
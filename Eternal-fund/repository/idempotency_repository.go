@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"eternal-fund/model"
+	"time"
+)
+
+type idempotencyRepo struct {
+	db *sql.DB
+}
+
+const idempotencyColumns = "key, scope, method, path, fingerprint, COALESCE(status_code, 0), content_type, response_body, created_at, completed_at"
+
+func scanIdempotencyRecord(row interface{ Scan(dest ...any) error }) (model.IdempotencyRecord, error) {
+	var record model.IdempotencyRecord
+	err := row.Scan(&record.Key, &record.Scope, &record.Method, &record.Path, &record.Fingerprint, &record.StatusCode,
+		&record.ContentType, &record.ResponseBody, &record.CreatedAt, &record.CompletedAt)
+	return record, err
+}
+
+// Reserve claims the key for a new request. When the key is already taken the
+// existing record is returned with reserved set to false.
+func (r *idempotencyRepo) Reserve(record model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	query := `INSERT INTO idempotency_keys (key, scope, method, path, fingerprint, content_type, response_body, created_at)
+		VALUES ($1, $2, $3, $4, $5, '', '', NOW()) ON CONFLICT (scope, key) DO NOTHING RETURNING created_at`
+	err := r.db.QueryRow(query, record.Key, record.Scope, record.Method, record.Path, record.Fingerprint).Scan(&record.CreatedAt)
+	if err == nil {
+		return record, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.IdempotencyRecord{}, false, err
+	}
+
+	existing, err := r.Find(record.Scope, record.Key)
+	if err != nil {
+		return model.IdempotencyRecord{}, false, err
+	}
+	return existing, false, nil
+}
+
+func (r *idempotencyRepo) Find(scope string, key string) (model.IdempotencyRecord, error) {
+	row := r.db.QueryRow("SELECT "+idempotencyColumns+" FROM idempotency_keys WHERE scope = $1 AND key = $2", scope, key)
+	record, err := scanIdempotencyRecord(row)
+	if err != nil {
+		return model.IdempotencyRecord{}, err
+	}
+	return record, nil
+}
+
+func (r *idempotencyRepo) Complete(record model.IdempotencyRecord) error {
+	query := `UPDATE idempotency_keys SET status_code = $1, content_type = $2, response_body = $3, completed_at = NOW()
+		WHERE scope = $4 AND key = $5`
+	_, err := r.db.Exec(query, record.StatusCode, record.ContentType, record.ResponseBody, record.Scope, record.Key)
+	return err
+}
+
+func (r *idempotencyRepo) Release(scope string, key string) error {
+	_, err := r.db.Exec("DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2", scope, key)
+	return err
+}
+
+func (r *idempotencyRepo) PurgeBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM idempotency_keys WHERE created_at < $1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+type IdempotencyRepo interface {
+	Reserve(record model.IdempotencyRecord) (model.IdempotencyRecord, bool, error)
+	Find(scope string, key string) (model.IdempotencyRecord, error)
+	Complete(record model.IdempotencyRecord) error
+	Release(scope string, key string) error
+	PurgeBefore(cutoff time.Time) (int64, error)
+}
+
+func NewIdempotencyRepo(db *sql.DB) IdempotencyRepo {
+	return &idempotencyRepo{db: db}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    IdempotencyRepo
+}
+
+func (suite *IdempotencyRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewIdempotencyRepo(suite.mockDB)
+}
+
+func (suite *IdempotencyRepoTestSuite) TestReserve_NewKey() {
+	now := time.Now()
+	record := model.IdempotencyRecord{Key: "k1", Scope: "scope", Method: "POST", Path: "/api/v1/transactions", Fingerprint: "fp"}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+		WithArgs("k1", "scope", "POST", "/api/v1/transactions", "fp").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+
+	reserved, ok, err := suite.repo.Reserve(record)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), now, reserved.CreatedAt)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *IdempotencyRepoTestSuite) TestReserve_ExistingKey() {
+	now := time.Now()
+	record := model.IdempotencyRecord{Key: "k1", Scope: "scope", Method: "POST", Path: "/api/v1/transactions", Fingerprint: "fp"}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+		WithArgs("k1", "scope", "POST", "/api/v1/transactions", "fp").
+		WillReturnError(sql.ErrNoRows)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT key, scope")).
+		WithArgs("scope", "k1").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "scope", "method", "path", "fingerprint", "status_code", "content_type",
+			"response_body", "created_at", "completed_at"}).
+			AddRow("k1", "scope", "POST", "/api/v1/transactions", "fp", 200, "application/json", []byte(`{}`), now, now))
+
+	existing, ok, err := suite.repo.Reserve(record)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), ok)
+	assert.Equal(suite.T(), 200, existing.StatusCode)
+	assert.True(suite.T(), existing.IsCompleted())
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyRepoTestSuite))
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"fmt"
+	"math"
+
+	"github.com/lib/pq"
+)
+
+type ledgerRepo struct {
+	db *sql.DB
+}
+
+// postJournal appends a balanced journal inside tx, so it is written together
+// with the change it records or not at all.
+func postJournal(tx *sql.Tx, journal model.LedgerJournal) (model.LedgerJournal, error) {
+	if !journal.IsBalanced() {
+		return model.LedgerJournal{}, model.ErrUnbalancedJournal
+	}
+	journal.Currency = journal.Currency.OrDefault()
+
+	err := tx.QueryRow("INSERT INTO ledger_journals (kind, reference, description, created_at) VALUES ($1, $2, $3, NOW()) RETURNING id, created_at",
+		journal.Kind, journal.Reference, journal.Description).Scan(&journal.ID, &journal.CreatedAt)
+	if err != nil {
+		return model.LedgerJournal{}, err
+	}
+
+	for i, entry := range journal.Entries {
+		err := tx.QueryRow("INSERT INTO ledger_entries (journal_id, account, amount, currency, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			journal.ID, entry.Account, entry.Amount, journal.Currency, journal.CreatedAt).Scan(&journal.Entries[i].ID)
+		if err != nil {
+			return model.LedgerJournal{}, err
+		}
+		journal.Entries[i].JournalID = journal.ID
+		journal.Entries[i].Currency = journal.Currency
+		journal.Entries[i].CreatedAt = journal.CreatedAt
+	}
+	return journal, nil
+}
+
+// donationJournal moves amount of a donation between the gateway clearing
+// account and the campaign, passing through the donor's account so the donor
+// has a statement of their giving. A negative amount reverses it.
+func donationJournal(kind string, reference string, transaction model.Transaction, amount int) model.LedgerJournal {
+	donor := model.DonorAccount(transaction.UserID)
+	entries := append(model.Transfer(model.LedgerAccountGatewayClearing, donor, amount),
+		model.Transfer(donor, model.CampaignAccount(transaction.CampaignID), amount)...)
+	return model.LedgerJournal{
+		Kind:        kind,
+		Reference:   reference,
+		Description: fmt.Sprintf("%s of transaction %s", kind, transaction.Code),
+		Currency:    transaction.Currency.OrDefault(),
+		Entries:     entries,
+	}
+}
+
+// feeJournal takes the fees of a paid donation from the campaign. Negative
+// fees give them back when the donation stops counting.
+func feeJournal(reference string, transaction model.Transaction, platformFee int, gatewayFee int) model.LedgerJournal {
+	entries := []model.LedgerEntry{{Account: model.CampaignAccount(transaction.CampaignID), Amount: platformFee + gatewayFee}}
+	if platformFee != 0 {
+		entries = append(entries, model.LedgerEntry{Account: model.LedgerAccountPlatformFees, Amount: -platformFee})
+	}
+	if gatewayFee != 0 {
+		entries = append(entries, model.LedgerEntry{Account: model.LedgerAccountGatewayFees, Amount: -gatewayFee})
+	}
+	return model.LedgerJournal{
+		Kind:        model.JournalKindFee,
+		Reference:   reference,
+		Description: fmt.Sprintf("fees of transaction %s", transaction.Code),
+		Currency:    transaction.Currency.OrDefault(),
+		Entries:     entries,
+	}
+}
+
+func (r *ledgerRepo) Balance(account string, currency model.Currency) (model.LedgerBalance, error) {
+	balance := model.LedgerBalance{Account: account, Currency: currency}
+	err := r.db.QueryRow(`SELECT COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0), COALESCE(-SUM(amount) FILTER (WHERE amount < 0), 0)
+		FROM ledger_entries WHERE account = $1 AND currency = $2`, account, currency).Scan(&balance.Debit, &balance.Credit)
+	if err != nil {
+		return model.LedgerBalance{}, err
+	}
+	balance.Balance = balance.Credit - balance.Debit
+	return balance, nil
+}
+
+func (r *ledgerRepo) FindEntries(account string, page int, size int) ([]model.LedgerEntry, dto.Paging, error) {
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM ledger_entries WHERE account = $1", account).Scan(&total); err != nil {
+		return nil, dto.Paging{}, err
+	}
+
+	offset := (page - 1) * size
+	rows, err := r.db.Query("SELECT id, journal_id, account, amount, currency, created_at FROM ledger_entries WHERE account = $1 ORDER BY id DESC LIMIT $2 OFFSET $3",
+		account, size, offset)
+	if err != nil {
+		return nil, dto.Paging{}, err
+	}
+	defer rows.Close()
+
+	var entries []model.LedgerEntry
+	for rows.Next() {
+		var entry model.LedgerEntry
+		if err := rows.Scan(&entry.ID, &entry.JournalID, &entry.Account, &entry.Amount, &entry.Currency, &entry.CreatedAt); err != nil {
+			return nil, dto.Paging{}, err
+		}
+		entries = append(entries, entry)
+	}
+
+	paging := dto.Paging{
+		Page:       page,
+		Size:       size,
+		TotalRows:  total,
+		TotalPages: int(math.Ceil(float64(total) / float64(size))),
+	}
+	return entries, paging, nil
+}
+
+// FindUnbalancedJournals returns the ids of journals whose entries do not sum
+// to zero or mix currencies. It is always empty unless rows were changed
+// outside postJournal.
+func (r *ledgerRepo) FindUnbalancedJournals() ([]int, error) {
+	rows, err := r.db.Query(`SELECT journal_id FROM ledger_entries GROUP BY journal_id
+		HAVING SUM(amount) <> 0 OR COUNT(DISTINCT currency) > 1 ORDER BY journal_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// FindCampaignDiscrepancies compares every campaign's current amount with the
+// amount its ledger account has raised in the campaign's currency.
+func (r *ledgerRepo) FindCampaignDiscrepancies() ([]model.LedgerDiscrepancy, error) {
+	query := `SELECT c.id, COALESCE(c.current_amount, 0), COALESCE(l.raised, 0)
+		FROM campaigns c
+		LEFT JOIN (
+			SELECT e.account, e.currency, -SUM(e.amount) AS raised FROM ledger_entries e
+			JOIN ledger_journals j ON j.id = e.journal_id
+			WHERE e.account LIKE 'campaign:%' AND j.kind = ANY($1)
+			GROUP BY e.account, e.currency
+		) l ON l.account = 'campaign:' || c.id AND l.currency = c.currency
+		WHERE COALESCE(c.current_amount, 0) <> COALESCE(l.raised, 0)
+		ORDER BY c.id`
+	rows, err := r.db.Query(query, pq.Array(model.RaisedJournalKinds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var discrepancies []model.LedgerDiscrepancy
+	for rows.Next() {
+		var discrepancy model.LedgerDiscrepancy
+		if err := rows.Scan(&discrepancy.CampaignID, &discrepancy.CurrentAmount, &discrepancy.LedgerRaised); err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, discrepancy)
+	}
+	return discrepancies, nil
+}
+
+type LedgerRepo interface {
+	Balance(account string, currency model.Currency) (model.LedgerBalance, error)
+	FindEntries(account string, page int, size int) ([]model.LedgerEntry, dto.Paging, error)
+	FindUnbalancedJournals() ([]int, error)
+	FindCampaignDiscrepancies() ([]model.LedgerDiscrepancy, error)
+}
+
+func NewLedgerRepo(db *sql.DB) LedgerRepo {
+	return &ledgerRepo{db: db}
+}
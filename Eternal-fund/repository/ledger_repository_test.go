@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// expectDonationJournal expects postJournal to write a donation journal of
+// amount for transaction.
+func expectJournal(mockSql sqlmock.Sqlmock, journal model.LedgerJournal) {
+	mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO ledger_journals")).
+		WithArgs(journal.Kind, journal.Reference, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+	for _, entry := range journal.Entries {
+		mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO ledger_entries")).
+			WithArgs(1, entry.Account, entry.Amount, journal.Currency.OrDefault(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	}
+}
+
+func expectDonationJournal(mockSql sqlmock.Sqlmock, kind string, reference string, transaction model.Transaction, amount int) {
+	expectJournal(mockSql, donationJournal(kind, reference, transaction, amount))
+}
+
+type LedgerRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    LedgerRepo
+}
+
+func (suite *LedgerRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewLedgerRepo(suite.mockDB)
+}
+
+func (suite *LedgerRepoTestSuite) TestDonationJournalBalances() {
+	transaction := model.Transaction{ID: 1, CampaignID: 2, UserID: 3, Amount: 5000, Code: "TRX-1"}
+	journal := donationJournal(model.JournalKindPayment, "transaction:1", transaction, 5000)
+
+	assert.True(suite.T(), journal.IsBalanced())
+	totals := map[string]int{}
+	for _, entry := range journal.Entries {
+		totals[entry.Account] += entry.Amount
+	}
+	assert.Equal(suite.T(), map[string]int{"gateway_clearing": 5000, "donor:3": 0, "campaign:2": -5000}, totals)
+}
+
+func (suite *LedgerRepoTestSuite) TestFeeJournalBalances() {
+	transaction := model.Transaction{ID: 1, CampaignID: 2, UserID: 3, Amount: 100000, Code: "TRX-1", Currency: model.CurrencyUSD}
+	journal := feeJournal("transaction:1", transaction, 5000, 4000)
+
+	assert.True(suite.T(), journal.IsBalanced())
+	assert.Equal(suite.T(), model.CurrencyUSD, journal.Currency)
+	assert.Equal(suite.T(), []model.LedgerEntry{
+		{Account: "campaign:2", Amount: 9000},
+		{Account: "platform_fees", Amount: -5000},
+		{Account: "gateway_fees", Amount: -4000},
+	}, journal.Entries)
+}
+
+func (suite *LedgerRepoTestSuite) TestPostJournal_Unbalanced() {
+	suite.mockSql.ExpectBegin()
+	tx, _ := suite.mockDB.Begin()
+
+	_, err := postJournal(tx, model.LedgerJournal{Kind: model.JournalKindPayment, Entries: []model.LedgerEntry{{Account: "campaign:1", Amount: -10}}})
+	assert.ErrorIs(suite.T(), err, model.ErrUnbalancedJournal)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *LedgerRepoTestSuite) TestBalance() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM ledger_entries WHERE account = $1 AND currency = $2")).
+		WithArgs("platform_fees", model.CurrencyUSD).
+		WillReturnRows(sqlmock.NewRows([]string{"debit", "credit"}).AddRow(1000, 6000))
+
+	balance, err := suite.repo.Balance("platform_fees", model.CurrencyUSD)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.LedgerBalance{Account: "platform_fees", Currency: model.CurrencyUSD, Debit: 1000, Credit: 6000, Balance: 5000}, balance)
+}
+
+func (suite *LedgerRepoTestSuite) TestFindCampaignDiscrepancies() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM campaigns c")).
+		WithArgs(pq.Array(model.RaisedJournalKinds)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "current_amount", "raised"}).AddRow(2, 7000, 5000))
+
+	discrepancies, err := suite.repo.FindCampaignDiscrepancies()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.LedgerDiscrepancy{{CampaignID: 2, CurrentAmount: 7000, LedgerRaised: 5000}}, discrepancies)
+}
+
+func TestLedgerRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(LedgerRepoTestSuite))
+}
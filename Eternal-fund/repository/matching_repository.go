@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+
+	"github.com/lib/pq"
+)
+
+type matchingRepo struct {
+	db *sql.DB
+}
+
+const matchingPledgeColumns = "id, campaign_id, sponsor_name, ratio_percent, cap, matched_amount, starts_at, ends_at, COALESCE(created_by, 0), created_at, cancelled_at"
+
+func scanMatchingPledge(row interface{ Scan(dest ...any) error }) (model.MatchingPledge, error) {
+	var p model.MatchingPledge
+	err := row.Scan(&p.ID, &p.CampaignID, &p.SponsorName, &p.RatioPercent, &p.Cap, &p.MatchedAmount, &p.StartsAt, &p.EndsAt,
+		&p.CreatedBy, &p.CreatedAt, &p.CancelledAt)
+	return p, err
+}
+
+func (r *matchingRepo) Save(pledge model.MatchingPledge) (model.MatchingPledge, error) {
+	err := r.db.QueryRow(`INSERT INTO matching_pledges (campaign_id, sponsor_name, ratio_percent, cap, starts_at, ends_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), NOW()) RETURNING id, created_at`,
+		pledge.CampaignID, pledge.SponsorName, pledge.RatioPercent, pledge.Cap, pledge.StartsAt, pledge.EndsAt, pledge.CreatedBy).
+		Scan(&pledge.ID, &pledge.CreatedAt)
+	if err != nil {
+		return model.MatchingPledge{}, err
+	}
+	return pledge, nil
+}
+
+func (r *matchingRepo) FindByID(id int) (model.MatchingPledge, error) {
+	return scanMatchingPledge(r.db.QueryRow("SELECT "+matchingPledgeColumns+" FROM matching_pledges WHERE id = $1", id))
+}
+
+func (r *matchingRepo) FindByCampaign(campaignID int) ([]model.MatchingPledge, error) {
+	rows, err := r.db.Query("SELECT "+matchingPledgeColumns+" FROM matching_pledges WHERE campaign_id = $1 ORDER BY id", campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pledges []model.MatchingPledge
+	for rows.Next() {
+		pledge, err := scanMatchingPledge(rows)
+		if err != nil {
+			return nil, err
+		}
+		pledges = append(pledges, pledge)
+	}
+	return pledges, rows.Err()
+}
+
+// FindByCampaigns loads the pledges of several campaigns in one query, ordered
+// by campaign.
+func (r *matchingRepo) FindByCampaigns(campaignIDs []int) ([]model.MatchingPledge, error) {
+	rows, err := r.db.Query("SELECT "+matchingPledgeColumns+" FROM matching_pledges WHERE campaign_id = ANY($1) ORDER BY campaign_id, id",
+		pq.Array(campaignIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pledges []model.MatchingPledge
+	for rows.Next() {
+		pledge, err := scanMatchingPledge(rows)
+		if err != nil {
+			return nil, err
+		}
+		pledges = append(pledges, pledge)
+	}
+	return pledges, rows.Err()
+}
+
+// Cancel stops a pledge from matching further donations. What it matched
+// already stays. It returns sql.ErrNoRows when the pledge does not exist or
+// is already cancelled.
+func (r *matchingRepo) Cancel(id int) (model.MatchingPledge, error) {
+	return scanMatchingPledge(r.db.QueryRow("UPDATE matching_pledges SET cancelled_at = NOW() WHERE id = $1 AND cancelled_at IS NULL RETURNING "+
+		matchingPledgeColumns, id))
+}
+
+func (r *matchingRepo) FindContributions(pledgeID int) ([]model.MatchedContribution, error) {
+	rows, err := r.db.Query(`SELECT id, pledge_id, transaction_id, campaign_id, amount, refunded_amount, created_at, reversed_at
+		FROM matched_contributions WHERE pledge_id = $1 ORDER BY id`, pledgeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contributions []model.MatchedContribution
+	for rows.Next() {
+		var c model.MatchedContribution
+		if err := rows.Scan(&c.ID, &c.PledgeID, &c.TransactionID, &c.CampaignID, &c.Amount, &c.RefundedAmount, &c.CreatedAt, &c.ReversedAt); err != nil {
+			return nil, err
+		}
+		contributions = append(contributions, c)
+	}
+	return contributions, rows.Err()
+}
+
+// matchDonation adds a matched contribution from every pledge that was active
+// when the donation was made, as far as each pledge's budget allows. The
+// pledges are locked so concurrent donations cannot overspend a cap.
+func matchDonation(tx *sql.Tx, transaction model.Transaction) error {
+	rows, err := tx.Query("SELECT "+matchingPledgeColumns+` FROM matching_pledges
+		WHERE campaign_id = $1 AND cancelled_at IS NULL AND starts_at <= $2 AND ends_at > $2 AND matched_amount < cap
+		ORDER BY id FOR UPDATE`, transaction.CampaignID, transaction.CreatedAt)
+	if err != nil {
+		return err
+	}
+	var pledges []model.MatchingPledge
+	for rows.Next() {
+		pledge, err := scanMatchingPledge(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		pledges = append(pledges, pledge)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, pledge := range pledges {
+		amount := min(transaction.MatchableAmount()*pledge.RatioPercent/100, pledge.Remaining())
+		if amount <= 0 {
+			continue
+		}
+		_, err := tx.Exec(`INSERT INTO matched_contributions (pledge_id, transaction_id, campaign_id, amount, created_at)
+			VALUES ($1, $2, $3, $4, NOW())`, pledge.ID, transaction.ID, transaction.CampaignID, amount)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE matching_pledges SET matched_amount = matched_amount + $1 WHERE id = $2", amount, pledge.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reduceMatches takes back the share of a donation's matched contributions
+// that a partial refund returns to the donor. remaining is what was left of
+// the donation before the refund, so each contribution shrinks by the same
+// fraction as the donation.
+func reduceMatches(tx *sql.Tx, transactionID int, refunded int, remaining int) error {
+	if remaining <= 0 {
+		return nil
+	}
+	rows, err := tx.Query(`SELECT id, pledge_id, amount - refunded_amount FROM matched_contributions
+		WHERE transaction_id = $1 AND reversed_at IS NULL ORDER BY id FOR UPDATE`, transactionID)
+	if err != nil {
+		return err
+	}
+	var contributions []model.MatchedContribution
+	for rows.Next() {
+		var c model.MatchedContribution
+		if err := rows.Scan(&c.ID, &c.PledgeID, &c.Amount); err != nil {
+			rows.Close()
+			return err
+		}
+		contributions = append(contributions, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range contributions {
+		amount := c.Amount * min(refunded, remaining) / remaining
+		if amount <= 0 {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE matched_contributions SET refunded_amount = refunded_amount + $1 WHERE id = $2", amount, c.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE matching_pledges SET matched_amount = matched_amount - $1 WHERE id = $2", amount, c.PledgeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reverseMatches gives what is left of the matched contributions of a
+// donation that no longer counts back to their pledges' budgets.
+func reverseMatches(tx *sql.Tx, transactionID int) error {
+	_, err := tx.Exec(`UPDATE matching_pledges p SET matched_amount = p.matched_amount - (c.amount - c.refunded_amount)
+		FROM matched_contributions c WHERE c.pledge_id = p.id AND c.transaction_id = $1 AND c.reversed_at IS NULL`, transactionID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE matched_contributions SET reversed_at = NOW() WHERE transaction_id = $1 AND reversed_at IS NULL", transactionID)
+	return err
+}
+
+type MatchingRepo interface {
+	Save(pledge model.MatchingPledge) (model.MatchingPledge, error)
+	FindByID(id int) (model.MatchingPledge, error)
+	FindByCampaign(campaignID int) ([]model.MatchingPledge, error)
+	FindByCampaigns(campaignIDs []int) ([]model.MatchingPledge, error)
+	Cancel(id int) (model.MatchingPledge, error)
+	FindContributions(pledgeID int) ([]model.MatchedContribution, error)
+}
+
+func NewMatchingRepo(db *sql.DB) MatchingRepo {
+	return &matchingRepo{db: db}
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MatchingRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    MatchingRepo
+}
+
+func (suite *MatchingRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewMatchingRepo(suite.mockDB)
+}
+
+func matchingPledgeRows(pledges ...model.MatchingPledge) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "campaign_id", "sponsor_name", "ratio_percent", "cap", "matched_amount", "starts_at", "ends_at",
+		"created_by", "created_at", "cancelled_at"})
+	for _, p := range pledges {
+		rows.AddRow(p.ID, p.CampaignID, p.SponsorName, p.RatioPercent, p.Cap, p.MatchedAmount, p.StartsAt, p.EndsAt, p.CreatedBy, p.CreatedAt, p.CancelledAt)
+	}
+	return rows
+}
+
+var expectedPledge = model.MatchingPledge{
+	ID:           4,
+	CampaignID:   3,
+	SponsorName:  "Acme",
+	RatioPercent: 100,
+	Cap:          5000000,
+	StartsAt:     time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC),
+	EndsAt:       time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC),
+	CreatedBy:    9,
+	CreatedAt:    time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC),
+}
+
+func (suite *MatchingRepoTestSuite) TestSave() {
+	pledge := expectedPledge
+	pledge.ID, pledge.CreatedAt = 0, time.Time{}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO matching_pledges")).
+		WithArgs(3, "Acme", 100, 5000000, pledge.StartsAt, pledge.EndsAt, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(4, expectedPledge.CreatedAt))
+
+	saved, err := suite.repo.Save(pledge)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), expectedPledge, saved)
+}
+
+func (suite *MatchingRepoTestSuite) TestFindByCampaign() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM matching_pledges WHERE campaign_id = $1 ORDER BY id")).
+		WithArgs(3).
+		WillReturnRows(matchingPledgeRows(expectedPledge))
+
+	pledges, err := suite.repo.FindByCampaign(3)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.MatchingPledge{expectedPledge}, pledges)
+}
+
+func (suite *MatchingRepoTestSuite) TestFindByCampaigns() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM matching_pledges WHERE campaign_id = ANY($1) ORDER BY campaign_id, id")).
+		WithArgs(pq.Array([]int{3, 5})).
+		WillReturnRows(matchingPledgeRows(expectedPledge))
+
+	pledges, err := suite.repo.FindByCampaigns([]int{3, 5})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.MatchingPledge{expectedPledge}, pledges)
+}
+
+func (suite *MatchingRepoTestSuite) TestCancel_AlreadyCancelled() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE matching_pledges SET cancelled_at = NOW() WHERE id = $1 AND cancelled_at IS NULL")).
+		WithArgs(4).
+		WillReturnRows(matchingPledgeRows())
+
+	_, err := suite.repo.Cancel(4)
+	assert.ErrorIs(suite.T(), err, sql.ErrNoRows)
+}
+
+func (suite *MatchingRepoTestSuite) TestFindContributions() {
+	createdAt := time.Now()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM matched_contributions WHERE pledge_id = $1")).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "pledge_id", "transaction_id", "campaign_id", "amount", "refunded_amount", "created_at", "reversed_at"}).
+			AddRow(1, 4, 23, 3, 50000, 10000, createdAt, nil))
+
+	contributions, err := suite.repo.FindContributions(4)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.MatchedContribution{{ID: 1, PledgeID: 4, TransactionID: 23, CampaignID: 3, Amount: 50000, RefundedAmount: 10000, CreatedAt: createdAt}}, contributions)
+}
+
+func TestMatchingRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(MatchingRepoTestSuite))
+}
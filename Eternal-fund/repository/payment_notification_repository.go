@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"math"
+)
+
+type paymentNotificationRepo struct {
+	db *sql.DB
+}
+
+const paymentNotificationColumns = "id, order_id, transaction_status, signature_key, payload, headers, result, error, received_at, processed_at"
+
+func scanPaymentNotification(row interface{ Scan(dest ...any) error }) (model.PaymentNotification, error) {
+	var notification model.PaymentNotification
+	var payload, headers []byte
+	err := row.Scan(&notification.ID, &notification.OrderID, &notification.TransactionStatus, &notification.SignatureKey,
+		&payload, &headers, &notification.Result, &notification.Error, &notification.ReceivedAt, &notification.ProcessedAt)
+	if err != nil {
+		return model.PaymentNotification{}, err
+	}
+	notification.Payload = payload
+	if err := json.Unmarshal(headers, &notification.Headers); err != nil {
+		return model.PaymentNotification{}, err
+	}
+	return notification, nil
+}
+
+func (r *paymentNotificationRepo) Save(notification model.PaymentNotification) (model.PaymentNotification, error) {
+	headers, err := json.Marshal(notification.Headers)
+	if err != nil {
+		return model.PaymentNotification{}, err
+	}
+
+	query := `INSERT INTO payment_notifications (order_id, transaction_status, signature_key, payload, headers, result, error, received_at)
+		VALUES ($1, $2, $3, $4, $5, $6, '', NOW()) RETURNING id, received_at`
+	err = r.db.QueryRow(query, notification.OrderID, notification.TransactionStatus, notification.SignatureKey,
+		[]byte(notification.Payload), headers, notification.Result).Scan(&notification.ID, &notification.ReceivedAt)
+	if err != nil {
+		return model.PaymentNotification{}, err
+	}
+	return notification, nil
+}
+
+func (r *paymentNotificationRepo) FindByID(id int) (model.PaymentNotification, error) {
+	row := r.db.QueryRow("SELECT "+paymentNotificationColumns+" FROM payment_notifications WHERE id = $1", id)
+	return scanPaymentNotification(row)
+}
+
+func (r *paymentNotificationRepo) FindAll(page int, size int) ([]model.PaymentNotification, dto.Paging, error) {
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM payment_notifications").Scan(&total); err != nil {
+		return nil, dto.Paging{}, err
+	}
+
+	offset := (page - 1) * size
+	rows, err := r.db.Query("SELECT "+paymentNotificationColumns+" FROM payment_notifications ORDER BY id DESC LIMIT $1 OFFSET $2", size, offset)
+	if err != nil {
+		return nil, dto.Paging{}, err
+	}
+	defer rows.Close()
+
+	var notifications []model.PaymentNotification
+	for rows.Next() {
+		notification, err := scanPaymentNotification(rows)
+		if err != nil {
+			return nil, dto.Paging{}, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	paging := dto.Paging{
+		Page:       page,
+		Size:       size,
+		TotalRows:  total,
+		TotalPages: int(math.Ceil(float64(total) / float64(size))),
+	}
+	return notifications, paging, nil
+}
+
+// IsProcessed reports whether another notification with the same order,
+// status and signature has already been applied.
+func (r *paymentNotificationRepo) IsProcessed(notification model.PaymentNotification) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM payment_notifications
+		WHERE order_id = $1 AND transaction_status = $2 AND signature_key = $3 AND result = $4 AND id <> $5)`
+	err := r.db.QueryRow(query, notification.OrderID, notification.TransactionStatus, notification.SignatureKey,
+		model.NotificationResultProcessed, notification.ID).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (r *paymentNotificationRepo) UpdateResult(id int, result string, errMessage string) error {
+	_, err := r.db.Exec("UPDATE payment_notifications SET result = $1, error = $2, processed_at = NOW() WHERE id = $3", result, errMessage, id)
+	return err
+}
+
+type PaymentNotificationRepo interface {
+	Save(notification model.PaymentNotification) (model.PaymentNotification, error)
+	FindByID(id int) (model.PaymentNotification, error)
+	FindAll(page int, size int) ([]model.PaymentNotification, dto.Paging, error)
+	IsProcessed(notification model.PaymentNotification) (bool, error)
+	UpdateResult(id int, result string, errMessage string) error
+}
+
+func NewPaymentNotificationRepo(db *sql.DB) PaymentNotificationRepo {
+	return &paymentNotificationRepo{db: db}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type PaymentNotificationRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    PaymentNotificationRepo
+}
+
+func (suite *PaymentNotificationRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewPaymentNotificationRepo(suite.mockDB)
+}
+
+func (suite *PaymentNotificationRepoTestSuite) TestSave_Success() {
+	now := time.Now()
+	notification := model.PaymentNotification{
+		OrderID:           "1",
+		TransactionStatus: "settlement",
+		SignatureKey:      "sig",
+		Payload:           []byte(`{"order_id":"1"}`),
+		Headers:           map[string]string{"Content-Type": "application/json"},
+		Result:            model.NotificationResultReceived,
+	}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO payment_notifications")).
+		WithArgs("1", "settlement", "sig", []byte(`{"order_id":"1"}`), []byte(`{"Content-Type":"application/json"}`), model.NotificationResultReceived).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "received_at"}).AddRow(9, now))
+
+	saved, err := suite.repo.Save(notification)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 9, saved.ID)
+	assert.Equal(suite.T(), now, saved.ReceivedAt)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PaymentNotificationRepoTestSuite) TestIsProcessed() {
+	notification := model.PaymentNotification{ID: 9, OrderID: "1", TransactionStatus: "settlement", SignatureKey: "sig"}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM payment_notifications")).
+		WithArgs("1", "settlement", "sig", model.NotificationResultProcessed, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	processed, err := suite.repo.IsProcessed(notification)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), processed)
+}
+
+func (suite *PaymentNotificationRepoTestSuite) TestFindByID_Success() {
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "order_id", "transaction_status", "signature_key", "payload", "headers", "result", "error", "received_at", "processed_at"}).
+		AddRow(9, "1", "settlement", "sig", []byte(`{"order_id":"1"}`), []byte(`{"User-Agent":"Veritrans"}`), model.NotificationResultProcessed, "", now, now)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM payment_notifications WHERE id = $1")).
+		WithArgs(9).
+		WillReturnRows(rows)
+
+	notification, err := suite.repo.FindByID(9)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Veritrans", notification.Headers["User-Agent"])
+	assert.JSONEq(suite.T(), `{"order_id":"1"}`, string(notification.Payload))
+	assert.Equal(suite.T(), &now, notification.ProcessedAt)
+}
+
+func TestPaymentNotificationRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(PaymentNotificationRepoTestSuite))
+}
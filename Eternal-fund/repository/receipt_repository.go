@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"eternal-fund/model"
+)
+
+// ErrReceiptExists is returned when a receipt is created for a transaction
+// that already has one.
+var ErrReceiptExists = errors.New("transaction already has a receipt")
+
+type receiptRepo struct {
+	db *sql.DB
+}
+
+const receiptColumns = "id, transaction_id, number, fiscal_year, sequence, amount, donated_at, issued_at, delivered_at"
+
+func scanReceipt(row interface{ Scan(dest ...any) error }) (model.Receipt, error) {
+	var receipt model.Receipt
+	err := row.Scan(&receipt.ID, &receipt.TransactionID, &receipt.Number, &receipt.FiscalYear, &receipt.Sequence,
+		&receipt.Amount, &receipt.DonatedAt, &receipt.IssuedAt, &receipt.DeliveredAt)
+	return receipt, err
+}
+
+// FindUnissued returns up to limit paid transactions that have no receipt yet.
+func (r *receiptRepo) FindUnissued(limit int) ([]model.Transaction, error) {
+	rows, err := r.db.Query("SELECT "+transactionColumns+` FROM transactions WHERE status = $1
+		AND NOT EXISTS (SELECT 1 FROM receipts WHERE receipts.transaction_id = transactions.id) ORDER BY id LIMIT $2`,
+		model.TransactionStatusPaid, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []model.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
+
+// Create gives the receipt the next number of its fiscal year. The counter
+// row stays locked until the receipt is stored, and a receipt that cannot be
+// stored rolls the counter back, so numbers have no gaps.
+func (r *receiptRepo) Create(receipt model.Receipt) (model.Receipt, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return model.Receipt{}, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`INSERT INTO receipt_sequences (fiscal_year, last_sequence) VALUES ($1, 1)
+		ON CONFLICT (fiscal_year) DO UPDATE SET last_sequence = receipt_sequences.last_sequence + 1 RETURNING last_sequence`,
+		receipt.FiscalYear).Scan(&receipt.Sequence)
+	if err != nil {
+		return model.Receipt{}, err
+	}
+	receipt.Number = model.ReceiptNumber(receipt.FiscalYear, receipt.Sequence)
+
+	err = tx.QueryRow(`INSERT INTO receipts (transaction_id, number, fiscal_year, sequence, amount, donated_at, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW()) ON CONFLICT (transaction_id) DO NOTHING RETURNING id, issued_at`,
+		receipt.TransactionID, receipt.Number, receipt.FiscalYear, receipt.Sequence, receipt.Amount, receipt.DonatedAt).
+		Scan(&receipt.ID, &receipt.IssuedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Receipt{}, ErrReceiptExists
+	}
+	if err != nil {
+		return model.Receipt{}, err
+	}
+
+	return receipt, tx.Commit()
+}
+
+func (r *receiptRepo) FindByTransactionID(transactionID int) (model.Receipt, error) {
+	return scanReceipt(r.db.QueryRow("SELECT "+receiptColumns+" FROM receipts WHERE transaction_id = $1", transactionID))
+}
+
+// FindUndelivered returns up to limit receipts that have not been emailed yet.
+func (r *receiptRepo) FindUndelivered(limit int) ([]model.Receipt, error) {
+	rows, err := r.db.Query("SELECT "+receiptColumns+" FROM receipts WHERE delivered_at IS NULL ORDER BY id LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []model.Receipt
+	for rows.Next() {
+		receipt, err := scanReceipt(rows)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+func (r *receiptRepo) MarkDelivered(id int) error {
+	_, err := r.db.Exec("UPDATE receipts SET delivered_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+type ReceiptRepo interface {
+	FindUnissued(limit int) ([]model.Transaction, error)
+	Create(receipt model.Receipt) (model.Receipt, error)
+	FindByTransactionID(transactionID int) (model.Receipt, error)
+	FindUndelivered(limit int) ([]model.Receipt, error)
+	MarkDelivered(id int) error
+}
+
+func NewReceiptRepo(db *sql.DB) ReceiptRepo {
+	return &receiptRepo{db: db}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReceiptRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    ReceiptRepo
+}
+
+func (suite *ReceiptRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewReceiptRepo(suite.mockDB)
+}
+
+func (suite *ReceiptRepoTestSuite) TestCreate_NumbersWithinFiscalYear() {
+	donatedAt := time.Date(2024, time.March, 5, 10, 0, 0, 0, time.UTC)
+	receipt := model.Receipt{TransactionID: 7, FiscalYear: 2024, Amount: 150000, DonatedAt: donatedAt}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO receipt_sequences (fiscal_year, last_sequence) VALUES ($1, 1)")).
+		WithArgs(2024).
+		WillReturnRows(sqlmock.NewRows([]string{"last_sequence"}).AddRow(12))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO receipts")).
+		WithArgs(7, "RCPT-2024-000012", 2024, 12, 150000, donatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "issued_at"}).AddRow(1, donatedAt))
+	suite.mockSql.ExpectCommit()
+
+	created, err := suite.repo.Create(receipt)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "RCPT-2024-000012", created.Number)
+	assert.Equal(suite.T(), 12, created.Sequence)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ReceiptRepoTestSuite) TestCreate_ExistingReceiptRollsBackNumber() {
+	receipt := model.Receipt{TransactionID: 7, FiscalYear: 2024, Amount: 150000}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO receipt_sequences")).
+		WithArgs(2024).
+		WillReturnRows(sqlmock.NewRows([]string{"last_sequence"}).AddRow(13))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("ON CONFLICT (transaction_id) DO NOTHING")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "issued_at"}))
+	suite.mockSql.ExpectRollback()
+
+	_, err := suite.repo.Create(receipt)
+	assert.ErrorIs(suite.T(), err, ErrReceiptExists)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func TestReceiptRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(ReceiptRepoTestSuite))
+}
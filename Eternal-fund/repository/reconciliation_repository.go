@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type reconciliationRepo struct {
+	db *sql.DB
+}
+
+const reconciliationItemColumns = `id, COALESCE(import_id, 0), COALESCE(transaction_id, 0), order_id, kind, source, local_amount, gateway_amount,
+	local_status, gateway_status, note, created_at, resolved_at, COALESCE(resolved_by, 0)`
+
+func scanReconciliationItem(row interface{ Scan(dest ...any) error }) (model.ReconciliationItem, error) {
+	var item model.ReconciliationItem
+	err := row.Scan(&item.ID, &item.ImportID, &item.TransactionID, &item.OrderID, &item.Kind, &item.Source, &item.LocalAmount,
+		&item.GatewayAmount, &item.LocalStatus, &item.GatewayStatus, &item.Note, &item.CreatedAt, &item.ResolvedAt, &item.ResolvedBy)
+	return item, err
+}
+
+// insertReconciliationItem records item unless the order already has an open
+// item of the same kind, in which case it reports false.
+func insertReconciliationItem(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, item model.ReconciliationItem) (model.ReconciliationItem, bool, error) {
+	saved, err := scanReconciliationItem(q.QueryRow(`INSERT INTO reconciliation_items (import_id, transaction_id, order_id, kind, source,
+		local_amount, gateway_amount, local_status, gateway_status, note, created_at)
+		VALUES (NULLIF($1, 0), NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		ON CONFLICT (order_id, kind) WHERE resolved_at IS NULL DO NOTHING RETURNING `+reconciliationItemColumns,
+		item.ImportID, item.TransactionID, item.OrderID, item.Kind, item.Source, item.LocalAmount, item.GatewayAmount,
+		item.LocalStatus, item.GatewayStatus, item.Note))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ReconciliationItem{}, false, nil
+	}
+	if err != nil {
+		return model.ReconciliationItem{}, false, err
+	}
+	return saved, true, nil
+}
+
+// FindPendingTransactions returns up to limit transactions still pending that
+// were created before before, starting with those checked longest ago. The
+// batch is stamped as checked when it is read, so transactions the gateway
+// cannot settle yet do not hold back the ones behind them on the next run.
+func (r *reconciliationRepo) FindPendingTransactions(before time.Time, limit int) ([]model.Transaction, error) {
+	return r.findTransactions(`UPDATE transactions SET last_reconciled_at = NOW() WHERE id IN (
+		SELECT id FROM transactions WHERE status = $1 AND created_at < $2 ORDER BY last_reconciled_at NULLS FIRST, id LIMIT $3
+		FOR UPDATE SKIP LOCKED) RETURNING `+transactionColumns,
+		model.TransactionStatusPending, before, limit)
+}
+
+func (r *reconciliationRepo) FindTransactionsByCodes(codes []string) ([]model.Transaction, error) {
+	return r.findTransactions("SELECT "+transactionColumns+" FROM transactions WHERE code = ANY($1)", pq.Array(codes))
+}
+
+// FindPaidTransactions returns the transactions that were paid between from
+// and to and still count as paid, including those refunded since.
+func (r *reconciliationRepo) FindPaidTransactions(from time.Time, to time.Time) ([]model.Transaction, error) {
+	return r.findTransactions("SELECT "+transactionColumns+` FROM transactions WHERE status = ANY($1) AND id IN (
+		SELECT transaction_id FROM transaction_status_logs WHERE to_status = $2 AND created_at >= $3 AND created_at < $4) ORDER BY id`,
+		pq.Array([]model.TransactionStatus{model.TransactionStatusPaid, model.TransactionStatusRefundPending,
+			model.TransactionStatusRefunded, model.TransactionStatusChargeback}), model.TransactionStatusPaid, from, to)
+}
+
+// SaveItem records a difference found outside of a settlement report. It
+// reports false when the order already has an open item of the same kind.
+func (r *reconciliationRepo) SaveItem(item model.ReconciliationItem) (model.ReconciliationItem, bool, error) {
+	return insertReconciliationItem(r.db, item)
+}
+
+// SaveImport records a settlement report together with the differences found
+// in it. Only the items not already open are returned.
+func (r *reconciliationRepo) SaveImport(settlement model.SettlementImport, items []model.ReconciliationItem) (model.SettlementImport, []model.ReconciliationItem, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return model.SettlementImport{}, nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`INSERT INTO settlement_imports (file_name, period_start, period_end, row_count, mismatch_count, imported_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NOW()) RETURNING id, created_at`,
+		settlement.FileName, settlement.PeriodStart, settlement.PeriodEnd, settlement.RowCount, settlement.MismatchCount,
+		settlement.ImportedBy).Scan(&settlement.ID, &settlement.CreatedAt)
+	if err != nil {
+		return model.SettlementImport{}, nil, err
+	}
+
+	var saved []model.ReconciliationItem
+	for _, item := range items {
+		item.ImportID = settlement.ID
+		item, created, err := insertReconciliationItem(tx, item)
+		if err != nil {
+			return model.SettlementImport{}, nil, err
+		}
+		if created {
+			saved = append(saved, item)
+		}
+	}
+
+	return settlement, saved, tx.Commit()
+}
+
+// FindItems returns the open items, or every item when all is set, newest
+// first.
+func (r *reconciliationRepo) FindItems(all bool, page int, size int) ([]model.ReconciliationItem, dto.Paging, error) {
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM reconciliation_items WHERE $1 OR resolved_at IS NULL", all).Scan(&total)
+	if err != nil {
+		return nil, dto.Paging{}, err
+	}
+
+	offset := (page - 1) * size
+	rows, err := r.db.Query("SELECT "+reconciliationItemColumns+" FROM reconciliation_items WHERE $1 OR resolved_at IS NULL ORDER BY id DESC LIMIT $2 OFFSET $3",
+		all, size, offset)
+	if err != nil {
+		return nil, dto.Paging{}, err
+	}
+	defer rows.Close()
+
+	var items []model.ReconciliationItem
+	for rows.Next() {
+		item, err := scanReconciliationItem(rows)
+		if err != nil {
+			return nil, dto.Paging{}, err
+		}
+		items = append(items, item)
+	}
+
+	paging := dto.Paging{
+		Page:       page,
+		Size:       size,
+		TotalRows:  total,
+		TotalPages: int(math.Ceil(float64(total) / float64(size))),
+	}
+	return items, paging, nil
+}
+
+// Resolve closes an open item. It returns sql.ErrNoRows if the item does not
+// exist or was already resolved.
+func (r *reconciliationRepo) Resolve(id int, userID int, note string) (model.ReconciliationItem, error) {
+	return scanReconciliationItem(r.db.QueryRow(`UPDATE reconciliation_items SET resolved_at = NOW(), resolved_by = NULLIF($1, 0),
+		note = CASE WHEN note = '' THEN $2 ELSE note || E'\n' || $2 END
+		WHERE id = $3 AND resolved_at IS NULL RETURNING `+reconciliationItemColumns, userID, note, id))
+}
+
+func (r *reconciliationRepo) findTransactions(query string, args ...any) ([]model.Transaction, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []model.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
+
+type ReconciliationRepo interface {
+	FindPendingTransactions(before time.Time, limit int) ([]model.Transaction, error)
+	FindTransactionsByCodes(codes []string) ([]model.Transaction, error)
+	FindPaidTransactions(from time.Time, to time.Time) ([]model.Transaction, error)
+	SaveItem(item model.ReconciliationItem) (model.ReconciliationItem, bool, error)
+	SaveImport(settlement model.SettlementImport, items []model.ReconciliationItem) (model.SettlementImport, []model.ReconciliationItem, error)
+	FindItems(all bool, page int, size int) ([]model.ReconciliationItem, dto.Paging, error)
+	Resolve(id int, userID int, note string) (model.ReconciliationItem, error)
+}
+
+func NewReconciliationRepo(db *sql.DB) ReconciliationRepo {
+	return &reconciliationRepo{db: db}
+}
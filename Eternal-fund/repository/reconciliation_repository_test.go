@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReconciliationRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    ReconciliationRepo
+}
+
+func (suite *ReconciliationRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewReconciliationRepo(suite.mockDB)
+}
+
+func reconciliationItemRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "import_id", "transaction_id", "order_id", "kind", "source", "local_amount", "gateway_amount",
+		"local_status", "gateway_status", "note", "created_at", "resolved_at", "resolved_by"})
+}
+
+func (suite *ReconciliationRepoTestSuite) TestFindPendingTransactions_RotatesByLastCheck() {
+	before := time.Date(2024, time.March, 8, 8, 30, 0, 0, time.UTC)
+	pending := expectedTransaction
+	pending.Status = model.TransactionStatusPending
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE transactions SET last_reconciled_at = NOW() WHERE id IN (") + ".*" +
+		regexp.QuoteMeta("ORDER BY last_reconciled_at NULLS FIRST, id LIMIT $3")).
+		WithArgs(model.TransactionStatusPending, before, 200).
+		WillReturnRows(transactionRow(pending))
+
+	transactions, err := suite.repo.FindPendingTransactions(before, 200)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.Transaction{pending}, transactions)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ReconciliationRepoTestSuite) TestSaveImport_SkipsOpenItems() {
+	now := time.Date(2024, time.March, 8, 9, 0, 0, 0, time.UTC)
+	settlement := model.SettlementImport{FileName: "settlement.csv", PeriodStart: now.AddDate(0, 0, -7), PeriodEnd: now.AddDate(0, 0, -1),
+		RowCount: 10, MismatchCount: 2, ImportedBy: 1}
+	items := []model.ReconciliationItem{
+		{TransactionID: 2, OrderID: "TRX-2", Kind: model.ReconciliationAmountMismatch, Source: model.ReconciliationSourceSettlement,
+			LocalAmount: 70000, GatewayAmount: 75000, LocalStatus: "paid", GatewayStatus: "settlement"},
+		{OrderID: "TRX-9", Kind: model.ReconciliationMissingTransaction, Source: model.ReconciliationSourceSettlement,
+			GatewayAmount: 30000, GatewayStatus: "settlement"},
+	}
+	insertItem := regexp.QuoteMeta("INSERT INTO reconciliation_items") + ".*" + regexp.QuoteMeta("ON CONFLICT (order_id, kind) WHERE resolved_at IS NULL DO NOTHING")
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO settlement_imports")).
+		WithArgs(settlement.FileName, settlement.PeriodStart, settlement.PeriodEnd, 10, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, now))
+	suite.mockSql.ExpectQuery(insertItem).
+		WithArgs(3, 2, "TRX-2", model.ReconciliationAmountMismatch, model.ReconciliationSourceSettlement, 70000, 75000, "paid", "settlement", "").
+		WillReturnRows(reconciliationItemRows())
+	suite.mockSql.ExpectQuery(insertItem).
+		WithArgs(3, 0, "TRX-9", model.ReconciliationMissingTransaction, model.ReconciliationSourceSettlement, 0, 30000, "", "settlement", "").
+		WillReturnRows(reconciliationItemRows().AddRow(8, 3, 0, "TRX-9", "missing_transaction", "settlement_report", 0, 30000, "", "settlement", "", now, nil, 0))
+	suite.mockSql.ExpectCommit()
+
+	saved, created, err := suite.repo.SaveImport(settlement, items)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, saved.ID)
+	assert.Len(suite.T(), created, 1)
+	assert.Equal(suite.T(), "TRX-9", created[0].OrderID)
+	assert.Equal(suite.T(), 3, created[0].ImportID)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ReconciliationRepoTestSuite) TestResolve_AlreadyResolved() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE reconciliation_items SET resolved_at = NOW()")).
+		WithArgs(1, "refunded at the gateway", 8).
+		WillReturnRows(reconciliationItemRows())
+
+	_, err := suite.repo.Resolve(8, 1, "refunded at the gateway")
+	assert.ErrorIs(suite.T(), err, sql.ErrNoRows)
+}
+
+func TestReconciliationRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(ReconciliationRepoTestSuite))
+}
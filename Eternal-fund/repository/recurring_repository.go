@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"eternal-fund/model"
+	"time"
+)
+
+// ErrRecurringPlanChanged is returned when a plan was changed between reading
+// and updating it, for example when the donor paused it while the biller was
+// about to charge it.
+var ErrRecurringPlanChanged = errors.New("recurring plan was changed concurrently")
+
+type recurringRepo struct {
+	db *sql.DB
+}
+
+const recurringPlanColumns = `p.id, p.campaign_id, p.user_id, p.amount, c.currency, p.cover_fees, p.interval, p.status, p.card_token,
+	p.anonymous, p.display_name, p.contact_opt_out, p.started_at, p.cycle, p.next_charge_at, p.retry_at, p.failed_attempts, COALESCE(p.last_transaction_id, 0),
+	p.charge_pending, COALESCE(t.status, ''), p.created_at, p.updated_at, p.cancelled_at`
+
+const recurringPlanSource = " FROM recurring_plans p JOIN campaigns c ON c.id = p.campaign_id LEFT JOIN transactions t ON t.id = p.last_transaction_id"
+
+func scanRecurringPlan(row interface{ Scan(dest ...any) error }) (model.RecurringPlan, error) {
+	var plan model.RecurringPlan
+	err := row.Scan(&plan.ID, &plan.CampaignID, &plan.UserID, &plan.Amount, &plan.Currency, &plan.CoverFees, &plan.Interval, &plan.Status,
+		&plan.CardToken, &plan.Anonymous, &plan.DisplayName, &plan.ContactOptOut, &plan.StartedAt, &plan.Cycle, &plan.NextChargeAt, &plan.RetryAt, &plan.FailedAttempts,
+		&plan.LastTransactionID, &plan.ChargePending, &plan.LastTransactionStatus, &plan.CreatedAt, &plan.UpdatedAt, &plan.CancelledAt)
+	return plan, err
+}
+
+func (r *recurringRepo) Save(plan model.RecurringPlan) (model.RecurringPlan, error) {
+	err := r.db.QueryRow(`INSERT INTO recurring_plans (campaign_id, user_id, amount, cover_fees, interval, status, card_token,
+		anonymous, display_name, contact_opt_out, started_at, cycle, next_charge_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW(), NOW()) RETURNING id, created_at, updated_at`,
+		plan.CampaignID, plan.UserID, plan.Amount, plan.CoverFees, plan.Interval, plan.Status, plan.CardToken,
+		plan.Anonymous, plan.DisplayName, plan.ContactOptOut, plan.StartedAt, plan.Cycle, plan.NextChargeAt).Scan(&plan.ID, &plan.CreatedAt, &plan.UpdatedAt)
+	if err != nil {
+		return model.RecurringPlan{}, err
+	}
+	return plan, nil
+}
+
+func (r *recurringRepo) FindByID(id int) (model.RecurringPlan, error) {
+	return scanRecurringPlan(r.db.QueryRow("SELECT "+recurringPlanColumns+recurringPlanSource+" WHERE p.id = $1", id))
+}
+
+func (r *recurringRepo) FindByUserID(userID int) ([]model.RecurringPlan, error) {
+	return r.findMany("SELECT "+recurringPlanColumns+recurringPlanSource+" WHERE p.user_id = $1 ORDER BY p.id DESC", userID)
+}
+
+// FindDue returns the plans to charge now: active plans whose next cycle has
+// come and past due plans whose retry has come.
+func (r *recurringRepo) FindDue(now time.Time) ([]model.RecurringPlan, error) {
+	return r.findMany("SELECT "+recurringPlanColumns+recurringPlanSource+` WHERE NOT p.charge_pending
+		AND ((p.status = $1 AND p.next_charge_at <= $3) OR (p.status = $2 AND p.retry_at <= $3))
+		ORDER BY p.id`, model.RecurringStatusActive, model.RecurringStatusPastDue, now)
+}
+
+// FindSettled returns the plans whose pending charge has an outcome.
+func (r *recurringRepo) FindSettled() ([]model.RecurringPlan, error) {
+	return r.findMany("SELECT "+recurringPlanColumns+recurringPlanSource+` WHERE p.charge_pending
+		AND COALESCE(t.status, '') <> $1 ORDER BY p.id`, model.TransactionStatusPending)
+}
+
+// Update stores the plan's status and billing state. It fails with
+// ErrRecurringPlanChanged if the plan was written since it was read, so a
+// donor pausing a plan and the biller charging it cannot overwrite each other.
+func (r *recurringRepo) Update(plan model.RecurringPlan) (model.RecurringPlan, error) {
+	err := r.db.QueryRow(`UPDATE recurring_plans SET status = $1, cycle = $2, next_charge_at = $3, retry_at = $4,
+		failed_attempts = $5, last_transaction_id = NULLIF($6, 0), charge_pending = $7, cancelled_at = $8, updated_at = NOW()
+		WHERE id = $9 AND updated_at = $10 RETURNING updated_at`,
+		plan.Status, plan.Cycle, plan.NextChargeAt, plan.RetryAt, plan.FailedAttempts, plan.LastTransactionID,
+		plan.ChargePending, plan.CancelledAt, plan.ID, plan.UpdatedAt).Scan(&plan.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.RecurringPlan{}, ErrRecurringPlanChanged
+	}
+	if err != nil {
+		return model.RecurringPlan{}, err
+	}
+	return plan, nil
+}
+
+// RecordCharge stores the transaction just billed for a claimed plan. It
+// only touches the billing state, which nothing else changes while a charge
+// is pending, so it is not guarded against concurrent status changes.
+func (r *recurringRepo) RecordCharge(plan model.RecurringPlan) (model.RecurringPlan, error) {
+	err := r.db.QueryRow(`UPDATE recurring_plans SET cycle = $1, next_charge_at = $2, retry_at = $3,
+		last_transaction_id = $4, charge_pending = TRUE, updated_at = NOW() WHERE id = $5 RETURNING status, updated_at`,
+		plan.Cycle, plan.NextChargeAt, plan.RetryAt, plan.LastTransactionID, plan.ID).Scan(&plan.Status, &plan.UpdatedAt)
+	if err != nil {
+		return model.RecurringPlan{}, err
+	}
+	plan.ChargePending = true
+	return plan, nil
+}
+
+func (r *recurringRepo) findMany(query string, args ...any) ([]model.RecurringPlan, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plans []model.RecurringPlan
+	for rows.Next() {
+		plan, err := scanRecurringPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+type RecurringRepo interface {
+	Save(plan model.RecurringPlan) (model.RecurringPlan, error)
+	FindByID(id int) (model.RecurringPlan, error)
+	FindByUserID(userID int) ([]model.RecurringPlan, error)
+	FindDue(now time.Time) ([]model.RecurringPlan, error)
+	FindSettled() ([]model.RecurringPlan, error)
+	Update(plan model.RecurringPlan) (model.RecurringPlan, error)
+	RecordCharge(plan model.RecurringPlan) (model.RecurringPlan, error)
+}
+
+func NewRecurringRepo(db *sql.DB) RecurringRepo {
+	return &recurringRepo{db: db}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RecurringRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    RecurringRepo
+}
+
+func (suite *RecurringRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewRecurringRepo(suite.mockDB)
+}
+
+func recurringPlanRow(plan model.RecurringPlan) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "campaign_id", "user_id", "amount", "currency", "cover_fees", "interval", "status", "card_token",
+		"anonymous", "display_name", "contact_opt_out", "started_at", "cycle", "next_charge_at", "retry_at", "failed_attempts", "last_transaction_id", "charge_pending",
+		"last_transaction_status", "created_at", "updated_at", "cancelled_at"}).
+		AddRow(plan.ID, plan.CampaignID, plan.UserID, plan.Amount, string(plan.Currency), plan.CoverFees, plan.Interval, plan.Status, plan.CardToken,
+			plan.Anonymous, plan.DisplayName, plan.ContactOptOut, plan.StartedAt, plan.Cycle, plan.NextChargeAt, plan.RetryAt, plan.FailedAttempts, plan.LastTransactionID,
+			plan.ChargePending, plan.LastTransactionStatus, plan.CreatedAt, plan.UpdatedAt, plan.CancelledAt)
+}
+
+func (suite *RecurringRepoTestSuite) TestFindDue() {
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	plan := model.RecurringPlan{ID: 3, CampaignID: 2, UserID: 4, Amount: 50000, Interval: model.RecurringIntervalMonthly,
+		Status: model.RecurringStatusActive, StartedAt: now.AddDate(0, -1, 0), Cycle: 1, NextChargeAt: now,
+		LastTransactionID: 11, LastTransactionStatus: model.TransactionStatusPaid, CreatedAt: now, UpdatedAt: now}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM recurring_plans p JOIN campaigns c ON c.id = p.campaign_id LEFT JOIN transactions t ON t.id = p.last_transaction_id WHERE NOT p.charge_pending")).
+		WithArgs(model.RecurringStatusActive, model.RecurringStatusPastDue, now).
+		WillReturnRows(recurringPlanRow(plan))
+
+	plans, err := suite.repo.FindDue(now)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.RecurringPlan{plan}, plans)
+}
+
+func (suite *RecurringRepoTestSuite) TestUpdate_ChangedConcurrently() {
+	updatedAt := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	plan := model.RecurringPlan{ID: 3, Status: model.RecurringStatusPaused, UpdatedAt: updatedAt}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE recurring_plans SET status = $1")).
+		WithArgs(plan.Status, plan.Cycle, plan.NextChargeAt, plan.RetryAt, plan.FailedAttempts, plan.LastTransactionID,
+			plan.ChargePending, plan.CancelledAt, plan.ID, updatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}))
+
+	_, err := suite.repo.Update(plan)
+	assert.ErrorIs(suite.T(), err, ErrRecurringPlanChanged)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *RecurringRepoTestSuite) TestRecordCharge() {
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	plan := model.RecurringPlan{ID: 3, Cycle: 2, NextChargeAt: now.AddDate(0, 1, 0), LastTransactionID: 12}
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE recurring_plans SET cycle = $1")).
+		WithArgs(plan.Cycle, plan.NextChargeAt, plan.RetryAt, plan.LastTransactionID, plan.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "updated_at"}).AddRow(model.RecurringStatusPaused, now))
+
+	recorded, err := suite.repo.RecordCharge(plan)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), recorded.ChargePending)
+	assert.Equal(suite.T(), model.RecurringStatusPaused, recorded.Status)
+	assert.Equal(suite.T(), now, recorded.UpdatedAt)
+}
+
+func TestRecurringRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(RecurringRepoTestSuite))
+}
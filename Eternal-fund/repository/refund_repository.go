@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"eternal-fund/model"
+	"fmt"
+)
+
+var ErrRefundExceedsBalance = errors.New("refund amount exceeds the campaign balance that has not been withdrawn")
+
+type refundRepo struct {
+	db *sql.DB
+}
+
+const refundColumns = "id, transaction_id, amount, reason, status, refund_key, COALESCE(requested_by, 0), error, created_at, updated_at"
+
+func scanRefund(row interface{ Scan(dest ...any) error }) (model.Refund, error) {
+	var refund model.Refund
+	err := row.Scan(&refund.ID, &refund.TransactionID, &refund.Amount, &refund.Reason, &refund.Status, &refund.RefundKey,
+		&refund.RequestedBy, &refund.Error, &refund.CreatedAt, &refund.UpdatedAt)
+	return refund, err
+}
+
+// Save stores a pending refund. The campaign row is locked and the refund
+// is refused when it is larger than what the campaign has not yet withdrawn
+// or promised to withdrawals, so money that was paid out is not refunded.
+func (r *refundRepo) Save(refund model.Refund) (model.Refund, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return model.Refund{}, err
+	}
+	defer tx.Rollback()
+
+	var campaignID int
+	err = tx.QueryRow("SELECT c.id FROM campaigns c JOIN transactions t ON t.campaign_id = c.id WHERE t.id = $1 FOR UPDATE OF c",
+		refund.TransactionID).Scan(&campaignID)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	available, err := availableBalance(tx, campaignID)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	if refund.Amount > available {
+		return model.Refund{}, ErrRefundExceedsBalance
+	}
+
+	query := `INSERT INTO refunds (transaction_id, amount, reason, status, refund_key, requested_by, error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, '', NULLIF($5, 0), '', NOW(), NOW()) RETURNING id, created_at, updated_at`
+	err = tx.QueryRow(query, refund.TransactionID, refund.Amount, refund.Reason, refund.Status, refund.RequestedBy).
+		Scan(&refund.ID, &refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	return refund, tx.Commit()
+}
+
+func (r *refundRepo) FindByTransactionID(transactionID int) ([]model.Refund, error) {
+	rows, err := r.db.Query("SELECT "+refundColumns+" FROM refunds WHERE transaction_id = $1 ORDER BY id", transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []model.Refund
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, nil
+}
+
+// SumRefunded returns how much of the transaction has been refunded or is
+// being refunded right now.
+func (r *refundRepo) SumRefunded(transactionID int) (int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE transaction_id = $1 AND status IN ($2, $3)",
+		transactionID, model.RefundStatusPending, model.RefundStatusSucceeded).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *refundRepo) MarkFailed(refund model.Refund) (model.Refund, error) {
+	query := "UPDATE refunds SET status = $1, error = $2, updated_at = NOW() WHERE id = $3 RETURNING updated_at"
+	refund.Status = model.RefundStatusFailed
+	if err := r.db.QueryRow(query, refund.Status, refund.Error, refund.ID).Scan(&refund.UpdatedAt); err != nil {
+		return model.Refund{}, err
+	}
+	return refund, nil
+}
+
+// MarkSucceeded completes the refund, takes its amount off the campaign's
+// current and net amounts, records it in the ledger and reduces the donation's
+// matched contributions in one database transaction.
+func (r *refundRepo) MarkSucceeded(refund model.Refund, transaction model.Transaction) (model.Refund, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return model.Refund{}, err
+	}
+	defer tx.Rollback()
+
+	refund.Status = model.RefundStatusSucceeded
+	err = tx.QueryRow("UPDATE refunds SET status = $1, refund_key = $2, updated_at = NOW() WHERE id = $3 RETURNING updated_at",
+		refund.Status, refund.RefundKey, refund.ID).Scan(&refund.UpdatedAt)
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	_, err = tx.Exec("UPDATE campaigns SET current_amount = COALESCE(current_amount, 0) - $1, net_amount = COALESCE(net_amount, 0) - $1, updated_at = NOW() WHERE id = $2",
+		refund.Amount, transaction.CampaignID)
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	if _, err := postJournal(tx, donationJournal(model.JournalKindRefund, fmt.Sprintf("refund:%d", refund.ID), transaction, -refund.Amount)); err != nil {
+		return model.Refund{}, err
+	}
+
+	// Sponsors take back their match in proportion to what the donor got
+	// back of the donation.
+	var refundedBefore int
+	err = tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE transaction_id = $1 AND status = $2 AND id <> $3",
+		transaction.ID, model.RefundStatusSucceeded, refund.ID).Scan(&refundedBefore)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	if err := reduceMatches(tx, transaction.ID, refund.Amount, transaction.Amount-refundedBefore); err != nil {
+		return model.Refund{}, err
+	}
+
+	return refund, tx.Commit()
+}
+
+type RefundRepo interface {
+	Save(refund model.Refund) (model.Refund, error)
+	FindByTransactionID(transactionID int) ([]model.Refund, error)
+	SumRefunded(transactionID int) (int, error)
+	MarkFailed(refund model.Refund) (model.Refund, error)
+	MarkSucceeded(refund model.Refund, transaction model.Transaction) (model.Refund, error)
+}
+
+func NewRefundRepo(db *sql.DB) RefundRepo {
+	return &refundRepo{db: db}
+}
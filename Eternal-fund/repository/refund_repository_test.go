@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RefundRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    RefundRepo
+}
+
+func (suite *RefundRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewRefundRepo(suite.mockDB)
+}
+
+func (suite *RefundRepoTestSuite) TestSave() {
+	now := time.Now()
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE t.id = $1 FOR UPDATE OF c")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM ledger_entries WHERE account = $1")).
+		WithArgs("campaign:2", 2, model.WithdrawalStatusRequested, model.RefundStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"available"}).AddRow(40000))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO refunds")).
+		WithArgs(1, 40000, "duplicate", model.RefundStatusPending, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(3, now, now))
+	suite.mockSql.ExpectCommit()
+
+	refund, err := suite.repo.Save(model.Refund{TransactionID: 1, Amount: 40000, Reason: "duplicate", Status: model.RefundStatusPending, RequestedBy: 9})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, refund.ID)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *RefundRepoTestSuite) TestSave_ExceedsBalanceNotWithdrawn() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE t.id = $1 FOR UPDATE OF c")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM ledger_entries WHERE account = $1")).
+		WithArgs("campaign:2", 2, model.WithdrawalStatusRequested, model.RefundStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"available"}).AddRow(10000))
+	suite.mockSql.ExpectRollback()
+
+	_, err := suite.repo.Save(model.Refund{TransactionID: 1, Amount: 40000, Status: model.RefundStatusPending})
+	assert.ErrorIs(suite.T(), err, ErrRefundExceedsBalance)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *RefundRepoTestSuite) TestSumRefunded() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(amount), 0) FROM refunds")).
+		WithArgs(1, model.RefundStatusPending, model.RefundStatusSucceeded).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(40000))
+
+	total, err := suite.repo.SumRefunded(1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 40000, total)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *RefundRepoTestSuite) TestMarkSucceeded() {
+	now := time.Now()
+	refund := model.Refund{ID: 7, TransactionID: 1, Amount: 40000, RefundKey: "TRX-1-refund-1", Status: model.RefundStatusPending}
+	transaction := model.Transaction{ID: 1, CampaignID: 2, UserID: 3, Amount: 100000, Code: "TRX-1"}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE refunds SET status = $1, refund_key = $2")).
+		WithArgs(model.RefundStatusSucceeded, "TRX-1-refund-1", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE campaigns SET current_amount = COALESCE(current_amount, 0) - $1")).
+		WithArgs(40000, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDonationJournal(suite.mockSql, model.JournalKindRefund, "refund:7", transaction, -40000)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE transaction_id = $1")).
+		WithArgs(1, model.RefundStatusSucceeded, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT id, pledge_id, amount - refunded_amount FROM matched_contributions")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "pledge_id", "amount"}))
+	suite.mockSql.ExpectCommit()
+
+	updated, err := suite.repo.MarkSucceeded(refund, transaction)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.RefundStatusSucceeded, updated.Status)
+	assert.Equal(suite.T(), now, updated.UpdatedAt)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *RefundRepoTestSuite) TestMarkSucceeded_ReducesMatchesProportionally() {
+	refund := model.Refund{ID: 8, TransactionID: 1, Amount: 30000, RefundKey: "TRX-1-refund-2", Status: model.RefundStatusPending}
+	transaction := model.Transaction{ID: 1, CampaignID: 2, UserID: 3, Amount: 100000, Code: "TRX-1"}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE refunds SET status = $1, refund_key = $2")).
+		WithArgs(model.RefundStatusSucceeded, "TRX-1-refund-2", 8).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE campaigns SET current_amount = COALESCE(current_amount, 0) - $1")).
+		WithArgs(30000, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDonationJournal(suite.mockSql, model.JournalKindRefund, "refund:8", transaction, -30000)
+	// 40000 was refunded earlier, so 30000 of the remaining 60000 takes back
+	// half of what is left of each match.
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE transaction_id = $1")).
+		WithArgs(1, model.RefundStatusSucceeded, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(40000))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT id, pledge_id, amount - refunded_amount FROM matched_contributions")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "pledge_id", "amount"}).AddRow(5, 4, 30000).AddRow(6, 9, 15000))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE matched_contributions SET refunded_amount = refunded_amount + $1 WHERE id = $2")).
+		WithArgs(15000, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE matching_pledges SET matched_amount = matched_amount - $1 WHERE id = $2")).
+		WithArgs(15000, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE matched_contributions SET refunded_amount = refunded_amount + $1 WHERE id = $2")).
+		WithArgs(7500, 6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE matching_pledges SET matched_amount = matched_amount - $1 WHERE id = $2")).
+		WithArgs(7500, 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+
+	_, err := suite.repo.MarkSucceeded(refund, transaction)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func TestRefundRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(RefundRepoTestSuite))
+}
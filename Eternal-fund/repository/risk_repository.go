@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type riskRepo struct {
+	db *sql.DB
+}
+
+// CountRecentByDonor counts the donations made since the given time by the
+// account, or by the guest email when userID is 0. Recurring billing is not
+// counted since the donor did not start it.
+func (r *riskRepo) CountRecentByDonor(userID int, email string, since time.Time) (int, error) {
+	return countRecentByDonor(r.db, userID, email, since)
+}
+
+// CountRecentByIP counts the donations made from the address since the given
+// time.
+func (r *riskRepo) CountRecentByIP(ip string, since time.Time) (int, error) {
+	return countRecentByIP(r.db, ip, since)
+}
+
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func countRecentByDonor(q rowQuerier, userID int, email string, since time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM transactions WHERE user_id = $1 AND created_at >= $2 AND recurring_plan_id IS NULL"
+	var donor any = userID
+	if userID == 0 {
+		query = "SELECT COUNT(*) FROM transactions WHERE user_id IS NULL AND LOWER(guest_email) = LOWER($1) AND created_at >= $2"
+		donor = email
+	}
+
+	var count int
+	if err := q.QueryRow(query, donor, since).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func countRecentByIP(q rowQuerier, ip string, since time.Time) (int, error) {
+	var count int
+	err := q.QueryRow("SELECT COUNT(*) FROM transactions WHERE client_ip = $1 AND created_at >= $2", ip, since).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Advisory lock namespaces for the velocity limits.
+const (
+	donorVelocityLock = 1
+	ipVelocityLock    = 2
+)
+
+// checkVelocity refuses a donation once its donor or address has reached the
+// limit. The donor and the address are locked until tx ends, so concurrent
+// donations from either are counted one after the other.
+func checkVelocity(tx *sql.Tx, transaction model.Transaction, limit model.VelocityLimit) error {
+	donorKey := "user:" + strconv.Itoa(transaction.UserID)
+	if transaction.UserID == 0 {
+		donorKey = "guest:" + strings.ToLower(transaction.GuestEmail)
+	}
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1, hashtext($2))", donorVelocityLock, donorKey); err != nil {
+		return err
+	}
+	count, err := countRecentByDonor(tx, transaction.UserID, transaction.GuestEmail, limit.Since)
+	if err != nil {
+		return err
+	}
+	if count >= limit.MaxPerDonor {
+		return model.ErrVelocityExceeded
+	}
+
+	if transaction.ClientIP == "" {
+		return nil
+	}
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1, hashtext($2))", ipVelocityLock, transaction.ClientIP); err != nil {
+		return err
+	}
+	count, err = countRecentByIP(tx, transaction.ClientIP, limit.Since)
+	if err != nil {
+		return err
+	}
+	if count >= limit.MaxPerIP {
+		return model.ErrVelocityExceeded
+	}
+	return nil
+}
+
+// FindReviewQueue lists the donations held for review, riskiest first.
+func (r *riskRepo) FindReviewQueue(page int, size int) ([]model.Transaction, dto.Paging, error) {
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM transactions WHERE status = $1", model.TransactionStatusReview).Scan(&total); err != nil {
+		return nil, dto.Paging{}, err
+	}
+
+	offset := (page - 1) * size
+	rows, err := r.db.Query("SELECT "+transactionColumns+" FROM transactions WHERE status = $1 ORDER BY risk_score DESC, id LIMIT $2 OFFSET $3",
+		model.TransactionStatusReview, size, offset)
+	if err != nil {
+		return nil, dto.Paging{}, err
+	}
+	defer rows.Close()
+
+	var transactions []model.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, dto.Paging{}, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	paging := dto.Paging{
+		Page:       page,
+		Size:       size,
+		TotalRows:  total,
+		TotalPages: int(math.Ceil(float64(total) / float64(size))),
+	}
+	return transactions, paging, nil
+}
+
+type RiskRepo interface {
+	CountRecentByDonor(userID int, email string, since time.Time) (int, error)
+	CountRecentByIP(ip string, since time.Time) (int, error)
+	FindReviewQueue(page int, size int) ([]model.Transaction, dto.Paging, error)
+}
+
+func NewRiskRepo(db *sql.DB) RiskRepo {
+	return &riskRepo{db: db}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RiskRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    RiskRepo
+}
+
+func (suite *RiskRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewRiskRepo(suite.mockDB)
+}
+
+func (suite *RiskRepoTestSuite) TestCountRecentByDonor_Account() {
+	since := time.Now().Add(-time.Hour)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE user_id = $1 AND created_at >= $2 AND recurring_plan_id IS NULL")).
+		WithArgs(1, since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := suite.repo.CountRecentByDonor(1, "", since)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, count)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *RiskRepoTestSuite) TestCountRecentByDonor_Guest() {
+	since := time.Now().Add(-time.Hour)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE user_id IS NULL AND LOWER(guest_email) = LOWER($1)")).
+		WithArgs("jane@example.com", since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	count, err := suite.repo.CountRecentByDonor(0, "jane@example.com", since)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+}
+
+func (suite *RiskRepoTestSuite) TestCountRecentByIP() {
+	since := time.Now().Add(-time.Hour)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE client_ip = $1 AND created_at >= $2")).
+		WithArgs("203.0.113.7", since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+	count, err := suite.repo.CountRecentByIP("203.0.113.7", since)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 12, count)
+}
+
+func (suite *RiskRepoTestSuite) TestFindReviewQueue() {
+	held := expectedTransaction
+	held.Status = model.TransactionStatusReview
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM transactions WHERE status = $1")).
+		WithArgs(model.TransactionStatusReview).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE status = $1 ORDER BY risk_score DESC, id LIMIT $2 OFFSET $3")).
+		WithArgs(model.TransactionStatusReview, 10, 0).
+		WillReturnRows(transactionRow(held))
+
+	transactions, paging, err := suite.repo.FindReviewQueue(1, 10)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.Transaction{held}, transactions)
+	assert.Equal(suite.T(), dto.Paging{Page: 1, Size: 10, TotalRows: 1, TotalPages: 1}, paging)
+}
+
+func TestRiskRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(RiskRepoTestSuite))
+}
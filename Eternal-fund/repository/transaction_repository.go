@@ -2,18 +2,47 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
+	"fmt"
 	"log"
+	"math"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrDuplicateTransactionCode is returned by Save and SaveWithinLimit when
+// the code is taken.
+var ErrDuplicateTransactionCode = errors.New("transaction code already exists")
+
 type transactionRepo struct {
 	db *sql.DB
 }
 
+const transactionColumns = `id, campaign_id, COALESCE(user_id, 0), guest_name, guest_email, amount, status, code, payment_url, cover_fees,
+	COALESCE(recurring_plan_id, 0), message, anonymous, display_name, contact_opt_out,
+	payment_method, gross_amount, platform_fee, gateway_fee, fee_amount, net_amount, created_at, updated_at,
+	currency, donor_amount, donor_currency, exchange_rate, COALESCE(client_ip, ''), risk_score, risk_reasons`
+
+func transactionFields(transaction *model.Transaction) []any {
+	return []any{&transaction.ID, &transaction.CampaignID, &transaction.UserID, &transaction.GuestName, &transaction.GuestEmail, &transaction.Amount, &transaction.Status, &transaction.Code,
+		&transaction.PaymentURL, &transaction.CoverFees, &transaction.RecurringPlanID, &transaction.Message, &transaction.Anonymous,
+		&transaction.DisplayName, &transaction.ContactOptOut, &transaction.PaymentMethod, &transaction.GrossAmount, &transaction.PlatformFee,
+		&transaction.GatewayFee, &transaction.FeeAmount, &transaction.NetAmount, &transaction.CreatedAt, &transaction.UpdatedAt,
+		&transaction.Currency, &transaction.DonorAmount, &transaction.DonorCurrency, &transaction.ExchangeRate,
+		&transaction.ClientIP, &transaction.RiskScore, pq.Array(&transaction.RiskReasons)}
+}
+
+func scanTransaction(row interface{ Scan(dest ...any) error }) (model.Transaction, error) {
+	var transaction model.Transaction
+	err := row.Scan(transactionFields(&transaction)...)
+	return transaction, err
+}
+
 func (r *transactionRepo) GetTransactionsByCampaignID(campaignID int) ([]model.Transaction, error) {
-	query := "SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE campaign_id = $1"
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE campaign_id = $1"
 	rows, err := r.db.Query(query, campaignID)
 	if err != nil {
 		return nil, err
@@ -22,8 +51,7 @@ func (r *transactionRepo) GetTransactionsByCampaignID(campaignID int) ([]model.T
 
 	var transactions []model.Transaction
 	for rows.Next() {
-		var transaction model.Transaction
-		err := rows.Scan(&transaction.ID, &transaction.CampaignID, &transaction.UserID, &transaction.Amount, &transaction.Status, &transaction.Code, &transaction.PaymentURL, &transaction.CreatedAt, &transaction.UpdatedAt)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -34,7 +62,7 @@ func (r *transactionRepo) GetTransactionsByCampaignID(campaignID int) ([]model.T
 }
 
 func (r *transactionRepo) GetTransactionsByUserID(userID int) ([]model.Transaction, error) {
-	query := "SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE user_id = $1"
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE user_id = $1"
 	rows, err := r.db.Query(query, userID)
 	if err != nil {
 		return nil, err
@@ -43,8 +71,7 @@ func (r *transactionRepo) GetTransactionsByUserID(userID int) ([]model.Transacti
 
 	var transactions []model.Transaction
 	for rows.Next() {
-		var transaction model.Transaction
-		err := rows.Scan(&transaction.ID, &transaction.CampaignID, &transaction.UserID, &transaction.Amount, &transaction.Status, &transaction.Code, &transaction.PaymentURL, &transaction.CreatedAt, &transaction.UpdatedAt)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -55,10 +82,8 @@ func (r *transactionRepo) GetTransactionsByUserID(userID int) ([]model.Transacti
 }
 
 func (r *transactionRepo) GetByID(id int) (model.Transaction, error) {
-	var transaction model.Transaction
-	query := "SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE id = $1"
-	row := r.db.QueryRow(query, id)
-	err := row.Scan(&transaction.ID, &transaction.CampaignID, &transaction.UserID, &transaction.Amount, &transaction.Status, &transaction.Code, &transaction.PaymentURL, &transaction.CreatedAt, &transaction.UpdatedAt)
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE id = $1"
+	transaction, err := scanTransaction(r.db.QueryRow(query, id))
 	if err != nil {
 		return transaction, err
 	}
@@ -66,16 +91,50 @@ func (r *transactionRepo) GetByID(id int) (model.Transaction, error) {
 }
 
 func (r *transactionRepo) Save(transaction model.Transaction) (model.Transaction, error) {
+	return insertTransaction(r.db, transaction)
+}
+
+// SaveWithinLimit saves a donation a donor started unless the donor or the
+// address has reached limit, in which case model.ErrVelocityExceeded is
+// returned. The count and the insert share one database transaction.
+func (r *transactionRepo) SaveWithinLimit(transaction model.Transaction, limit model.VelocityLimit) (model.Transaction, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	if err := checkVelocity(tx, transaction, limit); err != nil {
+		return model.Transaction{}, err
+	}
+	saved, err := insertTransaction(tx, transaction)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	return saved, tx.Commit()
+}
+
+func insertTransaction(q rowQuerier, transaction model.Transaction) (model.Transaction, error) {
 	query := `
-        INSERT INTO transactions (campaign_id, user_id, amount, status, code, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+        INSERT INTO transactions (campaign_id, user_id, amount, status, code, cover_fees, recurring_plan_id,
+            message, anonymous, display_name, contact_opt_out, guest_name, guest_email, currency, donor_amount, donor_currency,
+            exchange_rate, client_ip, risk_score, risk_reasons, created_at, updated_at)
+        VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, NULLIF($7, 0), $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+            NULLIF($18, ''), $19, COALESCE($20::TEXT[], '{}'), NOW(), NOW())
         RETURNING id, created_at, updated_at
     `
 	var id int
 	var createdAt, updatedAt time.Time
-	err := r.db.QueryRow(query, transaction.CampaignID, transaction.UserID, transaction.Amount, transaction.Status, transaction.Code).Scan(&id, &createdAt, &updatedAt)
+	err := q.QueryRow(query, transaction.CampaignID, transaction.UserID, transaction.Amount, transaction.Status, transaction.Code, transaction.CoverFees, transaction.RecurringPlanID,
+		transaction.Message, transaction.Anonymous, transaction.DisplayName, transaction.ContactOptOut, transaction.GuestName, transaction.GuestEmail,
+		transaction.Currency, transaction.DonorAmount, transaction.DonorCurrency, transaction.ExchangeRate,
+		transaction.ClientIP, transaction.RiskScore, pq.Array(transaction.RiskReasons)).Scan(&id, &createdAt, &updatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return model.Transaction{}, ErrDuplicateTransactionCode
+	}
 	if err != nil {
-		return transaction, err
+		return model.Transaction{}, err
 	}
 	transaction.ID = id
 	transaction.CreatedAt = createdAt
@@ -83,15 +142,157 @@ func (r *transactionRepo) Save(transaction model.Transaction) (model.Transaction
 	return transaction, nil
 }
 
-func (r *transactionRepo) Update(transaction model.Transaction) (model.Transaction, error) {
-	query := "UPDATE transactions SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at"
-	var updatedAt time.Time
-	err := r.db.QueryRow(query, transaction.Status, transaction.ID).Scan(&updatedAt)
+// ApplyStatus moves a transaction to change.ToStatus, records the change in
+// the status log and keeps the campaign totals and the ledger in step, all in
+// one database transaction. change.Fees are stored when the transaction starts
+// to count towards the campaign total. The row is locked so concurrent updates cannot
+// both pass the transition check or count the same donation twice.
+func (r *transactionRepo) ApplyStatus(change model.TransactionStatusChange) (model.Transaction, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return transaction, err
+		return model.Transaction{}, err
 	}
-	transaction.UpdatedAt = updatedAt
-	return transaction, nil
+	defer tx.Rollback()
+
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE id = $1 FOR UPDATE"
+	transaction, err := scanTransaction(tx.QueryRow(query, change.TransactionID))
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	// A change may name the status it expects to leave, so a decision
+	// taken on one status is not applied after the transaction moved on.
+	if change.FromStatus != "" && change.FromStatus != transaction.Status {
+		return transaction, model.ErrIllegalTransition
+	}
+	if !transaction.Status.CanTransitionTo(change.ToStatus) {
+		return transaction, model.ErrIllegalTransition
+	}
+	change.FromStatus = transaction.Status
+
+	err = tx.QueryRow("UPDATE transactions SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at", change.ToStatus, transaction.ID).Scan(&transaction.UpdatedAt)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	transaction.Status = change.ToStatus
+
+	_, err = tx.Exec(`INSERT INTO transaction_status_logs (transaction_id, from_status, to_status, source, actor_id, note, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, NOW())`,
+		transaction.ID, change.FromStatus, change.ToStatus, change.Source, change.ActorID, change.Note)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	counted, counts := change.FromStatus.CountsTowardsTotal(), change.ToStatus.CountsTowardsTotal()
+	if counted != counts {
+		if counts {
+			fees := change.Fees
+			if fees.GrossAmount == 0 {
+				fees = model.NoFees(transaction.Amount)
+			}
+			_, err = tx.Exec(`UPDATE transactions SET payment_method = $1, gross_amount = $2, platform_fee = $3, gateway_fee = $4, fee_amount = $5, net_amount = $6
+				WHERE id = $7`, fees.PaymentMethod, fees.GrossAmount, fees.PlatformFee, fees.GatewayFee, fees.FeeAmount, fees.NetAmount, transaction.ID)
+			if err != nil {
+				return model.Transaction{}, err
+			}
+			transaction.TransactionFees = fees
+		}
+
+		backers, amount, net := 1, transaction.Amount, transaction.NetAmount
+		if counted {
+			// Completed partial refunds were already taken off the total.
+			var refunded int
+			err = tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE transaction_id = $1 AND status = $2",
+				transaction.ID, model.RefundStatusSucceeded).Scan(&refunded)
+			if err != nil {
+				return model.Transaction{}, err
+			}
+			backers, amount, net = -1, refunded-amount, refunded-net
+		}
+		_, err = tx.Exec(`UPDATE campaigns SET backer_count = COALESCE(backer_count, 0) + $1, current_amount = COALESCE(current_amount, 0) + $2,
+			net_amount = COALESCE(net_amount, 0) + $3, updated_at = NOW() WHERE id = $4`, backers, amount, net, transaction.CampaignID)
+		if err != nil {
+			return model.Transaction{}, err
+		}
+
+		reference := fmt.Sprintf("transaction:%d", transaction.ID)
+		if amount != 0 {
+			kind := model.JournalKindPayment
+			if counted {
+				kind = model.JournalKindReversal
+			}
+			if _, err := postJournal(tx, donationJournal(kind, reference, transaction, amount)); err != nil {
+				return model.Transaction{}, err
+			}
+		}
+		// The fees are given back with the donation, so a fully refunded
+		// donation leaves the campaign where it was before.
+		if transaction.FeeAmount != 0 {
+			sign := 1
+			if counted {
+				sign = -1
+			}
+			if _, err := postJournal(tx, feeJournal(reference, transaction, sign*transaction.PlatformFee, sign*transaction.GatewayFee)); err != nil {
+				return model.Transaction{}, err
+			}
+		}
+
+		// Sponsors match a donation once it counts and take the match
+		// back if it stops counting.
+		if counts {
+			err = matchDonation(tx, transaction)
+		} else {
+			err = reverseMatches(tx, transaction.ID)
+		}
+		if err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	return transaction, tx.Commit()
+}
+
+func (r *transactionRepo) FindStatusLog(transactionID int) ([]model.TransactionStatusChange, error) {
+	query := `SELECT id, transaction_id, from_status, to_status, source, COALESCE(actor_id, 0), note, created_at
+		FROM transaction_status_logs WHERE transaction_id = $1 ORDER BY id`
+	rows, err := r.db.Query(query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []model.TransactionStatusChange
+	for rows.Next() {
+		var change model.TransactionStatusChange
+		err := rows.Scan(&change.ID, &change.TransactionID, &change.FromStatus, &change.ToStatus, &change.Source, &change.ActorID, &change.Note, &change.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// FindPaidTimes returns when each of the transactions was first paid, as
+// their status logs show. A donation that went back to paid after a failed
+// refund keeps its first paid time. Transactions never paid are left out.
+func (r *transactionRepo) FindPaidTimes(transactionIDs []int) (map[int]time.Time, error) {
+	rows, err := r.db.Query(`SELECT transaction_id, MIN(created_at) FROM transaction_status_logs
+		WHERE transaction_id = ANY($1) AND to_status = $2 GROUP BY transaction_id`, pq.Array(transactionIDs), model.TransactionStatusPaid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paidTimes := map[int]time.Time{}
+	for rows.Next() {
+		var transactionID int
+		var paidAt time.Time
+		if err := rows.Scan(&transactionID, &paidAt); err != nil {
+			return nil, err
+		}
+		paidTimes[transactionID] = paidAt
+	}
+	return paidTimes, rows.Err()
 }
 
 func (r *transactionRepo) UpdatePaymentURL(transaction model.Transaction) (model.Transaction, error) {
@@ -113,15 +314,14 @@ func (r *transactionRepo) FindAll(page int, size int) ([]model.Transaction, dto.
 
 	offset := (page - 1) * size
 
-	rows, err := r.db.Query("SELECT * FROM transactions LIMIT $1 OFFSET $2", size, offset)
+	rows, err := r.db.Query("SELECT "+transactionColumns+" FROM transactions LIMIT $1 OFFSET $2", size, offset)
 	if err != nil {
 		return nil, dto.Paging{}, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var transaction model.Transaction
-		err := rows.Scan(&transaction.ID, &transaction.CampaignID, &transaction.UserID, &transaction.Amount, &transaction.Status, &transaction.Code, &transaction.PaymentURL, &transaction.CreatedAt, &transaction.UpdatedAt)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			log.Println(err.Error())
 			return nil, dto.Paging{}, err
@@ -132,12 +332,152 @@ func (r *transactionRepo) FindAll(page int, size int) ([]model.Transaction, dto.
 	return listData, dto.Paging{}, nil
 }
 
+// FindCampaignReport sums the donations that count towards a campaign's total
+// and the fees taken from them.
+func (r *transactionRepo) FindCampaignReport(campaignID int) (model.CampaignReport, error) {
+	report := model.CampaignReport{CampaignID: campaignID}
+	err := r.db.QueryRow(`SELECT COUNT(t.id), COALESCE(c.current_amount, 0), COALESCE(SUM(t.platform_fee), 0), COALESCE(SUM(t.gateway_fee), 0), COALESCE(c.net_amount, 0)
+		FROM campaigns c LEFT JOIN transactions t ON t.campaign_id = c.id AND t.status = ANY($2)
+		WHERE c.id = $1 AND c.deleted_at IS NULL GROUP BY c.id`, campaignID, pq.Array(model.CountedTransactionStatuses)).
+		Scan(&report.Donations, &report.GrossRaised, &report.PlatformFees, &report.GatewayFees, &report.NetRaised)
+	if err != nil {
+		return model.CampaignReport{}, err
+	}
+	return report, nil
+}
+
+// supporterQuery selects donations as supporters. The donor's account or
+// guest name is only read for donations that are not anonymous, and a display
+// name takes its place when one was given.
+const supporterQuery = `SELECT t.campaign_id, CASE WHEN t.anonymous THEN '' ELSE COALESCE(NULLIF(t.display_name, ''), u.name, t.guest_name) END,
+	t.anonymous, t.message, t.amount, t.created_at
+	FROM transactions t LEFT JOIN users u ON u.id = t.user_id`
+
+func scanSupporter(row interface{ Scan(dest ...any) error }) (model.Supporter, error) {
+	var supporter model.Supporter
+	err := row.Scan(&supporter.CampaignID, &supporter.Name, &supporter.Anonymous, &supporter.Message, &supporter.Amount, &supporter.DonatedAt)
+	if supporter.Anonymous {
+		supporter.Name = model.AnonymousSupporterName
+	}
+	return supporter, err
+}
+
+// FindSupporter returns a counted donation as a supporter.
+func (r *transactionRepo) FindSupporter(transactionID int) (model.Supporter, error) {
+	row := r.db.QueryRow(supporterQuery+" WHERE t.id = $1 AND t.status = ANY($2)", transactionID, pq.Array(model.CountedTransactionStatuses))
+	supporter, err := scanSupporter(row)
+	if err != nil {
+		return model.Supporter{}, err
+	}
+	return supporter, nil
+}
+
+// FindPublicDonations returns the user's counted donations that are not
+// anonymous as supporters, newest first.
+func (r *transactionRepo) FindPublicDonations(userID int) ([]model.Supporter, error) {
+	rows, err := r.db.Query(supporterQuery+" WHERE t.user_id = $1 AND t.status = ANY($2) AND NOT t.anonymous ORDER BY t.id DESC",
+		userID, pq.Array(model.CountedTransactionStatuses))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var supporters []model.Supporter
+	for rows.Next() {
+		supporter, err := scanSupporter(rows)
+		if err != nil {
+			return nil, err
+		}
+		supporters = append(supporters, supporter)
+	}
+	return supporters, rows.Err()
+}
+
+// FindSupporters pages through the campaign's counted donations, newest
+// first.
+func (r *transactionRepo) FindSupporters(campaignID int, page int, size int) ([]model.Supporter, dto.Paging, error) {
+	statuses := pq.Array(model.CountedTransactionStatuses)
+
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM transactions WHERE campaign_id = $1 AND status = ANY($2)", campaignID, statuses).Scan(&total)
+	if err != nil {
+		return nil, dto.Paging{}, err
+	}
+
+	offset := (page - 1) * size
+	rows, err := r.db.Query(supporterQuery+" WHERE t.campaign_id = $1 AND t.status = ANY($2) ORDER BY t.id DESC LIMIT $3 OFFSET $4",
+		campaignID, statuses, size, offset)
+	if err != nil {
+		return nil, dto.Paging{}, err
+	}
+	defer rows.Close()
+
+	var supporters []model.Supporter
+	for rows.Next() {
+		supporter, err := scanSupporter(rows)
+		if err != nil {
+			return nil, dto.Paging{}, err
+		}
+		supporters = append(supporters, supporter)
+	}
+
+	paging := dto.Paging{
+		Page:       page,
+		Size:       size,
+		TotalRows:  total,
+		TotalPages: int(math.Ceil(float64(total) / float64(size))),
+	}
+	return supporters, paging, nil
+}
+
+// FindDonationExport returns every donation of the campaign with the donor's
+// account or guest name and email, whatever the donor chose to show publicly.
+func (r *transactionRepo) FindDonationExport(campaignID int) ([]model.DonationExportRow, error) {
+	rows, err := r.db.Query(`SELECT `+transactionColumns+`,
+		COALESCE((SELECT u.name FROM users u WHERE u.id = transactions.user_id), guest_name),
+		COALESCE((SELECT u.email FROM users u WHERE u.id = transactions.user_id), guest_email)
+		FROM transactions WHERE campaign_id = $1 ORDER BY id`, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var export []model.DonationExportRow
+	for rows.Next() {
+		var row model.DonationExportRow
+		if err := rows.Scan(append(transactionFields(&row.Transaction), &row.DonorName, &row.DonorEmail)...); err != nil {
+			return nil, err
+		}
+		export = append(export, row)
+	}
+	return export, nil
+}
+
+// ClaimGuestTransactions attaches the guest donations made with email to the
+// user. Claimed donations keep their guest details as a record of how they
+// were made.
+func (r *transactionRepo) ClaimGuestTransactions(userID int, email string) ([]model.Transaction, error) {
+	rows, err := r.db.Query(`UPDATE transactions SET user_id = $1, updated_at = NOW()
+		WHERE user_id IS NULL AND guest_email <> '' AND LOWER(guest_email) = LOWER($2) RETURNING `+transactionColumns, userID, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []model.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
+
 func (r *transactionRepo) GetByCode(code string) (*model.Transaction, error) {
-	var transaction model.Transaction
 	log.Println("Querying transaction with code:", code)
-	err := r.db.QueryRow("SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE code = $1", code).Scan(
-		&transaction.ID, &transaction.CampaignID, &transaction.UserID, &transaction.Amount, &transaction.Status, &transaction.Code, &transaction.PaymentURL, &transaction.CreatedAt, &transaction.UpdatedAt,
-	)
+	transaction, err := scanTransaction(r.db.QueryRow("SELECT "+transactionColumns+" FROM transactions WHERE code = $1", code))
 	if err != nil {
 		log.Println("Error querying transaction:", err)
 		return nil, err
@@ -151,8 +491,17 @@ type TransactionRepo interface {
 	GetTransactionsByUserID(userID int) ([]model.Transaction, error)
 	GetByID(id int) (model.Transaction, error)
 	Save(transaction model.Transaction) (model.Transaction, error)
-	Update(transaction model.Transaction) (model.Transaction, error)
+	SaveWithinLimit(transaction model.Transaction, limit model.VelocityLimit) (model.Transaction, error)
+	ApplyStatus(change model.TransactionStatusChange) (model.Transaction, error)
+	FindStatusLog(transactionID int) ([]model.TransactionStatusChange, error)
+	FindPaidTimes(transactionIDs []int) (map[int]time.Time, error)
 	FindAll(page int, size int) ([]model.Transaction, dto.Paging, error)
+	FindCampaignReport(campaignID int) (model.CampaignReport, error)
+	FindSupporters(campaignID int, page int, size int) ([]model.Supporter, dto.Paging, error)
+	FindSupporter(transactionID int) (model.Supporter, error)
+	FindPublicDonations(userID int) ([]model.Supporter, error)
+	FindDonationExport(campaignID int) ([]model.DonationExportRow, error)
+	ClaimGuestTransactions(userID int, email string) ([]model.Transaction, error)
 	UpdatePaymentURL(transaction model.Transaction) (model.Transaction, error)
 	GetByCode(code string) (*model.Transaction, error)
 }
@@ -2,28 +2,34 @@ package repository
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
 	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
 var expectedTransaction = model.Transaction{
-	ID:         23,
-	CampaignID: 3,
-	UserID:     1,
-	Amount:     100000000,
-	Status:     "settlement",
-	Code:       "TRX-1717468985",
-	PaymentURL: "https://app.sandbox.midtrans.com/snap/v4/redirection/796f4f19-e122-4b13-b537-6911a38a1b37",
-	CreatedAt:  time.Now(),
-	UpdatedAt:  time.Now(),
+	ID:          23,
+	CampaignID:  3,
+	UserID:      1,
+	Amount:      100000000,
+	Status:      "settlement",
+	Code:        "TRX-1717468985",
+	PaymentURL:  "https://app.sandbox.midtrans.com/snap/v4/redirection/796f4f19-e122-4b13-b537-6911a38a1b37",
+	ClientIP:    "203.0.113.7",
+	RiskScore:   30,
+	RiskReasons: []string{"new_account", "unverified_email"},
+	CreatedAt:   time.Now(),
+	UpdatedAt:   time.Now(),
 }
 
 type TransactionRepoTestSuite struct {
@@ -43,11 +49,10 @@ func (suite *TransactionRepoTestSuite) SetupTest() {
 func (suite *TransactionRepoTestSuite) TestGetTransactionsByCampaignID_Success() {
 	campaignID := 3
 
-	rows := sqlmock.NewRows([]string{"id", "campaign_id", "user_id", "amount", "status", "code", "payment_url", "created_at", "updated_at"}).
-		AddRow(expectedTransaction.ID, expectedTransaction.CampaignID, expectedTransaction.UserID, expectedTransaction.Amount,
-			expectedTransaction.Status, expectedTransaction.Code, expectedTransaction.PaymentURL, expectedTransaction.CreatedAt, expectedTransaction.UpdatedAt)
+	rows := sqlmock.NewRows(transactionRowColumns).
+		AddRow(transactionValues(expectedTransaction)...)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE campaign_id = $1`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE campaign_id = $1`)).
 		WithArgs(campaignID).
 		WillReturnRows(rows)
 
@@ -60,7 +65,7 @@ func (suite *TransactionRepoTestSuite) TestGetTransactionsByCampaignID_Success()
 func (suite *TransactionRepoTestSuite) TestGetTransactionsByCampaignID_Fail() {
 	campaignID := 3
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE campaign_id = $1`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE campaign_id = $1`)).
 		WithArgs(campaignID).
 		WillReturnError(fmt.Errorf("error"))
 
@@ -73,11 +78,10 @@ func (suite *TransactionRepoTestSuite) TestGetTransactionsByCampaignID_Fail() {
 func (suite *TransactionRepoTestSuite) TestGetTransactionsByUserID_Success() {
 	userID := 1
 
-	rows := sqlmock.NewRows([]string{"id", "campaign_id", "user_id", "amount", "status", "code", "payment_url", "created_at", "updated_at"}).
-		AddRow(expectedTransaction.ID, expectedTransaction.CampaignID, expectedTransaction.UserID, expectedTransaction.Amount,
-			expectedTransaction.Status, expectedTransaction.Code, expectedTransaction.PaymentURL, expectedTransaction.CreatedAt, expectedTransaction.UpdatedAt)
+	rows := sqlmock.NewRows(transactionRowColumns).
+		AddRow(transactionValues(expectedTransaction)...)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE user_id = $1`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE user_id = $1`)).
 		WithArgs(userID).
 		WillReturnRows(rows)
 
@@ -90,7 +94,7 @@ func (suite *TransactionRepoTestSuite) TestGetTransactionsByUserID_Success() {
 func (suite *TransactionRepoTestSuite) TestGetTransactionsByUserID_Fail() {
 	userID := 1
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE user_id = $1`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE user_id = $1`)).
 		WithArgs(userID).
 		WillReturnError(fmt.Errorf("error"))
 
@@ -101,11 +105,10 @@ func (suite *TransactionRepoTestSuite) TestGetTransactionsByUserID_Fail() {
 }
 
 func (suite *TransactionRepoTestSuite) TestGetByID_Success() {
-	rows := sqlmock.NewRows([]string{"id", "campaign_id", "user_id", "amount", "status", "code", "payment_url", "created_at", "updated_at"}).
-		AddRow(expectedTransaction.ID, expectedTransaction.CampaignID, expectedTransaction.UserID, expectedTransaction.Amount,
-			expectedTransaction.Status, expectedTransaction.Code, expectedTransaction.PaymentURL, expectedTransaction.CreatedAt, expectedTransaction.UpdatedAt)
+	rows := sqlmock.NewRows(transactionRowColumns).
+		AddRow(transactionValues(expectedTransaction)...)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE id = $1`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE id = $1`)).
 		WithArgs(expectedTransaction.ID).
 		WillReturnRows(rows)
 
@@ -116,7 +119,7 @@ func (suite *TransactionRepoTestSuite) TestGetByID_Success() {
 }
 
 func (suite *TransactionRepoTestSuite) TestGetByID_Fail() {
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE id = $1`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE id = $1`)).
 		WithArgs(expectedTransaction.ID).
 		WillReturnError(fmt.Errorf("error"))
 
@@ -136,13 +139,11 @@ func (suite *TransactionRepoTestSuite) TestFindAll_Success() {
 		{ID: 2, CampaignID: 2, UserID: 2, Amount: 20000000, Status: "success", Code: "TRX-2", PaymentURL: "https://payment-url.com/2", CreatedAt: time.Now(), UpdatedAt: time.Now()},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "campaign_id", "user_id", "amount", "status", "code", "payment_url", "created_at", "updated_at"}).
-		AddRow(expectedTransactions[0].ID, expectedTransactions[0].CampaignID, expectedTransactions[0].UserID, expectedTransactions[0].Amount,
-			expectedTransactions[0].Status, expectedTransactions[0].Code, expectedTransactions[0].PaymentURL, expectedTransactions[0].CreatedAt, expectedTransactions[0].UpdatedAt).
-		AddRow(expectedTransactions[1].ID, expectedTransactions[1].CampaignID, expectedTransactions[1].UserID, expectedTransactions[1].Amount,
-			expectedTransactions[1].Status, expectedTransactions[1].Code, expectedTransactions[1].PaymentURL, expectedTransactions[1].CreatedAt, expectedTransactions[1].UpdatedAt)
+	rows := sqlmock.NewRows(transactionRowColumns).
+		AddRow(transactionValues(expectedTransactions[0])...).
+		AddRow(transactionValues(expectedTransactions[1])...)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM transactions LIMIT $1 OFFSET $2`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions LIMIT $1 OFFSET $2`)).
 		WithArgs(size, offset).
 		WillReturnRows(rows)
 
@@ -162,7 +163,7 @@ func (suite *TransactionRepoTestSuite) TestFindAll_Fail() {
 	page := 1
 	offset := (page - 1) * size
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM transactions LIMIT $1 OFFSET $2`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions LIMIT $1 OFFSET $2`)).
 		WithArgs(size, offset).
 		WillReturnError(fmt.Errorf("error fetching transactions"))
 
@@ -179,11 +180,10 @@ func (suite *TransactionRepoTestSuite) TestFindAll_Fail() {
 func (suite *TransactionRepoTestSuite) TestGetByCode_Success() {
 	code := "TRX-1717468985"
 
-	rows := sqlmock.NewRows([]string{"id", "campaign_id", "user_id", "amount", "status", "code", "payment_url", "created_at", "updated_at"}).
-		AddRow(expectedTransaction.ID, expectedTransaction.CampaignID, expectedTransaction.UserID, expectedTransaction.Amount,
-			expectedTransaction.Status, expectedTransaction.Code, expectedTransaction.PaymentURL, expectedTransaction.CreatedAt, expectedTransaction.UpdatedAt)
+	rows := sqlmock.NewRows(transactionRowColumns).
+		AddRow(transactionValues(expectedTransaction)...)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE code = $1`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE code = $1`)).
 		WithArgs(code).
 		WillReturnRows(rows)
 
@@ -196,7 +196,7 @@ func (suite *TransactionRepoTestSuite) TestGetByCode_Success() {
 func (suite *TransactionRepoTestSuite) TestGetByCode_Fail() {
 	code := "TRX-1717468985"
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT id, campaign_id, user_id, amount, status, code, payment_url, created_at, updated_at FROM transactions WHERE code = $1`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE code = $1`)).
 		WithArgs(code).
 		WillReturnError(fmt.Errorf("error"))
 
@@ -207,11 +207,15 @@ func (suite *TransactionRepoTestSuite) TestGetByCode_Fail() {
 }
 
 func (suite *TransactionRepoTestSuite) TestSave_Success() {
-	expectedQuery := `INSERT INTO transactions \(campaign_id, user_id, amount, status, code, created_at, updated_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, NOW\(\), NOW\(\)\) RETURNING id, created_at, updated_at`
+	expectedQuery := `INSERT INTO transactions \(campaign_id, user_id, amount, status, code, cover_fees, recurring_plan_id, message, anonymous, display_name, contact_opt_out, guest_name, guest_email, created_at, updated_at\) VALUES \(\$1, NULLIF\(\$2, 0\), \$3, \$4, \$5, \$6, NULLIF\(\$7, 0\), \$8, \$9, \$10, \$11, \$12, \$13, NOW\(\), NOW\(\)\) RETURNING id, created_at, updated_at`
 
 	suite.mockSql.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
 		WithArgs(expectedTransaction.CampaignID, expectedTransaction.UserID, expectedTransaction.Amount,
-			expectedTransaction.Status, expectedTransaction.Code).
+			expectedTransaction.Status, expectedTransaction.Code, expectedTransaction.CoverFees, expectedTransaction.RecurringPlanID,
+			expectedTransaction.Message, expectedTransaction.Anonymous, expectedTransaction.DisplayName, expectedTransaction.ContactOptOut,
+			expectedTransaction.GuestName, expectedTransaction.GuestEmail, expectedTransaction.Currency, expectedTransaction.DonorAmount,
+			expectedTransaction.DonorCurrency, expectedTransaction.ExchangeRate,
+			expectedTransaction.ClientIP, expectedTransaction.RiskScore, pq.Array(expectedTransaction.RiskReasons)).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
 			AddRow(expectedTransaction.ID, expectedTransaction.CreatedAt, expectedTransaction.UpdatedAt))
 
@@ -222,9 +226,13 @@ func (suite *TransactionRepoTestSuite) TestSave_Success() {
 }
 
 func (suite *TransactionRepoTestSuite) TestSave_Fail() {
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO transactions (campaign_id, user_id, amount, status, code, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, NOW(), NOW()) RETURNING id, created_at, updated_at`)).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO transactions (campaign_id, user_id, amount, status, code, cover_fees, recurring_plan_id, message, anonymous, display_name, contact_opt_out, guest_name, guest_email, created_at, updated_at) VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, NULLIF($7, 0), $8, $9, $10, $11, $12, $13, NOW(), NOW()) RETURNING id, created_at, updated_at`)).
 		WithArgs(expectedTransaction.CampaignID, expectedTransaction.UserID, expectedTransaction.Amount,
-			expectedTransaction.Status, expectedTransaction.Code).
+			expectedTransaction.Status, expectedTransaction.Code, expectedTransaction.CoverFees, expectedTransaction.RecurringPlanID,
+			expectedTransaction.Message, expectedTransaction.Anonymous, expectedTransaction.DisplayName, expectedTransaction.ContactOptOut,
+			expectedTransaction.GuestName, expectedTransaction.GuestEmail, expectedTransaction.Currency, expectedTransaction.DonorAmount,
+			expectedTransaction.DonorCurrency, expectedTransaction.ExchangeRate,
+			expectedTransaction.ClientIP, expectedTransaction.RiskScore, pq.Array(expectedTransaction.RiskReasons)).
 		WillReturnError(fmt.Errorf("error"))
 	actualTransaction, err := suite.transactionRepo.Save(expectedTransaction)
 
@@ -232,29 +240,62 @@ func (suite *TransactionRepoTestSuite) TestSave_Fail() {
 	assert.Equal(suite.T(), model.Transaction{}, actualTransaction)
 }
 
-func (suite *TransactionRepoTestSuite) TestUpdate_Success() {
-	expectedQuery := "UPDATE transactions SET status = $1, updated_at = NOW\\(\\) WHERE id = $2 RETURNING updated_at"
+func (suite *TransactionRepoTestSuite) TestSave_DuplicateCode() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WithArgs(expectedTransaction.CampaignID, expectedTransaction.UserID, expectedTransaction.Amount,
+			expectedTransaction.Status, expectedTransaction.Code, expectedTransaction.CoverFees, expectedTransaction.RecurringPlanID,
+			expectedTransaction.Message, expectedTransaction.Anonymous, expectedTransaction.DisplayName, expectedTransaction.ContactOptOut,
+			expectedTransaction.GuestName, expectedTransaction.GuestEmail, expectedTransaction.Currency, expectedTransaction.DonorAmount,
+			expectedTransaction.DonorCurrency, expectedTransaction.ExchangeRate,
+			expectedTransaction.ClientIP, expectedTransaction.RiskScore, pq.Array(expectedTransaction.RiskReasons)).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_transactions_code"})
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-		WithArgs(expectedTransaction.Status, expectedTransaction.ID).
-		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).
-			AddRow(expectedTransaction.UpdatedAt))
+	_, err := suite.transactionRepo.Save(expectedTransaction)
+
+	assert.ErrorIs(suite.T(), err, ErrDuplicateTransactionCode)
+}
+
+func (suite *TransactionRepoTestSuite) TestSaveWithinLimit_Success() {
+	limit := model.VelocityLimit{Since: time.Now().Add(-time.Hour), MaxPerDonor: 5, MaxPerIP: 20}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1, hashtext($2))")).
+		WithArgs(donorVelocityLock, "user:1").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE user_id = $1 AND created_at >= $2")).
+		WithArgs(1, limit.Since).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1, hashtext($2))")).
+		WithArgs(ipVelocityLock, "203.0.113.7").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE client_ip = $1 AND created_at >= $2")).
+		WithArgs("203.0.113.7", limit.Since).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(19))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO transactions")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+			AddRow(expectedTransaction.ID, expectedTransaction.CreatedAt, expectedTransaction.UpdatedAt))
+	suite.mockSql.ExpectCommit()
 
-	actualTransaction, err := suite.transactionRepo.Update(expectedTransaction)
+	actualTransaction, err := suite.transactionRepo.SaveWithinLimit(expectedTransaction, limit)
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), expectedTransaction, actualTransaction)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
-func (suite *TransactionRepoTestSuite) TestUpdate_Fail() {
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`UPDATE transactions SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at`)).
-		WithArgs(expectedTransaction.Status, expectedTransaction.ID).
-		WillReturnError(fmt.Errorf("error"))
+func (suite *TransactionRepoTestSuite) TestSaveWithinLimit_Exceeded() {
+	guest := expectedTransaction
+	guest.UserID = 0
+	guest.GuestEmail = "Jane@Example.com"
+	limit := model.VelocityLimit{Since: time.Now().Add(-time.Hour), MaxPerDonor: 5, MaxPerIP: 20}
 
-	actualTransaction, err := suite.transactionRepo.Update(expectedTransaction)
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1, hashtext($2))")).
+		WithArgs(donorVelocityLock, "guest:jane@example.com").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE user_id IS NULL AND LOWER(guest_email) = LOWER($1)")).
+		WithArgs("Jane@Example.com", limit.Since).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	suite.mockSql.ExpectRollback()
 
-	assert.Error(suite.T(), err)
-	assert.Equal(suite.T(), model.Transaction{}, actualTransaction)
+	_, err := suite.transactionRepo.SaveWithinLimit(guest, limit)
+
+	assert.ErrorIs(suite.T(), err, model.ErrVelocityExceeded)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
 func (suite *TransactionRepoTestSuite) TestUpdatePaymentURL_Success() {
@@ -282,6 +323,220 @@ func (suite *TransactionRepoTestSuite) TestUpdatePaymentURL_Fail() {
 	assert.Equal(suite.T(), model.Transaction{}, actualTransaction)
 }
 
+var transactionRowColumns = []string{"id", "campaign_id", "user_id", "guest_name", "guest_email", "amount", "status", "code", "payment_url", "cover_fees",
+	"recurring_plan_id", "message", "anonymous", "display_name", "contact_opt_out", "payment_method", "gross_amount", "platform_fee", "gateway_fee", "fee_amount", "net_amount", "created_at", "updated_at",
+	"currency", "donor_amount", "donor_currency", "exchange_rate",
+	"client_ip", "risk_score", "risk_reasons"}
+
+func transactionValues(transaction model.Transaction) []driver.Value {
+	return []driver.Value{transaction.ID, transaction.CampaignID, transaction.UserID, transaction.GuestName, transaction.GuestEmail, transaction.Amount, transaction.Status,
+		transaction.Code, transaction.PaymentURL, transaction.CoverFees, transaction.RecurringPlanID, transaction.Message, transaction.Anonymous,
+		transaction.DisplayName, transaction.ContactOptOut, transaction.PaymentMethod, transaction.GrossAmount,
+		transaction.PlatformFee, transaction.GatewayFee, transaction.FeeAmount, transaction.NetAmount, transaction.CreatedAt, transaction.UpdatedAt,
+		string(transaction.Currency), transaction.DonorAmount, string(transaction.DonorCurrency), transaction.ExchangeRate,
+		transaction.ClientIP, transaction.RiskScore, "{" + strings.Join(transaction.RiskReasons, ",") + "}"}
+}
+
+func transactionRow(transaction model.Transaction) *sqlmock.Rows {
+	return sqlmock.NewRows(transactionRowColumns).AddRow(transactionValues(transaction)...)
+}
+
+func (suite *TransactionRepoTestSuite) TestApplyStatus_Paid() {
+	pending := expectedTransaction
+	pending.Status = model.TransactionStatusPending
+	fees := model.TransactionFees{PaymentMethod: "gopay", GrossAmount: pending.Amount, PlatformFee: 5000000, GatewayFee: 2000,
+		FeeAmount: 5002000, NetAmount: pending.Amount - 5002000}
+	change := model.TransactionStatusChange{TransactionID: pending.ID, ToStatus: model.TransactionStatusPaid, Source: model.StatusSourceGateway, Fees: fees}
+	paid := pending
+	paid.TransactionFees = fees
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE id = $1 FOR UPDATE`)).
+		WithArgs(pending.ID).
+		WillReturnRows(transactionRow(pending))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`UPDATE transactions SET status = $1`)).
+		WithArgs(model.TransactionStatusPaid, pending.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(pending.UpdatedAt))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(`INSERT INTO transaction_status_logs`)).
+		WithArgs(pending.ID, model.TransactionStatusPending, model.TransactionStatusPaid, model.StatusSourceGateway, 0, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE transactions SET payment_method = $1`)).
+		WithArgs("gopay", pending.Amount, 5000000, 2000, 5002000, pending.Amount-5002000, pending.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE campaigns SET backer_count = COALESCE(backer_count, 0) + $1, current_amount = COALESCE(current_amount, 0) + $2`)).
+		WithArgs(1, pending.Amount, pending.Amount-5002000, pending.CampaignID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDonationJournal(suite.mockSql, model.JournalKindPayment, "transaction:23", pending, pending.Amount)
+	expectJournal(suite.mockSql, feeJournal("transaction:23", paid, 5000000, 2000))
+	pledge := model.MatchingPledge{ID: 4, CampaignID: pending.CampaignID, SponsorName: "Acme", RatioPercent: 100, Cap: 30000000,
+		MatchedAmount: 1000000, StartsAt: pending.CreatedAt.Add(-time.Hour), EndsAt: pending.CreatedAt.Add(time.Hour)}
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM matching_pledges
+		WHERE campaign_id = $1 AND cancelled_at IS NULL AND starts_at <= $2 AND ends_at > $2 AND matched_amount < cap`)).
+		WithArgs(pending.CampaignID, pending.CreatedAt).
+		WillReturnRows(matchingPledgeRows(pledge))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(`INSERT INTO matched_contributions`)).
+		WithArgs(4, pending.ID, pending.CampaignID, 29000000).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE matching_pledges SET matched_amount = matched_amount + $1 WHERE id = $2`)).
+		WithArgs(29000000, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+
+	transaction, err := suite.transactionRepo.ApplyStatus(change)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.TransactionStatusPaid, transaction.Status)
+	assert.Equal(suite.T(), fees, transaction.TransactionFees)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepoTestSuite) TestApplyStatus_Refunded() {
+	paid := expectedTransaction
+	paid.Status = model.TransactionStatusPaid
+	paid.TransactionFees = model.TransactionFees{GrossAmount: paid.Amount, PlatformFee: 5000000, GatewayFee: 4000, FeeAmount: 5004000, NetAmount: paid.Amount - 5004000}
+	change := model.TransactionStatusChange{TransactionID: paid.ID, ToStatus: model.TransactionStatusRefunded, Source: model.StatusSourceAdmin, ActorID: 2, Note: "duplicate donation"}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE id = $1 FOR UPDATE`)).
+		WithArgs(paid.ID).
+		WillReturnRows(transactionRow(paid))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`UPDATE transactions SET status = $1`)).
+		WithArgs(model.TransactionStatusRefunded, paid.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(paid.UpdatedAt))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(`INSERT INTO transaction_status_logs`)).
+		WithArgs(paid.ID, model.TransactionStatusPaid, model.TransactionStatusRefunded, model.StatusSourceAdmin, 2, "duplicate donation").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(SUM(amount), 0) FROM refunds`)).
+		WithArgs(paid.ID, model.RefundStatusSucceeded).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE campaigns SET backer_count`)).
+		WithArgs(-1, -paid.Amount, -paid.NetAmount, paid.CampaignID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDonationJournal(suite.mockSql, model.JournalKindReversal, "transaction:23", paid, -paid.Amount)
+	expectJournal(suite.mockSql, feeJournal("transaction:23", paid, -5000000, -4000))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE matching_pledges p SET matched_amount = p.matched_amount - (c.amount - c.refunded_amount)`)).
+		WithArgs(paid.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE matched_contributions SET reversed_at = NOW()`)).
+		WithArgs(paid.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+
+	_, err := suite.transactionRepo.ApplyStatus(change)
+
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepoTestSuite) TestApplyStatus_IllegalTransition() {
+	paid := expectedTransaction
+	paid.Status = model.TransactionStatusPaid
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM transactions WHERE id = $1 FOR UPDATE`)).
+		WithArgs(paid.ID).
+		WillReturnRows(transactionRow(paid))
+	suite.mockSql.ExpectRollback()
+
+	transaction, err := suite.transactionRepo.ApplyStatus(model.TransactionStatusChange{TransactionID: paid.ID, ToStatus: model.TransactionStatusPending})
+
+	assert.ErrorIs(suite.T(), err, model.ErrIllegalTransition)
+	assert.Equal(suite.T(), model.TransactionStatusPaid, transaction.Status)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepoTestSuite) TestFindCampaignReport() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(`FROM campaigns c LEFT JOIN transactions t`)).
+		WithArgs(3, pq.Array(model.CountedTransactionStatuses)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "current_amount", "platform_fee", "gateway_fee", "net_amount"}).
+			AddRow(2, 150000, 7500, 8000, 134500))
+
+	report, err := suite.transactionRepo.FindCampaignReport(3)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.CampaignReport{CampaignID: 3, Donations: 2, GrossRaised: 150000, PlatformFees: 7500, GatewayFees: 8000, NetRaised: 134500}, report)
+}
+
+func (suite *TransactionRepoTestSuite) TestFindPaidTimes() {
+	paidAt := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT transaction_id, MIN(created_at) FROM transaction_status_logs")).
+		WithArgs(pq.Array([]int{7, 8}), model.TransactionStatusPaid).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "min"}).AddRow(7, paidAt))
+
+	paidTimes, err := suite.transactionRepo.FindPaidTimes([]int{7, 8})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[int]time.Time{7: paidAt}, paidTimes)
+}
+
+func (suite *TransactionRepoTestSuite) TestFindSupporters() {
+	donatedAt := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	statuses := pq.Array(model.CountedTransactionStatuses)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM transactions WHERE campaign_id = $1 AND status = ANY($2)")).
+		WithArgs(3, statuses).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("CASE WHEN t.anonymous THEN '' ELSE COALESCE(NULLIF(t.display_name, ''), u.name, t.guest_name) END")).
+		WithArgs(3, statuses, 2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"campaign_id", "name", "anonymous", "message", "amount", "created_at"}).
+			AddRow(3, "", true, "Keep going!", 50000, donatedAt).
+			AddRow(3, "The Smiths", false, "", 25000, donatedAt))
+
+	supporters, paging, err := suite.transactionRepo.FindSupporters(3, 1, 2)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.Supporter{
+		{CampaignID: 3, Name: model.AnonymousSupporterName, Anonymous: true, Message: "Keep going!", Amount: 50000, DonatedAt: donatedAt},
+		{CampaignID: 3, Name: "The Smiths", Amount: 25000, DonatedAt: donatedAt},
+	}, supporters)
+	assert.Equal(suite.T(), dto.Paging{Page: 1, Size: 2, TotalRows: 3, TotalPages: 2}, paging)
+}
+
+func (suite *TransactionRepoTestSuite) TestFindPublicDonations_LeavesOutAnonymous() {
+	donatedAt := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("WHERE t.user_id = $1 AND t.status = ANY($2) AND NOT t.anonymous ORDER BY t.id DESC")).
+		WithArgs(4, pq.Array(model.CountedTransactionStatuses)).
+		WillReturnRows(sqlmock.NewRows([]string{"campaign_id", "name", "anonymous", "message", "amount", "created_at"}).
+			AddRow(3, "Rina", false, "", 25000, donatedAt))
+
+	supporters, err := suite.transactionRepo.FindPublicDonations(4)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.Supporter{{CampaignID: 3, Name: "Rina", Amount: 25000, DonatedAt: donatedAt}}, supporters)
+}
+
+func (suite *TransactionRepoTestSuite) TestFindDonationExport() {
+	anonymous := expectedTransaction
+	anonymous.Anonymous = true
+	anonymous.Message = "Keep going!"
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM transactions WHERE campaign_id = $1 ORDER BY id")).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(append(transactionRowColumns, "donor_name", "donor_email")).
+			AddRow(append(transactionValues(anonymous), "Jane Doe", "jane@example.com")...))
+
+	export, err := suite.transactionRepo.FindDonationExport(3)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.DonationExportRow{{Transaction: anonymous, DonorName: "Jane Doe", DonorEmail: "jane@example.com"}}, export)
+}
+
+func (suite *TransactionRepoTestSuite) TestClaimGuestTransactions() {
+	claimed := expectedTransaction
+	claimed.UserID = 9
+	claimed.GuestName = "Jane Doe"
+	claimed.GuestEmail = "Jane@Example.com"
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE transactions SET user_id = $1, updated_at = NOW()")).
+		WithArgs(9, "jane@example.com").
+		WillReturnRows(transactionRow(claimed))
+
+	transactions, err := suite.transactionRepo.ClaimGuestTransactions(9, "jane@example.com")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.Transaction{claimed}, transactions)
+}
+
 func TestTransactionRepoTestSuite(t *testing.T) {
 	suite.Run(t, new(TransactionRepoTestSuite))
 }
@@ -13,6 +13,8 @@ type userRepo struct {
 	db *sql.DB
 }
 
+const userColumns = "id, name, occupation, email, password_hash, avatar_file_name, role, email_verified_at, created_at, updated_at"
+
 func (u *userRepo) Save(user model.User) (model.User, error) {
 	query := "INSERT INTO users (name, occupation, email, password_hash, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, NOW(), NOW()) RETURNING id, created_at, updated_at"
 	var id int
@@ -28,8 +30,11 @@ func (u *userRepo) Save(user model.User) (model.User, error) {
 }
 
 func (u *userRepo) Update(user model.User) (model.User, error) {
-	query := "UPDATE users SET name = $1, occupation = $2, email = $3, updated_at = NOW() WHERE id = $4 RETURNING id, name, occupation, email, updated_at"
-	err := u.db.QueryRow(query, user.Name, user.Occupation, user.Email, user.ID).Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.UpdatedAt)
+	// A new email has to be verified again.
+	query := `UPDATE users SET name = $1, occupation = $2, email = $3,
+		email_verified_at = CASE WHEN LOWER(email) = LOWER($3) THEN email_verified_at END, updated_at = NOW()
+		WHERE id = $4 AND deleted_at IS NULL RETURNING id, name, occupation, email, email_verified_at, updated_at`
+	err := u.db.QueryRow(query, user.Name, user.Occupation, user.Email, user.ID).Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.EmailVerifiedAt, &user.UpdatedAt)
 	if err != nil {
 		return user, err
 	}
@@ -37,10 +42,10 @@ func (u *userRepo) Update(user model.User) (model.User, error) {
 }
 
 func (u *userRepo) SaveAvatar(userId int, fileLocation string) (model.User, error) {
-	query := "UPDATE users SET avatar_file_name = $1, updated_at = NOW() WHERE id = $2 RETURNING id, name, occupation, email, password_hash, avatar_file_name, role, created_at, updated_at"
+	query := "UPDATE users SET avatar_file_name = $1, updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL RETURNING " + userColumns
 	var user model.User
 	err := u.db.QueryRow(query, fileLocation, userId).Scan(
-		&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		return user, err
@@ -56,13 +61,13 @@ func (u *userRepo) FindAll(page int, size int) ([]model.User, dto.Paging, error)
 	offset := (page - 1) * size
 
 	var err error
-	rows, err = u.db.Query("SELECT * FROM users limit $1 offset $2", size, offset)
+	rows, err = u.db.Query("SELECT "+userColumns+" FROM users WHERE deleted_at IS NULL limit $1 offset $2", size, offset)
 	if err != nil {
 		return nil, dto.Paging{}, err
 	}
 
 	totalRows := 0
-	err = u.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&totalRows)
+	err = u.db.QueryRow("SELECT COUNT(*) FROM users WHERE deleted_at IS NULL").Scan(&totalRows)
 	if err != nil {
 		return nil, dto.Paging{}, err
 	}
@@ -71,7 +76,7 @@ func (u *userRepo) FindAll(page int, size int) ([]model.User, dto.Paging, error)
 		var user model.User
 		var avatarFileName sql.NullString
 
-		err := rows.Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+		err := rows.Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 
 		if err != nil {
 			log.Println(err.Error())
@@ -101,7 +106,7 @@ func (u *userRepo) FindById(id int) (model.User, error) {
 	var user model.User
 	var avatarFileName sql.NullString
 
-	err := u.db.QueryRow("SELECT * FROM users WHERE id=$1", id).Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	err := u.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id=$1 AND deleted_at IS NULL", id).Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return model.User{}, err
@@ -121,8 +126,8 @@ func (u *userRepo) FindByEmail(email string) (model.User, error) {
 	var user model.User
 	var avatarFileName sql.NullString
 
-	err := u.db.QueryRow("SELECT * FROM users WHERE email=$1", email).
-		Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	err := u.db.QueryRow("SELECT "+userColumns+" FROM users WHERE email=$1 AND deleted_at IS NULL", email).
+		Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return model.User{}, err
@@ -137,6 +142,79 @@ func (u *userRepo) FindByEmail(email string) (model.User, error) {
 	return user, nil
 }
 
+// MarkEmailVerified records that the owner of email has confirmed it. An
+// email that is already verified keeps its original verification time.
+func (u *userRepo) MarkEmailVerified(email string) (model.User, error) {
+	var user model.User
+	err := u.db.QueryRow(`UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		WHERE email = $1 AND deleted_at IS NULL RETURNING `+userColumns, email).
+		Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// Delete soft deletes a user. It returns sql.ErrNoRows when the user does not
+// exist or is already deleted.
+func (u *userRepo) Delete(id int) error {
+	result, err := u.db.Exec("UPDATE users SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (u *userRepo) FindDeleted() ([]model.User, error) {
+	rows, err := u.db.Query("SELECT " + userColumns + ", deleted_at FROM users WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		var user model.User
+		err := rows.Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.EmailVerifiedAt,
+			&user.CreatedAt, &user.UpdatedAt, &user.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (u *userRepo) Restore(id int) (model.User, error) {
+	var user model.User
+	err := u.db.QueryRow("UPDATE users SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND deleted_at IS NOT NULL RETURNING "+userColumns, id).
+		Scan(&user.ID, &user.Name, &user.Occupation, &user.Email, &user.PasswordHash, &user.AvatarFileName, &user.Role, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// PurgeDeleted permanently removes users soft deleted before the cutoff.
+// Users who still own campaigns or made transactions are kept so those
+// records never lose their owner.
+func (u *userRepo) PurgeDeleted(before time.Time) (int64, error) {
+	result, err := u.db.Exec(`DELETE FROM users u WHERE u.deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM campaigns c WHERE c.user_id = u.id)
+		AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.user_id = u.id)`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 type UserRepo interface {
 	Save(user model.User) (model.User, error)
 	Update(user model.User) (model.User, error)
@@ -144,6 +222,11 @@ type UserRepo interface {
 	FindAll(page int, size int) ([]model.User, dto.Paging, error)
 	FindById(id int) (model.User, error)
 	FindByEmail(email string) (model.User, error)
+	MarkEmailVerified(email string) (model.User, error)
+	Delete(id int) error
+	FindDeleted() ([]model.User, error)
+	Restore(id int) (model.User, error)
+	PurgeDeleted(before time.Time) (int64, error)
 }
 
 func NewUserRepo(database *sql.DB) UserRepo {
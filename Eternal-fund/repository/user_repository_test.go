@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"eternal-fund/model"
+	"regexp"
 	"testing"
 	"time"
 
@@ -38,11 +39,11 @@ func (suite *UsersRepoTestSuite) TestFindByEmail_Success() {
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
-	suite.mockSql.ExpectQuery(`SELECT \* FROM users WHERE email=\$1`).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT " + userColumns + " FROM users WHERE email=$1 AND deleted_at IS NULL")).
 		WithArgs(email).
 		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "name", "occupation", "email", "password_hash", "avatar_file_name", "role", "created_at", "updated_at"}).
-				AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Occupation, expectedUser.Email, expectedUser.PasswordHash, nil, expectedUser.Role, expectedUser.CreatedAt, expectedUser.UpdatedAt),
+			sqlmock.NewRows([]string{"id", "name", "occupation", "email", "password_hash", "avatar_file_name", "role", "email_verified_at", "created_at", "updated_at"}).
+				AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Occupation, expectedUser.Email, expectedUser.PasswordHash, nil, expectedUser.Role, nil, expectedUser.CreatedAt, expectedUser.UpdatedAt),
 		)
 	user, err := suite.repo.FindByEmail(email)
 	assert.NoError(suite.T(), err, "Diharapkan tidak ada error")
@@ -71,11 +72,11 @@ func (suite *UsersRepoTestSuite) TestFindByID_Success() {
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
-	suite.mockSql.ExpectQuery("^SELECT \\* FROM users WHERE id=\\$1").
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT " + userColumns + " FROM users WHERE id=$1 AND deleted_at IS NULL")).
 		WithArgs(userID).
 		WillReturnRows(
-			suite.mockSql.NewRows([]string{"id", "name", "occupation", "email", "password_hash", "avatar_file_name", "role", "created_at", "updated_at"}).
-				AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Occupation, expectedUser.Email, expectedUser.PasswordHash, nil, expectedUser.Role, expectedUser.CreatedAt, expectedUser.UpdatedAt),
+			suite.mockSql.NewRows([]string{"id", "name", "occupation", "email", "password_hash", "avatar_file_name", "role", "email_verified_at", "created_at", "updated_at"}).
+				AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Occupation, expectedUser.Email, expectedUser.PasswordHash, nil, expectedUser.Role, nil, expectedUser.CreatedAt, expectedUser.UpdatedAt),
 		)
 	user, err := suite.repo.FindById(userID)
 	assert.NoError(suite.T(), err, "Expected no error")
@@ -100,11 +101,11 @@ func (suite *UsersRepoTestSuite) TestSaveAvatar_Success() {
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
-	suite.mockSql.ExpectQuery("^UPDATE users SET avatar_file_name = \\$1, updated_at = NOW\\(\\) WHERE id = \\$2 RETURNING id, name, occupation, email, password_hash, avatar_file_name, role, created_at, updated_at").
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE users SET avatar_file_name = $1, updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL RETURNING "+userColumns)).
 		WithArgs(fileLocation, userID).
 		WillReturnRows(
-			suite.mockSql.NewRows([]string{"id", "name", "occupation", "email", "password_hash", "avatar_file_name", "role", "created_at", "updated_at"}).
-				AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Occupation, expectedUser.Email, expectedUser.PasswordHash, expectedUser.AvatarFileName, expectedUser.Role, expectedUser.CreatedAt, expectedUser.UpdatedAt),
+			suite.mockSql.NewRows([]string{"id", "name", "occupation", "email", "password_hash", "avatar_file_name", "role", "email_verified_at", "created_at", "updated_at"}).
+				AddRow(expectedUser.ID, expectedUser.Name, expectedUser.Occupation, expectedUser.Email, expectedUser.PasswordHash, expectedUser.AvatarFileName, expectedUser.Role, nil, expectedUser.CreatedAt, expectedUser.UpdatedAt),
 		)
 	user, err := suite.repo.SaveAvatar(userID, fileLocation)
 	assert.NoError(suite.T(), err, "Expected no error")
@@ -193,14 +194,14 @@ func (suite *UsersRepoTestSuite) TestFindAll_Success() {
 		},
 	}
 
-	userRows := sqlmock.NewRows([]string{"id", "name", "occupation", "email", "password_hash", "avatar_file_name", "role", "created_at", "updated_at"})
+	userRows := sqlmock.NewRows([]string{"id", "name", "occupation", "email", "password_hash", "avatar_file_name", "role", "email_verified_at", "created_at", "updated_at"})
 	for _, user := range expectedUsers {
-		userRows.AddRow(user.ID, user.Name, user.Occupation, user.Email, user.PasswordHash, user.AvatarFileName, user.Role, user.CreatedAt, user.UpdatedAt)
+		userRows.AddRow(user.ID, user.Name, user.Occupation, user.Email, user.PasswordHash, user.AvatarFileName, user.Role, nil, user.CreatedAt, user.UpdatedAt)
 	}
-	suite.mockSql.ExpectQuery(`SELECT \* FROM users limit \$1 offset \$2`).
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT "+userColumns+" FROM users WHERE deleted_at IS NULL limit $1 offset $2")).
 		WithArgs(size, (page-1)*size).
 		WillReturnRows(userRows)
-	suite.mockSql.ExpectQuery("SELECT COUNT\\(\\*\\) FROM users").
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM users WHERE deleted_at IS NULL")).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(len(expectedUsers)))
 	users, paging, err := suite.repo.FindAll(page, size)
 	assert.NoError(suite.T(), err, "Expected no error")
@@ -216,6 +217,21 @@ func (suite *UsersRepoTestSuite) TestFindAll_Success() {
 	assert.Equal(suite.T(), 1, paging.TotalPages, "Expected total pages to match") // Assuming page size of 10 for simplicity
 }
 
+func (suite *UsersRepoTestSuite) TestMarkEmailVerified_Success() {
+	verifiedAt := time.Now()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW())")).
+		WithArgs("test@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "occupation", "email", "password_hash", "avatar_file_name", "role", "email_verified_at", "created_at", "updated_at"}).
+			AddRow(1, "Test User", "Tester", "test@example.com", "hashed_password", nil, "user", verifiedAt, verifiedAt, verifiedAt))
+
+	user, err := suite.repo.MarkEmailVerified("test@example.com")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, user.ID)
+	assert.Equal(suite.T(), &verifiedAt, user.EmailVerifiedAt)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
 func TestUserRepoTestSuite(t *testing.T) {
 	suite.Run(t, new(UsersRepoTestSuite))
 }
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"eternal-fund/model"
+	"fmt"
+)
+
+var (
+	ErrInsufficientBalance = errors.New("withdrawal amount exceeds the available campaign balance")
+	ErrEmptyPayoutBatch    = errors.New("there are no approved withdrawals to pay out")
+	ErrBatchNotExported    = errors.New("payout batch has already been completed")
+)
+
+type withdrawalRepo struct {
+	db *sql.DB
+}
+
+const withdrawalColumns = "id, campaign_id, bank_account_id, amount, status, COALESCE(requested_by, 0), COALESCE(reviewed_by, 0), note, COALESCE(batch_id, 0), created_at, updated_at"
+
+const payoutBatchColumns = "id, status, total, count, COALESCE(created_by, 0), created_at, completed_at"
+
+func scanWithdrawal(row interface{ Scan(dest ...any) error }) (model.Withdrawal, error) {
+	var withdrawal model.Withdrawal
+	err := row.Scan(&withdrawal.ID, &withdrawal.CampaignID, &withdrawal.BankAccountID, &withdrawal.Amount, &withdrawal.Status,
+		&withdrawal.RequestedBy, &withdrawal.ReviewedBy, &withdrawal.Note, &withdrawal.BatchID, &withdrawal.CreatedAt, &withdrawal.UpdatedAt)
+	return withdrawal, err
+}
+
+func scanPayoutBatch(row interface{ Scan(dest ...any) error }) (model.PayoutBatch, error) {
+	var batch model.PayoutBatch
+	err := row.Scan(&batch.ID, &batch.Status, &batch.Total, &batch.Count, &batch.CreatedBy, &batch.CreatedAt, &batch.CompletedAt)
+	return batch, err
+}
+
+// availableBalance is the campaign's ledger balance less what is already
+// requested but not yet approved and the refunds still in flight. Approved
+// withdrawals and completed refunds have left the ledger balance already.
+// Callers lock the campaign row first so the balance cannot be spent twice.
+func availableBalance(q rowQuerier, campaignID int) (int, error) {
+	var available int
+	err := q.QueryRow(`SELECT
+		COALESCE((SELECT -SUM(amount) FROM ledger_entries WHERE account = $1), 0) -
+		COALESCE((SELECT SUM(amount) FROM withdrawals WHERE campaign_id = $2 AND status = $3), 0) -
+		COALESCE((SELECT SUM(r.amount) FROM refunds r JOIN transactions t ON t.id = r.transaction_id
+			WHERE t.campaign_id = $2 AND r.status = $4), 0)`,
+		model.CampaignAccount(campaignID), campaignID, model.WithdrawalStatusRequested, model.RefundStatusPending).Scan(&available)
+	if err != nil {
+		return 0, err
+	}
+	return available, nil
+}
+
+func (r *withdrawalRepo) AvailableBalance(campaignID int) (int, error) {
+	return availableBalance(r.db, campaignID)
+}
+
+// Create stores a withdrawal request. The campaign row is locked so two
+// requests cannot both be checked against the same balance.
+func (r *withdrawalRepo) Create(withdrawal model.Withdrawal) (model.Withdrawal, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return model.Withdrawal{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT id FROM campaigns WHERE id = $1 FOR UPDATE", withdrawal.CampaignID); err != nil {
+		return model.Withdrawal{}, err
+	}
+	available, err := availableBalance(tx, withdrawal.CampaignID)
+	if err != nil {
+		return model.Withdrawal{}, err
+	}
+	if withdrawal.Amount > available {
+		return model.Withdrawal{}, ErrInsufficientBalance
+	}
+
+	withdrawal.Status = model.WithdrawalStatusRequested
+	err = tx.QueryRow(`INSERT INTO withdrawals (campaign_id, bank_account_id, amount, status, requested_by, note, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), '', NOW(), NOW()) RETURNING id, created_at, updated_at`,
+		withdrawal.CampaignID, withdrawal.BankAccountID, withdrawal.Amount, withdrawal.Status, withdrawal.RequestedBy).
+		Scan(&withdrawal.ID, &withdrawal.CreatedAt, &withdrawal.UpdatedAt)
+	if err != nil {
+		return model.Withdrawal{}, err
+	}
+
+	return withdrawal, tx.Commit()
+}
+
+func (r *withdrawalRepo) FindByID(id int) (model.Withdrawal, error) {
+	row := r.db.QueryRow("SELECT "+withdrawalColumns+" FROM withdrawals WHERE id = $1", id)
+	withdrawal, err := scanWithdrawal(row)
+	if err != nil {
+		return model.Withdrawal{}, err
+	}
+	return withdrawal, nil
+}
+
+func (r *withdrawalRepo) FindByCampaignID(campaignID int) ([]model.Withdrawal, error) {
+	return r.findMany("SELECT "+withdrawalColumns+" FROM withdrawals WHERE campaign_id = $1 ORDER BY id DESC", campaignID)
+}
+
+func (r *withdrawalRepo) FindByStatus(status model.WithdrawalStatus) ([]model.Withdrawal, error) {
+	return r.findMany("SELECT "+withdrawalColumns+" FROM withdrawals WHERE status = $1 ORDER BY id", status)
+}
+
+// UpdateStatus moves a requested or approved withdrawal on and writes the
+// ledger journal for it in the same database transaction: approval moves
+// the amount from the campaign to the payouts account and a failed payout
+// moves it back.
+func (r *withdrawalRepo) UpdateStatus(id int, status model.WithdrawalStatus, reviewedBy int, note string) (model.Withdrawal, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return model.Withdrawal{}, err
+	}
+	defer tx.Rollback()
+
+	withdrawal, err := scanWithdrawal(tx.QueryRow("SELECT "+withdrawalColumns+" FROM withdrawals WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		return model.Withdrawal{}, err
+	}
+	if !withdrawal.Status.CanTransitionTo(status) {
+		return withdrawal, model.ErrIllegalWithdrawalTransition
+	}
+	if status == model.WithdrawalStatusApproved {
+		// Refunds and chargebacks since the request may have taken the
+		// balance below it. The request itself still counts as requested.
+		if _, err := tx.Exec("SELECT id FROM campaigns WHERE id = $1 FOR UPDATE", withdrawal.CampaignID); err != nil {
+			return model.Withdrawal{}, err
+		}
+		available, err := availableBalance(tx, withdrawal.CampaignID)
+		if err != nil {
+			return model.Withdrawal{}, err
+		}
+		if available < 0 {
+			return withdrawal, ErrInsufficientBalance
+		}
+	}
+
+	err = tx.QueryRow("UPDATE withdrawals SET status = $1, reviewed_by = NULLIF($2, 0), note = $3, updated_at = NOW() WHERE id = $4 RETURNING updated_at",
+		status, reviewedBy, note, id).Scan(&withdrawal.UpdatedAt)
+	if err != nil {
+		return model.Withdrawal{}, err
+	}
+	withdrawal.Status, withdrawal.ReviewedBy, withdrawal.Note = status, reviewedBy, note
+
+	campaign := model.CampaignAccount(withdrawal.CampaignID)
+	var entries []model.LedgerEntry
+	switch status {
+	case model.WithdrawalStatusApproved:
+		entries = model.Transfer(campaign, model.LedgerAccountPayouts, withdrawal.Amount)
+	case model.WithdrawalStatusFailed:
+		entries = model.Transfer(model.LedgerAccountPayouts, campaign, withdrawal.Amount)
+	}
+	if entries != nil {
+		var currency model.Currency
+		if err := tx.QueryRow("SELECT currency FROM campaigns WHERE id = $1", withdrawal.CampaignID).Scan(&currency); err != nil {
+			return model.Withdrawal{}, err
+		}
+		_, err = postJournal(tx, model.LedgerJournal{
+			Kind:        model.JournalKindPayout,
+			Reference:   fmt.Sprintf("withdrawal:%d", withdrawal.ID),
+			Description: fmt.Sprintf("withdrawal %d %s", withdrawal.ID, status),
+			Currency:    currency,
+			Entries:     entries,
+		})
+		if err != nil {
+			return model.Withdrawal{}, err
+		}
+	}
+
+	return withdrawal, tx.Commit()
+}
+
+// CreateBatch puts every approved withdrawal into a new payout batch.
+func (r *withdrawalRepo) CreateBatch(createdBy int) (model.PayoutBatch, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return model.PayoutBatch{}, err
+	}
+	defer tx.Rollback()
+
+	var batch model.PayoutBatch
+	err = tx.QueryRow("INSERT INTO payout_batches (status, total, count, created_by, created_at) VALUES ($1, 0, 0, NULLIF($2, 0), NOW()) RETURNING id, created_at",
+		model.PayoutBatchStatusExported, createdBy).Scan(&batch.ID, &batch.CreatedAt)
+	if err != nil {
+		return model.PayoutBatch{}, err
+	}
+	batch.Status, batch.CreatedBy = model.PayoutBatchStatusExported, createdBy
+
+	rows, err := tx.Query("UPDATE withdrawals SET status = $1, batch_id = $2, updated_at = NOW() WHERE status = $3 RETURNING "+withdrawalColumns,
+		model.WithdrawalStatusProcessing, batch.ID, model.WithdrawalStatusApproved)
+	if err != nil {
+		return model.PayoutBatch{}, err
+	}
+	for rows.Next() {
+		withdrawal, err := scanWithdrawal(rows)
+		if err != nil {
+			rows.Close()
+			return model.PayoutBatch{}, err
+		}
+		batch.Withdrawals = append(batch.Withdrawals, withdrawal)
+		batch.Total += withdrawal.Amount
+	}
+	rows.Close()
+	if len(batch.Withdrawals) == 0 {
+		return model.PayoutBatch{}, ErrEmptyPayoutBatch
+	}
+	batch.Count = len(batch.Withdrawals)
+
+	if _, err := tx.Exec("UPDATE payout_batches SET total = $1, count = $2 WHERE id = $3", batch.Total, batch.Count, batch.ID); err != nil {
+		return model.PayoutBatch{}, err
+	}
+
+	return batch, tx.Commit()
+}
+
+func (r *withdrawalRepo) FindBatch(id int) (model.PayoutBatch, error) {
+	batch, err := scanPayoutBatch(r.db.QueryRow("SELECT "+payoutBatchColumns+" FROM payout_batches WHERE id = $1", id))
+	if err != nil {
+		return model.PayoutBatch{}, err
+	}
+	batch.Withdrawals, err = r.findMany("SELECT "+withdrawalColumns+" FROM withdrawals WHERE batch_id = $1 ORDER BY id", id)
+	if err != nil {
+		return model.PayoutBatch{}, err
+	}
+	return batch, nil
+}
+
+// FindBatchLines returns the transfers of a batch that still have to be
+// paid, with the bank details they go to.
+func (r *withdrawalRepo) FindBatchLines(batchID int) ([]model.PayoutLine, error) {
+	rows, err := r.db.Query(`SELECT w.id, b.bank_code, b.account_number, b.account_name, w.amount
+		FROM withdrawals w JOIN bank_accounts b ON b.id = w.bank_account_id
+		WHERE w.batch_id = $1 AND w.status = $2 ORDER BY w.id`, batchID, model.WithdrawalStatusProcessing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []model.PayoutLine
+	for rows.Next() {
+		var line model.PayoutLine
+		if err := rows.Scan(&line.WithdrawalID, &line.BankCode, &line.AccountNumber, &line.AccountName, &line.Amount); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// CompleteBatch marks the batch's outstanding withdrawals as paid and moves
+// their total from the payouts account out of gateway clearing. Withdrawals
+// that failed before completion are left as they are.
+func (r *withdrawalRepo) CompleteBatch(id int) (model.PayoutBatch, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return model.PayoutBatch{}, err
+	}
+	defer tx.Rollback()
+
+	batch, err := scanPayoutBatch(tx.QueryRow("SELECT "+payoutBatchColumns+" FROM payout_batches WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		return model.PayoutBatch{}, err
+	}
+	if batch.Status != model.PayoutBatchStatusExported {
+		return batch, ErrBatchNotExported
+	}
+
+	// A batch can pay campaigns in several currencies, and each one is
+	// journalled on its own.
+	rows, err := tx.Query(`WITH paid AS (
+			UPDATE withdrawals SET status = $1, updated_at = NOW() WHERE batch_id = $2 AND status = $3 RETURNING campaign_id, amount
+		) SELECT c.currency, SUM(p.amount) FROM paid p JOIN campaigns c ON c.id = p.campaign_id GROUP BY c.currency ORDER BY c.currency`,
+		model.WithdrawalStatusPaid, id, model.WithdrawalStatusProcessing)
+	if err != nil {
+		return model.PayoutBatch{}, err
+	}
+	var journals []model.LedgerJournal
+	for rows.Next() {
+		journal := model.LedgerJournal{
+			Kind:        model.JournalKindPayout,
+			Reference:   fmt.Sprintf("payout_batch:%d", id),
+			Description: fmt.Sprintf("payout batch %d transferred", id),
+		}
+		var paid int
+		if err := rows.Scan(&journal.Currency, &paid); err != nil {
+			rows.Close()
+			return model.PayoutBatch{}, err
+		}
+		journal.Entries = model.Transfer(model.LedgerAccountPayouts, model.LedgerAccountGatewayClearing, paid)
+		journals = append(journals, journal)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return model.PayoutBatch{}, err
+	}
+
+	for _, journal := range journals {
+		if _, err := postJournal(tx, journal); err != nil {
+			return model.PayoutBatch{}, err
+		}
+	}
+
+	err = tx.QueryRow("UPDATE payout_batches SET status = $1, completed_at = NOW() WHERE id = $2 RETURNING completed_at",
+		model.PayoutBatchStatusCompleted, id).Scan(&batch.CompletedAt)
+	if err != nil {
+		return model.PayoutBatch{}, err
+	}
+	batch.Status = model.PayoutBatchStatusCompleted
+
+	return batch, tx.Commit()
+}
+
+func (r *withdrawalRepo) findMany(query string, args ...any) ([]model.Withdrawal, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var withdrawals []model.Withdrawal
+	for rows.Next() {
+		withdrawal, err := scanWithdrawal(rows)
+		if err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	return withdrawals, nil
+}
+
+type WithdrawalRepo interface {
+	AvailableBalance(campaignID int) (int, error)
+	Create(withdrawal model.Withdrawal) (model.Withdrawal, error)
+	FindByID(id int) (model.Withdrawal, error)
+	FindByCampaignID(campaignID int) ([]model.Withdrawal, error)
+	FindByStatus(status model.WithdrawalStatus) ([]model.Withdrawal, error)
+	UpdateStatus(id int, status model.WithdrawalStatus, reviewedBy int, note string) (model.Withdrawal, error)
+	CreateBatch(createdBy int) (model.PayoutBatch, error)
+	FindBatch(id int) (model.PayoutBatch, error)
+	FindBatchLines(batchID int) ([]model.PayoutLine, error)
+	CompleteBatch(id int) (model.PayoutBatch, error)
+}
+
+func NewWithdrawalRepo(db *sql.DB) WithdrawalRepo {
+	return &withdrawalRepo{db: db}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"eternal-fund/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type WithdrawalRepoTestSuite struct {
+	suite.Suite
+	mockDB  *sql.DB
+	mockSql sqlmock.Sqlmock
+	repo    WithdrawalRepo
+}
+
+func (suite *WithdrawalRepoTestSuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.mockDB = db
+	suite.mockSql = mock
+	suite.repo = NewWithdrawalRepo(suite.mockDB)
+}
+
+func withdrawalRow(withdrawal model.Withdrawal) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "campaign_id", "bank_account_id", "amount", "status", "requested_by", "reviewed_by", "note",
+		"batch_id", "created_at", "updated_at"}).
+		AddRow(withdrawal.ID, withdrawal.CampaignID, withdrawal.BankAccountID, withdrawal.Amount, withdrawal.Status, withdrawal.RequestedBy,
+			withdrawal.ReviewedBy, withdrawal.Note, withdrawal.BatchID, withdrawal.CreatedAt, withdrawal.UpdatedAt)
+}
+
+func (suite *WithdrawalRepoTestSuite) TestCreate_InsufficientBalance() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("SELECT id FROM campaigns WHERE id = $1 FOR UPDATE")).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM ledger_entries WHERE account = $1")).
+		WithArgs("campaign:2", 2, model.WithdrawalStatusRequested, model.RefundStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"available"}).AddRow(30000))
+	suite.mockSql.ExpectRollback()
+
+	_, err := suite.repo.Create(model.Withdrawal{CampaignID: 2, BankAccountID: 6, Amount: 50000, RequestedBy: 4})
+	assert.ErrorIs(suite.T(), err, ErrInsufficientBalance)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *WithdrawalRepoTestSuite) TestUpdateStatus_Approved() {
+	now := time.Now()
+	requested := model.Withdrawal{ID: 1, CampaignID: 2, BankAccountID: 6, Amount: 50000, Status: model.WithdrawalStatusRequested,
+		RequestedBy: 4, CreatedAt: now, UpdatedAt: now}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM withdrawals WHERE id = $1 FOR UPDATE")).
+		WithArgs(1).
+		WillReturnRows(withdrawalRow(requested))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("SELECT id FROM campaigns WHERE id = $1 FOR UPDATE")).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM ledger_entries WHERE account = $1")).
+		WithArgs("campaign:2", 2, model.WithdrawalStatusRequested, model.RefundStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"available"}).AddRow(0))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE withdrawals SET status = $1")).
+		WithArgs(model.WithdrawalStatusApproved, 9, "ok", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("SELECT currency FROM campaigns WHERE id = $1")).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("SGD"))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO ledger_journals")).
+		WithArgs(model.JournalKindPayout, "withdrawal:1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, now))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO ledger_entries")).
+		WithArgs(5, "campaign:2", 50000, model.CurrencySGD, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO ledger_entries")).
+		WithArgs(5, model.LedgerAccountPayouts, -50000, model.CurrencySGD, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	suite.mockSql.ExpectCommit()
+
+	withdrawal, err := suite.repo.UpdateStatus(1, model.WithdrawalStatusApproved, 9, "ok")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.WithdrawalStatusApproved, withdrawal.Status)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *WithdrawalRepoTestSuite) TestUpdateStatus_ApprovalRechecksBalance() {
+	requested := model.Withdrawal{ID: 1, CampaignID: 2, Amount: 50000, Status: model.WithdrawalStatusRequested}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM withdrawals WHERE id = $1 FOR UPDATE")).
+		WithArgs(1).
+		WillReturnRows(withdrawalRow(requested))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("SELECT id FROM campaigns WHERE id = $1 FOR UPDATE")).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// A refund since the request left 20000 of it uncovered.
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM ledger_entries WHERE account = $1")).
+		WithArgs("campaign:2", 2, model.WithdrawalStatusRequested, model.RefundStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"available"}).AddRow(-20000))
+	suite.mockSql.ExpectRollback()
+
+	_, err := suite.repo.UpdateStatus(1, model.WithdrawalStatusApproved, 9, "")
+	assert.ErrorIs(suite.T(), err, ErrInsufficientBalance)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *WithdrawalRepoTestSuite) TestUpdateStatus_AlreadyApproved() {
+	approved := model.Withdrawal{ID: 1, CampaignID: 2, Amount: 50000, Status: model.WithdrawalStatusApproved}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM withdrawals WHERE id = $1 FOR UPDATE")).
+		WithArgs(1).
+		WillReturnRows(withdrawalRow(approved))
+	suite.mockSql.ExpectRollback()
+
+	_, err := suite.repo.UpdateStatus(1, model.WithdrawalStatusApproved, 9, "")
+	assert.ErrorIs(suite.T(), err, model.ErrIllegalWithdrawalTransition)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *WithdrawalRepoTestSuite) TestCompleteBatch_JournalsEachCurrency() {
+	now := time.Now()
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("FROM payout_batches WHERE id = $1 FOR UPDATE")).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "total", "count", "created_by", "created_at", "completed_at"}).
+			AddRow(3, model.PayoutBatchStatusExported, 80000, 2, 9, now, nil))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE withdrawals SET status = $1")).
+		WithArgs(model.WithdrawalStatusPaid, 3, model.WithdrawalStatusProcessing).
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "sum"}).AddRow("IDR", 50000).AddRow("USD", 30000))
+	for i, currency := range []model.Currency{model.CurrencyIDR, model.CurrencyUSD} {
+		amount := []int{50000, 30000}[i]
+		suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO ledger_journals")).
+			WithArgs(model.JournalKindPayout, "payout_batch:3", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7+i, now))
+		suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO ledger_entries")).
+			WithArgs(7+i, model.LedgerAccountPayouts, amount, currency, now).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO ledger_entries")).
+			WithArgs(7+i, model.LedgerAccountGatewayClearing, -amount, currency, now).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	}
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("UPDATE payout_batches SET status = $1, completed_at = NOW()")).
+		WithArgs(model.PayoutBatchStatusCompleted, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"completed_at"}).AddRow(now))
+	suite.mockSql.ExpectCommit()
+
+	batch, err := suite.repo.CompleteBatch(3)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.PayoutBatchStatusCompleted, batch.Status)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func TestWithdrawalRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(WithdrawalRepoTestSuite))
+}
@@ -5,6 +5,7 @@ import (
 	"eternal-fund/config"
 	"eternal-fund/controller"
 	"eternal-fund/middleware"
+	"eternal-fund/model"
 	"eternal-fund/repository"
 	"eternal-fund/usecase"
 	"eternal-fund/usecase/service"
@@ -19,22 +20,65 @@ type Server struct {
 	campaignsUC   usecase.CampaignsUseCase
 	authUc        usecase.AuthUseCase
 	transactionUC usecase.TransactionUseCase
+	trendingUC    usecase.TrendingUseCase
+	memberUC      usecase.CampaignMemberUseCase
+	retentionUC   usecase.RetentionUseCase
+	idempotencyUC usecase.IdempotencyUseCase
+	ledgerUC      usecase.LedgerUseCase
+	payoutUC      usecase.PayoutUseCase
+	recurringUC   usecase.RecurringUseCase
+	reconcileUC   usecase.ReconciliationUseCase
+	receiptUC     usecase.ReceiptUseCase
+	statementUC   usecase.StatementUseCase
+	exchangeUC    usecase.ExchangeRateUseCase
+	riskReviewUC  usecase.RiskReviewUseCase
+	matchingUC    usecase.MatchingUseCase
 	jwtService    service.JwtService
+	payment       service.PaymentProvider
 	engine        *gin.Engine
+	scheduler     config.SchedulerConfig
 }
 
 func (s *Server) initRoute() {
+	authMiddleware := middleware.NewAuthMiddleware(s.jwtService)
+
 	rg := s.engine.Group("/api/v1")
+	rg.Use(authMiddleware.Identify(), middleware.NewIdempotencyMiddleware(s.idempotencyUC, s.jwtService).Check())
 
-	authMiddleware := middleware.NewAuthMiddleware(s.jwtService)
 	controller.NewUserController(s.userUC, rg, authMiddleware).Routing()
 	controller.NewCampaignsController(s.campaignsUC, rg, authMiddleware).Routing()
 	controller.NewAuthController(s.authUc, rg).Route()
 	controller.NewTransactionController(s.transactionUC, rg, authMiddleware).Routing()
+	controller.NewTrendingController(s.trendingUC, rg, authMiddleware).Routing()
+	controller.NewCampaignMemberController(s.memberUC, rg, authMiddleware).Routing()
+	controller.NewLedgerController(s.ledgerUC, rg, authMiddleware).Routing()
+	controller.NewPayoutController(s.payoutUC, rg, authMiddleware).Routing()
+	controller.NewRecurringController(s.recurringUC, rg, authMiddleware).Routing()
+	controller.NewReconciliationController(s.reconcileUC, rg, authMiddleware).Routing()
+	controller.NewReceiptController(s.receiptUC, rg, authMiddleware).Routing()
+	controller.NewStatementController(s.statementUC, rg, authMiddleware).Routing()
+	controller.NewExchangeRateController(s.exchangeUC, rg, authMiddleware).Routing()
+	controller.NewRiskReviewController(s.riskReviewUC, rg, authMiddleware).Routing()
+	controller.NewMatchingController(s.matchingUC, rg, authMiddleware).Routing()
+
+	if fakeGateway, ok := s.payment.(service.FakeGateway); ok {
+		controller.NewFakeGatewayController(fakeGateway, s.engine.Group("/fake-gateway")).Routing()
+	}
+}
+
+func (s *Server) startJobs() {
+	go s.trendingUC.StartRefresher(s.scheduler.TrendingInterval)
+	go s.retentionUC.StartPurger(s.scheduler.PurgeInterval)
+	go s.idempotencyUC.StartPurger(s.scheduler.PurgeInterval)
+	go s.ledgerUC.StartChecker(s.scheduler.LedgerCheckInterval)
+	go s.recurringUC.StartBiller(s.scheduler.RecurringInterval)
+	go s.reconcileUC.StartReconciler(s.scheduler.ReconcileInterval)
+	go s.receiptUC.StartIssuer(s.scheduler.ReceiptInterval)
 }
 
 func (s *Server) Run() {
 	s.initRoute()
+	s.startJobs()
 
 	s.engine.Run(":2000")
 }
@@ -51,25 +95,95 @@ func NewServer() *Server {
 		panic("connection Error")
 	}
 	userRepo := repository.NewUserRepo(database)
-	userUC := usecase.NewUserUseCase(userRepo)
+	mailService := service.NewMailService(c.MailConfig)
+	jwtService := service.NewJwtService(c.TokenConfig)
+	userUC := usecase.NewUserUseCase(userRepo, mailService, jwtService, c.BaseURL)
 
 	campaignsRepo := repository.NewCampaignsRepo(database)
-	campaignsUseCase := usecase.NewCampaignsUseCase(campaignsRepo, userRepo)
+	campaignMemberRepo := repository.NewCampaignMemberRepo(database)
+	campaignVersionRepo := repository.NewCampaignVersionRepo(database)
+	matchingRepo := repository.NewMatchingRepo(database)
+	paymentProvider, err := service.NewPaymentProvider(c.PaymentConfig, c.MidtransConfig, c.BaseURL)
+	if err != nil {
+		panic(err)
+	}
+	campaignsUseCase := usecase.NewCampaignsUseCase(campaignsRepo, userRepo, campaignMemberRepo, campaignVersionRepo, matchingRepo, paymentProvider)
+	matchingUC := usecase.NewMatchingUseCase(matchingRepo, campaignsRepo)
+	memberUC := usecase.NewCampaignMemberUseCase(campaignMemberRepo, campaignsRepo, userRepo, mailService, c.BaseURL)
 
-	jwtService := service.NewJwtService(c.TokenConfig)
 	authUseCase := usecase.NewAuthUseCase(jwtService, userUC)
 
 	transactionRepo := repository.NewTransactionRepo(database)
-	paymentService := service.NewPaymentService()
-	transactionUC := usecase.NewTransactionUseCase(transactionRepo, campaignsRepo, paymentService)
+	paymentNotificationRepo := repository.NewPaymentNotificationRepo(database)
+	refundRepo := repository.NewRefundRepo(database)
+	fees := model.FeeSchedule{
+		PlatformBasisPoints: c.PlatformFeeBasisPoints,
+		GatewayFees:         map[model.Currency]model.GatewayFees{},
+		DonorCoversFees:     c.DonorCoversFees,
+	}
+	for code, fee := range c.DefaultGatewayFees {
+		fees.GatewayFees[model.Currency(code)] = model.GatewayFees{Default: fee}
+	}
+	for code, byMethod := range c.GatewayFees {
+		gatewayFees := fees.GatewayFees[model.Currency(code)]
+		gatewayFees.ByMethod = byMethod
+		fees.GatewayFees[model.Currency(code)] = gatewayFees
+	}
+	exchangeUC := usecase.NewExchangeRateUseCase(repository.NewExchangeRateRepo(database))
+	riskRepo := repository.NewRiskRepo(database)
+	riskEngine := usecase.NewRiskEngine(riskRepo, userRepo, c.VelocityWindow, c.MaxPerDonor, c.MaxPerIP, c.ReviewScore)
+	transactionUC := usecase.NewTransactionUseCase(transactionRepo, campaignsRepo, paymentNotificationRepo, refundRepo, campaignMemberRepo, userRepo,
+		paymentProvider, mailService, fees, jwtService, exchangeUC, riskEngine, c.BaseURL)
+	riskReviewUC := usecase.NewRiskReviewUseCase(transactionRepo, riskRepo, transactionUC, userRepo, mailService)
+
+	campaignRankingRepo := repository.NewCampaignRankingRepo(database)
+	trendingUC := usecase.NewTrendingUseCase(campaignRankingRepo, campaignsRepo, exchangeUC)
+
+	retentionUC := usecase.NewRetentionUseCase(campaignsRepo, userRepo, c.SoftDeleteRetention)
+
+	ledgerUC := usecase.NewLedgerUseCase(repository.NewLedgerRepo(database))
+	payoutUC := usecase.NewPayoutUseCase(repository.NewBankAccountRepo(database), repository.NewWithdrawalRepo(database), campaignMemberRepo)
+	recurringUC := usecase.NewRecurringUseCase(repository.NewRecurringRepo(database), userRepo, transactionUC, mailService,
+		c.RecurringRetryDelays)
+	reconcileUC := usecase.NewReconciliationUseCase(repository.NewReconciliationRepo(database), transactionRepo, transactionUC, paymentProvider,
+		c.ReconcileAfter, c.PaymentWindow)
+	blobStorage := service.NewBlobStorage(c.StorageConfig)
+	receiptUC := usecase.NewReceiptUseCase(repository.NewReceiptRepo(database), transactionRepo, refundRepo, campaignsRepo, userRepo,
+		blobStorage, mailService, c.Issuer, c.FiscalYearStart)
+	statementUC := usecase.NewStatementUseCase(transactionRepo, refundRepo, campaignsRepo, userRepo, blobStorage, c.Issuer, c.FiscalYearStart)
+
+	idempotencyRepo := repository.NewIdempotencyRepo(database)
+	idempotencyUC := usecase.NewIdempotencyUseCase(idempotencyRepo, c.IdempotencyRetention)
+
+	// Client addresses limit donations per IP, so X-Forwarded-For is only
+	// read from the proxies in front of the API.
+	engine := gin.Default()
+	if err := engine.SetTrustedProxies(c.TrustedProxies); err != nil {
+		panic(err)
+	}
 
 	return &Server{
 		userUC:        userUC,
 		campaignsUC:   campaignsUseCase,
 		transactionUC: transactionUC,
-		engine:        gin.Default(),
+		trendingUC:    trendingUC,
+		memberUC:      memberUC,
+		retentionUC:   retentionUC,
+		idempotencyUC: idempotencyUC,
+		ledgerUC:      ledgerUC,
+		payoutUC:      payoutUC,
+		recurringUC:   recurringUC,
+		reconcileUC:   reconcileUC,
+		receiptUC:     receiptUC,
+		statementUC:   statementUC,
+		exchangeUC:    exchangeUC,
+		riskReviewUC:  riskReviewUC,
+		matchingUC:    matchingUC,
+		engine:        engine,
 		jwtService:    jwtService,
+		payment:       paymentProvider,
 		authUc:        authUseCase,
+		scheduler:     c.SchedulerConfig,
 	}
 
 }
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"eternal-fund/usecase/service"
+	"fmt"
+	"log"
+	"strings"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrAlreadyMember      = errors.New("email is already a member of this campaign")
+	ErrLastOwner          = errors.New("a campaign must keep at least one owner")
+
+	ErrInvitationEmailNotVerified = errors.New("verify your email before answering invitations")
+)
+
+// authorizeCampaign checks that user has one of roles on the campaign.
+// Platform admins are always allowed.
+func authorizeCampaign(memberRepo repository.CampaignMemberRepo, campaignID int, user model.User, roles ...string) error {
+	if user.Role == "admin" {
+		return nil
+	}
+
+	member, err := memberRepo.FindMembership(campaignID, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCampaignForbidden
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if member.Role == role {
+			return nil
+		}
+	}
+	return ErrCampaignForbidden
+}
+
+type campaignMemberUseCase struct {
+	memberRepo    repository.CampaignMemberRepo
+	campaignsRepo repository.CampaignsRepo
+	userRepo      repository.UserRepo
+	mailService   service.MailService
+	baseURL       string
+}
+
+func (m *campaignMemberUseCase) InviteMember(input model.InviteMemberInput) (model.CampaignMember, error) {
+	if err := authorizeCampaign(m.memberRepo, input.CampaignID, input.User, model.CampaignRoleOwner); err != nil {
+		return model.CampaignMember{}, err
+	}
+
+	campaign, err := m.campaignsRepo.FindByIdCampaigns(input.CampaignID)
+	if err != nil {
+		return model.CampaignMember{}, err
+	}
+
+	existing, err := m.memberRepo.FindByCampaignAndEmail(input.CampaignID, input.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.CampaignMember{}, err
+	}
+
+	var member model.CampaignMember
+	switch {
+	case err == nil && existing.Status != model.MemberStatusDeclined:
+		return model.CampaignMember{}, ErrAlreadyMember
+	case err == nil:
+		// A declined invitation can be sent again.
+		existing.Role = input.Role
+		existing.Status = model.MemberStatusInvited
+		existing.InvitedBy = input.User.ID
+		member, err = m.memberRepo.Update(existing)
+	default:
+		member, err = m.memberRepo.Save(model.CampaignMember{
+			CampaignID: input.CampaignID,
+			Email:      strings.ToLower(input.Email),
+			Role:       input.Role,
+			Status:     model.MemberStatusInvited,
+			InvitedBy:  input.User.ID,
+		})
+	}
+	if err != nil {
+		return model.CampaignMember{}, err
+	}
+
+	// Accepting and declining need the invitee to be signed in, so the
+	// email points to the invitation rather than linking to either action.
+	body := fmt.Sprintf("You have been invited to join the campaign %q as %s.\n\n"+
+		"Sign in to Eternal Fund at %s with this email address to accept or decline it. "+
+		"You will find it among your pending invitations as invitation #%d.\n",
+		campaign.Name, member.Role, m.baseURL, member.ID)
+	if err := m.mailService.Send(member.Email, "Invitation to manage "+campaign.Name, body); err != nil {
+		log.Println("Error sending invitation email:", err)
+	}
+
+	return member, nil
+}
+
+func (m *campaignMemberUseCase) ListMembers(campaignID int, user model.User) ([]model.CampaignMember, error) {
+	err := authorizeCampaign(m.memberRepo, campaignID, user, model.CampaignRoleOwner, model.CampaignRoleEditor, model.CampaignRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return m.memberRepo.FindByCampaignID(campaignID)
+}
+
+func (m *campaignMemberUseCase) ListInvitations(userID int) ([]model.CampaignMember, error) {
+	user, err := m.userRepo.FindById(userID)
+	if err != nil {
+		return nil, err
+	}
+	return m.memberRepo.FindPendingByEmail(user.Email)
+}
+
+func (m *campaignMemberUseCase) AcceptInvitation(invitationID int, userID int) (model.CampaignMember, error) {
+	return m.respondInvitation(invitationID, userID, model.MemberStatusAccepted)
+}
+
+func (m *campaignMemberUseCase) DeclineInvitation(invitationID int, userID int) (model.CampaignMember, error) {
+	return m.respondInvitation(invitationID, userID, model.MemberStatusDeclined)
+}
+
+func (m *campaignMemberUseCase) respondInvitation(invitationID int, userID int, status string) (model.CampaignMember, error) {
+	member, err := m.memberRepo.FindByID(invitationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.CampaignMember{}, ErrInvitationNotFound
+	}
+	if err != nil {
+		return model.CampaignMember{}, err
+	}
+
+	user, err := m.userRepo.FindById(userID)
+	if err != nil {
+		return model.CampaignMember{}, err
+	}
+
+	// Invitations are addressed to an email, so only that account may answer.
+	if member.Status != model.MemberStatusInvited || !strings.EqualFold(member.Email, user.Email) {
+		return model.CampaignMember{}, ErrInvitationNotFound
+	}
+	// The email only proves who the account is once it has been verified.
+	if user.EmailVerifiedAt == nil {
+		return model.CampaignMember{}, ErrInvitationEmailNotVerified
+	}
+
+	member.Status = status
+	if status == model.MemberStatusAccepted {
+		member.UserID = user.ID
+	}
+
+	return m.memberRepo.Update(member)
+}
+
+func (m *campaignMemberUseCase) RemoveMember(campaignID int, memberID int, user model.User) error {
+	if err := authorizeCampaign(m.memberRepo, campaignID, user, model.CampaignRoleOwner); err != nil {
+		return err
+	}
+
+	member, err := m.memberRepo.FindByID(memberID)
+	if err != nil {
+		return err
+	}
+	if member.CampaignID != campaignID {
+		return sql.ErrNoRows
+	}
+
+	if member.Role == model.CampaignRoleOwner && member.Status == model.MemberStatusAccepted {
+		owners, err := m.memberRepo.CountOwners(campaignID)
+		if err != nil {
+			return err
+		}
+		if owners <= 1 {
+			return ErrLastOwner
+		}
+	}
+
+	return m.memberRepo.Delete(memberID)
+}
+
+type CampaignMemberUseCase interface {
+	InviteMember(input model.InviteMemberInput) (model.CampaignMember, error)
+	ListMembers(campaignID int, user model.User) ([]model.CampaignMember, error)
+	ListInvitations(userID int) ([]model.CampaignMember, error)
+	AcceptInvitation(invitationID int, userID int) (model.CampaignMember, error)
+	DeclineInvitation(invitationID int, userID int) (model.CampaignMember, error)
+	RemoveMember(campaignID int, memberID int, user model.User) error
+}
+
+func NewCampaignMemberUseCase(memberRepo repository.CampaignMemberRepo, campaignsRepo repository.CampaignsRepo, userRepo repository.UserRepo,
+	mailService service.MailService, baseURL string) CampaignMemberUseCase {
+	return &campaignMemberUseCase{
+		memberRepo:    memberRepo,
+		campaignsRepo: campaignsRepo,
+		userRepo:      userRepo,
+		mailService:   mailService,
+		baseURL:       baseURL,
+	}
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"database/sql"
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type CampaignMemberUseCaseTestSuite struct {
+	suite.Suite
+	muc          *campaignMemberUseCase
+	memberRepo   *mocking.CampaignMemberRepoMock
+	campaignRepo *mocking.CampaignRepoMock
+	userRepo     *mocking.UserRepoMock
+	mailService  *mocking.MailServiceMock
+}
+
+func (suite *CampaignMemberUseCaseTestSuite) SetupTest() {
+	suite.memberRepo = new(mocking.CampaignMemberRepoMock)
+	suite.campaignRepo = new(mocking.CampaignRepoMock)
+	suite.userRepo = new(mocking.UserRepoMock)
+	suite.mailService = new(mocking.MailServiceMock)
+	suite.muc = &campaignMemberUseCase{
+		memberRepo:    suite.memberRepo,
+		campaignsRepo: suite.campaignRepo,
+		userRepo:      suite.userRepo,
+		mailService:   suite.mailService,
+		baseURL:       "http://localhost:8080",
+	}
+}
+
+func (suite *CampaignMemberUseCaseTestSuite) TestInviteMember() {
+	input := model.InviteMemberInput{CampaignID: 1, Email: "Editor@Example.com", Role: model.CampaignRoleEditor, User: model.User{ID: 1}}
+	expected := model.CampaignMember{CampaignID: 1, Email: "editor@example.com", Role: model.CampaignRoleEditor,
+		Status: model.MemberStatusInvited, InvitedBy: 1}
+	saved := expected
+	saved.ID = 5
+
+	suite.memberRepo.On("FindMembership", 1, 1).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
+	suite.campaignRepo.On("FindByIdCampaigns", 1).Return(model.Campaigns{ID: 1, Name: "Clean Water"}, nil)
+	suite.memberRepo.On("FindByCampaignAndEmail", 1, input.Email).Return(model.CampaignMember{}, sql.ErrNoRows)
+	suite.memberRepo.On("Save", expected).Return(saved, nil)
+	suite.mailService.On("Send", "editor@example.com", "Invitation to manage Clean Water", mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "invitation #5") && !strings.Contains(body, "/accept") && !strings.Contains(body, "/decline")
+	})).Return(nil)
+
+	member, err := suite.muc.InviteMember(input)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), saved, member)
+	suite.memberRepo.AssertExpectations(suite.T())
+	suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *CampaignMemberUseCaseTestSuite) TestInviteMember_EditorForbidden() {
+	input := model.InviteMemberInput{CampaignID: 1, Email: "viewer@example.com", Role: model.CampaignRoleViewer, User: model.User{ID: 2}}
+	suite.memberRepo.On("FindMembership", 1, 2).Return(model.CampaignMember{Role: model.CampaignRoleEditor}, nil)
+
+	_, err := suite.muc.InviteMember(input)
+	assert.ErrorIs(suite.T(), err, ErrCampaignForbidden)
+	suite.memberRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *CampaignMemberUseCaseTestSuite) TestInviteMember_AlreadyMember() {
+	input := model.InviteMemberInput{CampaignID: 1, Email: "editor@example.com", Role: model.CampaignRoleEditor, User: model.User{ID: 1, Role: "admin"}}
+	suite.campaignRepo.On("FindByIdCampaigns", 1).Return(model.Campaigns{ID: 1}, nil)
+	suite.memberRepo.On("FindByCampaignAndEmail", 1, input.Email).Return(model.CampaignMember{ID: 3, Status: model.MemberStatusAccepted}, nil)
+
+	_, err := suite.muc.InviteMember(input)
+	assert.ErrorIs(suite.T(), err, ErrAlreadyMember)
+}
+
+func (suite *CampaignMemberUseCaseTestSuite) TestAcceptInvitation() {
+	invitation := model.CampaignMember{ID: 5, CampaignID: 1, Email: "editor@example.com", Role: model.CampaignRoleEditor, Status: model.MemberStatusInvited}
+	expected := invitation
+	expected.UserID = 7
+	expected.Status = model.MemberStatusAccepted
+
+	suite.memberRepo.On("FindByID", 5).Return(invitation, nil)
+	verifiedAt := time.Now()
+	suite.userRepo.On("FindById", 7).Return(model.User{ID: 7, Email: "Editor@example.com", EmailVerifiedAt: &verifiedAt}, nil)
+	suite.memberRepo.On("Update", expected).Return(expected, nil)
+
+	member, err := suite.muc.AcceptInvitation(5, 7)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), expected, member)
+}
+
+func (suite *CampaignMemberUseCaseTestSuite) TestAcceptInvitation_EmailNotVerified() {
+	invitation := model.CampaignMember{ID: 5, CampaignID: 1, Email: "editor@example.com", Status: model.MemberStatusInvited}
+	suite.memberRepo.On("FindByID", 5).Return(invitation, nil)
+	suite.userRepo.On("FindById", 7).Return(model.User{ID: 7, Email: "editor@example.com"}, nil)
+
+	_, err := suite.muc.AcceptInvitation(5, 7)
+	assert.ErrorIs(suite.T(), err, ErrInvitationEmailNotVerified)
+	suite.memberRepo.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *CampaignMemberUseCaseTestSuite) TestAcceptInvitation_OtherEmail() {
+	invitation := model.CampaignMember{ID: 5, CampaignID: 1, Email: "editor@example.com", Status: model.MemberStatusInvited}
+	suite.memberRepo.On("FindByID", 5).Return(invitation, nil)
+	suite.userRepo.On("FindById", 8).Return(model.User{ID: 8, Email: "someone@example.com"}, nil)
+
+	_, err := suite.muc.AcceptInvitation(5, 8)
+	assert.ErrorIs(suite.T(), err, ErrInvitationNotFound)
+	suite.memberRepo.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *CampaignMemberUseCaseTestSuite) TestRemoveMember_LastOwner() {
+	suite.memberRepo.On("FindMembership", 1, 1).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
+	suite.memberRepo.On("FindByID", 2).Return(model.CampaignMember{ID: 2, CampaignID: 1, Role: model.CampaignRoleOwner,
+		Status: model.MemberStatusAccepted}, nil)
+	suite.memberRepo.On("CountOwners", 1).Return(1, nil)
+
+	err := suite.muc.RemoveMember(1, 2, model.User{ID: 1})
+	assert.ErrorIs(suite.T(), err, ErrLastOwner)
+	suite.memberRepo.AssertNotCalled(suite.T(), "Delete", mock.Anything)
+}
+
+func TestCampaignMemberUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(CampaignMemberUseCaseTestSuite))
+}
@@ -1,17 +1,35 @@
 package usecase
 
 import (
+	"database/sql"
+	"errors"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
 	"eternal-fund/repository"
+	"eternal-fund/usecase/service"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gosimple/slug"
 )
 
+var (
+	ErrCampaignForbidden    = errors.New("you are not allowed to manage this campaign")
+	ErrCampaignHasDonations = errors.New("campaigns with donations cannot be deleted")
+)
+
+// slugAttempts is how often a campaign is saved under a fresh slug before
+// giving up on concurrent campaigns taking each one first.
+const slugAttempts = 3
+
 type campaignsUseCase struct {
-	campaignsRepo repository.CampaignsRepo
-	userRepo      repository.UserRepo
+	campaignsRepo   repository.CampaignsRepo
+	userRepo        repository.UserRepo
+	memberRepo      repository.CampaignMemberRepo
+	versionRepo     repository.CampaignVersionRepo
+	matchingRepo    repository.MatchingRepo
+	paymentProvider service.PaymentProvider
 }
 
 func (a *campaignsUseCase) CreateCampaigns(input model.Campaigns) (model.Campaigns, error) {
@@ -22,11 +40,23 @@ func (a *campaignsUseCase) CreateCampaigns(input model.Campaigns) (model.Campaig
 	campaign.Perks = input.Perks
 	campaign.Goal_amount = input.Goal_amount
 	campaign.User_id = input.User_id
+	// The currency is fixed when the campaign is created, since its
+	// amounts are kept in it.
+	currency, err := model.ParseCurrency(string(input.Currency))
+	if err != nil {
+		return model.Campaigns{}, err
+	}
+	if !a.paymentProvider.SupportsCurrency(currency) {
+		return model.Campaigns{}, service.ErrUnsupportedCurrency
+	}
+	campaign.Currency = currency
+	campaign.Min_donation = input.Min_donation
+	campaign.Max_donation = input.Max_donation
+	if err := campaign.ValidateDonationLimits(); err != nil {
+		return model.Campaigns{}, err
+	}
 
-	slugCandidate := fmt.Sprintf("%s %d", input.Name, input.User_id)
-	campaign.Slug = slug.Make(slugCandidate)
-
-	newCampaign, err := a.campaignsRepo.CreateCampaigns(campaign)
+	newCampaign, err := a.saveWithUniqueSlug(campaign, a.campaignsRepo.CreateCampaigns)
 	if err != nil {
 		return newCampaign, err
 	}
@@ -35,6 +65,23 @@ func (a *campaignsUseCase) CreateCampaigns(input model.Campaigns) (model.Campaig
 		return newCampaign, err
 	}
 
+	_, err = a.memberRepo.Save(model.CampaignMember{
+		CampaignID: newCampaign.ID,
+		UserID:     user.ID,
+		Email:      strings.ToLower(user.Email),
+		Role:       model.CampaignRoleOwner,
+		Status:     model.MemberStatusAccepted,
+		InvitedBy:  user.ID,
+	})
+	if err != nil {
+		return newCampaign, err
+	}
+
+	err = a.saveVersion(model.Campaigns{ID: newCampaign.ID}, newCampaign, user.ID, "created")
+	if err != nil {
+		return newCampaign, err
+	}
+
 	newCampaign.User = user
 
 	return newCampaign, nil
@@ -46,13 +93,15 @@ func (a *campaignsUseCase) FindAllCampaigns(page int, size int) ([]model.Campaig
 		return nil, dto.Paging{}, err
 	}
 	for i := range campaigns {
-		user, err := a.userRepo.FindById(campaigns[i].User_id)
+		user, err := a.findOwner(campaigns[i].User_id)
 		if err != nil {
 			return nil, dto.Paging{}, err
 		}
-		user.PasswordHash = ""
 		campaigns[i].User = user
 	}
+	if err := a.withPageMatching(campaigns); err != nil {
+		return nil, dto.Paging{}, err
+	}
 
 	return campaigns, paging, nil
 }
@@ -62,54 +111,295 @@ func (a *campaignsUseCase) FindByIdCampaigns(inputID int) (model.Campaigns, erro
 	if err != nil {
 		return model.Campaigns{}, err
 	}
-	user, err := a.userRepo.FindById(campaign.User_id)
+	user, err := a.findOwner(campaign.User_id)
 	if err != nil {
 		return model.Campaigns{}, err
 	}
-	user.PasswordHash = ""
 
 	campaign.User = user
+	if err := a.withMatching(&campaign); err != nil {
+		return model.Campaigns{}, err
+	}
 
 	return campaign, nil
 
 }
 
-func (a *campaignsUseCase) UpdateCampaigns(id int) (model.Campaigns, error) {
-	inputData := model.Campaigns{}
+func (a *campaignsUseCase) FindBySlug(campaignSlug string) (model.Campaigns, error) {
+	campaign, err := a.campaignsRepo.FindBySlug(campaignSlug)
+	if errors.Is(err, sql.ErrNoRows) {
+		campaignID, historyErr := a.campaignsRepo.FindCampaignIDBySlugHistory(campaignSlug)
+		if historyErr != nil {
+			return model.Campaigns{}, historyErr
+		}
+		campaign, err = a.campaignsRepo.FindByIdCampaigns(campaignID)
+	}
+	if err != nil {
+		return model.Campaigns{}, err
+	}
+
+	user, err := a.findOwner(campaign.User_id)
+	if err != nil {
+		return model.Campaigns{}, err
+	}
+
+	campaign.User = user
+	if err := a.withMatching(&campaign); err != nil {
+		return model.Campaigns{}, err
+	}
+
+	return campaign, nil
+}
+
+// withMatching fills in what the campaign's matching pledges do for a gift
+// made now.
+func (a *campaignsUseCase) withMatching(campaign *model.Campaigns) error {
+	pledges, err := a.matchingRepo.FindByCampaign(campaign.ID)
+	if err != nil {
+		return err
+	}
+	campaign.Matching = model.SummarizeMatching(pledges, time.Now())
+	return nil
+}
+
+// withPageMatching fills in the matching of a page of campaigns from one
+// query for all their pledges.
+func (a *campaignsUseCase) withPageMatching(campaigns []model.Campaigns) error {
+	if len(campaigns) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		ids = append(ids, campaign.ID)
+	}
+	pledges, err := a.matchingRepo.FindByCampaigns(ids)
+	if err != nil {
+		return err
+	}
+
+	byCampaign := map[int][]model.MatchingPledge{}
+	for _, pledge := range pledges {
+		byCampaign[pledge.CampaignID] = append(byCampaign[pledge.CampaignID], pledge)
+	}
+	now := time.Now()
+	for i := range campaigns {
+		campaigns[i].Matching = model.SummarizeMatching(byCampaign[campaigns[i].ID], now)
+	}
+	return nil
+}
+
+func (a *campaignsUseCase) UpdateCampaigns(id int, input model.UpdateCampaignInput) (model.Campaigns, error) {
+	return a.updateCampaign(id, input, "")
+}
+
+func (a *campaignsUseCase) updateCampaign(id int, input model.UpdateCampaignInput, note string) (model.Campaigns, error) {
 	campaign, err := a.campaignsRepo.FindByIdCampaigns(id)
 	if err != nil {
 		return model.Campaigns{}, err
 	}
 
-	if campaign.User_id != inputData.ID {
+	err = authorizeCampaign(a.memberRepo, campaign.ID, input.User, model.CampaignRoleOwner, model.CampaignRoleEditor)
+	if err != nil {
 		return model.Campaigns{}, err
 	}
 
-	campaign.Name = inputData.Name
-	campaign.Short_description = inputData.Short_description
-	campaign.Description = inputData.Description
-	campaign.Perks = inputData.Perks
-	campaign.Goal_amount = inputData.Goal_amount
+	previous := campaign
+	campaign.Name = input.Name
+	campaign.Short_description = input.Short_description
+	campaign.Description = input.Description
+	campaign.Perks = input.Perks
+	campaign.Goal_amount = input.Goal_amount
+	campaign.Min_donation = input.Min_donation
+	campaign.Max_donation = input.Max_donation
+	if err := campaign.ValidateDonationLimits(); err != nil {
+		return model.Campaigns{}, err
+	}
 
-	updatedCampaign, err := a.campaignsRepo.UpdateCampaigns(campaign.ID)
+	// The repository keeps the old slug in the history on a rename and stores
+	// the version together with the edit.
+	save := func(campaign model.Campaigns) (model.Campaigns, error) {
+		return a.campaignsRepo.UpdateCampaigns(campaign, newVersion(previous, campaign, input.User.ID, note))
+	}
+	var updatedCampaign model.Campaigns
+	if campaign.Name != previous.Name {
+		updatedCampaign, err = a.saveWithUniqueSlug(campaign, save)
+	} else {
+		updatedCampaign, err = save(campaign)
+	}
 	if err != nil {
 		return model.Campaigns{}, err
 	}
-	user, err := a.userRepo.FindById(campaign.User_id)
+	user, err := a.findOwner(campaign.User_id)
 	if err != nil {
 		return model.Campaigns{}, err
 	}
-	user.PasswordHash = ""
 
 	updatedCampaign.User = user
 
 	return updatedCampaign, nil
 }
 
-func (a *campaignsUseCase) DeleteCampaigns(id int) error {
+// saveVersion records the new state of a campaign with the fields that changed.
+// Edits that change nothing do not create a version.
+func (a *campaignsUseCase) saveVersion(before model.Campaigns, after model.Campaigns, editedBy int, note string) error {
+	version := newVersion(before, after, editedBy, note)
+	if len(version.Changes) == 0 {
+		return nil
+	}
+
+	_, err := a.versionRepo.Save(version)
+	return err
+}
+
+// newVersion describes the state of a campaign after an edit and the fields
+// the edit changed.
+func newVersion(before model.Campaigns, after model.Campaigns, editedBy int, note string) model.CampaignVersion {
+	return model.CampaignVersion{
+		CampaignID: after.ID,
+		EditedBy:   editedBy,
+		Note:       note,
+		Snapshot:   after.Snapshot(),
+		Changes:    before.Snapshot().Diff(after.Snapshot()),
+	}
+}
+
+func (a *campaignsUseCase) FindHistory(id int) ([]model.CampaignVersion, error) {
+	if _, err := a.campaignsRepo.FindByIdCampaigns(id); err != nil {
+		return nil, err
+	}
+	return a.versionRepo.FindByCampaignID(id)
+}
+
+func (a *campaignsUseCase) DiffVersions(id int, from int, to int) (model.CampaignVersionDiff, error) {
+	fromVersion, err := a.versionRepo.FindByVersion(id, from)
+	if err != nil {
+		return model.CampaignVersionDiff{}, err
+	}
+	toVersion, err := a.versionRepo.FindByVersion(id, to)
+	if err != nil {
+		return model.CampaignVersionDiff{}, err
+	}
+
+	return model.CampaignVersionDiff{
+		CampaignID:  id,
+		FromVersion: from,
+		ToVersion:   to,
+		Changes:     fromVersion.Snapshot.Diff(toVersion.Snapshot),
+	}, nil
+}
+
+// RollbackCampaign restores the fields of an earlier version. The rollback is
+// recorded as a new version so the history is never rewritten.
+func (a *campaignsUseCase) RollbackCampaign(id int, version int, user model.User) (model.Campaigns, error) {
+	target, err := a.versionRepo.FindByVersion(id, version)
+	if err != nil {
+		return model.Campaigns{}, err
+	}
+
+	input := model.UpdateCampaignInput{
+		Name:              target.Snapshot.Name,
+		Short_description: target.Snapshot.Short_description,
+		Description:       target.Snapshot.Description,
+		Perks:             target.Snapshot.Perks,
+		Goal_amount:       target.Snapshot.Goal_amount,
+		Min_donation:      target.Snapshot.Min_donation,
+		Max_donation:      target.Snapshot.Max_donation,
+		User:              user,
+	}
+	return a.updateCampaign(id, input, fmt.Sprintf("rollback to version %d", version))
+}
+
+// saveWithUniqueSlug gives the campaign a slug made from its name and saves
+// it, drawing the slug again if another campaign took it in the meantime.
+func (a *campaignsUseCase) saveWithUniqueSlug(campaign model.Campaigns, save func(model.Campaigns) (model.Campaigns, error)) (model.Campaigns, error) {
+	var err error
+	for attempt := 0; attempt < slugAttempts; attempt++ {
+		campaign.Slug, err = a.uniqueSlug(campaign.Name, campaign.ID)
+		if err != nil {
+			return model.Campaigns{}, err
+		}
+
+		var saved model.Campaigns
+		saved, err = save(campaign)
+		if !errors.Is(err, repository.ErrDuplicateSlug) {
+			return saved, err
+		}
+	}
+	return model.Campaigns{}, err
+}
+
+// uniqueSlug builds a slug from name and appends -2, -3, ... until it finds
+// one that no other campaign uses now or used before a rename.
+func (a *campaignsUseCase) uniqueSlug(name string, campaignID int) (string, error) {
+	base := slug.Make(name)
+	if base == "" {
+		base = "campaign"
+	}
+
+	candidate := base
+	for suffix := 2; ; suffix++ {
+		ownerID, err := a.campaignsRepo.FindSlugOwner(candidate)
+		if errors.Is(err, sql.ErrNoRows) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if campaignID != 0 && ownerID == campaignID {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, suffix)
+	}
+}
+
+// findOwner loads the campaign owner without the password hash. An owner who
+// deleted their account is returned as an empty user.
+func (a *campaignsUseCase) findOwner(userID int) (model.User, error) {
+	user, err := a.userRepo.FindById(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, nil
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
+
+func (a *campaignsUseCase) DeleteCampaigns(id int, user model.User) error {
+	if err := authorizeCampaign(a.memberRepo, id, user, model.CampaignRoleOwner); err != nil {
+		return err
+	}
+
+	donations, err := a.campaignsRepo.CountDonations(id)
+	if err != nil {
+		return err
+	}
+	if donations > 0 {
+		return ErrCampaignHasDonations
+	}
+
 	return a.campaignsRepo.DeleteCampaigns(id)
 }
 
+func (a *campaignsUseCase) FindDeletedCampaigns() ([]model.Campaigns, error) {
+	return a.campaignsRepo.FindDeletedCampaigns()
+}
+
+func (a *campaignsUseCase) RestoreCampaigns(id int) (model.Campaigns, error) {
+	campaign, err := a.campaignsRepo.RestoreCampaigns(id)
+	if err != nil {
+		return model.Campaigns{}, err
+	}
+	user, err := a.findOwner(campaign.User_id)
+	if err != nil {
+		return model.Campaigns{}, err
+	}
+	campaign.User = user
+
+	return campaign, nil
+}
+
 func (a *campaignsUseCase) SaveCampaignImage(input model.CampaignImage, fileLocation string) (model.CampaignImage, error) {
 	inputID := model.Campaigns{
 		ID: input.CampaignID,
@@ -119,7 +409,8 @@ func (a *campaignsUseCase) SaveCampaignImage(input model.CampaignImage, fileLoca
 		return model.CampaignImage{}, err
 	}
 
-	if campaign.User_id != input.User.ID {
+	err = authorizeCampaign(a.memberRepo, campaign.ID, input.User, model.CampaignRoleOwner, model.CampaignRoleEditor)
+	if err != nil {
 		return model.CampaignImage{}, err
 	}
 
@@ -151,11 +442,19 @@ type CampaignsUseCase interface {
 	CreateCampaigns(input model.Campaigns) (model.Campaigns, error)
 	FindAllCampaigns(page int, size int) ([]model.Campaigns, dto.Paging, error)
 	FindByIdCampaigns(inputID int) (model.Campaigns, error)
-	UpdateCampaigns(id int) (model.Campaigns, error)
-	DeleteCampaigns(id int) error
+	FindBySlug(campaignSlug string) (model.Campaigns, error)
+	UpdateCampaigns(id int, input model.UpdateCampaignInput) (model.Campaigns, error)
+	DeleteCampaigns(id int, user model.User) error
+	FindDeletedCampaigns() ([]model.Campaigns, error)
+	RestoreCampaigns(id int) (model.Campaigns, error)
 	SaveCampaignImage(input model.CampaignImage, fileLocation string) (model.CampaignImage, error)
+	FindHistory(id int) ([]model.CampaignVersion, error)
+	DiffVersions(id int, from int, to int) (model.CampaignVersionDiff, error)
+	RollbackCampaign(id int, version int, user model.User) (model.Campaigns, error)
 }
 
-func NewCampaignsUseCase(campaignsRepo repository.CampaignsRepo, userRepo repository.UserRepo) CampaignsUseCase {
-	return &campaignsUseCase{campaignsRepo: campaignsRepo, userRepo: userRepo}
+func NewCampaignsUseCase(campaignsRepo repository.CampaignsRepo, userRepo repository.UserRepo, memberRepo repository.CampaignMemberRepo,
+	versionRepo repository.CampaignVersionRepo, matchingRepo repository.MatchingRepo, paymentProvider service.PaymentProvider) CampaignsUseCase {
+	return &campaignsUseCase{campaignsRepo: campaignsRepo, userRepo: userRepo, memberRepo: memberRepo, versionRepo: versionRepo,
+		matchingRepo: matchingRepo, paymentProvider: paymentProvider}
 }
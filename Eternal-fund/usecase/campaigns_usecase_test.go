@@ -1,12 +1,17 @@
 package usecase
 
 import (
+	"database/sql"
 	"eternal-fund/mocking"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
+	"eternal-fund/repository"
+	"eternal-fund/usecase/service"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -15,14 +20,29 @@ type CampaignUseCaseTestSuite struct {
 	cuc          *campaignsUseCase
 	campaignRepo *mocking.CampaignRepoMock
 	userRepo     *mocking.UserRepoMock
+	memberRepo   *mocking.CampaignMemberRepoMock
+	versionRepo  *mocking.CampaignVersionRepoMock
+	matchingRepo *mocking.MatchingRepoMock
+	payment      *mocking.PaymentProviderMock
 }
 
 func (suite *CampaignUseCaseTestSuite) SetupTest() {
 	suite.campaignRepo = new(mocking.CampaignRepoMock)
 	suite.userRepo = new(mocking.UserRepoMock)
+	suite.memberRepo = new(mocking.CampaignMemberRepoMock)
+	suite.versionRepo = new(mocking.CampaignVersionRepoMock)
+	suite.matchingRepo = new(mocking.MatchingRepoMock)
+	suite.matchingRepo.On("FindByCampaign", mock.Anything).Return([]model.MatchingPledge(nil), nil)
+	suite.matchingRepo.On("FindByCampaigns", mock.Anything).Return([]model.MatchingPledge(nil), nil)
+	suite.payment = new(mocking.PaymentProviderMock)
+	suite.payment.On("SupportsCurrency", mock.Anything).Return(true).Maybe()
 	suite.cuc = &campaignsUseCase{
-		campaignsRepo: suite.campaignRepo,
-		userRepo:      suite.userRepo,
+		campaignsRepo:   suite.campaignRepo,
+		userRepo:        suite.userRepo,
+		memberRepo:      suite.memberRepo,
+		versionRepo:     suite.versionRepo,
+		matchingRepo:    suite.matchingRepo,
+		paymentProvider: suite.payment,
 	}
 }
 
@@ -34,17 +54,68 @@ func (suite *CampaignUseCaseTestSuite) TestCreateCampaigns() {
 		Goal_amount:       100000,
 		User_id:           1,
 	}
-	savedCampaign := input
+	expected := input
+	expected.Slug = "test-campaign"
+	expected.Currency = model.CurrencyIDR
+	savedCampaign := expected
 	savedCampaign.ID = 1
 
-	suite.campaignRepo.On("CreateCampaigns", input).Return(savedCampaign, nil)
-	suite.userRepo.On("FindById", input.User_id).Return(model.User{}, nil)
+	suite.campaignRepo.On("FindSlugOwner", "test-campaign").Return(0, sql.ErrNoRows)
+	suite.campaignRepo.On("CreateCampaigns", expected).Return(savedCampaign, nil)
+	suite.userRepo.On("FindById", input.User_id).Return(model.User{ID: 1, Email: "Owner@Example.com"}, nil)
+	owner := model.CampaignMember{CampaignID: 1, UserID: 1, Email: "owner@example.com", Role: model.CampaignRoleOwner,
+		Status: model.MemberStatusAccepted, InvitedBy: 1}
+	suite.memberRepo.On("Save", owner).Return(owner, nil)
+	suite.versionRepo.On("Save", mock.MatchedBy(func(v model.CampaignVersion) bool {
+		return v.CampaignID == 1 && v.EditedBy == 1 && v.Snapshot == savedCampaign.Snapshot() && len(v.Changes) == 5
+	})).Return(model.CampaignVersion{}, nil)
 
 	createdCampaign, err := suite.cuc.CreateCampaigns(input)
 	assert.NoError(suite.T(), err)
+	savedCampaign.User = model.User{ID: 1, Email: "Owner@Example.com"}
 	assert.Equal(suite.T(), savedCampaign, createdCampaign)
 	suite.campaignRepo.AssertExpectations(suite.T())
 	suite.userRepo.AssertExpectations(suite.T())
+	suite.memberRepo.AssertExpectations(suite.T())
+}
+
+func (suite *CampaignUseCaseTestSuite) TestCreateCampaigns_RetriesTakenSlug() {
+	input := model.Campaigns{Name: "Test Campaign", Goal_amount: 100000, User_id: 1}
+
+	suite.campaignRepo.On("FindSlugOwner", "test-campaign").Return(0, sql.ErrNoRows).Once()
+	suite.campaignRepo.On("CreateCampaigns", mock.MatchedBy(func(c model.Campaigns) bool {
+		return c.Slug == "test-campaign"
+	})).Return(model.Campaigns{}, repository.ErrDuplicateSlug).Once()
+	suite.campaignRepo.On("FindSlugOwner", "test-campaign").Return(2, nil)
+	suite.campaignRepo.On("FindSlugOwner", "test-campaign-2").Return(0, sql.ErrNoRows)
+	suite.campaignRepo.On("CreateCampaigns", mock.MatchedBy(func(c model.Campaigns) bool {
+		return c.Slug == "test-campaign-2"
+	})).Return(model.Campaigns{ID: 1, Name: "Test Campaign", Slug: "test-campaign-2"}, nil)
+	suite.userRepo.On("FindById", 1).Return(model.User{ID: 1, Email: "owner@example.com"}, nil)
+	suite.memberRepo.On("Save", mock.AnythingOfType("model.CampaignMember")).Return(model.CampaignMember{}, nil)
+	suite.versionRepo.On("Save", mock.AnythingOfType("model.CampaignVersion")).Return(model.CampaignVersion{}, nil)
+
+	campaign, err := suite.cuc.CreateCampaigns(input)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "test-campaign-2", campaign.Slug)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestCreateCampaigns_InvalidDonationLimits() {
+	input := model.Campaigns{Name: "Test Campaign", Goal_amount: 100000, User_id: 1, Min_donation: 50000, Max_donation: 10000}
+
+	_, err := suite.cuc.CreateCampaigns(input)
+	assert.ErrorIs(suite.T(), err, model.ErrInvalidDonationLimits)
+	suite.campaignRepo.AssertNotCalled(suite.T(), "CreateCampaigns", mock.Anything)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestCreateCampaigns_CurrencyGatewayCannotCharge() {
+	suite.payment.ExpectedCalls = nil
+	suite.payment.On("SupportsCurrency", model.CurrencyUSD).Return(false)
+	input := model.Campaigns{Name: "Test Campaign", Goal_amount: 100000, User_id: 1, Currency: model.CurrencyUSD}
+
+	_, err := suite.cuc.CreateCampaigns(input)
+	assert.ErrorIs(suite.T(), err, service.ErrUnsupportedCurrency)
+	suite.campaignRepo.AssertNotCalled(suite.T(), "CreateCampaigns", mock.Anything)
 }
 
 func (suite *CampaignUseCaseTestSuite) TestFindAllCampaigns() {
@@ -85,31 +156,234 @@ func (suite *CampaignUseCaseTestSuite) TestFindByIdCampaigns() {
 	suite.userRepo.AssertExpectations(suite.T())
 }
 
+func (suite *CampaignUseCaseTestSuite) TestFindByIdCampaigns_Matching() {
+	now := time.Now()
+	pledges := []model.MatchingPledge{
+		{ID: 1, CampaignID: 3, SponsorName: "Acme", RatioPercent: 100, Cap: 1000000, MatchedAmount: 400000,
+			StartsAt: now.Add(-time.Hour), EndsAt: now.Add(48 * time.Hour)},
+		{ID: 2, CampaignID: 3, SponsorName: "Globex", RatioPercent: 50, Cap: 500000,
+			StartsAt: now.Add(-time.Hour), EndsAt: now.Add(24 * time.Hour)},
+		{ID: 3, CampaignID: 3, SponsorName: "Initech", RatioPercent: 100, Cap: 200000, MatchedAmount: 200000,
+			StartsAt: now.Add(-time.Hour), EndsAt: now.Add(24 * time.Hour)},
+	}
+	suite.matchingRepo.ExpectedCalls = nil
+	suite.matchingRepo.On("FindByCampaign", 3).Return(pledges, nil)
+	suite.campaignRepo.On("FindByIdCampaigns", 3).Return(model.Campaigns{ID: 3, User_id: 1}, nil)
+	suite.userRepo.On("FindById", 1).Return(model.User{ID: 1}, nil)
+
+	campaign, err := suite.cuc.FindByIdCampaigns(3)
+	assert.NoError(suite.T(), err)
+	endsAt := pledges[1].EndsAt
+	assert.Equal(suite.T(), &model.CampaignMatching{Active: true, Multiplier: "2.5x", RatioPercent: 150, RemainingBudget: 1100000,
+		MatchedAmount: 600000, Sponsors: []string{"Acme", "Globex"}, EndsAt: &endsAt}, campaign.Matching)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestFindAllCampaigns_MatchingInOneQuery() {
+	now := time.Now()
+	pledge := model.MatchingPledge{ID: 1, CampaignID: 2, SponsorName: "Acme", RatioPercent: 100, Cap: 1000000,
+		StartsAt: now.Add(-time.Hour), EndsAt: now.Add(24 * time.Hour)}
+	suite.matchingRepo.ExpectedCalls = nil
+	suite.matchingRepo.On("FindByCampaigns", []int{1, 2}).Return([]model.MatchingPledge{pledge}, nil).Once()
+	suite.campaignRepo.On("FindAllCampaigns", 1, 10).Return([]model.Campaigns{{ID: 1, User_id: 1}, {ID: 2, User_id: 1}}, dto.Paging{}, nil)
+	suite.userRepo.On("FindById", 1).Return(model.User{ID: 1}, nil)
+
+	campaigns, _, err := suite.cuc.FindAllCampaigns(1, 10)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.SummarizeMatching(nil, now), campaigns[0].Matching)
+	assert.Equal(suite.T(), model.SummarizeMatching([]model.MatchingPledge{pledge}, now), campaigns[1].Matching)
+	suite.matchingRepo.AssertExpectations(suite.T())
+	suite.matchingRepo.AssertNotCalled(suite.T(), "FindByCampaign", mock.Anything)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestCreateCampaigns_SlugCollision() {
+	input := model.Campaigns{Name: "Test Campaign", Goal_amount: 100000, User_id: 1}
+	expected := input
+	expected.Slug = "test-campaign-3"
+	expected.Currency = model.CurrencyIDR
+
+	suite.campaignRepo.On("FindSlugOwner", "test-campaign").Return(7, nil)
+	suite.campaignRepo.On("FindSlugOwner", "test-campaign-2").Return(8, nil)
+	suite.campaignRepo.On("FindSlugOwner", "test-campaign-3").Return(0, sql.ErrNoRows)
+	suite.campaignRepo.On("CreateCampaigns", expected).Return(expected, nil)
+	suite.userRepo.On("FindById", input.User_id).Return(model.User{}, nil)
+	suite.memberRepo.On("Save", mock.AnythingOfType("model.CampaignMember")).Return(model.CampaignMember{}, nil)
+	suite.versionRepo.On("Save", mock.AnythingOfType("model.CampaignVersion")).Return(model.CampaignVersion{}, nil)
+
+	createdCampaign, err := suite.cuc.CreateCampaigns(input)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "test-campaign-3", createdCampaign.Slug)
+}
+
 func (suite *CampaignUseCaseTestSuite) TestUpdateCampaigns() {
 	campaignID := 1
-	mockCampaign := model.Campaigns{ID: campaignID, Name: "Test Campaign"}
+	mockCampaign := model.Campaigns{ID: campaignID, User_id: 1, Name: "Test Campaign", Slug: "test-campaign"}
+	input := model.UpdateCampaignInput{Name: "Test Campaign", Description: "New description", Goal_amount: 5000, User: model.User{ID: 1}}
+	expected := mockCampaign
+	expected.Description = input.Description
+	expected.Goal_amount = input.Goal_amount
 
 	suite.campaignRepo.On("FindByIdCampaigns", campaignID).Return(mockCampaign, nil)
+	suite.memberRepo.On("FindMembership", campaignID, 1).Return(model.CampaignMember{Role: model.CampaignRoleEditor}, nil)
 	suite.userRepo.On("FindById", mockCampaign.User_id).Return(model.User{}, nil)
-	suite.campaignRepo.On("UpdateCampaigns", campaignID).Return(mockCampaign, nil)
+	suite.campaignRepo.On("UpdateCampaigns", expected, model.CampaignVersion{
+		CampaignID: campaignID,
+		EditedBy:   1,
+		Snapshot:   expected.Snapshot(),
+		Changes: []model.CampaignFieldChange{
+			{Field: "description", Old: "", New: "New description"},
+			{Field: "goal_amount", Old: 0, New: 5000},
+		},
+	}).Return(expected, nil)
 
-	updatedCampaign, err := suite.cuc.UpdateCampaigns(campaignID)
+	updatedCampaign, err := suite.cuc.UpdateCampaigns(campaignID, input)
 	assert.NoError(suite.T(), err)
-	assert.Equal(suite.T(), mockCampaign, updatedCampaign)
+	assert.Equal(suite.T(), expected, updatedCampaign)
 	suite.campaignRepo.AssertExpectations(suite.T())
 	suite.userRepo.AssertExpectations(suite.T())
+	suite.versionRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestUpdateCampaigns_NoChangeSkipsVersion() {
+	campaignID := 1
+	mockCampaign := model.Campaigns{ID: campaignID, User_id: 1, Name: "Test Campaign", Slug: "test-campaign", Goal_amount: 5000}
+	input := model.UpdateCampaignInput{Name: "Test Campaign", Goal_amount: 5000, User: model.User{ID: 1, Role: "admin"}}
+
+	suite.campaignRepo.On("FindByIdCampaigns", campaignID).Return(mockCampaign, nil)
+	suite.campaignRepo.On("UpdateCampaigns", mockCampaign, mock.MatchedBy(func(v model.CampaignVersion) bool {
+		return len(v.Changes) == 0
+	})).Return(mockCampaign, nil)
+	suite.userRepo.On("FindById", 1).Return(model.User{}, nil)
+
+	_, err := suite.cuc.UpdateCampaigns(campaignID, input)
+	assert.NoError(suite.T(), err)
+	suite.campaignRepo.AssertExpectations(suite.T())
+}
+
+func (suite *CampaignUseCaseTestSuite) TestDiffVersions() {
+	suite.versionRepo.On("FindByVersion", 1, 1).Return(model.CampaignVersion{Version: 1,
+		Snapshot: model.CampaignSnapshot{Name: "Water", Goal_amount: 1000, Slug: "water"}}, nil)
+	suite.versionRepo.On("FindByVersion", 1, 3).Return(model.CampaignVersion{Version: 3,
+		Snapshot: model.CampaignSnapshot{Name: "Water", Goal_amount: 5000, Slug: "water"}}, nil)
+
+	diff, err := suite.cuc.DiffVersions(1, 1, 3)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.CampaignFieldChange{{Field: "goal_amount", Old: 1000, New: 5000}}, diff.Changes)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestRollbackCampaign() {
+	campaignID := 1
+	current := model.Campaigns{ID: campaignID, User_id: 2, Name: "Water", Slug: "water", Goal_amount: 5000}
+	rolledBack := current
+	rolledBack.Goal_amount = 1000
+	admin := model.User{ID: 9, Role: "admin"}
+
+	suite.versionRepo.On("FindByVersion", campaignID, 1).Return(model.CampaignVersion{Version: 1,
+		Snapshot: model.CampaignSnapshot{Name: "Water", Goal_amount: 1000, Slug: "water"}}, nil)
+	suite.campaignRepo.On("FindByIdCampaigns", campaignID).Return(current, nil)
+	suite.campaignRepo.On("UpdateCampaigns", rolledBack, mock.MatchedBy(func(v model.CampaignVersion) bool {
+		return v.EditedBy == 9 && v.Note == "rollback to version 1" && v.Snapshot.Goal_amount == 1000
+	})).Return(rolledBack, nil)
+	suite.userRepo.On("FindById", 2).Return(model.User{}, nil)
+
+	campaign, err := suite.cuc.RollbackCampaign(campaignID, 1, admin)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1000, campaign.Goal_amount)
+	suite.campaignRepo.AssertExpectations(suite.T())
+}
+
+func (suite *CampaignUseCaseTestSuite) TestUpdateCampaigns_RenameKeepsSlugHistory() {
+	campaignID := 1
+	mockCampaign := model.Campaigns{ID: campaignID, User_id: 1, Name: "Old Name", Slug: "old-name"}
+	input := model.UpdateCampaignInput{Name: "New Name", Goal_amount: 5000, User: model.User{ID: 1}}
+
+	suite.campaignRepo.On("FindByIdCampaigns", campaignID).Return(mockCampaign, nil)
+	suite.memberRepo.On("FindMembership", campaignID, 1).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
+	suite.campaignRepo.On("FindSlugOwner", "new-name").Return(0, sql.ErrNoRows)
+	suite.campaignRepo.On("UpdateCampaigns", mock.MatchedBy(func(c model.Campaigns) bool {
+		return c.Slug == "new-name" && c.Name == "New Name"
+	}), mock.MatchedBy(func(v model.CampaignVersion) bool {
+		return v.Snapshot.Slug == "new-name"
+	})).Return(model.Campaigns{ID: campaignID, Slug: "new-name"}, nil)
+	suite.userRepo.On("FindById", mockCampaign.User_id).Return(model.User{}, nil)
+
+	updatedCampaign, err := suite.cuc.UpdateCampaigns(campaignID, input)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "new-name", updatedCampaign.Slug)
+	suite.campaignRepo.AssertExpectations(suite.T())
+}
+
+func (suite *CampaignUseCaseTestSuite) TestUpdateCampaigns_NotMember() {
+	campaignID := 1
+	suite.campaignRepo.On("FindByIdCampaigns", campaignID).Return(model.Campaigns{ID: campaignID, User_id: 2}, nil)
+	suite.memberRepo.On("FindMembership", campaignID, 1).Return(model.CampaignMember{}, sql.ErrNoRows)
+
+	_, err := suite.cuc.UpdateCampaigns(campaignID, model.UpdateCampaignInput{Name: "x", User: model.User{ID: 1}})
+	assert.ErrorIs(suite.T(), err, ErrCampaignForbidden)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestUpdateCampaigns_ViewerForbidden() {
+	campaignID := 1
+	suite.campaignRepo.On("FindByIdCampaigns", campaignID).Return(model.Campaigns{ID: campaignID, User_id: 2}, nil)
+	suite.memberRepo.On("FindMembership", campaignID, 1).Return(model.CampaignMember{Role: model.CampaignRoleViewer}, nil)
+
+	_, err := suite.cuc.UpdateCampaigns(campaignID, model.UpdateCampaignInput{Name: "x", User: model.User{ID: 1}})
+	assert.ErrorIs(suite.T(), err, ErrCampaignForbidden)
+	suite.campaignRepo.AssertNotCalled(suite.T(), "UpdateCampaigns", mock.Anything, mock.Anything)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestFindBySlug_OldSlug() {
+	suite.campaignRepo.On("FindBySlug", "old-name").Return(model.Campaigns{}, sql.ErrNoRows)
+	suite.campaignRepo.On("FindCampaignIDBySlugHistory", "old-name").Return(1, nil)
+	suite.campaignRepo.On("FindByIdCampaigns", 1).Return(model.Campaigns{ID: 1, Slug: "new-name"}, nil)
+	suite.userRepo.On("FindById", 0).Return(model.User{}, nil)
+
+	campaign, err := suite.cuc.FindBySlug("old-name")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "new-name", campaign.Slug)
 }
 
 func (suite *CampaignUseCaseTestSuite) TestDeleteCampaigns() {
 	campaignID := 1
 
+	suite.memberRepo.On("FindMembership", campaignID, 1).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
+	suite.campaignRepo.On("CountDonations", campaignID).Return(0, nil)
 	suite.campaignRepo.On("DeleteCampaigns", campaignID).Return(nil)
 
-	err := suite.cuc.DeleteCampaigns(campaignID)
+	err := suite.cuc.DeleteCampaigns(campaignID, model.User{ID: 1})
 	assert.NoError(suite.T(), err)
 	suite.campaignRepo.AssertExpectations(suite.T())
 }
 
+func (suite *CampaignUseCaseTestSuite) TestDeleteCampaigns_HasDonations() {
+	campaignID := 1
+
+	suite.campaignRepo.On("CountDonations", campaignID).Return(2, nil)
+
+	err := suite.cuc.DeleteCampaigns(campaignID, model.User{ID: 1, Role: "admin"})
+	assert.ErrorIs(suite.T(), err, ErrCampaignHasDonations)
+	suite.campaignRepo.AssertNotCalled(suite.T(), "DeleteCampaigns", mock.Anything)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestFindAllCampaigns_DeletedOwner() {
+	mockCampaigns := []model.Campaigns{{ID: 1, User_id: 5}}
+
+	suite.campaignRepo.On("FindAllCampaigns", 1, 10).Return(mockCampaigns, dto.Paging{}, nil)
+	suite.userRepo.On("FindById", 5).Return(model.User{}, sql.ErrNoRows)
+
+	campaigns, _, err := suite.cuc.FindAllCampaigns(1, 10)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.User{}, campaigns[0].User)
+}
+
+func (suite *CampaignUseCaseTestSuite) TestRestoreCampaigns() {
+	suite.campaignRepo.On("RestoreCampaigns", 1).Return(model.Campaigns{ID: 1, User_id: 5}, nil)
+	suite.userRepo.On("FindById", 5).Return(model.User{ID: 5, PasswordHash: "hash"}, nil)
+
+	campaign, err := suite.cuc.RestoreCampaigns(1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.User{ID: 5}, campaign.User)
+}
+
 func (suite *CampaignUseCaseTestSuite) TestSaveCampaignImage() {
 	input := model.CampaignImage{
 		CampaignID: 1,
@@ -119,6 +393,7 @@ func (suite *CampaignUseCaseTestSuite) TestSaveCampaignImage() {
 	fileLocation := "/path/to/image.jpg"
 
 	suite.campaignRepo.On("FindByIdCampaigns", input.CampaignID).Return(model.Campaigns{}, nil)
+	suite.memberRepo.On("FindMembership", 0, input.User.ID).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
 	suite.campaignRepo.On("MarkAllImagesAsNonPrimary", input.CampaignID).Return(nil)
 	suite.campaignRepo.On("CreateImage", input).Return(input, nil)
 
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"strings"
+)
+
+var (
+	ErrSameCurrency         = errors.New("an exchange rate needs two different currencies")
+	ErrExchangeRateNotFound = errors.New("there is no exchange rate between these currencies")
+	ErrDonationTooSmall     = errors.New("the donation is worth less than the smallest unit of the campaign's currency")
+)
+
+// inverseRatePlaces is how many decimals a rate derived from the opposite
+// pair is rounded to, the most the exchange_rates table takes.
+const inverseRatePlaces = 10
+
+type exchangeRateUseCase struct {
+	exchangeRateRepo repository.ExchangeRateRepo
+}
+
+func (e *exchangeRateUseCase) SaveRate(input model.ExchangeRateInput) (model.ExchangeRate, error) {
+	base, quote, err := currencyPair(input.Base, input.Quote)
+	if err != nil {
+		return model.ExchangeRate{}, err
+	}
+	rate := strings.TrimSpace(input.Rate)
+	if _, err := model.ParseRate(rate); err != nil {
+		return model.ExchangeRate{}, err
+	}
+	return e.exchangeRateRepo.Save(model.ExchangeRate{Base: base, Quote: quote, Rate: rate, UpdatedBy: input.User.ID})
+}
+
+func (e *exchangeRateUseCase) GetRates() ([]model.ExchangeRate, error) {
+	return e.exchangeRateRepo.FindAll()
+}
+
+func (e *exchangeRateUseCase) DeleteRate(base string, quote string) error {
+	from, to, err := currencyPair(base, quote)
+	if err != nil {
+		return err
+	}
+	return e.exchangeRateRepo.Delete(from, to)
+}
+
+// Convert turns an amount in minor units of from into minor units of to and
+// returns the rate it used. Without a rate for the pair, the rate of the
+// opposite pair is inverted.
+func (e *exchangeRateUseCase) Convert(amount int, from model.Currency, to model.Currency) (int, string, error) {
+	if from == to {
+		return amount, "1", nil
+	}
+
+	rate, err := e.rate(from, to)
+	if err != nil {
+		return 0, "", err
+	}
+	value, err := model.ParseRate(rate)
+	if err != nil {
+		return 0, "", err
+	}
+	converted := model.Convert(amount, from, to, value)
+	if converted <= 0 {
+		return 0, "", ErrDonationTooSmall
+	}
+	return converted, rate, nil
+}
+
+func (e *exchangeRateUseCase) rate(from model.Currency, to model.Currency) (string, error) {
+	rate, err := e.exchangeRateRepo.Find(from, to)
+	if err == nil {
+		return rate.Rate, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	rate, err = e.exchangeRateRepo.Find(to, from)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrExchangeRateNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	value, err := model.ParseRate(rate.Rate)
+	if err != nil {
+		return "", err
+	}
+	inverse := value.Inv(value).FloatString(inverseRatePlaces)
+	inverse = strings.TrimRight(strings.TrimRight(inverse, "0"), ".")
+	if inverse == "0" {
+		return "", ErrExchangeRateNotFound
+	}
+	return inverse, nil
+}
+
+func currencyPair(base string, quote string) (model.Currency, model.Currency, error) {
+	from, err := model.ParseCurrency(base)
+	if err != nil {
+		return "", "", err
+	}
+	to, err := model.ParseCurrency(quote)
+	if err != nil {
+		return "", "", err
+	}
+	if from == to {
+		return "", "", ErrSameCurrency
+	}
+	return from, to, nil
+}
+
+type ExchangeRateUseCase interface {
+	SaveRate(input model.ExchangeRateInput) (model.ExchangeRate, error)
+	GetRates() ([]model.ExchangeRate, error)
+	DeleteRate(base string, quote string) error
+	Convert(amount int, from model.Currency, to model.Currency) (int, string, error)
+}
+
+func NewExchangeRateUseCase(exchangeRateRepo repository.ExchangeRateRepo) ExchangeRateUseCase {
+	return &exchangeRateUseCase{exchangeRateRepo: exchangeRateRepo}
+}
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"database/sql"
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExchangeRateUseCaseTestSuite struct {
+	suite.Suite
+	euc              *exchangeRateUseCase
+	exchangeRateRepo *mocking.ExchangeRateRepoMock
+}
+
+func (suite *ExchangeRateUseCaseTestSuite) SetupTest() {
+	suite.exchangeRateRepo = new(mocking.ExchangeRateRepoMock)
+	suite.euc = &exchangeRateUseCase{exchangeRateRepo: suite.exchangeRateRepo}
+}
+
+func (suite *ExchangeRateUseCaseTestSuite) TestFormatMoney() {
+	assert.Equal(suite.T(), "Rp1.500.000,00", model.FormatMoney(1500000, model.CurrencyIDR))
+	assert.Equal(suite.T(), "$1,234.56", model.FormatMoney(123456, model.CurrencyUSD))
+	assert.Equal(suite.T(), "S$0.05", model.FormatMoney(5, model.CurrencySGD))
+	assert.Equal(suite.T(), "RM10.00", model.FormatMoney(1000, model.CurrencyMYR))
+	assert.Equal(suite.T(), model.Transaction{Amount: 150000}.AmountFormatIDR(), model.Transaction{Amount: 150000}.AmountFormat())
+}
+
+func (suite *ExchangeRateUseCaseTestSuite) TestConvert_UsesRateOfPair() {
+	suite.exchangeRateRepo.On("Find", model.CurrencyUSD, model.CurrencyIDR).
+		Return(model.ExchangeRate{Base: model.CurrencyUSD, Quote: model.CurrencyIDR, Rate: "16250.5"}, nil)
+
+	// $25.00 is Rp406.262,50, which rounds up to Rp406.263.
+	amount, rate, err := suite.euc.Convert(2500, model.CurrencyUSD, model.CurrencyIDR)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 406263, amount)
+	assert.Equal(suite.T(), "16250.5", rate)
+}
+
+func (suite *ExchangeRateUseCaseTestSuite) TestConvert_InvertsOppositePair() {
+	suite.exchangeRateRepo.On("Find", model.CurrencyIDR, model.CurrencySGD).Return(model.ExchangeRate{}, sql.ErrNoRows)
+	suite.exchangeRateRepo.On("Find", model.CurrencySGD, model.CurrencyIDR).
+		Return(model.ExchangeRate{Base: model.CurrencySGD, Quote: model.CurrencyIDR, Rate: "12500"}, nil)
+
+	amount, rate, err := suite.euc.Convert(1000000, model.CurrencyIDR, model.CurrencySGD)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 8000, amount)
+	assert.Equal(suite.T(), "0.00008", rate)
+}
+
+func (suite *ExchangeRateUseCaseTestSuite) TestConvert_NoRate() {
+	suite.exchangeRateRepo.On("Find", mock.Anything, mock.Anything).Return(model.ExchangeRate{}, sql.ErrNoRows)
+
+	_, _, err := suite.euc.Convert(1000, model.CurrencyMYR, model.CurrencyUSD)
+
+	assert.ErrorIs(suite.T(), err, ErrExchangeRateNotFound)
+}
+
+func (suite *ExchangeRateUseCaseTestSuite) TestConvert_TooSmall() {
+	suite.exchangeRateRepo.On("Find", model.CurrencyIDR, model.CurrencyUSD).
+		Return(model.ExchangeRate{Rate: "0.0000615"}, nil)
+
+	_, _, err := suite.euc.Convert(50, model.CurrencyIDR, model.CurrencyUSD)
+
+	assert.ErrorIs(suite.T(), err, ErrDonationTooSmall)
+}
+
+func (suite *ExchangeRateUseCaseTestSuite) TestSaveRate_Validates() {
+	_, err := suite.euc.SaveRate(model.ExchangeRateInput{Base: "usd", Quote: "USD", Rate: "1"})
+	assert.ErrorIs(suite.T(), err, ErrSameCurrency)
+
+	_, err = suite.euc.SaveRate(model.ExchangeRateInput{Base: "USD", Quote: "IDR", Rate: "1e4"})
+	assert.ErrorIs(suite.T(), err, model.ErrInvalidExchangeRate)
+
+	_, err = suite.euc.SaveRate(model.ExchangeRateInput{Base: "USD", Quote: "IDR", Rate: "0"})
+	assert.ErrorIs(suite.T(), err, model.ErrInvalidExchangeRate)
+
+	suite.exchangeRateRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *ExchangeRateUseCaseTestSuite) TestSaveRate() {
+	rate := model.ExchangeRate{Base: model.CurrencyUSD, Quote: model.CurrencyIDR, Rate: "16250.5", UpdatedBy: 1}
+	suite.exchangeRateRepo.On("Save", rate).Return(rate, nil)
+
+	saved, err := suite.euc.SaveRate(model.ExchangeRateInput{Base: "usd", Quote: "idr", Rate: " 16250.5 ", User: model.User{ID: 1}})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), rate, saved)
+}
+
+func TestExchangeRateUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(ExchangeRateUseCaseTestSuite))
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"log"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+type idempotencyUseCase struct {
+	idempotencyRepo repository.IdempotencyRepo
+	retention       time.Duration
+}
+
+// Begin reserves the key of a new request. When the key was seen before and
+// the original request has completed, the stored record is returned with
+// replay set to true so the caller can answer with the original response.
+func (i *idempotencyUseCase) Begin(record model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	existing, reserved, err := i.idempotencyRepo.Reserve(record)
+	if err != nil {
+		return model.IdempotencyRecord{}, false, err
+	}
+	if reserved {
+		return existing, false, nil
+	}
+
+	// A key past its retention window that has not been purged yet is free
+	// to be used again.
+	if existing.CreatedAt.Before(time.Now().Add(-i.retention)) {
+		if err := i.idempotencyRepo.Release(existing.Scope, existing.Key); err != nil {
+			return model.IdempotencyRecord{}, false, err
+		}
+		return i.Begin(record)
+	}
+
+	if existing.Fingerprint != record.Fingerprint {
+		return model.IdempotencyRecord{}, false, ErrIdempotencyKeyReused
+	}
+	if !existing.IsCompleted() {
+		return model.IdempotencyRecord{}, false, ErrIdempotencyInProgress
+	}
+	return existing, true, nil
+}
+
+func (i *idempotencyUseCase) Finish(record model.IdempotencyRecord) error {
+	return i.idempotencyRepo.Complete(record)
+}
+
+// Abandon releases the key of a request that failed on the server side so
+// the client can retry it.
+func (i *idempotencyUseCase) Abandon(record model.IdempotencyRecord) error {
+	return i.idempotencyRepo.Release(record.Scope, record.Key)
+}
+
+func (i *idempotencyUseCase) PurgeExpired() error {
+	cutoff := time.Now().Add(-i.retention)
+	purged, err := i.idempotencyRepo.PurgeBefore(cutoff)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("[JOB] purged %d idempotency keys created before %s", purged, cutoff.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (i *idempotencyUseCase) StartPurger(interval time.Duration) {
+	runPeriodically("idempotency key purge", interval, i.PurgeExpired)
+}
+
+type IdempotencyUseCase interface {
+	Begin(record model.IdempotencyRecord) (model.IdempotencyRecord, bool, error)
+	Finish(record model.IdempotencyRecord) error
+	Abandon(record model.IdempotencyRecord) error
+	PurgeExpired() error
+	StartPurger(interval time.Duration)
+}
+
+func NewIdempotencyUseCase(idempotencyRepo repository.IdempotencyRepo, retention time.Duration) IdempotencyUseCase {
+	return &idempotencyUseCase{idempotencyRepo: idempotencyRepo, retention: retention}
+}
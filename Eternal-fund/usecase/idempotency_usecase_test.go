@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyUseCaseTestSuite struct {
+	suite.Suite
+	iuc             *idempotencyUseCase
+	idempotencyRepo *mocking.IdempotencyRepoMock
+}
+
+func (suite *IdempotencyUseCaseTestSuite) SetupTest() {
+	suite.idempotencyRepo = new(mocking.IdempotencyRepoMock)
+	suite.iuc = &idempotencyUseCase{
+		idempotencyRepo: suite.idempotencyRepo,
+		retention:       24 * time.Hour,
+	}
+}
+
+func (suite *IdempotencyUseCaseTestSuite) TestBegin_NewKey() {
+	record := model.IdempotencyRecord{Key: "k1", Scope: "s", Fingerprint: "fp"}
+	suite.idempotencyRepo.On("Reserve", record).Return(record, true, nil)
+
+	stored, replay, err := suite.iuc.Begin(record)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), replay)
+	assert.Equal(suite.T(), record, stored)
+}
+
+func (suite *IdempotencyUseCaseTestSuite) TestBegin_Replay() {
+	now := time.Now()
+	record := model.IdempotencyRecord{Key: "k1", Scope: "s", Fingerprint: "fp"}
+	existing := model.IdempotencyRecord{Key: "k1", Scope: "s", Fingerprint: "fp", StatusCode: 200,
+		ResponseBody: []byte(`{}`), CreatedAt: now, CompletedAt: &now}
+	suite.idempotencyRepo.On("Reserve", record).Return(existing, false, nil)
+
+	stored, replay, err := suite.iuc.Begin(record)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), replay)
+	assert.Equal(suite.T(), existing, stored)
+}
+
+func (suite *IdempotencyUseCaseTestSuite) TestBegin_DifferentBody() {
+	now := time.Now()
+	record := model.IdempotencyRecord{Key: "k1", Scope: "s", Fingerprint: "other"}
+	existing := model.IdempotencyRecord{Key: "k1", Scope: "s", Fingerprint: "fp", CreatedAt: now, CompletedAt: &now}
+	suite.idempotencyRepo.On("Reserve", record).Return(existing, false, nil)
+
+	_, _, err := suite.iuc.Begin(record)
+	assert.ErrorIs(suite.T(), err, ErrIdempotencyKeyReused)
+}
+
+func (suite *IdempotencyUseCaseTestSuite) TestBegin_InProgress() {
+	record := model.IdempotencyRecord{Key: "k1", Scope: "s", Fingerprint: "fp"}
+	existing := model.IdempotencyRecord{Key: "k1", Scope: "s", Fingerprint: "fp", CreatedAt: time.Now()}
+	suite.idempotencyRepo.On("Reserve", record).Return(existing, false, nil)
+
+	_, _, err := suite.iuc.Begin(record)
+	assert.ErrorIs(suite.T(), err, ErrIdempotencyInProgress)
+}
+
+func (suite *IdempotencyUseCaseTestSuite) TestBegin_ExpiredKeyIsReused() {
+	old := time.Now().Add(-48 * time.Hour)
+	record := model.IdempotencyRecord{Key: "k1", Scope: "s", Fingerprint: "other"}
+	expired := model.IdempotencyRecord{Key: "k1", Scope: "s", Fingerprint: "fp", CreatedAt: old, CompletedAt: &old}
+	suite.idempotencyRepo.On("Reserve", record).Return(expired, false, nil).Once()
+	suite.idempotencyRepo.On("Release", "s", "k1").Return(nil)
+	suite.idempotencyRepo.On("Reserve", record).Return(record, true, nil).Once()
+
+	_, replay, err := suite.iuc.Begin(record)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), replay)
+	suite.idempotencyRepo.AssertExpectations(suite.T())
+}
+
+func TestIdempotencyUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyUseCaseTestSuite))
+}
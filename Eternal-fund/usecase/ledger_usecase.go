@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"eternal-fund/repository"
+	"fmt"
+	"log"
+	"time"
+)
+
+type ledgerUseCase struct {
+	ledgerRepo repository.LedgerRepo
+}
+
+// LedgerReport is the result of checking the ledger invariants.
+type LedgerReport struct {
+	UnbalancedJournals []int                     `json:"unbalanced_journals"`
+	Discrepancies      []model.LedgerDiscrepancy `json:"discrepancies"`
+	CheckedAt          time.Time                 `json:"checked_at"`
+}
+
+func (r LedgerReport) OK() bool {
+	return len(r.UnbalancedJournals) == 0 && len(r.Discrepancies) == 0
+}
+
+// GetBalance sums account in currency, which defaults to rupiah.
+func (l *ledgerUseCase) GetBalance(account string, currency string) (model.LedgerBalance, error) {
+	code, err := model.ParseCurrency(currency)
+	if err != nil {
+		return model.LedgerBalance{}, err
+	}
+	return l.ledgerRepo.Balance(account, code)
+}
+
+func (l *ledgerUseCase) GetEntries(account string, page int, size int) ([]model.LedgerEntry, dto.Paging, error) {
+	return l.ledgerRepo.FindEntries(account, page, size)
+}
+
+// CheckInvariants verifies that every journal balances and that every
+// campaign's current amount equals what the ledger says it raised.
+func (l *ledgerUseCase) CheckInvariants() (LedgerReport, error) {
+	unbalanced, err := l.ledgerRepo.FindUnbalancedJournals()
+	if err != nil {
+		return LedgerReport{}, err
+	}
+	discrepancies, err := l.ledgerRepo.FindCampaignDiscrepancies()
+	if err != nil {
+		return LedgerReport{}, err
+	}
+	return LedgerReport{UnbalancedJournals: unbalanced, Discrepancies: discrepancies, CheckedAt: time.Now()}, nil
+}
+
+func (l *ledgerUseCase) checkAndLog() error {
+	report, err := l.CheckInvariants()
+	if err != nil {
+		return err
+	}
+	for _, discrepancy := range report.Discrepancies {
+		log.Printf("[LEDGER] campaign %d current_amount %d does not match ledger %d",
+			discrepancy.CampaignID, discrepancy.CurrentAmount, discrepancy.LedgerRaised)
+	}
+	if !report.OK() {
+		return fmt.Errorf("%d unbalanced journals and %d campaign discrepancies", len(report.UnbalancedJournals), len(report.Discrepancies))
+	}
+	return nil
+}
+
+func (l *ledgerUseCase) StartChecker(interval time.Duration) {
+	runPeriodically("ledger invariant check", interval, l.checkAndLog)
+}
+
+type LedgerUseCase interface {
+	GetBalance(account string, currency string) (model.LedgerBalance, error)
+	GetEntries(account string, page int, size int) ([]model.LedgerEntry, dto.Paging, error)
+	CheckInvariants() (LedgerReport, error)
+	StartChecker(interval time.Duration)
+}
+
+func NewLedgerUseCase(ledgerRepo repository.LedgerRepo) LedgerUseCase {
+	return &ledgerUseCase{ledgerRepo: ledgerRepo}
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LedgerUseCaseTestSuite struct {
+	suite.Suite
+	luc        *ledgerUseCase
+	ledgerRepo *mocking.LedgerRepoMock
+}
+
+func (suite *LedgerUseCaseTestSuite) SetupTest() {
+	suite.ledgerRepo = new(mocking.LedgerRepoMock)
+	suite.luc = &ledgerUseCase{ledgerRepo: suite.ledgerRepo}
+}
+
+func (suite *LedgerUseCaseTestSuite) TestCheckInvariants_Consistent() {
+	suite.ledgerRepo.On("FindUnbalancedJournals").Return([]int(nil), nil)
+	suite.ledgerRepo.On("FindCampaignDiscrepancies").Return([]model.LedgerDiscrepancy(nil), nil)
+
+	report, err := suite.luc.CheckInvariants()
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), report.OK())
+	assert.NoError(suite.T(), suite.luc.checkAndLog())
+}
+
+func (suite *LedgerUseCaseTestSuite) TestCheckInvariants_Discrepancy() {
+	discrepancies := []model.LedgerDiscrepancy{{CampaignID: 2, CurrentAmount: 7000, LedgerRaised: 5000}}
+	suite.ledgerRepo.On("FindUnbalancedJournals").Return([]int(nil), nil)
+	suite.ledgerRepo.On("FindCampaignDiscrepancies").Return(discrepancies, nil)
+
+	report, err := suite.luc.CheckInvariants()
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), report.OK())
+	assert.Equal(suite.T(), discrepancies, report.Discrepancies)
+	assert.Error(suite.T(), suite.luc.checkAndLog())
+}
+
+func (suite *LedgerUseCaseTestSuite) TestGetBalance_PerCurrency() {
+	expected := model.LedgerBalance{Account: "platform_fees", Currency: model.CurrencyUSD, Credit: 500, Balance: 500}
+	suite.ledgerRepo.On("Balance", "platform_fees", model.CurrencyUSD).Return(expected, nil)
+	suite.ledgerRepo.On("Balance", "platform_fees", model.CurrencyIDR).Return(model.LedgerBalance{Account: "platform_fees", Currency: model.CurrencyIDR}, nil)
+
+	balance, err := suite.luc.GetBalance("platform_fees", "usd")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), expected, balance)
+
+	balance, err = suite.luc.GetBalance("platform_fees", "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.CurrencyIDR, balance.Currency)
+
+	_, err = suite.luc.GetBalance("platform_fees", "EUR")
+	assert.ErrorIs(suite.T(), err, model.ErrUnsupportedCurrency)
+}
+
+func TestLedgerUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(LedgerUseCaseTestSuite))
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"strings"
+)
+
+type matchingUseCase struct {
+	matchingRepo repository.MatchingRepo
+	campaignRepo repository.CampaignsRepo
+}
+
+// CreatePledge records a sponsor's matching pledge for a campaign. The cap is
+// in the campaign's currency.
+func (uc *matchingUseCase) CreatePledge(input model.MatchingPledgeInput) (model.MatchingPledge, error) {
+	if !input.EndsAt.After(input.StartsAt) {
+		return model.MatchingPledge{}, model.ErrInvalidMatchingWindow
+	}
+	if _, err := uc.campaignRepo.FindByIdCampaigns(input.CampaignID); err != nil {
+		return model.MatchingPledge{}, err
+	}
+
+	return uc.matchingRepo.Save(model.MatchingPledge{
+		CampaignID:   input.CampaignID,
+		SponsorName:  strings.TrimSpace(input.SponsorName),
+		RatioPercent: input.RatioPercent,
+		Cap:          input.Cap,
+		StartsAt:     input.StartsAt,
+		EndsAt:       input.EndsAt,
+		CreatedBy:    input.User.ID,
+	})
+}
+
+func (uc *matchingUseCase) GetPledges(campaignID int) ([]model.MatchingPledge, error) {
+	if _, err := uc.campaignRepo.FindByIdCampaigns(campaignID); err != nil {
+		return nil, err
+	}
+	return uc.matchingRepo.FindByCampaign(campaignID)
+}
+
+func (uc *matchingUseCase) CancelPledge(id int) (model.MatchingPledge, error) {
+	return uc.matchingRepo.Cancel(id)
+}
+
+func (uc *matchingUseCase) GetContributions(pledgeID int) ([]model.MatchedContribution, error) {
+	if _, err := uc.matchingRepo.FindByID(pledgeID); err != nil {
+		return nil, err
+	}
+	return uc.matchingRepo.FindContributions(pledgeID)
+}
+
+type MatchingUseCase interface {
+	CreatePledge(input model.MatchingPledgeInput) (model.MatchingPledge, error)
+	GetPledges(campaignID int) ([]model.MatchingPledge, error)
+	CancelPledge(id int) (model.MatchingPledge, error)
+	GetContributions(pledgeID int) ([]model.MatchedContribution, error)
+}
+
+func NewMatchingUseCase(matchingRepo repository.MatchingRepo, campaignRepo repository.CampaignsRepo) MatchingUseCase {
+	return &matchingUseCase{matchingRepo: matchingRepo, campaignRepo: campaignRepo}
+}
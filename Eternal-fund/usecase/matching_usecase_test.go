@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"database/sql"
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MatchingUseCaseTestSuite struct {
+	suite.Suite
+	muc          *matchingUseCase
+	matchingRepo *mocking.MatchingRepoMock
+	campaignRepo *mocking.CampaignRepoMock
+}
+
+func (suite *MatchingUseCaseTestSuite) SetupTest() {
+	suite.matchingRepo = new(mocking.MatchingRepoMock)
+	suite.campaignRepo = new(mocking.CampaignRepoMock)
+	suite.muc = &matchingUseCase{matchingRepo: suite.matchingRepo, campaignRepo: suite.campaignRepo}
+}
+
+func (suite *MatchingUseCaseTestSuite) TestCreatePledge() {
+	startsAt := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	input := model.MatchingPledgeInput{CampaignID: 3, SponsorName: " Acme ", RatioPercent: 100, Cap: 5000000,
+		StartsAt: startsAt, EndsAt: startsAt.AddDate(0, 1, 0), User: model.User{ID: 9}}
+	pledge := model.MatchingPledge{CampaignID: 3, SponsorName: "Acme", RatioPercent: 100, Cap: 5000000,
+		StartsAt: input.StartsAt, EndsAt: input.EndsAt, CreatedBy: 9}
+
+	suite.campaignRepo.On("FindByIdCampaigns", 3).Return(model.Campaigns{ID: 3}, nil)
+	suite.matchingRepo.On("Save", pledge).Return(model.MatchingPledge{ID: 4}, nil)
+
+	saved, err := suite.muc.CreatePledge(input)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 4, saved.ID)
+}
+
+func (suite *MatchingUseCaseTestSuite) TestCreatePledge_InvalidWindow() {
+	now := time.Now()
+	input := model.MatchingPledgeInput{CampaignID: 3, SponsorName: "Acme", RatioPercent: 100, Cap: 5000000, StartsAt: now, EndsAt: now}
+
+	_, err := suite.muc.CreatePledge(input)
+	assert.ErrorIs(suite.T(), err, model.ErrInvalidMatchingWindow)
+	suite.matchingRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *MatchingUseCaseTestSuite) TestCreatePledge_CampaignNotFound() {
+	now := time.Now()
+	input := model.MatchingPledgeInput{CampaignID: 3, SponsorName: "Acme", RatioPercent: 100, Cap: 5000000, StartsAt: now, EndsAt: now.Add(time.Hour)}
+	suite.campaignRepo.On("FindByIdCampaigns", 3).Return(model.Campaigns{}, sql.ErrNoRows)
+
+	_, err := suite.muc.CreatePledge(input)
+	assert.ErrorIs(suite.T(), err, sql.ErrNoRows)
+	suite.matchingRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *MatchingUseCaseTestSuite) TestMatchableAmount() {
+	assert.Equal(suite.T(), 10000, model.Transaction{Amount: 10000, TransactionFees: model.TransactionFees{FeeAmount: 600}}.MatchableAmount())
+	assert.Equal(suite.T(), 10000, model.Transaction{Amount: 10632, CoverFees: true, TransactionFees: model.TransactionFees{FeeAmount: 632}}.MatchableAmount())
+}
+
+func (suite *MatchingUseCaseTestSuite) TestSummarizeMatching_NoActivePledge() {
+	now := time.Now()
+	cancelledAt := now.Add(-time.Minute)
+	pledges := []model.MatchingPledge{
+		{SponsorName: "Acme", RatioPercent: 100, Cap: 1000, MatchedAmount: 300, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), CancelledAt: &cancelledAt},
+		{SponsorName: "Globex", RatioPercent: 100, Cap: 1000, MatchedAmount: 200, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+	}
+
+	assert.Nil(suite.T(), model.SummarizeMatching(nil, now))
+	assert.Equal(suite.T(), &model.CampaignMatching{MatchedAmount: 500}, model.SummarizeMatching(pledges, now))
+}
+
+func TestMatchingUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(MatchingUseCaseTestSuite))
+}
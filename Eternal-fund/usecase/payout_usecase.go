@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"fmt"
+	"strconv"
+)
+
+var (
+	ErrBankAccountNotVerified = errors.New("bank account is not a verified account of yours")
+	ErrBankAccountReviewed    = errors.New("bank account has already been reviewed")
+)
+
+type payoutUseCase struct {
+	bankAccountRepo repository.BankAccountRepo
+	withdrawalRepo  repository.WithdrawalRepo
+	memberRepo      repository.CampaignMemberRepo
+}
+
+func (p *payoutUseCase) RegisterBankAccount(input model.BankAccountInput) (model.BankAccount, error) {
+	return p.bankAccountRepo.Save(model.BankAccount{
+		UserID:        input.User.ID,
+		BankCode:      input.BankCode,
+		AccountNumber: input.AccountNumber,
+		AccountName:   input.AccountName,
+		Status:        model.BankAccountStatusUnverified,
+	})
+}
+
+// ListBankAccounts returns the user's own accounts. Admins can instead list
+// every account in a status, such as those waiting for verification.
+func (p *payoutUseCase) ListBankAccounts(user model.User, status string) ([]model.BankAccount, error) {
+	if user.Role == "admin" && status != "" {
+		return p.bankAccountRepo.FindByStatus(status)
+	}
+	return p.bankAccountRepo.FindByUserID(user.ID)
+}
+
+func (p *payoutUseCase) ReviewBankAccount(id int, verified bool, admin model.User) (model.BankAccount, error) {
+	account, err := p.bankAccountRepo.FindByID(id)
+	if err != nil {
+		return model.BankAccount{}, err
+	}
+	if account.Status != model.BankAccountStatusUnverified {
+		return model.BankAccount{}, ErrBankAccountReviewed
+	}
+
+	status := model.BankAccountStatusRejected
+	if verified {
+		status = model.BankAccountStatusVerified
+	}
+	return p.bankAccountRepo.UpdateStatus(id, status, admin.ID)
+}
+
+func (p *payoutUseCase) GetAvailableBalance(campaignID int, user model.User) (int, error) {
+	if err := authorizeCampaign(p.memberRepo, campaignID, user, model.CampaignRoleOwner); err != nil {
+		return 0, err
+	}
+	return p.withdrawalRepo.AvailableBalance(campaignID)
+}
+
+// RequestWithdrawal asks for part of a campaign's balance to be paid into one
+// of the requesting owner's verified bank accounts.
+func (p *payoutUseCase) RequestWithdrawal(input model.WithdrawalInput) (model.Withdrawal, error) {
+	if err := authorizeCampaign(p.memberRepo, input.CampaignID, input.User, model.CampaignRoleOwner); err != nil {
+		return model.Withdrawal{}, err
+	}
+
+	account, err := p.bankAccountRepo.FindByID(input.BankAccountID)
+	if err != nil || account.UserID != input.User.ID || account.Status != model.BankAccountStatusVerified {
+		return model.Withdrawal{}, ErrBankAccountNotVerified
+	}
+
+	return p.withdrawalRepo.Create(model.Withdrawal{
+		CampaignID:    input.CampaignID,
+		BankAccountID: account.ID,
+		Amount:        input.Amount,
+		RequestedBy:   input.User.ID,
+	})
+}
+
+func (p *payoutUseCase) ListCampaignWithdrawals(campaignID int, user model.User) ([]model.Withdrawal, error) {
+	err := authorizeCampaign(p.memberRepo, campaignID, user, model.CampaignRoleOwner, model.CampaignRoleEditor, model.CampaignRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return p.withdrawalRepo.FindByCampaignID(campaignID)
+}
+
+func (p *payoutUseCase) ListWithdrawals(status model.WithdrawalStatus) ([]model.Withdrawal, error) {
+	if status == "" {
+		status = model.WithdrawalStatusRequested
+	}
+	return p.withdrawalRepo.FindByStatus(status)
+}
+
+func (p *payoutUseCase) ApproveWithdrawal(id int, input model.ReviewWithdrawalInput) (model.Withdrawal, error) {
+	return p.withdrawalRepo.UpdateStatus(id, model.WithdrawalStatusApproved, input.User.ID, input.Note)
+}
+
+func (p *payoutUseCase) RejectWithdrawal(id int, input model.ReviewWithdrawalInput) (model.Withdrawal, error) {
+	return p.withdrawalRepo.UpdateStatus(id, model.WithdrawalStatusRejected, input.User.ID, input.Note)
+}
+
+// FailWithdrawal records that the bank transfer for an approved withdrawal
+// did not go through, which returns the amount to the campaign balance.
+func (p *payoutUseCase) FailWithdrawal(id int, input model.ReviewWithdrawalInput) (model.Withdrawal, error) {
+	return p.withdrawalRepo.UpdateStatus(id, model.WithdrawalStatusFailed, input.User.ID, input.Note)
+}
+
+func (p *payoutUseCase) CreatePayoutBatch(admin model.User) (model.PayoutBatch, error) {
+	return p.withdrawalRepo.CreateBatch(admin.ID)
+}
+
+func (p *payoutUseCase) GetPayoutBatch(id int) (model.PayoutBatch, error) {
+	return p.withdrawalRepo.FindBatch(id)
+}
+
+// ExportPayoutBatch renders the outstanding transfers of a batch as a CSV
+// file for the bank's bulk transfer upload.
+func (p *payoutUseCase) ExportPayoutBatch(id int) ([]byte, error) {
+	if _, err := p.withdrawalRepo.FindBatch(id); err != nil {
+		return nil, err
+	}
+	lines, err := p.withdrawalRepo.FindBatchLines(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"reference", "bank_code", "account_number", "account_name", "amount"})
+	for _, line := range lines {
+		writer.Write([]string{
+			fmt.Sprintf("WD-%d", line.WithdrawalID),
+			line.BankCode,
+			line.AccountNumber,
+			line.AccountName,
+			strconv.Itoa(line.Amount),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *payoutUseCase) CompletePayoutBatch(id int) (model.PayoutBatch, error) {
+	return p.withdrawalRepo.CompleteBatch(id)
+}
+
+type PayoutUseCase interface {
+	RegisterBankAccount(input model.BankAccountInput) (model.BankAccount, error)
+	ListBankAccounts(user model.User, status string) ([]model.BankAccount, error)
+	ReviewBankAccount(id int, verified bool, admin model.User) (model.BankAccount, error)
+	GetAvailableBalance(campaignID int, user model.User) (int, error)
+	RequestWithdrawal(input model.WithdrawalInput) (model.Withdrawal, error)
+	ListCampaignWithdrawals(campaignID int, user model.User) ([]model.Withdrawal, error)
+	ListWithdrawals(status model.WithdrawalStatus) ([]model.Withdrawal, error)
+	ApproveWithdrawal(id int, input model.ReviewWithdrawalInput) (model.Withdrawal, error)
+	RejectWithdrawal(id int, input model.ReviewWithdrawalInput) (model.Withdrawal, error)
+	FailWithdrawal(id int, input model.ReviewWithdrawalInput) (model.Withdrawal, error)
+	CreatePayoutBatch(admin model.User) (model.PayoutBatch, error)
+	GetPayoutBatch(id int) (model.PayoutBatch, error)
+	ExportPayoutBatch(id int) ([]byte, error)
+	CompletePayoutBatch(id int) (model.PayoutBatch, error)
+}
+
+func NewPayoutUseCase(bankAccountRepo repository.BankAccountRepo, withdrawalRepo repository.WithdrawalRepo, memberRepo repository.CampaignMemberRepo) PayoutUseCase {
+	return &payoutUseCase{bankAccountRepo: bankAccountRepo, withdrawalRepo: withdrawalRepo, memberRepo: memberRepo}
+}
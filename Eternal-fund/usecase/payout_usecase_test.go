@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PayoutUseCaseTestSuite struct {
+	suite.Suite
+	puc             *payoutUseCase
+	bankAccountRepo *mocking.BankAccountRepoMock
+	withdrawalRepo  *mocking.WithdrawalRepoMock
+	memberRepo      *mocking.CampaignMemberRepoMock
+}
+
+func (suite *PayoutUseCaseTestSuite) SetupTest() {
+	suite.bankAccountRepo = new(mocking.BankAccountRepoMock)
+	suite.withdrawalRepo = new(mocking.WithdrawalRepoMock)
+	suite.memberRepo = new(mocking.CampaignMemberRepoMock)
+	suite.puc = &payoutUseCase{
+		bankAccountRepo: suite.bankAccountRepo,
+		withdrawalRepo:  suite.withdrawalRepo,
+		memberRepo:      suite.memberRepo,
+	}
+}
+
+func (suite *PayoutUseCaseTestSuite) TestRequestWithdrawal() {
+	owner := model.User{ID: 4, Role: "user"}
+	expected := model.Withdrawal{CampaignID: 2, BankAccountID: 6, Amount: 50000, RequestedBy: 4}
+	created := expected
+	created.ID = 1
+	created.Status = model.WithdrawalStatusRequested
+
+	suite.memberRepo.On("FindMembership", 2, 4).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
+	suite.bankAccountRepo.On("FindByID", 6).Return(model.BankAccount{ID: 6, UserID: 4, Status: model.BankAccountStatusVerified}, nil)
+	suite.withdrawalRepo.On("Create", expected).Return(created, nil)
+
+	withdrawal, err := suite.puc.RequestWithdrawal(model.WithdrawalInput{CampaignID: 2, BankAccountID: 6, Amount: 50000, User: owner})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), created, withdrawal)
+}
+
+func (suite *PayoutUseCaseTestSuite) TestRequestWithdrawal_UnverifiedAccount() {
+	owner := model.User{ID: 4, Role: "user"}
+	suite.memberRepo.On("FindMembership", 2, 4).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
+	suite.bankAccountRepo.On("FindByID", 6).Return(model.BankAccount{ID: 6, UserID: 4, Status: model.BankAccountStatusUnverified}, nil)
+
+	_, err := suite.puc.RequestWithdrawal(model.WithdrawalInput{CampaignID: 2, BankAccountID: 6, Amount: 50000, User: owner})
+	assert.ErrorIs(suite.T(), err, ErrBankAccountNotVerified)
+	suite.withdrawalRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *PayoutUseCaseTestSuite) TestRequestWithdrawal_OtherUsersAccount() {
+	owner := model.User{ID: 4, Role: "user"}
+	suite.memberRepo.On("FindMembership", 2, 4).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
+	suite.bankAccountRepo.On("FindByID", 6).Return(model.BankAccount{ID: 6, UserID: 9, Status: model.BankAccountStatusVerified}, nil)
+
+	_, err := suite.puc.RequestWithdrawal(model.WithdrawalInput{CampaignID: 2, BankAccountID: 6, Amount: 50000, User: owner})
+	assert.ErrorIs(suite.T(), err, ErrBankAccountNotVerified)
+}
+
+func (suite *PayoutUseCaseTestSuite) TestRequestWithdrawal_InsufficientBalance() {
+	admin := model.User{ID: 1, Role: "admin"}
+	suite.bankAccountRepo.On("FindByID", 6).Return(model.BankAccount{ID: 6, UserID: 1, Status: model.BankAccountStatusVerified}, nil)
+	suite.withdrawalRepo.On("Create", mock.AnythingOfType("model.Withdrawal")).Return(model.Withdrawal{}, repository.ErrInsufficientBalance)
+
+	_, err := suite.puc.RequestWithdrawal(model.WithdrawalInput{CampaignID: 2, BankAccountID: 6, Amount: 50000, User: admin})
+	assert.ErrorIs(suite.T(), err, repository.ErrInsufficientBalance)
+}
+
+func (suite *PayoutUseCaseTestSuite) TestReviewBankAccount_AlreadyReviewed() {
+	suite.bankAccountRepo.On("FindByID", 6).Return(model.BankAccount{ID: 6, Status: model.BankAccountStatusVerified}, nil)
+
+	_, err := suite.puc.ReviewBankAccount(6, false, model.User{ID: 1, Role: "admin"})
+	assert.ErrorIs(suite.T(), err, ErrBankAccountReviewed)
+	suite.bankAccountRepo.AssertNotCalled(suite.T(), "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PayoutUseCaseTestSuite) TestExportPayoutBatch() {
+	suite.withdrawalRepo.On("FindBatch", 3).Return(model.PayoutBatch{ID: 3, Status: model.PayoutBatchStatusExported}, nil)
+	suite.withdrawalRepo.On("FindBatchLines", 3).Return([]model.PayoutLine{
+		{WithdrawalID: 1, BankCode: "014", AccountNumber: "1234567890", AccountName: "Budi, Santoso", Amount: 50000},
+	}, nil)
+
+	file, err := suite.puc.ExportPayoutBatch(3)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "reference,bank_code,account_number,account_name,amount\nWD-1,014,1234567890,\"Budi, Santoso\",50000\n", string(file))
+}
+
+func TestPayoutUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(PayoutUseCaseTestSuite))
+}
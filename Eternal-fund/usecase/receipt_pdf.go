@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"bytes"
+	"eternal-fund/model"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// receiptDocument is everything printed on a donation receipt.
+type receiptDocument struct {
+	Issuer      string
+	Receipt     model.Receipt
+	Transaction model.Transaction
+	Donor       model.User
+	Campaign    string
+}
+
+// renderReceipt lays out a receipt as a one page A4 PDF. The document is
+// dated with the receipt's issue time, so rendering the same receipt again
+// gives the same file.
+func renderReceipt(doc receiptDocument) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(doc.Receipt.IssuedAt)
+	pdf.SetModificationDate(doc.Receipt.IssuedAt)
+	pdf.SetTitle("Donation receipt "+doc.Receipt.Number, true)
+	pdf.SetAuthor(doc.Issuer, true)
+	// The core fonts only cover cp1252, so names are translated to it.
+	text := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(0, 12, text(doc.Issuer), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 14)
+	pdf.CellFormat(0, 8, "Donation Receipt", "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	rows := [][2]string{
+		{"Receipt number", doc.Receipt.Number},
+		{"Issued on", doc.Receipt.IssuedAt.Format("2 January 2006")},
+		{"Donation date", doc.Receipt.DonatedAt.Format("2 January 2006")},
+		{"Donor", doc.Donor.Name},
+		{"Donor email", doc.Donor.Email},
+		{"Campaign", doc.Campaign},
+		{"Transaction", doc.Transaction.Code},
+		{"Amount", model.FormatMoney(doc.Receipt.Amount, doc.Transaction.Currency)},
+	}
+	for _, row := range rows {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(45, 8, row[0], "B", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 8, text(row[1]), "B", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(8)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(0, 5, text("Thank you for your donation to "+doc.Campaign+". "+
+		"Please keep this receipt for your tax records."), "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"eternal-fund/usecase/service"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	ErrReceiptForbidden    = errors.New("only the donor can download the receipt of a donation")
+	ErrReceiptNotAvailable = errors.New("receipts are only available for paid donations")
+)
+
+// receiptBatchSize caps the receipts issued and delivered in a single run.
+const receiptBatchSize = 100
+
+type receiptUseCase struct {
+	receiptRepo     repository.ReceiptRepo
+	transactionRepo repository.TransactionRepo
+	refundRepo      repository.RefundRepo
+	campaignRepo    repository.CampaignsRepo
+	userRepo        repository.UserRepo
+	blobStorage     service.BlobStorage
+	mailService     service.MailService
+	issuer          string
+	fiscalYearStart time.Month
+}
+
+// IssueReceipts numbers a receipt for every paid donation that has none yet
+// and then emails the receipts that have not been delivered. Running it again
+// after a failure picks up where the last run stopped.
+func (r *receiptUseCase) IssueReceipts() error {
+	failed := 0
+
+	transactions, err := r.receiptRepo.FindUnissued(receiptBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, transaction := range transactions {
+		if _, err := r.issue(transaction); err != nil && !errors.Is(err, repository.ErrReceiptExists) {
+			log.Printf("[RECEIPT] issuing receipt for transaction %d: %v", transaction.ID, err)
+			failed++
+		}
+	}
+
+	receipts, err := r.receiptRepo.FindUndelivered(receiptBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, receipt := range receipts {
+		if err := r.deliver(receipt); err != nil {
+			log.Printf("[RECEIPT] delivering receipt %s: %v", receipt.Number, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d receipts could not be issued or delivered", failed, len(transactions)+len(receipts))
+	}
+	return nil
+}
+
+func (r *receiptUseCase) StartIssuer(interval time.Duration) {
+	runPeriodically("receipt issuing", interval, r.IssueReceipts)
+}
+
+// issue numbers the receipt of a paid transaction, dated when it was paid
+// and for what has not been refunded of it.
+func (r *receiptUseCase) issue(transaction model.Transaction) (model.Receipt, error) {
+	paidTimes, err := findPaidTimes(r.transactionRepo, []model.Transaction{transaction})
+	if err != nil {
+		return model.Receipt{}, err
+	}
+	refunded, err := refundedAmount(r.refundRepo, transaction)
+	if err != nil {
+		return model.Receipt{}, err
+	}
+	donatedAt := paidTimes[transaction.ID]
+	return r.receiptRepo.Create(model.Receipt{
+		TransactionID: transaction.ID,
+		FiscalYear:    model.FiscalYear(donatedAt, r.fiscalYearStart),
+		Amount:        transaction.Amount - refunded,
+		DonatedAt:     donatedAt,
+	})
+}
+
+// findPaidTimes returns when each of the transactions was paid. Donations
+// paid before status logs were kept count as paid when they were last
+// updated.
+func findPaidTimes(transactionRepo repository.TransactionRepo, transactions []model.Transaction) (map[int]time.Time, error) {
+	ids := make([]int, 0, len(transactions))
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.ID)
+	}
+	paidTimes, err := transactionRepo.FindPaidTimes(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		if _, ok := paidTimes[transaction.ID]; !ok {
+			paidTimes[transaction.ID] = transaction.UpdatedAt
+		}
+	}
+	return paidTimes, nil
+}
+
+// deliver stores the receipt's PDF and emails it to the donor.
+func (r *receiptUseCase) deliver(receipt model.Receipt) error {
+	transaction, err := r.transactionRepo.GetByID(receipt.TransactionID)
+	if err != nil {
+		return err
+	}
+	donor, err := donorOf(r.userRepo, transaction)
+	if err != nil {
+		return err
+	}
+	pdf, err := r.store(receipt, transaction, donor)
+	if err != nil {
+		return err
+	}
+
+	if donor.Email != "" {
+		err = r.mailService.SendWithAttachment(donor.Email, "Your donation receipt "+receipt.Number, fmt.Sprintf("Hi %s,\n\n"+
+			"Thank you for your donation of %s. Your receipt %s is attached.\n",
+			donor.Name, model.FormatMoney(receipt.Amount, transaction.Currency), receipt.Number), service.Attachment{
+			Name:        receipt.Number + ".pdf",
+			ContentType: "application/pdf",
+			Data:        pdf,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return r.receiptRepo.MarkDelivered(receipt.ID)
+}
+
+// store renders the receipt and saves it in blob storage.
+func (r *receiptUseCase) store(receipt model.Receipt, transaction model.Transaction, donor model.User) ([]byte, error) {
+	campaign, err := campaignName(r.campaignRepo, transaction.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf, err := renderReceipt(receiptDocument{
+		Issuer:      r.issuer,
+		Receipt:     receipt,
+		Transaction: transaction,
+		Donor:       donor,
+		Campaign:    campaign,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.blobStorage.Put(receipt.BlobKey(), pdf); err != nil {
+		return nil, err
+	}
+	return pdf, nil
+}
+
+// campaignName names a campaign on donor documents, which outlive the
+// campaigns they mention.
+func campaignName(campaignRepo repository.CampaignsRepo, campaignID int) (string, error) {
+	campaign, err := campaignRepo.FindByIdCampaigns(campaignID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	if campaign.Name == "" {
+		// The campaign has been deleted since.
+		return fmt.Sprintf("campaign #%d", campaignID), nil
+	}
+	return campaign.Name, nil
+}
+
+// GetReceipt returns the receipt of one of the user's donations as a PDF. A
+// receipt the issuer has not got to yet is issued straight away.
+func (r *receiptUseCase) GetReceipt(transactionID int, user model.User) (model.Receipt, []byte, error) {
+	transaction, err := r.transactionRepo.GetByID(transactionID)
+	if err != nil {
+		return model.Receipt{}, nil, err
+	}
+	if transaction.UserID == 0 || transaction.UserID != user.ID {
+		return model.Receipt{}, nil, ErrReceiptForbidden
+	}
+	if !transaction.Status.CountsTowardsTotal() {
+		return model.Receipt{}, nil, ErrReceiptNotAvailable
+	}
+
+	receipt, err := r.receiptRepo.FindByTransactionID(transactionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		receipt, err = r.issue(transaction)
+		if errors.Is(err, repository.ErrReceiptExists) {
+			receipt, err = r.receiptRepo.FindByTransactionID(transactionID)
+		}
+	}
+	if err != nil {
+		return model.Receipt{}, nil, err
+	}
+
+	pdf, err := r.blobStorage.Get(receipt.BlobKey())
+	if errors.Is(err, service.ErrBlobNotFound) {
+		var donor model.User
+		donor, err = donorOf(r.userRepo, transaction)
+		if err == nil {
+			pdf, err = r.store(receipt, transaction, donor)
+		}
+	}
+	if err != nil {
+		return model.Receipt{}, nil, err
+	}
+	return receipt, pdf, nil
+}
+
+type ReceiptUseCase interface {
+	IssueReceipts() error
+	StartIssuer(interval time.Duration)
+	GetReceipt(transactionID int, user model.User) (model.Receipt, []byte, error)
+}
+
+func NewReceiptUseCase(receiptRepo repository.ReceiptRepo, transactionRepo repository.TransactionRepo, refundRepo repository.RefundRepo,
+	campaignRepo repository.CampaignsRepo, userRepo repository.UserRepo, blobStorage service.BlobStorage, mailService service.MailService,
+	issuer string, fiscalYearStart time.Month) ReceiptUseCase {
+	return &receiptUseCase{
+		receiptRepo:     receiptRepo,
+		transactionRepo: transactionRepo,
+		refundRepo:      refundRepo,
+		campaignRepo:    campaignRepo,
+		userRepo:        userRepo,
+		blobStorage:     blobStorage,
+		mailService:     mailService,
+		issuer:          issuer,
+		fiscalYearStart: fiscalYearStart,
+	}
+}
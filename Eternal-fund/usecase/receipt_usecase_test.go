@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"bytes"
+	"database/sql"
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"eternal-fund/usecase/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReceiptUseCaseTestSuite struct {
+	suite.Suite
+	ruc             *receiptUseCase
+	receiptRepo     *mocking.ReceiptRepoMock
+	transactionRepo *mocking.TransactionRepoMock
+	refundRepo      *mocking.RefundRepoMock
+	campaignRepo    *mocking.CampaignRepoMock
+	userRepo        *mocking.UserRepoMock
+	blobStorage     *mocking.BlobStorageMock
+	mailService     *mocking.MailServiceMock
+}
+
+var (
+	receiptDonor    = model.User{ID: 4, Name: "Siti Rahma", Email: "siti@example.com", Role: "user"}
+	receiptPaidAt   = time.Date(2024, time.March, 5, 10, 0, 0, 0, time.UTC)
+	paidDonation    = model.Transaction{ID: 7, CampaignID: 2, UserID: 4, Amount: 150000, Status: model.TransactionStatusPaid, Code: "TRX-7", UpdatedAt: receiptPaidAt.AddDate(0, 1, 0)}
+	issuedReceipt   = model.Receipt{ID: 1, TransactionID: 7, Number: "RCPT-2023-000012", FiscalYear: 2023, Sequence: 12, Amount: 150000, DonatedAt: receiptPaidAt, IssuedAt: receiptPaidAt}
+	receiptCampaign = model.Campaigns{ID: 2, Name: "Clean Water for Sumba"}
+)
+
+func (suite *ReceiptUseCaseTestSuite) SetupTest() {
+	suite.receiptRepo = new(mocking.ReceiptRepoMock)
+	suite.transactionRepo = new(mocking.TransactionRepoMock)
+	suite.refundRepo = new(mocking.RefundRepoMock)
+	suite.campaignRepo = new(mocking.CampaignRepoMock)
+	suite.userRepo = new(mocking.UserRepoMock)
+	suite.blobStorage = new(mocking.BlobStorageMock)
+	suite.mailService = new(mocking.MailServiceMock)
+	suite.ruc = &receiptUseCase{
+		receiptRepo:     suite.receiptRepo,
+		transactionRepo: suite.transactionRepo,
+		refundRepo:      suite.refundRepo,
+		campaignRepo:    suite.campaignRepo,
+		userRepo:        suite.userRepo,
+		blobStorage:     suite.blobStorage,
+		mailService:     suite.mailService,
+		issuer:          "Eternal Fund",
+		fiscalYearStart: time.April,
+	}
+}
+
+func (suite *ReceiptUseCaseTestSuite) TestFiscalYear() {
+	assert.Equal(suite.T(), 2023, model.FiscalYear(receiptPaidAt, time.April))
+	assert.Equal(suite.T(), 2024, model.FiscalYear(receiptPaidAt.AddDate(0, 1, 0), time.April))
+	assert.Equal(suite.T(), 2024, model.FiscalYear(receiptPaidAt, time.January))
+	assert.Equal(suite.T(), "RCPT-2024-000012", model.ReceiptNumber(2024, 12))
+}
+
+func (suite *ReceiptUseCaseTestSuite) TestIssueReceipts_IssuesAndEmails() {
+	suite.receiptRepo.On("FindUnissued", receiptBatchSize).Return([]model.Transaction{paidDonation}, nil)
+	suite.transactionRepo.On("FindPaidTimes", []int{7}).Return(map[int]time.Time{7: receiptPaidAt}, nil)
+	suite.refundRepo.On("FindByTransactionID", 7).Return([]model.Refund{
+		{ID: 1, TransactionID: 7, Amount: 50000, Status: model.RefundStatusSucceeded},
+		{ID: 2, TransactionID: 7, Amount: 10000, Status: model.RefundStatusFailed},
+	}, nil)
+	suite.receiptRepo.On("Create", model.Receipt{TransactionID: 7, FiscalYear: 2023, Amount: 100000, DonatedAt: receiptPaidAt}).Return(issuedReceipt, nil)
+	suite.receiptRepo.On("FindUndelivered", receiptBatchSize).Return([]model.Receipt{issuedReceipt}, nil)
+	suite.transactionRepo.On("GetByID", 7).Return(paidDonation, nil)
+	suite.userRepo.On("FindById", 4).Return(receiptDonor, nil)
+	suite.campaignRepo.On("FindByIdCampaigns", 2).Return(receiptCampaign, nil)
+	suite.blobStorage.On("Put", "receipts/2023/RCPT-2023-000012.pdf", mock.Anything).Return(nil)
+	suite.mailService.On("SendWithAttachment", "siti@example.com", "Your donation receipt RCPT-2023-000012", mock.Anything,
+		mock.MatchedBy(func(attachment service.Attachment) bool {
+			return attachment.Name == "RCPT-2023-000012.pdf" && bytes.HasPrefix(attachment.Data, []byte("%PDF-"))
+		})).Return(nil)
+	suite.receiptRepo.On("MarkDelivered", 1).Return(nil)
+
+	err := suite.ruc.IssueReceipts()
+
+	assert.NoError(suite.T(), err)
+	suite.receiptRepo.AssertExpectations(suite.T())
+	suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *ReceiptUseCaseTestSuite) TestIssueReceipts_KeepsUndeliveredOnMailFailure() {
+	suite.receiptRepo.On("FindUnissued", receiptBatchSize).Return([]model.Transaction{}, nil)
+	suite.receiptRepo.On("FindUndelivered", receiptBatchSize).Return([]model.Receipt{issuedReceipt}, nil)
+	suite.transactionRepo.On("GetByID", 7).Return(paidDonation, nil)
+	suite.userRepo.On("FindById", 4).Return(receiptDonor, nil)
+	suite.campaignRepo.On("FindByIdCampaigns", 2).Return(receiptCampaign, nil)
+	suite.blobStorage.On("Put", mock.Anything, mock.Anything).Return(nil)
+	suite.mailService.On("SendWithAttachment", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(assert.AnError)
+
+	err := suite.ruc.IssueReceipts()
+
+	assert.Error(suite.T(), err)
+	suite.receiptRepo.AssertNotCalled(suite.T(), "MarkDelivered", mock.Anything)
+}
+
+func (suite *ReceiptUseCaseTestSuite) TestGetReceipt_OnlyDonor() {
+	suite.transactionRepo.On("GetByID", 7).Return(paidDonation, nil)
+
+	_, _, err := suite.ruc.GetReceipt(7, model.User{ID: 9, Role: "admin"})
+
+	assert.ErrorIs(suite.T(), err, ErrReceiptForbidden)
+}
+
+func (suite *ReceiptUseCaseTestSuite) TestGetReceipt_NotPaid() {
+	pending := paidDonation
+	pending.Status = model.TransactionStatusPending
+	suite.transactionRepo.On("GetByID", 7).Return(pending, nil)
+
+	_, _, err := suite.ruc.GetReceipt(7, receiptDonor)
+
+	assert.ErrorIs(suite.T(), err, ErrReceiptNotAvailable)
+}
+
+func (suite *ReceiptUseCaseTestSuite) TestGetReceipt_IssuesAndRendersMissingReceipt() {
+	suite.transactionRepo.On("GetByID", 7).Return(paidDonation, nil)
+	suite.receiptRepo.On("FindByTransactionID", 7).Return(model.Receipt{}, sql.ErrNoRows)
+	suite.transactionRepo.On("FindPaidTimes", []int{7}).Return(map[int]time.Time{7: receiptPaidAt}, nil)
+	suite.refundRepo.On("FindByTransactionID", 7).Return([]model.Refund(nil), nil)
+	suite.receiptRepo.On("Create", model.Receipt{TransactionID: 7, FiscalYear: 2023, Amount: 150000, DonatedAt: receiptPaidAt}).Return(issuedReceipt, nil)
+	suite.blobStorage.On("Get", "receipts/2023/RCPT-2023-000012.pdf").Return([]byte(nil), service.ErrBlobNotFound)
+	suite.userRepo.On("FindById", 4).Return(receiptDonor, nil)
+	suite.campaignRepo.On("FindByIdCampaigns", 2).Return(receiptCampaign, nil)
+	suite.blobStorage.On("Put", "receipts/2023/RCPT-2023-000012.pdf", mock.Anything).Return(nil)
+
+	receipt, pdf, err := suite.ruc.GetReceipt(7, receiptDonor)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "RCPT-2023-000012", receipt.Number)
+	assert.True(suite.T(), bytes.HasPrefix(pdf, []byte("%PDF-")))
+}
+
+func TestReceiptUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(ReceiptUseCaseTestSuite))
+}
@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"eternal-fund/repository"
+	"eternal-fund/usecase/service"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSettlementReport = errors.New("settlement report must be a CSV with order_id, gross_amount and transaction_status columns")
+	ErrInvalidSettlementPeriod = errors.New("period_end must not be before period_start")
+)
+
+// reconcileBatchSize caps the status lookups of a single run so a backlog of
+// pending transactions cannot use up the gateway's rate limit.
+const reconcileBatchSize = 200
+
+type reconciliationUseCase struct {
+	reconciliationRepo repository.ReconciliationRepo
+	transactionRepo    repository.TransactionRepo
+	transactionUC      TransactionUseCase
+	paymentProvider    service.PaymentProvider
+	reconcileAfter     time.Duration
+	paymentWindow      time.Duration
+}
+
+// settlementRow is one order in a gateway settlement report.
+type settlementRow struct {
+	orderID     string
+	grossAmount string
+	status      string
+}
+
+// Reconcile asks the gateway for the status of transactions that have been
+// pending for longer than reconcileAfter, in case their webhook was missed.
+func (r *reconciliationUseCase) Reconcile(now time.Time) error {
+	pending, err := r.reconciliationRepo.FindPendingTransactions(now.Add(-r.reconcileAfter), reconcileBatchSize)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, transaction := range pending {
+		if err := r.checkStatus(transaction, now); err != nil {
+			log.Printf("[RECONCILE] checking transaction %s: %v", transaction.Code, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d pending transactions could not be reconciled", failed, len(pending))
+	}
+	return nil
+}
+
+func (r *reconciliationUseCase) StartReconciler(interval time.Duration) {
+	runPeriodically("payment reconciliation", interval, func() error {
+		return r.Reconcile(time.Now())
+	})
+}
+
+// checkStatus applies the gateway's status of a pending transaction the same
+// way a webhook would. A transaction the gateway has never heard of is expired
+// once the payment window has passed, since nothing can settle it any more.
+func (r *reconciliationUseCase) checkStatus(transaction model.Transaction, now time.Time) error {
+	status, err := r.paymentProvider.FetchStatus(transaction.Code)
+	if errors.Is(err, service.ErrChargeNotFound) {
+		if now.Sub(transaction.CreatedAt) < r.paymentWindow {
+			return nil
+		}
+		_, err = r.transactionRepo.ApplyStatus(model.TransactionStatusChange{
+			TransactionID: transaction.ID,
+			ToStatus:      model.TransactionStatusExpired,
+			Source:        model.StatusSourceSystem,
+			Note:          "no payment at the gateway within the payment window",
+		})
+		if errors.Is(err, model.ErrIllegalTransition) {
+			// A webhook settled it in the meantime.
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if !grossAmountMatches(status.GrossAmount, transaction.Amount) {
+		return r.flag(model.ReconciliationItem{
+			TransactionID: transaction.ID,
+			OrderID:       transaction.Code,
+			Kind:          model.ReconciliationAmountMismatch,
+			Source:        model.ReconciliationSourceStatusCheck,
+			LocalAmount:   transaction.Amount,
+			GatewayAmount: gatewayAmount(status.GrossAmount),
+			LocalStatus:   string(transaction.Status),
+			GatewayStatus: status.TransactionStatus,
+		})
+	}
+	if status.InternalStatus() == model.TransactionStatusPending {
+		return nil
+	}
+	_, err = r.transactionUC.ProcessPayment(status)
+	return err
+}
+
+func (r *reconciliationUseCase) flag(item model.ReconciliationItem) error {
+	_, created, err := r.reconciliationRepo.SaveItem(item)
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Printf("[RECONCILE] %s on transaction %s", item.Kind, item.OrderID)
+	}
+	return nil
+}
+
+// ImportSettlement compares a gateway settlement report with the transactions
+// here. It flags orders whose amount or paid state differ, orders the report
+// has and we do not, and transactions paid during the period that the report
+// left out.
+func (r *reconciliationUseCase) ImportSettlement(input model.SettlementImportInput) (model.SettlementImport, []model.ReconciliationItem, error) {
+	if input.PeriodEnd.Before(input.PeriodStart) {
+		return model.SettlementImport{}, nil, ErrInvalidSettlementPeriod
+	}
+	rows, err := parseSettlementReport(input.Report)
+	if err != nil {
+		return model.SettlementImport{}, nil, err
+	}
+
+	// Reports list every status change of an order, so the last row of an
+	// order is its current state.
+	var codes []string
+	reported := make(map[string]settlementRow)
+	for _, row := range rows {
+		if _, seen := reported[row.orderID]; !seen {
+			codes = append(codes, row.orderID)
+		}
+		reported[row.orderID] = row
+	}
+
+	transactions, err := r.reconciliationRepo.FindTransactionsByCodes(codes)
+	if err != nil {
+		return model.SettlementImport{}, nil, err
+	}
+	byCode := make(map[string]model.Transaction, len(transactions))
+	for _, transaction := range transactions {
+		byCode[transaction.Code] = transaction
+	}
+
+	var items []model.ReconciliationItem
+	for _, code := range codes {
+		row := reported[code]
+		item := model.ReconciliationItem{
+			OrderID:       code,
+			Source:        model.ReconciliationSourceSettlement,
+			GatewayAmount: gatewayAmount(row.grossAmount),
+			GatewayStatus: row.status,
+		}
+		transaction, found := byCode[code]
+		if !found {
+			item.Kind = model.ReconciliationMissingTransaction
+			items = append(items, item)
+			continue
+		}
+
+		item.TransactionID = transaction.ID
+		item.LocalAmount = transaction.Amount
+		item.LocalStatus = string(transaction.Status)
+		if !grossAmountMatches(row.grossAmount, transaction.Amount) {
+			item.Kind = model.ReconciliationAmountMismatch
+			items = append(items, item)
+		}
+		if settledAtGateway(row.status) != chargeSucceeded(transaction.Status) {
+			item.Kind = model.ReconciliationStatusMismatch
+			items = append(items, item)
+		}
+	}
+
+	paid, err := r.reconciliationRepo.FindPaidTransactions(input.PeriodStart, input.PeriodEnd.AddDate(0, 0, 1))
+	if err != nil {
+		return model.SettlementImport{}, nil, err
+	}
+	for _, transaction := range paid {
+		if _, ok := reported[transaction.Code]; ok {
+			continue
+		}
+		items = append(items, model.ReconciliationItem{
+			TransactionID: transaction.ID,
+			OrderID:       transaction.Code,
+			Kind:          model.ReconciliationMissingSettlement,
+			Source:        model.ReconciliationSourceSettlement,
+			LocalAmount:   transaction.Amount,
+			LocalStatus:   string(transaction.Status),
+		})
+	}
+
+	return r.reconciliationRepo.SaveImport(model.SettlementImport{
+		FileName:      input.FileName,
+		PeriodStart:   input.PeriodStart,
+		PeriodEnd:     input.PeriodEnd,
+		RowCount:      len(rows),
+		MismatchCount: len(items),
+		ImportedBy:    input.User.ID,
+	}, items)
+}
+
+func (r *reconciliationUseCase) GetItems(all bool, page int, size int) ([]model.ReconciliationItem, dto.Paging, error) {
+	return r.reconciliationRepo.FindItems(all, page, size)
+}
+
+func (r *reconciliationUseCase) ResolveItem(id int, input model.ResolveReconciliationInput) (model.ReconciliationItem, error) {
+	return r.reconciliationRepo.Resolve(id, input.User.ID, strings.TrimSpace(input.Note))
+}
+
+// parseSettlementReport reads the order_id, gross_amount and
+// transaction_status columns of a settlement report. Columns are found by
+// their header so reports with extra or reordered columns still import.
+func parseSettlementReport(report []byte) ([]settlementRow, error) {
+	reader := csv.NewReader(bytes.NewReader(report))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidSettlementReport
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	orderCol, hasOrder := columns["order_id"]
+	amountCol, hasAmount := columns["gross_amount"]
+	statusCol, hasStatus := columns["transaction_status"]
+	if !hasOrder || !hasAmount || !hasStatus {
+		return nil, ErrInvalidSettlementReport
+	}
+
+	var rows []settlementRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSettlementReport, err)
+		}
+		row := settlementRow{
+			orderID:     strings.TrimSpace(record[orderCol]),
+			grossAmount: strings.TrimSpace(record[amountCol]),
+			status:      strings.ToLower(strings.TrimSpace(record[statusCol])),
+		}
+		if row.orderID == "" {
+			return nil, fmt.Errorf("%w: line %d has no order_id", ErrInvalidSettlementReport, line)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// settledAtGateway reports whether a gateway status means the donor paid,
+// including payments refunded or charged back since.
+func settledAtGateway(status string) bool {
+	switch status {
+	case "settlement", "capture", "refund", "partial_refund", "chargeback", "partial_chargeback":
+		return true
+	}
+	return false
+}
+
+// grossAmountMatches compares a gateway amount such as "50000.00" with the
+// amount of a transaction.
+func grossAmountMatches(grossAmount string, amount int) bool {
+	gross, ok := new(big.Rat).SetString(grossAmount)
+	return ok && gross.Cmp(new(big.Rat).SetInt64(int64(amount))) == 0
+}
+
+// gatewayAmount is a gateway amount rounded to whole rupiah, or 0 if it cannot
+// be read.
+func gatewayAmount(grossAmount string) int {
+	gross, ok := new(big.Rat).SetString(grossAmount)
+	if !ok {
+		return 0
+	}
+	value, _ := gross.Float64()
+	return int(math.Round(value))
+}
+
+type ReconciliationUseCase interface {
+	Reconcile(now time.Time) error
+	StartReconciler(interval time.Duration)
+	ImportSettlement(input model.SettlementImportInput) (model.SettlementImport, []model.ReconciliationItem, error)
+	GetItems(all bool, page int, size int) ([]model.ReconciliationItem, dto.Paging, error)
+	ResolveItem(id int, input model.ResolveReconciliationInput) (model.ReconciliationItem, error)
+}
+
+func NewReconciliationUseCase(reconciliationRepo repository.ReconciliationRepo, transactionRepo repository.TransactionRepo, transactionUC TransactionUseCase,
+	paymentProvider service.PaymentProvider, reconcileAfter time.Duration, paymentWindow time.Duration) ReconciliationUseCase {
+	return &reconciliationUseCase{
+		reconciliationRepo: reconciliationRepo,
+		transactionRepo:    transactionRepo,
+		transactionUC:      transactionUC,
+		paymentProvider:    paymentProvider,
+		reconcileAfter:     reconcileAfter,
+		paymentWindow:      paymentWindow,
+	}
+}
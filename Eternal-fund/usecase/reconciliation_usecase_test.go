@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"eternal-fund/usecase/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReconciliationUseCaseTestSuite struct {
+	suite.Suite
+	ruc                *reconciliationUseCase
+	reconciliationRepo *mocking.ReconciliationRepoMock
+	transactionRepo    *mocking.TransactionRepoMock
+	transactionUC      *mocking.TransactionUseCaseMock
+	paymentProvider    *mocking.PaymentProviderMock
+}
+
+var reconcileNow = time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+func (suite *ReconciliationUseCaseTestSuite) SetupTest() {
+	suite.reconciliationRepo = new(mocking.ReconciliationRepoMock)
+	suite.transactionRepo = new(mocking.TransactionRepoMock)
+	suite.transactionUC = new(mocking.TransactionUseCaseMock)
+	suite.paymentProvider = new(mocking.PaymentProviderMock)
+	suite.ruc = &reconciliationUseCase{
+		reconciliationRepo: suite.reconciliationRepo,
+		transactionRepo:    suite.transactionRepo,
+		transactionUC:      suite.transactionUC,
+		paymentProvider:    suite.paymentProvider,
+		reconcileAfter:     30 * time.Minute,
+		paymentWindow:      24 * time.Hour,
+	}
+}
+
+func pendingTransaction(id int, code string, age time.Duration) model.Transaction {
+	return model.Transaction{ID: id, Code: code, Amount: 50000, Status: model.TransactionStatusPending, CreatedAt: reconcileNow.Add(-age)}
+}
+
+func (suite *ReconciliationUseCaseTestSuite) TestReconcile_AppliesMissedWebhook() {
+	transaction := pendingTransaction(1, "TRX-1", time.Hour)
+	status := model.TransactionNotificationInput{OrderID: "TRX-1", TransactionStatus: "settlement", GrossAmount: "50000.00"}
+
+	suite.reconciliationRepo.On("FindPendingTransactions", reconcileNow.Add(-30*time.Minute), reconcileBatchSize).Return([]model.Transaction{transaction}, nil)
+	suite.paymentProvider.On("FetchStatus", "TRX-1").Return(status, nil)
+	suite.transactionUC.On("ProcessPayment", status).Return(model.Transaction{ID: 1, Status: model.TransactionStatusPaid}, nil)
+
+	err := suite.ruc.Reconcile(reconcileNow)
+
+	assert.NoError(suite.T(), err)
+	suite.transactionUC.AssertExpectations(suite.T())
+}
+
+func (suite *ReconciliationUseCaseTestSuite) TestReconcile_StillPendingAtGateway() {
+	transaction := pendingTransaction(1, "TRX-1", time.Hour)
+
+	suite.reconciliationRepo.On("FindPendingTransactions", mock.Anything, reconcileBatchSize).Return([]model.Transaction{transaction}, nil)
+	suite.paymentProvider.On("FetchStatus", "TRX-1").Return(model.TransactionNotificationInput{
+		OrderID: "TRX-1", TransactionStatus: "pending", GrossAmount: "50000.00",
+	}, nil)
+
+	err := suite.ruc.Reconcile(reconcileNow)
+
+	assert.NoError(suite.T(), err)
+	suite.transactionUC.AssertNotCalled(suite.T(), "ProcessPayment", mock.Anything)
+}
+
+func (suite *ReconciliationUseCaseTestSuite) TestReconcile_ExpiresUnknownChargeAfterPaymentWindow() {
+	fresh := pendingTransaction(1, "TRX-1", time.Hour)
+	stale := pendingTransaction(2, "TRX-2", 25*time.Hour)
+
+	suite.reconciliationRepo.On("FindPendingTransactions", mock.Anything, reconcileBatchSize).Return([]model.Transaction{fresh, stale}, nil)
+	suite.paymentProvider.On("FetchStatus", mock.Anything).Return(model.TransactionNotificationInput{}, service.ErrChargeNotFound)
+	suite.transactionRepo.On("ApplyStatus", mock.MatchedBy(func(change model.TransactionStatusChange) bool {
+		return change.TransactionID == 2 && change.ToStatus == model.TransactionStatusExpired && change.Source == model.StatusSourceSystem
+	})).Return(model.Transaction{ID: 2, Status: model.TransactionStatusExpired}, nil)
+
+	err := suite.ruc.Reconcile(reconcileNow)
+
+	assert.NoError(suite.T(), err)
+	suite.transactionRepo.AssertNumberOfCalls(suite.T(), "ApplyStatus", 1)
+}
+
+func (suite *ReconciliationUseCaseTestSuite) TestReconcile_FlagsAmountMismatch() {
+	transaction := pendingTransaction(1, "TRX-1", time.Hour)
+
+	suite.reconciliationRepo.On("FindPendingTransactions", mock.Anything, reconcileBatchSize).Return([]model.Transaction{transaction}, nil)
+	suite.paymentProvider.On("FetchStatus", "TRX-1").Return(model.TransactionNotificationInput{
+		OrderID: "TRX-1", TransactionStatus: "settlement", GrossAmount: "5000.00",
+	}, nil)
+	suite.reconciliationRepo.On("SaveItem", model.ReconciliationItem{
+		TransactionID: 1, OrderID: "TRX-1", Kind: model.ReconciliationAmountMismatch, Source: model.ReconciliationSourceStatusCheck,
+		LocalAmount: 50000, GatewayAmount: 5000, LocalStatus: "pending", GatewayStatus: "settlement",
+	}).Return(model.ReconciliationItem{ID: 9}, true, nil)
+
+	err := suite.ruc.Reconcile(reconcileNow)
+
+	assert.NoError(suite.T(), err)
+	suite.reconciliationRepo.AssertExpectations(suite.T())
+	suite.transactionUC.AssertNotCalled(suite.T(), "ProcessPayment", mock.Anything)
+}
+
+func (suite *ReconciliationUseCaseTestSuite) TestImportSettlement_FlagsMismatches() {
+	report := "order_id,payment_type,gross_amount,transaction_status\n" +
+		"TRX-1,bank_transfer,50000.00,settlement\n" +
+		"TRX-2,gopay,75000.00,settlement\n" +
+		"TRX-3,qris,20000.00,settlement\n" +
+		"TRX-4,gopay,10000.00,settlement\n" +
+		"TRX-4,gopay,10000.00,refund\n" +
+		"TRX-9,gopay,30000.00,settlement\n"
+	periodStart := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2024, time.March, 7, 0, 0, 0, 0, time.UTC)
+
+	suite.reconciliationRepo.On("FindTransactionsByCodes", []string{"TRX-1", "TRX-2", "TRX-3", "TRX-4", "TRX-9"}).Return([]model.Transaction{
+		{ID: 1, Code: "TRX-1", Amount: 50000, Status: model.TransactionStatusPaid},
+		{ID: 2, Code: "TRX-2", Amount: 70000, Status: model.TransactionStatusPaid},
+		{ID: 3, Code: "TRX-3", Amount: 20000, Status: model.TransactionStatusExpired},
+		{ID: 4, Code: "TRX-4", Amount: 10000, Status: model.TransactionStatusRefunded},
+	}, nil)
+	suite.reconciliationRepo.On("FindPaidTransactions", periodStart, periodEnd.AddDate(0, 0, 1)).Return([]model.Transaction{
+		{ID: 1, Code: "TRX-1", Amount: 50000, Status: model.TransactionStatusPaid},
+		{ID: 5, Code: "TRX-5", Amount: 40000, Status: model.TransactionStatusPaid},
+	}, nil)
+
+	expected := []model.ReconciliationItem{
+		{TransactionID: 2, OrderID: "TRX-2", Kind: model.ReconciliationAmountMismatch, Source: model.ReconciliationSourceSettlement,
+			LocalAmount: 70000, GatewayAmount: 75000, LocalStatus: "paid", GatewayStatus: "settlement"},
+		{TransactionID: 3, OrderID: "TRX-3", Kind: model.ReconciliationStatusMismatch, Source: model.ReconciliationSourceSettlement,
+			LocalAmount: 20000, GatewayAmount: 20000, LocalStatus: "expired", GatewayStatus: "settlement"},
+		{OrderID: "TRX-9", Kind: model.ReconciliationMissingTransaction, Source: model.ReconciliationSourceSettlement,
+			GatewayAmount: 30000, GatewayStatus: "settlement"},
+		{TransactionID: 5, OrderID: "TRX-5", Kind: model.ReconciliationMissingSettlement, Source: model.ReconciliationSourceSettlement,
+			LocalAmount: 40000, LocalStatus: "paid"},
+	}
+	settlement := model.SettlementImport{FileName: "settlement.csv", PeriodStart: periodStart, PeriodEnd: periodEnd,
+		RowCount: 6, MismatchCount: 4, ImportedBy: 1}
+	suite.reconciliationRepo.On("SaveImport", settlement, expected).Return(model.SettlementImport{ID: 3}, expected, nil)
+
+	saved, items, err := suite.ruc.ImportSettlement(model.SettlementImportInput{
+		FileName: "settlement.csv", PeriodStart: periodStart, PeriodEnd: periodEnd, Report: []byte(report), User: model.User{ID: 1},
+	})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, saved.ID)
+	assert.Len(suite.T(), items, 4)
+	suite.reconciliationRepo.AssertExpectations(suite.T())
+}
+
+func (suite *ReconciliationUseCaseTestSuite) TestImportSettlement_MissingColumns() {
+	_, _, err := suite.ruc.ImportSettlement(model.SettlementImportInput{
+		PeriodStart: reconcileNow, PeriodEnd: reconcileNow, Report: []byte("order_id,amount\nTRX-1,50000\n"),
+	})
+
+	assert.ErrorIs(suite.T(), err, ErrInvalidSettlementReport)
+	suite.reconciliationRepo.AssertNotCalled(suite.T(), "SaveImport", mock.Anything, mock.Anything)
+}
+
+func (suite *ReconciliationUseCaseTestSuite) TestImportSettlement_InvalidPeriod() {
+	_, _, err := suite.ruc.ImportSettlement(model.SettlementImportInput{
+		PeriodStart: reconcileNow, PeriodEnd: reconcileNow.AddDate(0, 0, -1), Report: []byte("order_id,gross_amount,transaction_status\n"),
+	})
+
+	assert.ErrorIs(suite.T(), err, ErrInvalidSettlementPeriod)
+}
+
+func TestReconciliationUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(ReconciliationUseCaseTestSuite))
+}
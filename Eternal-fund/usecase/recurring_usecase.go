@@ -0,0 +1,348 @@
+package usecase
+
+import (
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"eternal-fund/usecase/service"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidRecurringInterval = errors.New("interval must be monthly, quarterly or yearly")
+	ErrRecurringPlanForbidden   = errors.New("recurring plan belongs to another donor")
+)
+
+type recurringUseCase struct {
+	recurringRepo repository.RecurringRepo
+	userRepo      repository.UserRepo
+	transactionUC TransactionUseCase
+	mailService   service.MailService
+	retryDelays   []time.Duration
+}
+
+// CreatePlan starts a recurring donation and bills its first cycle straight
+// away. The plan is cancelled again if that first charge cannot be created.
+func (r *recurringUseCase) CreatePlan(input model.CreateRecurringPlanInput) (model.RecurringPlan, model.Transaction, error) {
+	if !input.Interval.IsValid() {
+		return model.RecurringPlan{}, model.Transaction{}, ErrInvalidRecurringInterval
+	}
+	donor, err := r.userRepo.FindById(input.User.ID)
+	if err != nil {
+		return model.RecurringPlan{}, model.Transaction{}, err
+	}
+
+	now := time.Now()
+	plan, err := r.recurringRepo.Save(model.RecurringPlan{
+		CampaignID:    input.CampaignID,
+		UserID:        donor.ID,
+		Amount:        input.Amount,
+		CoverFees:     input.CoverFees,
+		Interval:      input.Interval,
+		Status:        model.RecurringStatusActive,
+		CardToken:     input.CardToken,
+		Anonymous:     input.Anonymous,
+		DisplayName:   strings.TrimSpace(input.DisplayName),
+		ContactOptOut: input.ContactOptOut,
+		StartedAt:     now,
+		NextChargeAt:  now,
+	})
+	if err != nil {
+		return model.RecurringPlan{}, model.Transaction{}, err
+	}
+
+	plan, transaction, err := r.charge(plan, donor, input.Message, input.ClientIP)
+	if err != nil {
+		plan.Status = model.RecurringStatusCancelled
+		plan.CancelledAt = &now
+		if _, cancelErr := r.recurringRepo.Update(plan); cancelErr != nil {
+			log.Printf("Error cancelling recurring plan %d after its first charge failed: %v", plan.ID, cancelErr)
+		}
+		return model.RecurringPlan{}, model.Transaction{}, err
+	}
+	return plan, transaction, nil
+}
+
+func (r *recurringUseCase) ListPlans(user model.User) ([]model.RecurringPlan, error) {
+	return r.recurringRepo.FindByUserID(user.ID)
+}
+
+func (r *recurringUseCase) GetPlan(id int, user model.User) (model.RecurringPlan, error) {
+	plan, err := r.recurringRepo.FindByID(id)
+	if err != nil {
+		return model.RecurringPlan{}, err
+	}
+	if plan.UserID != user.ID && user.Role != "admin" {
+		return model.RecurringPlan{}, ErrRecurringPlanForbidden
+	}
+	return plan, nil
+}
+
+func (r *recurringUseCase) PausePlan(id int, user model.User) (model.RecurringPlan, error) {
+	return r.changeStatus(id, user, model.RecurringStatusPaused)
+}
+
+// ResumePlan reactivates a paused plan. Cycles that fell due while it was
+// paused are skipped rather than billed.
+func (r *recurringUseCase) ResumePlan(id int, user model.User) (model.RecurringPlan, error) {
+	return r.changeStatus(id, user, model.RecurringStatusActive)
+}
+
+func (r *recurringUseCase) CancelPlan(id int, user model.User) (model.RecurringPlan, error) {
+	return r.changeStatus(id, user, model.RecurringStatusCancelled)
+}
+
+func (r *recurringUseCase) changeStatus(id int, user model.User, status model.RecurringStatus) (model.RecurringPlan, error) {
+	plan, err := r.GetPlan(id, user)
+	if err != nil {
+		return model.RecurringPlan{}, err
+	}
+	if !plan.Status.CanTransitionTo(status) {
+		return plan, model.ErrIllegalRecurringTransition
+	}
+
+	now := time.Now()
+	plan.Status = status
+	plan.RetryAt = nil
+	switch status {
+	case model.RecurringStatusActive:
+		plan.FailedAttempts = 0
+		plan.SkipMissedCycles(now)
+	case model.RecurringStatusCancelled:
+		plan.CancelledAt = &now
+	}
+	return r.recurringRepo.Update(plan)
+}
+
+// charge creates the transaction for the plan's current cycle, either by
+// charging the saved card or as a payment link, and records it on the plan.
+// A retry of a past due plan bills the same cycle again. Only the first
+// charge is made from the donor's request and goes through risk checks.
+func (r *recurringUseCase) charge(plan model.RecurringPlan, donor model.User, message string, clientIP string) (model.RecurringPlan, model.Transaction, error) {
+	transaction, err := r.transactionUC.CreateTransaction(model.CreateTransactionInput{
+		CampaignID: plan.CampaignID,
+		Amount:     plan.Amount,
+		CoverFees:  plan.CoverFees,
+		DonorChoices: model.DonorChoices{
+			Message:       message,
+			Anonymous:     plan.Anonymous,
+			DisplayName:   plan.DisplayName,
+			ContactOptOut: plan.ContactOptOut,
+		},
+		User:            donor,
+		RecurringPlanID: plan.ID,
+		CardToken:       plan.CardToken,
+		Renewal:         plan.Cycle > 0,
+		ClientIP:        clientIP,
+	})
+	if err != nil {
+		return plan, model.Transaction{}, err
+	}
+
+	plan.Currency = transaction.Currency
+	plan.LastTransactionID = transaction.ID
+	plan.LastTransactionStatus = transaction.Status
+	plan.RetryAt = nil
+	if plan.Status == model.RecurringStatusActive {
+		plan.AdvanceCycle()
+	}
+	plan, err = r.recurringRepo.RecordCharge(plan)
+	if err != nil {
+		return plan, model.Transaction{}, err
+	}
+	return plan, transaction, nil
+}
+
+// RunBilling first settles the plans whose last charge has an outcome and
+// then charges the plans that are due at now.
+func (r *recurringUseCase) RunBilling(now time.Time) error {
+	failed := 0
+
+	settled, err := r.recurringRepo.FindSettled()
+	if err != nil {
+		return err
+	}
+	for _, plan := range settled {
+		if err := r.settle(plan, now); err != nil {
+			log.Printf("[RECURRING] settling plan %d: %v", plan.ID, err)
+			failed++
+		}
+	}
+
+	due, err := r.recurringRepo.FindDue(now)
+	if err != nil {
+		return err
+	}
+	for _, plan := range due {
+		if err := r.bill(plan, now); err != nil {
+			log.Printf("[RECURRING] billing plan %d: %v", plan.ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d recurring plans could not be processed", failed, len(settled)+len(due))
+	}
+	return nil
+}
+
+func (r *recurringUseCase) StartBiller(interval time.Duration) {
+	runPeriodically("recurring billing", interval, func() error {
+		return r.RunBilling(time.Now())
+	})
+}
+
+// bill claims a due plan by marking its charge pending, so overlapping runs
+// cannot bill the same cycle twice, and then charges it. A charge that cannot
+// even be created counts as a failed attempt.
+func (r *recurringUseCase) bill(plan model.RecurringPlan, now time.Time) error {
+	plan.ChargePending = true
+	plan, err := r.recurringRepo.Update(plan)
+	if errors.Is(err, repository.ErrRecurringPlanChanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	donor, err := r.userRepo.FindById(plan.UserID)
+	if err != nil {
+		return r.fail(plan, donor, now, err)
+	}
+	charged, transaction, err := r.charge(plan, donor, "", "")
+	if err != nil {
+		return r.fail(plan, donor, now, err)
+	}
+
+	if !charged.AutoCharge() {
+		r.notify(donor, "Your recurring donation is due", fmt.Sprintf("Hi %s,\n\n"+
+			"Your %s donation of %s is due. Please complete the payment here:\n%s\n",
+			donor.Name, charged.Interval, transaction.AmountFormat(), transaction.PaymentURL))
+	}
+	return nil
+}
+
+// fail releases a claimed plan whose charge could not be created and counts
+// it as a failed attempt.
+func (r *recurringUseCase) fail(plan model.RecurringPlan, donor model.User, now time.Time, cause error) error {
+	plan.ChargePending = false
+	r.recordFailure(&plan, now)
+	if _, err := r.recurringRepo.Update(plan); err != nil {
+		return err
+	}
+	r.notifyFailure(plan, donor)
+	return cause
+}
+
+// settle applies the outcome of the plan's last charge. A paid charge clears
+// the dunning state; a failed one schedules the next retry or, once the
+// retries are used up, cancels the plan. Plans paused or cancelled while the
+// charge was pending only have the charge released.
+func (r *recurringUseCase) settle(plan model.RecurringPlan, now time.Time) error {
+	plan.ChargePending = false
+	paid := chargeSucceeded(plan.LastTransactionStatus)
+	billing := plan.Status == model.RecurringStatusActive || plan.Status == model.RecurringStatusPastDue
+
+	switch {
+	case paid:
+		plan.FailedAttempts = 0
+		plan.RetryAt = nil
+		if plan.Status == model.RecurringStatusPastDue {
+			plan.Status = model.RecurringStatusActive
+			plan.SkipMissedCycles(now)
+		}
+	case billing:
+		r.recordFailure(&plan, now)
+	}
+
+	plan, err := r.recurringRepo.Update(plan)
+	if errors.Is(err, repository.ErrRecurringPlanChanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !paid && billing {
+		donor, err := r.userRepo.FindById(plan.UserID)
+		if err != nil {
+			return err
+		}
+		r.notifyFailure(plan, donor)
+	}
+	return nil
+}
+
+// chargeSucceeded reports whether a charge was paid, including charges that
+// have since been refunded or charged back.
+func chargeSucceeded(status model.TransactionStatus) bool {
+	switch status {
+	case model.TransactionStatusPaid, model.TransactionStatusRefundPending,
+		model.TransactionStatusRefunded, model.TransactionStatusChargeback:
+		return true
+	}
+	return false
+}
+
+// recordFailure counts a failed charge and either schedules the next retry
+// or cancels the plan when every retry has failed.
+func (r *recurringUseCase) recordFailure(plan *model.RecurringPlan, now time.Time) {
+	plan.FailedAttempts++
+	if plan.FailedAttempts > len(r.retryDelays) {
+		plan.Status = model.RecurringStatusCancelled
+		plan.CancelledAt = &now
+		plan.RetryAt = nil
+		return
+	}
+
+	retryAt := now.Add(r.retryDelays[plan.FailedAttempts-1])
+	plan.Status = model.RecurringStatusPastDue
+	plan.RetryAt = &retryAt
+}
+
+func (r *recurringUseCase) notifyFailure(plan model.RecurringPlan, donor model.User) {
+	if plan.Status == model.RecurringStatusCancelled {
+		r.notify(donor, "Your recurring donation has been cancelled", fmt.Sprintf("Hi %s,\n\n"+
+			"We could not collect your %s donation of %s after %d attempts, so it has been cancelled.\n"+
+			"You are welcome to start a new one at any time.\n",
+			donor.Name, plan.Interval, model.FormatMoney(plan.Amount, plan.Currency), plan.FailedAttempts))
+		return
+	}
+	r.notify(donor, "Your recurring donation could not be collected", fmt.Sprintf("Hi %s,\n\n"+
+		"We could not collect your %s donation of %s. We will try again on %s.\n",
+		donor.Name, plan.Interval, model.FormatMoney(plan.Amount, plan.Currency), plan.RetryAt.Format("2 January 2006")))
+}
+
+func (r *recurringUseCase) notify(donor model.User, subject string, body string) {
+	if donor.Email == "" {
+		return
+	}
+	if err := r.mailService.Send(donor.Email, subject, body); err != nil {
+		log.Printf("Error sending %q to user %d: %v", subject, donor.ID, err)
+	}
+}
+
+type RecurringUseCase interface {
+	CreatePlan(input model.CreateRecurringPlanInput) (model.RecurringPlan, model.Transaction, error)
+	ListPlans(user model.User) ([]model.RecurringPlan, error)
+	GetPlan(id int, user model.User) (model.RecurringPlan, error)
+	PausePlan(id int, user model.User) (model.RecurringPlan, error)
+	ResumePlan(id int, user model.User) (model.RecurringPlan, error)
+	CancelPlan(id int, user model.User) (model.RecurringPlan, error)
+	RunBilling(now time.Time) error
+	StartBiller(interval time.Duration)
+}
+
+func NewRecurringUseCase(recurringRepo repository.RecurringRepo, userRepo repository.UserRepo, transactionUC TransactionUseCase,
+	mailService service.MailService, retryDelays []time.Duration) RecurringUseCase {
+	return &recurringUseCase{
+		recurringRepo: recurringRepo,
+		userRepo:      userRepo,
+		transactionUC: transactionUC,
+		mailService:   mailService,
+		retryDelays:   retryDelays,
+	}
+}
@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type RecurringUseCaseTestSuite struct {
+	suite.Suite
+	ruc           *recurringUseCase
+	recurringRepo *mocking.RecurringRepoMock
+	userRepo      *mocking.UserRepoMock
+	transactionUC *mocking.TransactionUseCaseMock
+	mailService   *mocking.MailServiceMock
+}
+
+var recurringDonor = model.User{ID: 4, Name: "Donor", Email: "donor@example.com", Role: "user"}
+
+func (suite *RecurringUseCaseTestSuite) SetupTest() {
+	suite.recurringRepo = new(mocking.RecurringRepoMock)
+	suite.userRepo = new(mocking.UserRepoMock)
+	suite.transactionUC = new(mocking.TransactionUseCaseMock)
+	suite.mailService = new(mocking.MailServiceMock)
+	suite.ruc = &recurringUseCase{
+		recurringRepo: suite.recurringRepo,
+		userRepo:      suite.userRepo,
+		transactionUC: suite.transactionUC,
+		mailService:   suite.mailService,
+		retryDelays:   []time.Duration{24 * time.Hour, 72 * time.Hour},
+	}
+}
+
+func (suite *RecurringUseCaseTestSuite) TestChargeDate_ClampsToMonthEnd() {
+	anchor := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(suite.T(), time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), model.RecurringIntervalMonthly.ChargeDate(anchor, 1))
+	assert.Equal(suite.T(), time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC), model.RecurringIntervalMonthly.ChargeDate(anchor, 2))
+	assert.Equal(suite.T(), time.Date(2024, time.April, 30, 9, 0, 0, 0, time.UTC), model.RecurringIntervalQuarterly.ChargeDate(anchor, 1))
+	assert.Equal(suite.T(), time.Date(2025, time.January, 31, 9, 0, 0, 0, time.UTC), model.RecurringIntervalYearly.ChargeDate(anchor, 1))
+}
+
+func (suite *RecurringUseCaseTestSuite) TestCreatePlan_InvalidInterval() {
+	_, _, err := suite.ruc.CreatePlan(model.CreateRecurringPlanInput{CampaignID: 2, Amount: 50000, Interval: "weekly", User: recurringDonor})
+
+	assert.ErrorIs(suite.T(), err, ErrInvalidRecurringInterval)
+	suite.recurringRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *RecurringUseCaseTestSuite) TestCreatePlan_ChargesFirstCycle() {
+	transaction := model.Transaction{ID: 11, CampaignID: 2, UserID: 4, Amount: 50000, Status: model.TransactionStatusPending, RecurringPlanID: 3}
+	started := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+	saved := model.RecurringPlan{ID: 3, CampaignID: 2, UserID: 4, Amount: 50000, Interval: model.RecurringIntervalMonthly,
+		Status: model.RecurringStatusActive, CardToken: "saved-card", StartedAt: started, NextChargeAt: started}
+	recorded := saved
+	recorded.Cycle = 1
+	recorded.NextChargeAt = time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC)
+	recorded.LastTransactionID = 11
+	recorded.ChargePending = true
+
+	suite.userRepo.On("FindById", 4).Return(recurringDonor, nil)
+	suite.recurringRepo.On("Save", mock.MatchedBy(func(plan model.RecurringPlan) bool {
+		return plan.CampaignID == 2 && plan.UserID == 4 && plan.Status == model.RecurringStatusActive &&
+			plan.Cycle == 0 && plan.NextChargeAt.Equal(plan.StartedAt)
+	})).Return(saved, nil)
+	suite.transactionUC.On("CreateTransaction", model.CreateTransactionInput{
+		CampaignID: 2, Amount: 50000, User: recurringDonor, RecurringPlanID: 3, CardToken: "saved-card", ClientIP: "203.0.113.7",
+	}).Return(transaction, nil)
+	suite.recurringRepo.On("RecordCharge", mock.MatchedBy(func(plan model.RecurringPlan) bool {
+		return plan.Cycle == 1 && plan.LastTransactionID == 11 && plan.NextChargeAt.Equal(recorded.NextChargeAt)
+	})).Return(recorded, nil)
+
+	plan, charged, err := suite.ruc.CreatePlan(model.CreateRecurringPlanInput{
+		CampaignID: 2, Amount: 50000, Interval: model.RecurringIntervalMonthly, CardToken: "saved-card", User: model.User{ID: 4},
+		ClientIP: "203.0.113.7",
+	})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), transaction, charged)
+	assert.Equal(suite.T(), recorded, plan)
+}
+
+func (suite *RecurringUseCaseTestSuite) TestRunBilling_ChargesDuePlanWithPaymentLink() {
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	started := time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)
+	due := model.RecurringPlan{ID: 3, CampaignID: 2, UserID: 4, Amount: 50000, Interval: model.RecurringIntervalMonthly,
+		Status: model.RecurringStatusActive, StartedAt: started, Cycle: 1, NextChargeAt: started.AddDate(0, 1, 0)}
+	claimed := due
+	claimed.ChargePending = true
+	transaction := model.Transaction{ID: 12, Amount: 50000, Status: model.TransactionStatusPending, PaymentURL: "https://pay.example/12"}
+
+	suite.recurringRepo.On("FindSettled").Return([]model.RecurringPlan{}, nil)
+	suite.recurringRepo.On("FindDue", now).Return([]model.RecurringPlan{due}, nil)
+	suite.recurringRepo.On("Update", claimed).Return(claimed, nil)
+	suite.userRepo.On("FindById", 4).Return(recurringDonor, nil)
+	suite.transactionUC.On("CreateTransaction", model.CreateTransactionInput{CampaignID: 2, Amount: 50000, User: recurringDonor, RecurringPlanID: 3, Renewal: true}).
+		Return(transaction, nil)
+	suite.recurringRepo.On("RecordCharge", mock.MatchedBy(func(plan model.RecurringPlan) bool {
+		return plan.Cycle == 2 && plan.LastTransactionID == 12 && plan.NextChargeAt.Equal(time.Date(2024, time.April, 1, 9, 0, 0, 0, time.UTC))
+	})).Return(claimed, nil)
+	suite.mailService.On("Send", "donor@example.com", "Your recurring donation is due", mock.MatchedBy(func(body string) bool {
+		return assert.Contains(suite.T(), body, "https://pay.example/12")
+	})).Return(nil)
+
+	assert.NoError(suite.T(), suite.ruc.RunBilling(now))
+	suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *RecurringUseCaseTestSuite) TestRunBilling_SkipsPlanClaimedElsewhere() {
+	now := time.Now()
+	due := model.RecurringPlan{ID: 3, UserID: 4, Status: model.RecurringStatusActive}
+
+	suite.recurringRepo.On("FindSettled").Return([]model.RecurringPlan{}, nil)
+	suite.recurringRepo.On("FindDue", now).Return([]model.RecurringPlan{due}, nil)
+	suite.recurringRepo.On("Update", mock.AnythingOfType("model.RecurringPlan")).Return(model.RecurringPlan{}, repository.ErrRecurringPlanChanged)
+
+	assert.NoError(suite.T(), suite.ruc.RunBilling(now))
+	suite.transactionUC.AssertNotCalled(suite.T(), "CreateTransaction", mock.Anything)
+}
+
+func (suite *RecurringUseCaseTestSuite) TestRunBilling_FailedChargeSchedulesRetry() {
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	retryAt := now.Add(24 * time.Hour)
+	settled := model.RecurringPlan{ID: 3, UserID: 4, Amount: 5000, Currency: model.CurrencyUSD, Interval: model.RecurringIntervalMonthly,
+		Status: model.RecurringStatusActive, ChargePending: true, LastTransactionStatus: model.TransactionStatusFailed}
+	expected := settled
+	expected.ChargePending = false
+	expected.Status = model.RecurringStatusPastDue
+	expected.FailedAttempts = 1
+	expected.RetryAt = &retryAt
+
+	suite.recurringRepo.On("FindSettled").Return([]model.RecurringPlan{settled}, nil)
+	suite.recurringRepo.On("Update", expected).Return(expected, nil)
+	suite.recurringRepo.On("FindDue", now).Return([]model.RecurringPlan{}, nil)
+	suite.userRepo.On("FindById", 4).Return(recurringDonor, nil)
+	suite.mailService.On("Send", "donor@example.com", "Your recurring donation could not be collected", mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "donation of "+model.FormatMoney(5000, model.CurrencyUSD)+".")
+	})).Return(nil)
+
+	assert.NoError(suite.T(), suite.ruc.RunBilling(now))
+	suite.recurringRepo.AssertExpectations(suite.T())
+	suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *RecurringUseCaseTestSuite) TestRunBilling_CancelsAfterLastRetry() {
+	now := time.Date(2024, time.March, 4, 10, 0, 0, 0, time.UTC)
+	settled := model.RecurringPlan{ID: 3, UserID: 4, Amount: 50000, Interval: model.RecurringIntervalMonthly,
+		Status: model.RecurringStatusPastDue, FailedAttempts: 2, ChargePending: true, LastTransactionStatus: model.TransactionStatusExpired}
+
+	suite.recurringRepo.On("FindSettled").Return([]model.RecurringPlan{settled}, nil)
+	suite.recurringRepo.On("Update", mock.MatchedBy(func(plan model.RecurringPlan) bool {
+		return plan.Status == model.RecurringStatusCancelled && plan.FailedAttempts == 3 && plan.RetryAt == nil &&
+			plan.CancelledAt != nil && plan.CancelledAt.Equal(now)
+	})).Return(model.RecurringPlan{ID: 3, UserID: 4, Amount: 50000, Interval: model.RecurringIntervalMonthly,
+		Status: model.RecurringStatusCancelled, FailedAttempts: 3, CancelledAt: &now}, nil)
+	suite.recurringRepo.On("FindDue", now).Return([]model.RecurringPlan{}, nil)
+	suite.userRepo.On("FindById", 4).Return(recurringDonor, nil)
+	suite.mailService.On("Send", "donor@example.com", "Your recurring donation has been cancelled", mock.Anything).Return(nil)
+
+	assert.NoError(suite.T(), suite.ruc.RunBilling(now))
+	suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *RecurringUseCaseTestSuite) TestRunBilling_PaidRetryReactivatesPlan() {
+	now := time.Date(2024, time.April, 5, 10, 0, 0, 0, time.UTC)
+	started := time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)
+	retryAt := now
+	settled := model.RecurringPlan{ID: 3, UserID: 4, Interval: model.RecurringIntervalMonthly, Status: model.RecurringStatusPastDue,
+		StartedAt: started, Cycle: 2, NextChargeAt: time.Date(2024, time.April, 1, 9, 0, 0, 0, time.UTC), RetryAt: &retryAt,
+		FailedAttempts: 2, ChargePending: true, LastTransactionStatus: model.TransactionStatusPaid}
+
+	suite.recurringRepo.On("FindSettled").Return([]model.RecurringPlan{settled}, nil)
+	suite.recurringRepo.On("Update", mock.MatchedBy(func(plan model.RecurringPlan) bool {
+		return plan.Status == model.RecurringStatusActive && plan.FailedAttempts == 0 && plan.RetryAt == nil && !plan.ChargePending &&
+			plan.Cycle == 3 && plan.NextChargeAt.Equal(time.Date(2024, time.May, 1, 9, 0, 0, 0, time.UTC))
+	})).Return(settled, nil)
+	suite.recurringRepo.On("FindDue", now).Return([]model.RecurringPlan{}, nil)
+
+	assert.NoError(suite.T(), suite.ruc.RunBilling(now))
+	suite.recurringRepo.AssertExpectations(suite.T())
+	suite.mailService.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *RecurringUseCaseTestSuite) TestPausePlan_OtherDonor() {
+	suite.recurringRepo.On("FindByID", 3).Return(model.RecurringPlan{ID: 3, UserID: 9, Status: model.RecurringStatusActive}, nil)
+
+	_, err := suite.ruc.PausePlan(3, recurringDonor)
+
+	assert.ErrorIs(suite.T(), err, ErrRecurringPlanForbidden)
+	suite.recurringRepo.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *RecurringUseCaseTestSuite) TestResumePlan_CancelledPlan() {
+	suite.recurringRepo.On("FindByID", 3).Return(model.RecurringPlan{ID: 3, UserID: 4, Status: model.RecurringStatusCancelled}, nil)
+
+	_, err := suite.ruc.ResumePlan(3, recurringDonor)
+
+	assert.ErrorIs(suite.T(), err, model.ErrIllegalRecurringTransition)
+}
+
+func (suite *RecurringUseCaseTestSuite) TestResumePlan_SkipsMissedCycles() {
+	started := time.Now().AddDate(0, -3, 0)
+	paused := model.RecurringPlan{ID: 3, UserID: 4, Interval: model.RecurringIntervalMonthly, Status: model.RecurringStatusPaused,
+		StartedAt: started, Cycle: 1, NextChargeAt: model.RecurringIntervalMonthly.ChargeDate(started, 1), FailedAttempts: 1}
+
+	suite.recurringRepo.On("FindByID", 3).Return(paused, nil)
+	suite.recurringRepo.On("Update", mock.MatchedBy(func(plan model.RecurringPlan) bool {
+		return plan.Status == model.RecurringStatusActive && plan.FailedAttempts == 0 && plan.Cycle > 1 &&
+			plan.NextChargeAt.After(time.Now()) && plan.NextChargeAt.Equal(model.RecurringIntervalMonthly.ChargeDate(started, plan.Cycle))
+	})).Return(paused, nil)
+
+	_, err := suite.ruc.ResumePlan(3, recurringDonor)
+
+	assert.NoError(suite.T(), err)
+	suite.recurringRepo.AssertExpectations(suite.T())
+}
+
+func TestRecurringUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(RecurringUseCaseTestSuite))
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"eternal-fund/repository"
+	"log"
+	"time"
+)
+
+type retentionUseCase struct {
+	campaignsRepo repository.CampaignsRepo
+	userRepo      repository.UserRepo
+	retention     time.Duration
+}
+
+// PurgeDeleted permanently removes campaigns and users that have been soft
+// deleted for longer than the retention period.
+func (r *retentionUseCase) PurgeDeleted() error {
+	cutoff := time.Now().Add(-r.retention)
+
+	campaigns, err := r.campaignsRepo.PurgeDeletedCampaigns(cutoff)
+	if err != nil {
+		return err
+	}
+	users, err := r.userRepo.PurgeDeleted(cutoff)
+	if err != nil {
+		return err
+	}
+
+	if campaigns > 0 || users > 0 {
+		log.Printf("[JOB] purged %d campaigns and %d users deleted before %s", campaigns, users, cutoff.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (r *retentionUseCase) StartPurger(interval time.Duration) {
+	runPeriodically("soft delete purge", interval, r.PurgeDeleted)
+}
+
+type RetentionUseCase interface {
+	PurgeDeleted() error
+	StartPurger(interval time.Duration)
+}
+
+func NewRetentionUseCase(campaignsRepo repository.CampaignsRepo, userRepo repository.UserRepo, retention time.Duration) RetentionUseCase {
+	return &retentionUseCase{campaignsRepo: campaignsRepo, userRepo: userRepo, retention: retention}
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"errors"
+	"eternal-fund/mocking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type RetentionUseCaseTestSuite struct {
+	suite.Suite
+	ruc          *retentionUseCase
+	campaignRepo *mocking.CampaignRepoMock
+	userRepo     *mocking.UserRepoMock
+}
+
+func (suite *RetentionUseCaseTestSuite) SetupTest() {
+	suite.campaignRepo = new(mocking.CampaignRepoMock)
+	suite.userRepo = new(mocking.UserRepoMock)
+	suite.ruc = &retentionUseCase{
+		campaignsRepo: suite.campaignRepo,
+		userRepo:      suite.userRepo,
+		retention:     30 * 24 * time.Hour,
+	}
+}
+
+func (suite *RetentionUseCaseTestSuite) TestPurgeDeleted() {
+	inWindow := mock.MatchedBy(func(before time.Time) bool {
+		cutoff := time.Now().Add(-30 * 24 * time.Hour)
+		return before.Sub(cutoff).Abs() < time.Minute
+	})
+	suite.campaignRepo.On("PurgeDeletedCampaigns", inWindow).Return(int64(2), nil)
+	suite.userRepo.On("PurgeDeleted", inWindow).Return(int64(1), nil)
+
+	err := suite.ruc.PurgeDeleted()
+	assert.NoError(suite.T(), err)
+	suite.campaignRepo.AssertExpectations(suite.T())
+	suite.userRepo.AssertExpectations(suite.T())
+}
+
+func (suite *RetentionUseCaseTestSuite) TestPurgeDeleted_CampaignError() {
+	suite.campaignRepo.On("PurgeDeletedCampaigns", mock.Anything).Return(int64(0), errors.New("db down"))
+
+	err := suite.ruc.PurgeDeleted()
+	assert.Error(suite.T(), err)
+	suite.userRepo.AssertNotCalled(suite.T(), "PurgeDeleted", mock.Anything)
+}
+
+func TestRetentionUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(RetentionUseCaseTestSuite))
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"time"
+)
+
+// Risk rules and the weight each adds to a donation's score.
+const (
+	riskLargeAmount     = "large_amount"
+	riskNearMaximum     = "near_maximum"
+	riskGuest           = "guest"
+	riskNewAccount      = "new_account"
+	riskUnverifiedEmail = "unverified_email"
+	riskDonorVelocity   = "donor_velocity"
+	riskIPVelocity      = "ip_velocity"
+	riskForeignCurrency = "foreign_currency"
+)
+
+var riskWeights = map[string]int{
+	riskLargeAmount:     30,
+	riskNearMaximum:     15,
+	riskGuest:           15,
+	riskNewAccount:      20,
+	riskUnverifiedEmail: 10,
+	riskDonorVelocity:   25,
+	riskIPVelocity:      25,
+	riskForeignCurrency: 10,
+}
+
+// newAccountAge is how young an account is when it counts as new.
+const newAccountAge = 24 * time.Hour
+
+type riskEngine struct {
+	riskRepo    repository.RiskRepo
+	userRepo    repository.UserRepo
+	window      time.Duration
+	maxPerDonor int
+	maxPerIP    int
+	reviewScore int
+}
+
+// Assess scores a donation before it is saved. A donor or address that has
+// already reached its limit within the window is refused outright, and
+// reaching half of it adds to the score. Concurrent donations can all pass
+// these counts, so the limit is returned to be enforced again on save.
+func (e *riskEngine) Assess(transaction model.Transaction, campaign model.Campaigns) (model.RiskAssessment, error) {
+	var assessment model.RiskAssessment
+	add := func(reason string) { assessment.Add(reason, riskWeights[reason]) }
+
+	since := time.Now().Add(-e.window)
+	assessment.Limit = &model.VelocityLimit{Since: since, MaxPerDonor: e.maxPerDonor, MaxPerIP: e.maxPerIP}
+	donorCount, err := e.riskRepo.CountRecentByDonor(transaction.UserID, transaction.GuestEmail, since)
+	if err != nil {
+		return model.RiskAssessment{}, err
+	}
+	if donorCount >= e.maxPerDonor {
+		return model.RiskAssessment{}, model.ErrVelocityExceeded
+	}
+	if donorCount*2 >= e.maxPerDonor {
+		add(riskDonorVelocity)
+	}
+	if transaction.ClientIP != "" {
+		ipCount, err := e.riskRepo.CountRecentByIP(transaction.ClientIP, since)
+		if err != nil {
+			return model.RiskAssessment{}, err
+		}
+		if ipCount >= e.maxPerIP {
+			return model.RiskAssessment{}, model.ErrVelocityExceeded
+		}
+		if ipCount*2 >= e.maxPerIP {
+			add(riskIPVelocity)
+		}
+	}
+
+	if campaign.Goal_amount > 0 && transaction.Amount*2 >= campaign.Goal_amount {
+		add(riskLargeAmount)
+	}
+	if campaign.Max_donation > 0 && transaction.Amount*10 >= campaign.Max_donation*9 {
+		add(riskNearMaximum)
+	}
+	if transaction.DonorCurrency != "" && transaction.DonorCurrency != transaction.Currency {
+		add(riskForeignCurrency)
+	}
+
+	if transaction.UserID == 0 {
+		add(riskGuest)
+	} else {
+		donor, err := e.userRepo.FindById(transaction.UserID)
+		if err != nil {
+			return model.RiskAssessment{}, err
+		}
+		if time.Since(donor.CreatedAt) < newAccountAge {
+			add(riskNewAccount)
+		}
+		if donor.EmailVerifiedAt == nil {
+			add(riskUnverifiedEmail)
+		}
+	}
+
+	assessment.Review = assessment.Score >= e.reviewScore
+	return assessment, nil
+}
+
+type RiskEngine interface {
+	Assess(transaction model.Transaction, campaign model.Campaigns) (model.RiskAssessment, error)
+}
+
+func NewRiskEngine(riskRepo repository.RiskRepo, userRepo repository.UserRepo, window time.Duration, maxPerDonor int, maxPerIP int,
+	reviewScore int) RiskEngine {
+	return &riskEngine{
+		riskRepo:    riskRepo,
+		userRepo:    userRepo,
+		window:      window,
+		maxPerDonor: maxPerDonor,
+		maxPerIP:    maxPerIP,
+		reviewScore: reviewScore,
+	}
+}
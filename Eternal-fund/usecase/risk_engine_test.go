@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type RiskEngineTestSuite struct {
+	suite.Suite
+	engine   *riskEngine
+	riskRepo *mocking.RiskRepoMock
+	userRepo *mocking.UserRepoMock
+}
+
+func (suite *RiskEngineTestSuite) SetupTest() {
+	suite.riskRepo = new(mocking.RiskRepoMock)
+	suite.userRepo = new(mocking.UserRepoMock)
+	suite.engine = &riskEngine{
+		riskRepo:    suite.riskRepo,
+		userRepo:    suite.userRepo,
+		window:      time.Hour,
+		maxPerDonor: 5,
+		maxPerIP:    20,
+		reviewScore: 60,
+	}
+}
+
+func (suite *RiskEngineTestSuite) TestAssess_TrustedDonor() {
+	verified := time.Now().Add(-30 * 24 * time.Hour)
+	suite.riskRepo.On("CountRecentByDonor", 1, "", mock.AnythingOfType("time.Time")).Return(0, nil)
+	suite.riskRepo.On("CountRecentByIP", "203.0.113.7", mock.AnythingOfType("time.Time")).Return(1, nil)
+	suite.userRepo.On("FindById", 1).Return(model.User{ID: 1, CreatedAt: verified, EmailVerifiedAt: &verified}, nil)
+
+	transaction := model.Transaction{UserID: 1, Amount: 10000, Currency: model.CurrencyIDR, DonorCurrency: model.CurrencyIDR, ClientIP: "203.0.113.7"}
+	assessment, err := suite.engine.Assess(transaction, model.Campaigns{Goal_amount: 1000000})
+
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), assessment.Score)
+	assert.Empty(suite.T(), assessment.Reasons)
+	assert.False(suite.T(), assessment.Review)
+	assert.Equal(suite.T(), 5, assessment.Limit.MaxPerDonor)
+	assert.Equal(suite.T(), 20, assessment.Limit.MaxPerIP)
+}
+
+func (suite *RiskEngineTestSuite) TestAssess_HoldsRiskyDonation() {
+	suite.riskRepo.On("CountRecentByDonor", 0, "jane@example.com", mock.AnythingOfType("time.Time")).Return(3, nil)
+	suite.riskRepo.On("CountRecentByIP", "203.0.113.7", mock.AnythingOfType("time.Time")).Return(2, nil)
+
+	transaction := model.Transaction{GuestEmail: "jane@example.com", Amount: 600000, Currency: model.CurrencyIDR,
+		DonorCurrency: model.CurrencyUSD, ClientIP: "203.0.113.7"}
+	assessment, err := suite.engine.Assess(transaction, model.Campaigns{Goal_amount: 1000000})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 80, assessment.Score)
+	assert.Equal(suite.T(), []string{riskDonorVelocity, riskLargeAmount, riskForeignCurrency, riskGuest}, assessment.Reasons)
+	assert.True(suite.T(), assessment.Review)
+	suite.userRepo.AssertNotCalled(suite.T(), "FindById", mock.Anything)
+}
+
+func (suite *RiskEngineTestSuite) TestAssess_NewUnverifiedAccount() {
+	suite.riskRepo.On("CountRecentByDonor", 2, "", mock.AnythingOfType("time.Time")).Return(0, nil)
+	suite.userRepo.On("FindById", 2).Return(model.User{ID: 2, CreatedAt: time.Now().Add(-time.Hour)}, nil)
+
+	assessment, err := suite.engine.Assess(model.Transaction{UserID: 2, Amount: 95000}, model.Campaigns{Max_donation: 100000})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 45, assessment.Score)
+	assert.Equal(suite.T(), []string{riskNearMaximum, riskNewAccount, riskUnverifiedEmail}, assessment.Reasons)
+	assert.False(suite.T(), assessment.Review)
+}
+
+func (suite *RiskEngineTestSuite) TestAssess_DonorVelocityExceeded() {
+	suite.riskRepo.On("CountRecentByDonor", 1, "", mock.AnythingOfType("time.Time")).Return(5, nil)
+
+	_, err := suite.engine.Assess(model.Transaction{UserID: 1, Amount: 10000, ClientIP: "203.0.113.7"}, model.Campaigns{})
+
+	assert.ErrorIs(suite.T(), err, model.ErrVelocityExceeded)
+	suite.riskRepo.AssertNotCalled(suite.T(), "CountRecentByIP", mock.Anything, mock.Anything)
+}
+
+func (suite *RiskEngineTestSuite) TestAssess_IPVelocityExceeded() {
+	suite.riskRepo.On("CountRecentByDonor", 1, "", mock.AnythingOfType("time.Time")).Return(0, nil)
+	suite.riskRepo.On("CountRecentByIP", "203.0.113.7", mock.AnythingOfType("time.Time")).Return(20, nil)
+
+	_, err := suite.engine.Assess(model.Transaction{UserID: 1, Amount: 10000, ClientIP: "203.0.113.7"}, model.Campaigns{})
+
+	assert.ErrorIs(suite.T(), err, model.ErrVelocityExceeded)
+}
+
+func TestRiskEngineTestSuite(t *testing.T) {
+	suite.Run(t, new(RiskEngineTestSuite))
+}
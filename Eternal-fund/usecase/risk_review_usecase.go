@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/model/dto"
+	"eternal-fund/repository"
+	"eternal-fund/usecase/service"
+	"fmt"
+	"log"
+)
+
+var ErrNotUnderReview = errors.New("transaction is not held for review")
+
+type riskReviewUseCase struct {
+	transactionRepo repository.TransactionRepo
+	riskRepo        repository.RiskRepo
+	transactionUC   TransactionUseCase
+	userRepo        repository.UserRepo
+	mailService     service.MailService
+}
+
+func (uc *riskReviewUseCase) GetQueue(page int, size int) ([]model.Transaction, dto.Paging, error) {
+	return uc.riskRepo.FindReviewQueue(page, size)
+}
+
+// Approve releases a held donation: it becomes pending and the donor is
+// emailed its payment link. The charge is created while the donation is still
+// held, so a gateway failure leaves it in review to be approved again.
+func (uc *riskReviewUseCase) Approve(transactionID int, input model.RiskReviewInput) (model.Transaction, error) {
+	transaction, err := uc.held(transactionID)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	donor, err := donorOf(uc.userRepo, transaction)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	// A link from an earlier attempt that failed later on is reused; the
+	// gateway would reject a second charge for the same code.
+	if transaction.PaymentURL == "" {
+		transaction.PaymentURL, err = uc.transactionUC.GetPaymentURL(transaction, donor)
+		if err != nil {
+			return model.Transaction{}, err
+		}
+		if _, err := uc.transactionRepo.UpdatePaymentURL(transaction); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	transaction, err = uc.decide(transaction, model.TransactionStatusPending, input)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nYour donation of %s (%s) has been approved.\nComplete the payment here:\n%s\n",
+		donor.Name, transaction.AmountFormat(), transaction.Code, transaction.PaymentURL)
+	if err := uc.mailService.Send(donor.Email, "Your donation was approved", body); err != nil {
+		log.Printf("Error sending approval email for transaction %d: %v", transaction.ID, err)
+	}
+	return transaction, nil
+}
+
+// Reject cancels a held donation and lets the donor know.
+func (uc *riskReviewUseCase) Reject(transactionID int, input model.RiskReviewInput) (model.Transaction, error) {
+	transaction, err := uc.held(transactionID)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	transaction, err = uc.decide(transaction, model.TransactionStatusCancelled, input)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	donor, err := donorOf(uc.userRepo, transaction)
+	if err != nil {
+		log.Printf("Error finding the donor of transaction %d: %v", transaction.ID, err)
+		return transaction, nil
+	}
+	body := fmt.Sprintf("Hi %s,\n\nYour donation of %s (%s) could not be accepted and has been cancelled. You have not been charged.\n",
+		donor.Name, transaction.AmountFormat(), transaction.Code)
+	if err := uc.mailService.Send(donor.Email, "Your donation was cancelled", body); err != nil {
+		log.Printf("Error sending rejection email for transaction %d: %v", transaction.ID, err)
+	}
+	return transaction, nil
+}
+
+// held returns a donation that is waiting for a decision.
+func (uc *riskReviewUseCase) held(transactionID int) (model.Transaction, error) {
+	transaction, err := uc.transactionRepo.GetByID(transactionID)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if transaction.Status != model.TransactionStatusReview {
+		return model.Transaction{}, ErrNotUnderReview
+	}
+	return transaction, nil
+}
+
+// decide moves a held donation to status. The change names review as the
+// status it leaves, so two admins deciding at once cannot both succeed.
+func (uc *riskReviewUseCase) decide(transaction model.Transaction, status model.TransactionStatus, input model.RiskReviewInput) (model.Transaction, error) {
+	updated, err := uc.transactionRepo.ApplyStatus(model.TransactionStatusChange{
+		TransactionID: transaction.ID,
+		FromStatus:    model.TransactionStatusReview,
+		ToStatus:      status,
+		Source:        model.StatusSourceAdmin,
+		ActorID:       input.User.ID,
+		Note:          input.Note,
+	})
+	if errors.Is(err, model.ErrIllegalTransition) {
+		return model.Transaction{}, ErrNotUnderReview
+	}
+	return updated, err
+}
+
+type RiskReviewUseCase interface {
+	GetQueue(page int, size int) ([]model.Transaction, dto.Paging, error)
+	Approve(transactionID int, input model.RiskReviewInput) (model.Transaction, error)
+	Reject(transactionID int, input model.RiskReviewInput) (model.Transaction, error)
+}
+
+func NewRiskReviewUseCase(transactionRepo repository.TransactionRepo, riskRepo repository.RiskRepo, transactionUC TransactionUseCase,
+	userRepo repository.UserRepo, mailService service.MailService) RiskReviewUseCase {
+	return &riskReviewUseCase{
+		transactionRepo: transactionRepo,
+		riskRepo:        riskRepo,
+		transactionUC:   transactionUC,
+		userRepo:        userRepo,
+		mailService:     mailService,
+	}
+}
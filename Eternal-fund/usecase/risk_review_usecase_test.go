@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"errors"
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type RiskReviewUseCaseTestSuite struct {
+	suite.Suite
+	ruc             *riskReviewUseCase
+	transactionRepo *mocking.TransactionRepoMock
+	riskRepo        *mocking.RiskRepoMock
+	transactionUC   *mocking.TransactionUseCaseMock
+	userRepo        *mocking.UserRepoMock
+	mailService     *mocking.MailServiceMock
+}
+
+func (suite *RiskReviewUseCaseTestSuite) SetupTest() {
+	suite.transactionRepo = new(mocking.TransactionRepoMock)
+	suite.riskRepo = new(mocking.RiskRepoMock)
+	suite.transactionUC = new(mocking.TransactionUseCaseMock)
+	suite.userRepo = new(mocking.UserRepoMock)
+	suite.mailService = new(mocking.MailServiceMock)
+	suite.ruc = &riskReviewUseCase{
+		transactionRepo: suite.transactionRepo,
+		riskRepo:        suite.riskRepo,
+		transactionUC:   suite.transactionUC,
+		userRepo:        suite.userRepo,
+		mailService:     suite.mailService,
+	}
+}
+
+var reviewAdmin = model.User{ID: 9, Role: "admin"}
+
+func (suite *RiskReviewUseCaseTestSuite) TestApprove_IssuesPaymentLink() {
+	held := model.Transaction{ID: 7, UserID: 1, Amount: 900000, Status: model.TransactionStatusReview, Code: "TRX-7"}
+	linked := held
+	linked.PaymentURL = "http://payment.url"
+	pending := linked
+	pending.Status = model.TransactionStatusPending
+	donor := model.User{ID: 1, Name: "Jane", Email: "jane@example.com"}
+
+	suite.transactionRepo.On("GetByID", 7).Return(held, nil)
+	suite.userRepo.On("FindById", 1).Return(donor, nil)
+	suite.transactionUC.On("GetPaymentURL", held, donor).Return("http://payment.url", nil)
+	suite.transactionRepo.On("UpdatePaymentURL", linked).Return(linked, nil)
+	suite.transactionRepo.On("ApplyStatus", model.TransactionStatusChange{TransactionID: 7, FromStatus: model.TransactionStatusReview,
+		ToStatus: model.TransactionStatusPending, Source: model.StatusSourceAdmin, ActorID: 9, Note: "called the donor"}).Return(pending, nil)
+	suite.mailService.On("Send", "jane@example.com", "Your donation was approved", mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "http://payment.url")
+	})).Return(nil)
+
+	transaction, err := suite.ruc.Approve(7, model.RiskReviewInput{Note: "called the donor", User: reviewAdmin})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pending, transaction)
+	suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *RiskReviewUseCaseTestSuite) TestApprove_GatewayFailureStaysInReview() {
+	held := model.Transaction{ID: 7, UserID: 1, Amount: 900000, Status: model.TransactionStatusReview, Code: "TRX-7"}
+	donor := model.User{ID: 1, Name: "Jane", Email: "jane@example.com"}
+
+	suite.transactionRepo.On("GetByID", 7).Return(held, nil)
+	suite.userRepo.On("FindById", 1).Return(donor, nil)
+	suite.transactionUC.On("GetPaymentURL", held, donor).Return("", errors.New("gateway unavailable"))
+
+	_, err := suite.ruc.Approve(7, model.RiskReviewInput{User: reviewAdmin})
+	assert.EqualError(suite.T(), err, "gateway unavailable")
+	suite.transactionRepo.AssertNotCalled(suite.T(), "ApplyStatus", mock.Anything)
+	suite.transactionRepo.AssertNotCalled(suite.T(), "UpdatePaymentURL", mock.Anything)
+	suite.mailService.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *RiskReviewUseCaseTestSuite) TestApprove_ReusesEarlierPaymentLink() {
+	held := model.Transaction{ID: 7, UserID: 1, Status: model.TransactionStatusReview, Code: "TRX-7", PaymentURL: "http://payment.url"}
+	pending := held
+	pending.Status = model.TransactionStatusPending
+	donor := model.User{ID: 1, Name: "Jane", Email: "jane@example.com"}
+
+	suite.transactionRepo.On("GetByID", 7).Return(held, nil)
+	suite.userRepo.On("FindById", 1).Return(donor, nil)
+	suite.transactionRepo.On("ApplyStatus", mock.Anything).Return(pending, nil)
+	suite.mailService.On("Send", "jane@example.com", "Your donation was approved", mock.Anything).Return(nil)
+
+	transaction, err := suite.ruc.Approve(7, model.RiskReviewInput{User: reviewAdmin})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "http://payment.url", transaction.PaymentURL)
+	suite.transactionUC.AssertNotCalled(suite.T(), "GetPaymentURL", mock.Anything, mock.Anything)
+}
+
+func (suite *RiskReviewUseCaseTestSuite) TestReject_CancelsGuestDonation() {
+	held := model.Transaction{ID: 8, GuestName: "Sam", GuestEmail: "sam@example.com", Status: model.TransactionStatusReview}
+	cancelled := held
+	cancelled.Status = model.TransactionStatusCancelled
+
+	suite.transactionRepo.On("GetByID", 8).Return(held, nil)
+	suite.transactionRepo.On("ApplyStatus", mock.MatchedBy(func(change model.TransactionStatusChange) bool {
+		return change.FromStatus == model.TransactionStatusReview && change.ToStatus == model.TransactionStatusCancelled
+	})).Return(cancelled, nil)
+	suite.mailService.On("Send", "sam@example.com", "Your donation was cancelled", mock.Anything).Return(nil)
+
+	transaction, err := suite.ruc.Reject(8, model.RiskReviewInput{User: reviewAdmin})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.TransactionStatusCancelled, transaction.Status)
+	suite.transactionUC.AssertNotCalled(suite.T(), "GetPaymentURL", mock.Anything, mock.Anything)
+	suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *RiskReviewUseCaseTestSuite) TestDecide_NotUnderReview() {
+	suite.transactionRepo.On("GetByID", 3).Return(model.Transaction{ID: 3, Status: model.TransactionStatusPending}, nil)
+
+	_, err := suite.ruc.Reject(3, model.RiskReviewInput{User: reviewAdmin})
+	assert.ErrorIs(suite.T(), err, ErrNotUnderReview)
+	suite.transactionRepo.AssertNotCalled(suite.T(), "ApplyStatus", mock.Anything)
+}
+
+func (suite *RiskReviewUseCaseTestSuite) TestDecide_AlreadyDecided() {
+	suite.transactionRepo.On("GetByID", 4).Return(model.Transaction{ID: 4, GuestEmail: "sam@example.com", Status: model.TransactionStatusReview,
+		PaymentURL: "http://payment.url"}, nil)
+	suite.transactionRepo.On("ApplyStatus", mock.Anything).Return(model.Transaction{ID: 4, Status: model.TransactionStatusCancelled}, model.ErrIllegalTransition)
+
+	_, err := suite.ruc.Approve(4, model.RiskReviewInput{User: reviewAdmin})
+	assert.ErrorIs(suite.T(), err, ErrNotUnderReview)
+	suite.mailService.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRiskReviewUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(RiskReviewUseCaseTestSuite))
+}
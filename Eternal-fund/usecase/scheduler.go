@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"log"
+	"time"
+)
+
+// runPeriodically runs fn immediately and then on every tick of interval.
+// Failures are logged so a single bad run does not stop the job.
+func runPeriodically(job string, interval time.Duration, fn func() error) {
+	if interval <= 0 {
+		log.Printf("[JOB] %s disabled", job)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(); err != nil {
+			log.Printf("[JOB] %s failed: %v", job, err)
+		}
+		<-ticker.C
+	}
+}
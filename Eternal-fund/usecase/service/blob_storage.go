@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"eternal-fund/config"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStorage keeps generated files under slash separated keys such as
+// "receipts/2024/RCPT-2024-000001.pdf".
+type BlobStorage interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+}
+
+type localBlobStorage struct {
+	dir string
+}
+
+func (s *localBlobStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, cleaned), nil
+}
+
+// Put writes the blob to a temporary file first so a reader never sees a
+// partly written blob.
+func (s *localBlobStorage) Put(key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *localBlobStorage) Get(key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return data, err
+}
+
+// NewBlobStorage stores blobs on the local disk under BLOB_STORAGE_DIR.
+func NewBlobStorage(c config.StorageConfig) BlobStorage {
+	return &localBlobStorage{dir: c.BlobDir}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"eternal-fund/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type BlobStorageTestSuite struct {
+	suite.Suite
+	storage BlobStorage
+}
+
+func (suite *BlobStorageTestSuite) SetupTest() {
+	suite.storage = NewBlobStorage(config.StorageConfig{BlobDir: suite.T().TempDir()})
+}
+
+func (suite *BlobStorageTestSuite) TestPutAndGet() {
+	err := suite.storage.Put("receipts/2024/RCPT-2024-000001.pdf", []byte("%PDF-1.3"))
+	assert.NoError(suite.T(), err)
+
+	data, err := suite.storage.Get("receipts/2024/RCPT-2024-000001.pdf")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []byte("%PDF-1.3"), data)
+}
+
+func (suite *BlobStorageTestSuite) TestGet_NotFound() {
+	_, err := suite.storage.Get("receipts/2024/missing.pdf")
+	assert.ErrorIs(suite.T(), err, ErrBlobNotFound)
+}
+
+func (suite *BlobStorageTestSuite) TestRejectsKeysOutsideTheStorage() {
+	assert.Error(suite.T(), suite.storage.Put("../outside.pdf", []byte("x")))
+	_, err := suite.storage.Get("/etc/passwd")
+	assert.Error(suite.T(), err)
+}
+
+func TestBlobStorageTestSuite(t *testing.T) {
+	suite.Run(t, new(BlobStorageTestSuite))
+}
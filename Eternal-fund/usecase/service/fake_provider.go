@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"eternal-fund/model"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FakeCharge is a charge held by the fake provider.
+type FakeCharge struct {
+	OrderID   string
+	Amount    int
+	Name      string
+	Email     string
+	Status    string
+	Refunded  int
+	CreatedAt time.Time
+}
+
+// FakeGateway is an in-process payment provider for running donation flows
+// without a real gateway. Donors pay on a local checkout page and the outcome
+// is delivered to the notification endpoint as a signed webhook, exactly like
+// Midtrans would.
+type FakeGateway interface {
+	PaymentProvider
+	FindCharge(orderID string) (FakeCharge, error)
+	Complete(orderID string, gatewayStatus string) error
+}
+
+type fakeProvider struct {
+	serverKey  string
+	baseURL    string
+	webhookURL string
+	client     *http.Client
+
+	mu      sync.Mutex
+	charges map[string]*FakeCharge
+	refunds map[string]model.PaymentRefund
+}
+
+func NewFakeProvider(serverKey string, baseURL string) FakeGateway {
+	return &fakeProvider{
+		serverKey:  serverKey,
+		baseURL:    baseURL,
+		webhookURL: baseURL + "/api/v1/transactions/notification",
+		client:     &http.Client{Timeout: 10 * time.Second},
+		charges:    map[string]*FakeCharge{},
+		refunds:    map[string]model.PaymentRefund{},
+	}
+}
+
+func (f *fakeProvider) SupportsCurrency(currency model.Currency) bool {
+	return true
+}
+
+func (f *fakeProvider) CreateCharge(transaction model.Transaction, user model.User) (model.PaymentCharge, error) {
+	orderID := transaction.Code
+
+	f.mu.Lock()
+	f.charges[orderID] = &FakeCharge{
+		OrderID:   orderID,
+		Amount:    transaction.Amount,
+		Name:      user.Name,
+		Email:     user.Email,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}
+	f.mu.Unlock()
+
+	return model.PaymentCharge{
+		OrderID:     orderID,
+		Token:       "fake-" + orderID,
+		RedirectURL: f.baseURL + "/fake-gateway/checkout/" + orderID,
+	}, nil
+}
+
+// FakeDeclinedCardToken is a saved card the fake provider always declines,
+// for exercising failed recurring charges.
+const FakeDeclinedCardToken = "fake-declined-card"
+
+// ChargeToken settles the charge straight away, or denies it for
+// FakeDeclinedCardToken. The webhook is sent in the background because a
+// real gateway reports card charges asynchronously too.
+func (f *fakeProvider) ChargeToken(transaction model.Transaction, user model.User, cardToken string) (model.PaymentCharge, error) {
+	charge, err := f.CreateCharge(transaction, user)
+	if err != nil {
+		return model.PaymentCharge{}, err
+	}
+
+	gatewayStatus := "settlement"
+	if cardToken == FakeDeclinedCardToken {
+		gatewayStatus = "deny"
+	}
+	go func() {
+		if err := f.Complete(charge.OrderID, gatewayStatus); err != nil {
+			log.Printf("[FAKE GATEWAY] card charge %s: %v", charge.OrderID, err)
+		}
+	}()
+
+	charge.RedirectURL = ""
+	return charge, nil
+}
+
+func (f *fakeProvider) FindCharge(orderID string) (FakeCharge, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	charge, ok := f.charges[orderID]
+	if !ok {
+		return FakeCharge{}, ErrChargeNotFound
+	}
+	return *charge, nil
+}
+
+func (f *fakeProvider) FetchStatus(orderID string) (model.TransactionNotificationInput, error) {
+	charge, err := f.FindCharge(orderID)
+	if err != nil {
+		return model.TransactionNotificationInput{}, err
+	}
+	return f.notification(charge), nil
+}
+
+// Complete settles, denies, expires or cancels a pending charge and sends the
+// result to the webhook endpoint.
+func (f *fakeProvider) Complete(orderID string, gatewayStatus string) error {
+	switch gatewayStatus {
+	case "settlement", "deny", "expire", "cancel":
+	default:
+		return fmt.Errorf("unsupported status %q", gatewayStatus)
+	}
+
+	f.mu.Lock()
+	charge, ok := f.charges[orderID]
+	if !ok {
+		f.mu.Unlock()
+		return ErrChargeNotFound
+	}
+	if charge.Status != "pending" {
+		f.mu.Unlock()
+		return fmt.Errorf("charge %s is already %s", orderID, charge.Status)
+	}
+	charge.Status = gatewayStatus
+	completed := *charge
+	f.mu.Unlock()
+
+	return f.sendWebhook(f.notification(completed))
+}
+
+func (f *fakeProvider) Refund(orderID string, refundKey string, amount int, reason string) (model.PaymentRefund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if refund, ok := f.refunds[refundKey]; ok {
+		return refund, nil
+	}
+
+	charge, ok := f.charges[orderID]
+	if !ok {
+		return model.PaymentRefund{}, ErrChargeNotFound
+	}
+	if charge.Status != "settlement" && charge.Status != "partial_refund" {
+		return model.PaymentRefund{}, fmt.Errorf("charge %s is %s and cannot be refunded", orderID, charge.Status)
+	}
+	if charge.Refunded+amount > charge.Amount {
+		return model.PaymentRefund{}, fmt.Errorf("refund of %d exceeds the refundable amount of charge %s", amount, orderID)
+	}
+
+	charge.Refunded += amount
+	charge.Status = "partial_refund"
+	if charge.Refunded == charge.Amount {
+		charge.Status = "refund"
+	}
+
+	refund := model.PaymentRefund{
+		OrderID:   orderID,
+		RefundKey: refundKey,
+		Amount:    amount,
+		Status:    charge.Status,
+	}
+	f.refunds[refundKey] = refund
+	return refund, nil
+}
+
+func (f *fakeProvider) VerifyWebhook(notification model.TransactionNotificationInput) bool {
+	return verifyNotificationSignature(notification, f.serverKey)
+}
+
+func (f *fakeProvider) notification(charge FakeCharge) model.TransactionNotificationInput {
+	statusCode := "200"
+	switch charge.Status {
+	case "pending":
+		statusCode = "201"
+	case "deny", "expire", "cancel":
+		statusCode = "202"
+	}
+
+	notification := model.TransactionNotificationInput{
+		TransactionStatus: charge.Status,
+		OrderID:           charge.OrderID,
+		PaymentType:       "fake",
+		StatusCode:        statusCode,
+		GrossAmount:       strconv.Itoa(charge.Amount) + ".00",
+		TransactionID:     "fake-" + charge.OrderID,
+		TransactionTime:   charge.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	notification.SignatureKey = notificationSignature(notification, f.serverKey)
+	return notification
+}
+
+func (f *fakeProvider) sendWebhook(notification model.TransactionNotificationInput) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	resp, err := f.client.Post(f.webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook for order %s was answered with %s", notification.OrderID, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"encoding/json"
+	"eternal-fund/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type FakeProviderTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	received []model.TransactionNotificationInput
+	provider FakeGateway
+}
+
+func (suite *FakeProviderTestSuite) SetupTest() {
+	suite.received = nil
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification model.TransactionNotificationInput
+		if r.URL.Path != "/api/v1/transactions/notification" || json.NewDecoder(r.Body).Decode(&notification) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		suite.received = append(suite.received, notification)
+	}))
+	suite.provider = NewFakeProvider("secret", suite.server.URL)
+}
+
+func (suite *FakeProviderTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *FakeProviderTestSuite) TestCompleteSendsSignedWebhook() {
+	charge, err := suite.provider.CreateCharge(model.Transaction{ID: 7, Code: "TRX-7", Amount: 50000}, model.User{Name: "Budi", Email: "budi@example.com"})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.server.URL+"/fake-gateway/checkout/TRX-7", charge.RedirectURL)
+
+	err = suite.provider.Complete("TRX-7", "settlement")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), suite.received, 1)
+
+	notification := suite.received[0]
+	assert.Equal(suite.T(), "settlement", notification.TransactionStatus)
+	assert.Equal(suite.T(), "50000.00", notification.GrossAmount)
+	assert.True(suite.T(), suite.provider.VerifyWebhook(notification))
+	assert.False(suite.T(), NewFakeProvider("other", suite.server.URL).VerifyWebhook(notification))
+	assert.Equal(suite.T(), model.TransactionStatusPaid, notification.InternalStatus())
+}
+
+func (suite *FakeProviderTestSuite) TestCompleteOnlyOnce() {
+	suite.provider.CreateCharge(model.Transaction{ID: 8, Code: "TRX-8", Amount: 1000}, model.User{})
+
+	assert.NoError(suite.T(), suite.provider.Complete("TRX-8", "expire"))
+	assert.Error(suite.T(), suite.provider.Complete("TRX-8", "settlement"))
+	assert.ErrorIs(suite.T(), suite.provider.Complete("TRX-9", "settlement"), ErrChargeNotFound)
+}
+
+func (suite *FakeProviderTestSuite) TestRefund() {
+	suite.provider.CreateCharge(model.Transaction{ID: 10, Code: "TRX-10", Amount: 1000}, model.User{})
+
+	_, err := suite.provider.Refund("TRX-10", "TRX-10-refund-1", 1000, "duplicate")
+	assert.Error(suite.T(), err, "pending charges cannot be refunded")
+
+	suite.provider.Complete("TRX-10", "settlement")
+	refund, err := suite.provider.Refund("TRX-10", "TRX-10-refund-2", 400, "partial")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "partial_refund", refund.Status)
+
+	_, err = suite.provider.Refund("TRX-10", "TRX-10-refund-3", 700, "too much")
+	assert.Error(suite.T(), err)
+
+	refund, err = suite.provider.Refund("TRX-10", "TRX-10-refund-4", 600, "rest")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "refund", refund.Status)
+
+	status, err := suite.provider.FetchStatus("TRX-10")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "refund", status.TransactionStatus)
+	assert.True(suite.T(), suite.provider.VerifyWebhook(status))
+}
+
+func (suite *FakeProviderTestSuite) TestRefund_RetryWithSameKeyPaysOnce() {
+	suite.provider.CreateCharge(model.Transaction{ID: 11, Code: "TRX-11", Amount: 1000}, model.User{})
+	suite.provider.Complete("TRX-11", "settlement")
+
+	first, err := suite.provider.Refund("TRX-11", "TRX-11-refund-5", 400, "partial")
+	assert.NoError(suite.T(), err)
+	retried, err := suite.provider.Refund("TRX-11", "TRX-11-refund-5", 400, "partial")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), first, retried)
+
+	charge, err := suite.provider.FindCharge("TRX-11")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 400, charge.Refunded)
+}
+
+func TestFakeProviderTestSuite(t *testing.T) {
+	suite.Run(t, new(FakeProviderTestSuite))
+}
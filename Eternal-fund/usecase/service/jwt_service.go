@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"eternal-fund/config"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
@@ -13,9 +14,21 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Purposes of the signed links sent by email, and of the guest tokens that
+// keep the idempotency keys of different guests apart.
+const (
+	LinkPurposeGuestDonation = "guest_donation"
+	LinkPurposeVerifyEmail   = "verify_email"
+	LinkPurposeGuestSession  = "guest_session"
+)
+
+var ErrInvalidLinkToken = errors.New("link is invalid or has expired")
+
 type JwtService interface {
 	CreateToken(user model.User) (dto.AuthResponDto, error)
 	ValidateToken(token string) (jwt.MapClaims, error)
+	CreateLinkToken(purpose string, subject string) (string, error)
+	ValidateLinkToken(purpose string, token string) (string, error)
 }
 
 type jwtService struct {
@@ -68,6 +81,38 @@ func (j *jwtService) ValidateToken(tokenHeader string) (jwt.MapClaims, error) {
 
 }
 
+// CreateLinkToken signs subject for a link sent by email. Link tokens carry no
+// user id or role, so they are never accepted as access tokens.
+func (j *jwtService) CreateLinkToken(purpose string, subject string) (string, error) {
+	claims := utils.LinkClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    j.co.IssuerName,
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.co.LinkExpiresTime)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Purpose: purpose,
+	}
+
+	ss, err := jwt.NewWithClaims(j.co.SigningMethod, claims).SignedString(j.co.SignatureKey)
+	if err != nil {
+		return "", fmt.Errorf("failed create link token")
+	}
+	return ss, nil
+}
+
+// ValidateLinkToken returns the subject of a link token issued for purpose.
+func (j *jwtService) ValidateLinkToken(purpose string, token string) (string, error) {
+	var claims utils.LinkClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		return j.co.SignatureKey, nil
+	}, jwt.WithValidMethods([]string{j.co.SigningMethod.Alg()}), jwt.WithIssuer(j.co.IssuerName))
+	if err != nil || claims.Purpose != purpose {
+		return "", ErrInvalidLinkToken
+	}
+	return claims.Subject, nil
+}
+
 func NewJwtService(c config.TokenConfig) JwtService {
 	return &jwtService{co: c}
 }
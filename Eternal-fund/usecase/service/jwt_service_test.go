@@ -0,0 +1,56 @@
+package service
+
+import (
+	"eternal-fund/config"
+	"eternal-fund/model"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type JwtServiceTestSuite struct {
+	suite.Suite
+	service JwtService
+}
+
+func (suite *JwtServiceTestSuite) SetupTest() {
+	suite.service = NewJwtService(config.TokenConfig{
+		IssuerName:      "eternal-fund",
+		SignatureKey:    []byte("secret"),
+		SigningMethod:   jwt.SigningMethodHS256,
+		ExpiresTime:     time.Hour,
+		LinkExpiresTime: time.Hour,
+	})
+}
+
+func (suite *JwtServiceTestSuite) TestLinkTokenRoundTrip() {
+	token, err := suite.service.CreateLinkToken(LinkPurposeGuestDonation, "42")
+	assert.NoError(suite.T(), err)
+
+	subject, err := suite.service.ValidateLinkToken(LinkPurposeGuestDonation, token)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "42", subject)
+}
+
+func (suite *JwtServiceTestSuite) TestLinkTokenRejectsOtherPurpose() {
+	token, err := suite.service.CreateLinkToken(LinkPurposeGuestDonation, "42")
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.service.ValidateLinkToken(LinkPurposeVerifyEmail, token)
+	assert.ErrorIs(suite.T(), err, ErrInvalidLinkToken)
+}
+
+func (suite *JwtServiceTestSuite) TestLinkTokenRejectsAccessToken() {
+	access, err := suite.service.CreateToken(model.User{ID: 42, Role: "user"})
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.service.ValidateLinkToken(LinkPurposeGuestDonation, access.Token)
+	assert.ErrorIs(suite.T(), err, ErrInvalidLinkToken)
+}
+
+func TestJwtServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(JwtServiceTestSuite))
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"eternal-fund/config"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+)
+
+// Attachment is a file sent along with an email.
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+type MailService interface {
+	Send(to string, subject string, body string) error
+	SendWithAttachment(to string, subject string, body string, attachment Attachment) error
+}
+
+type mailService struct {
+	co config.MailConfig
+}
+
+// Send delivers a plain-text email. When no SMTP host is configured the
+// message is only logged, which keeps local development working.
+func (m *mailService) Send(to string, subject string, body string) error {
+	if m.co.SmtpHost == "" {
+		log.Printf("[MAIL] to=%s subject=%q\n%s", to, subject, body)
+		return nil
+	}
+
+	return m.deliver(to, subject, []string{
+		"Content-Type: text/plain; charset=\"utf-8\"",
+		"",
+		body,
+	})
+}
+
+// SendWithAttachment delivers a plain-text email with one attached file.
+func (m *mailService) SendWithAttachment(to string, subject string, body string, attachment Attachment) error {
+	if m.co.SmtpHost == "" {
+		log.Printf("[MAIL] to=%s subject=%q attachment=%s (%d bytes)\n%s", to, subject, attachment.Name, len(attachment.Data), body)
+		return nil
+	}
+
+	var random [12]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return err
+	}
+	boundary := "eternal-fund-" + hex.EncodeToString(random[:])
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	var wrapped strings.Builder
+	for len(encoded) > 76 {
+		wrapped.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	wrapped.WriteString(encoded)
+
+	return m.deliver(to, subject, []string{
+		"Content-Type: multipart/mixed; boundary=\"" + boundary + "\"",
+		"",
+		"--" + boundary,
+		"Content-Type: text/plain; charset=\"utf-8\"",
+		"",
+		body,
+		"--" + boundary,
+		"Content-Type: " + attachment.ContentType,
+		"Content-Transfer-Encoding: base64",
+		"Content-Disposition: " + mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}),
+		"",
+		wrapped.String(),
+		"--" + boundary + "--",
+	})
+}
+
+func (m *mailService) deliver(to string, subject string, content []string) error {
+	message := strings.Join(append([]string{
+		"From: " + m.co.MailFrom,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+	}, content...), "\r\n")
+
+	addr := fmt.Sprintf("%s:%s", m.co.SmtpHost, m.co.SmtpPort)
+	auth := smtp.PlainAuth("", m.co.SmtpUsername, m.co.SmtpPassword, m.co.SmtpHost)
+	if err := smtp.SendMail(addr, auth, m.co.MailFrom, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+	return nil
+}
+
+func NewMailService(c config.MailConfig) MailService {
+	return &mailService{co: c}
+}
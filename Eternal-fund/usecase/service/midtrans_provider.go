@@ -0,0 +1,148 @@
+package service
+
+import (
+	"eternal-fund/config"
+	"eternal-fund/model"
+	"fmt"
+
+	midtrans "github.com/veritrans/go-midtrans"
+)
+
+type midtransProvider struct {
+	cfg config.MidtransConfig
+}
+
+func NewMidtransProvider(cfg config.MidtransConfig) PaymentProvider {
+	return &midtransProvider{cfg: cfg}
+}
+
+func (s *midtransProvider) client() midtrans.Client {
+	midclient := midtrans.NewClient()
+	midclient.ServerKey = s.cfg.ServerKey
+	midclient.ClientKey = s.cfg.ClientKey
+	midclient.APIEnvType = midtrans.Sandbox
+	if s.cfg.Environment == "production" {
+		midclient.APIEnvType = midtrans.Production
+	}
+	return midclient
+}
+
+// SupportsCurrency is true only for rupiah, the one currency Midtrans charges in.
+func (s *midtransProvider) SupportsCurrency(currency model.Currency) bool {
+	return currency.OrDefault() == model.CurrencyIDR
+}
+
+func (s *midtransProvider) CreateCharge(transaction model.Transaction, user model.User) (model.PaymentCharge, error) {
+	if !s.SupportsCurrency(transaction.Currency) {
+		return model.PaymentCharge{}, ErrUnsupportedCurrency
+	}
+	midclient := s.client()
+	snapGateway := midtrans.SnapGateway{
+		Client: midclient,
+	}
+
+	orderID := transaction.Code
+	snapReq := &midtrans.SnapReq{
+		CustomerDetail: &midtrans.CustDetail{
+			Email: user.Email,
+			FName: user.Name,
+		},
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  orderID,
+			GrossAmt: int64(transaction.Amount),
+		},
+	}
+
+	snapTokenResp, err := snapGateway.GetToken(snapReq)
+	if err != nil {
+		return model.PaymentCharge{}, err
+	}
+
+	return model.PaymentCharge{OrderID: orderID, Token: snapTokenResp.Token, RedirectURL: snapTokenResp.RedirectURL}, nil
+}
+
+func (s *midtransProvider) ChargeToken(transaction model.Transaction, user model.User, cardToken string) (model.PaymentCharge, error) {
+	if !s.SupportsCurrency(transaction.Currency) {
+		return model.PaymentCharge{}, ErrUnsupportedCurrency
+	}
+	midclient := s.client()
+	coreGateway := midtrans.CoreGateway{
+		Client: midclient,
+	}
+
+	orderID := transaction.Code
+	resp, err := coreGateway.Charge(&midtrans.ChargeReq{
+		PaymentType: midtrans.SourceCreditCard,
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  orderID,
+			GrossAmt: int64(transaction.Amount),
+		},
+		CreditCard: &midtrans.CreditCardDetail{
+			TokenID: cardToken,
+		},
+		CustomerDetail: &midtrans.CustDetail{
+			Email: user.Email,
+			FName: user.Name,
+		},
+	})
+	if err != nil {
+		return model.PaymentCharge{}, err
+	}
+	if resp.StatusCode != "200" && resp.StatusCode != "201" {
+		return model.PaymentCharge{}, fmt.Errorf("midtrans card charge for order %s: %s %s", orderID, resp.StatusCode, resp.StatusMessage)
+	}
+
+	return model.PaymentCharge{OrderID: orderID, Token: resp.TransactionID}, nil
+}
+
+func (s *midtransProvider) FetchStatus(orderID string) (model.TransactionNotificationInput, error) {
+	midclient := s.client()
+	coreGateway := midtrans.CoreGateway{
+		Client: midclient,
+	}
+
+	resp, err := coreGateway.Status(orderID)
+	if err != nil {
+		return model.TransactionNotificationInput{}, err
+	}
+	if resp.StatusCode == "404" {
+		return model.TransactionNotificationInput{}, ErrChargeNotFound
+	}
+	if resp.TransactionStatus == "" {
+		return model.TransactionNotificationInput{}, fmt.Errorf("midtrans status for order %s: %s %s", orderID, resp.StatusCode, resp.StatusMessage)
+	}
+
+	return model.TransactionNotificationInput{
+		TransactionStatus: resp.TransactionStatus,
+		OrderID:           resp.OrderID,
+		PaymentType:       resp.PaymentType,
+		FraudStatus:       resp.FraudStatus,
+		StatusCode:        resp.StatusCode,
+		GrossAmount:       resp.GrossAmount,
+		SignatureKey:      resp.SignKey,
+		TransactionID:     resp.TransactionID,
+		TransactionTime:   resp.TransactionTime,
+	}, nil
+}
+
+func (s *midtransProvider) Refund(orderID string, refundKey string, amount int, reason string) (model.PaymentRefund, error) {
+	midclient := s.client()
+	coreGateway := midtrans.CoreGateway{
+		Client: midclient,
+	}
+
+	resp, err := coreGateway.Refund(orderID, &midtrans.RefundReq{RefundKey: refundKey, Amount: int64(amount), Reason: reason})
+	if err != nil {
+		return model.PaymentRefund{}, err
+	}
+	if resp.StatusCode != "200" && resp.StatusCode != "201" {
+		return model.PaymentRefund{}, fmt.Errorf("midtrans refund for order %s: %s %s", orderID, resp.StatusCode, resp.StatusMessage)
+	}
+
+	return model.PaymentRefund{OrderID: orderID, RefundKey: refundKey, Amount: amount, Status: resp.TransactionStatus}, nil
+}
+
+// VerifyWebhook checks the notification signature_key against the server key.
+func (s *midtransProvider) VerifyWebhook(notification model.TransactionNotificationInput) bool {
+	return verifyNotificationSignature(notification, s.cfg.ServerKey)
+}
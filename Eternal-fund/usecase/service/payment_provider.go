@@ -0,0 +1,64 @@
+package service
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"eternal-fund/config"
+	"eternal-fund/model"
+	"fmt"
+)
+
+const (
+	PaymentProviderMidtrans = "midtrans"
+	PaymentProviderFake     = "fake"
+)
+
+// ErrChargeNotFound is returned when the gateway has no charge for an order,
+// for example because the donor never opened the payment page.
+var ErrChargeNotFound = errors.New("charge not found")
+
+// ErrUnsupportedCurrency is returned when the gateway cannot charge in the
+// currency of a transaction.
+var ErrUnsupportedCurrency = errors.New("the payment gateway cannot charge in this currency")
+
+// PaymentProvider is a payment gateway able to take, look up and refund
+// donations and to authenticate the webhooks it sends back. ChargeToken
+// charges a card saved by an earlier checkout without the donor present; its
+// outcome arrives through the webhook like any other charge. SupportsCurrency
+// reports whether the gateway can charge donations in a currency. A refund
+// sent again with the same refund key is only paid out once.
+type PaymentProvider interface {
+	SupportsCurrency(currency model.Currency) bool
+	CreateCharge(transaction model.Transaction, user model.User) (model.PaymentCharge, error)
+	ChargeToken(transaction model.Transaction, user model.User, cardToken string) (model.PaymentCharge, error)
+	FetchStatus(orderID string) (model.TransactionNotificationInput, error)
+	Refund(orderID string, refundKey string, amount int, reason string) (model.PaymentRefund, error)
+	VerifyWebhook(notification model.TransactionNotificationInput) bool
+}
+
+// NewPaymentProvider returns the provider selected by PAYMENT_PROVIDER.
+func NewPaymentProvider(cfg config.PaymentConfig, midtransCfg config.MidtransConfig, baseURL string) (PaymentProvider, error) {
+	switch cfg.Provider {
+	case PaymentProviderMidtrans:
+		return NewMidtransProvider(midtransCfg), nil
+	case PaymentProviderFake:
+		return NewFakeProvider(cfg.FakeServerKey, baseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+	}
+}
+
+// notificationSignature is the Midtrans signature_key: SHA-512 of order_id +
+// status_code + gross_amount + server key. The fake provider signs the same
+// way so webhook handling is identical for both.
+func notificationSignature(notification model.TransactionNotificationInput, serverKey string) string {
+	sum := sha512.Sum512([]byte(notification.OrderID + notification.StatusCode + notification.GrossAmount + serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func verifyNotificationSignature(notification model.TransactionNotificationInput, serverKey string) bool {
+	expected := notificationSignature(notification, serverKey)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(notification.SignatureKey)) == 1
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"eternal-fund/model"
+	"fmt"
+	"strconv"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// renderStatementCSV lists every donation of the statement under its
+// campaign, followed by a subtotal row per campaign and a total row per
+// currency. Amounts are in minor units of the row's currency.
+func renderStatementCSV(statement model.DonationStatement) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"campaign_id", "campaign", "transaction", "donated_at", "currency", "amount", "refunded", "net"})
+	for _, campaign := range statement.Campaigns {
+		campaignID := strconv.Itoa(campaign.CampaignID)
+		currency := string(campaign.Currency)
+		for _, donation := range campaign.Donations {
+			writer.Write([]string{campaignID, csvText(campaign.CampaignName), donation.Code, donation.DonatedAt.Format("2006-01-02"), currency,
+				strconv.Itoa(donation.Amount), strconv.Itoa(donation.Refunded), strconv.Itoa(donation.Net)})
+		}
+		writer.Write([]string{campaignID, csvText(campaign.CampaignName), "subtotal", "", currency,
+			strconv.Itoa(campaign.Donated), strconv.Itoa(campaign.Refunded), strconv.Itoa(campaign.Net)})
+	}
+	for _, total := range statement.Totals {
+		writer.Write([]string{"", "", "total", "", string(total.Currency),
+			strconv.Itoa(total.Donated), strconv.Itoa(total.Refunded), strconv.Itoa(total.Net)})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderStatementPDF lays out a statement as an A4 PDF with a table per
+// campaign.
+func renderStatementPDF(issuer string, statement model.DonationStatement) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Donation statement %d", statement.Year), true)
+	pdf.SetAuthor(issuer, true)
+	text := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(0, 12, text(issuer), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 14)
+	pdf.CellFormat(0, 8, fmt.Sprintf("Donation Statement %d", statement.Year), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, text(statement.DonorName+" <"+statement.DonorEmail+">"), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, statement.PeriodStart.Format("2 January 2006")+" - "+statement.PeriodEnd.Format("2 January 2006"), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	widths := []float64{35, 55, 35, 30, 35}
+	row := func(style string, border string, cells ...string) {
+		pdf.SetFont("Helvetica", style, 10)
+		for i, cell := range cells {
+			align := "R"
+			if i < 2 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 7, text(cell), border, 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	if len(statement.Campaigns) == 0 {
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 8, "No donations were made in this period.", "", 1, "L", false, 0, "")
+	}
+	for _, campaign := range statement.Campaigns {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 8, text(campaign.CampaignName), "", 1, "L", false, 0, "")
+		row("B", "B", "Date", "Transaction", "Amount", "Refunded", "Net")
+		money := func(amount int) string { return model.FormatMoney(amount, campaign.Currency) }
+		for _, donation := range campaign.Donations {
+			row("", "", donation.DonatedAt.Format("2 Jan 2006"), donation.Code,
+				money(donation.Amount), money(donation.Refunded), money(donation.Net))
+		}
+		row("B", "T", "Subtotal", "", money(campaign.Donated), money(campaign.Refunded), money(campaign.Net))
+		pdf.Ln(4)
+	}
+
+	for _, total := range statement.Totals {
+		rows := [][2]string{
+			{"Total donated", model.FormatMoney(total.Donated, total.Currency)},
+			{"Total refunded", model.FormatMoney(total.Refunded, total.Currency)},
+			{"Net donations", model.FormatMoney(total.Net, total.Currency)},
+		}
+		for _, line := range rows {
+			pdf.SetFont("Helvetica", "B", 11)
+			pdf.CellFormat(45, 8, line[0]+" ("+string(total.Currency)+")", "B", 0, "L", false, 0, "")
+			pdf.SetFont("Helvetica", "", 11)
+			pdf.CellFormat(0, 8, line[1], "B", 1, "L", false, 0, "")
+		}
+		pdf.Ln(2)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"eternal-fund/usecase/service"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	StatementFormatPDF = "pdf"
+	StatementFormatCSV = "csv"
+)
+
+var ErrInvalidStatementFormat = errors.New("statement format must be pdf or csv")
+
+type statementUseCase struct {
+	transactionRepo repository.TransactionRepo
+	refundRepo      repository.RefundRepo
+	campaignRepo    repository.CampaignsRepo
+	userRepo        repository.UserRepo
+	blobStorage     service.BlobStorage
+	issuer          string
+	fiscalYearStart time.Month
+}
+
+// GetStatement returns the user's donation statement for a fiscal year as a
+// PDF or CSV file. Files are cached under a digest of the statement, so a
+// refund or a late payment produces a fresh file instead of a stale one.
+func (s *statementUseCase) GetStatement(user model.User, year int, format string) ([]byte, error) {
+	if format != StatementFormatPDF && format != StatementFormatCSV {
+		return nil, ErrInvalidStatementFormat
+	}
+	statement, err := s.buildStatement(user.ID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.statementKey(user.ID, statement, format)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.blobStorage.Get(key)
+	if err == nil {
+		return file, nil
+	}
+	if !errors.Is(err, service.ErrBlobNotFound) {
+		return nil, err
+	}
+
+	if format == StatementFormatCSV {
+		file, err = renderStatementCSV(statement)
+	} else {
+		file, err = renderStatementPDF(s.issuer, statement)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.blobStorage.Put(key, file); err != nil {
+		// The statement can be rendered again next time.
+		log.Printf("[STATEMENT] caching %s: %v", key, err)
+	}
+	return file, nil
+}
+
+// buildStatement adds up the user's donations that were charged and paid
+// during the fiscal year. Refunds are netted out of the donation they belong
+// to and a chargeback reverses the whole donation.
+func (s *statementUseCase) buildStatement(userID int, year int) (model.DonationStatement, error) {
+	donor, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return model.DonationStatement{}, err
+	}
+	transactions, err := s.transactionRepo.GetTransactionsByUserID(userID)
+	if err != nil {
+		return model.DonationStatement{}, err
+	}
+	paidTimes, err := findPaidTimes(s.transactionRepo, transactions)
+	if err != nil {
+		return model.DonationStatement{}, err
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		left, right := paidTimes[transactions[i].ID], paidTimes[transactions[j].ID]
+		if left.Equal(right) {
+			return transactions[i].ID < transactions[j].ID
+		}
+		return left.Before(right)
+	})
+
+	from, to := model.FiscalYearBounds(year, s.fiscalYearStart, time.Local)
+	statement := model.DonationStatement{
+		Year:        year,
+		PeriodStart: from,
+		PeriodEnd:   to.AddDate(0, 0, -1),
+		DonorName:   donor.Name,
+		DonorEmail:  donor.Email,
+	}
+	names := map[int]string{}
+	for _, transaction := range transactions {
+		paidAt := paidTimes[transaction.ID]
+		if !chargeSucceeded(transaction.Status) || paidAt.Before(from) || !paidAt.Before(to) {
+			continue
+		}
+		refunded, err := refundedAmount(s.refundRepo, transaction)
+		if err != nil {
+			return model.DonationStatement{}, err
+		}
+		name, ok := names[transaction.CampaignID]
+		if !ok {
+			if name, err = campaignName(s.campaignRepo, transaction.CampaignID); err != nil {
+				return model.DonationStatement{}, err
+			}
+			names[transaction.CampaignID] = name
+		}
+		statement.Add(transaction.CampaignID, name, transaction.Currency, model.StatementDonation{
+			TransactionID: transaction.ID,
+			Code:          transaction.Code,
+			DonatedAt:     paidAt,
+			Amount:        transaction.Amount,
+			Refunded:      refunded,
+			Net:           transaction.Amount - refunded,
+		})
+	}
+	return statement, nil
+}
+
+// refundedAmount is the part of a donation that has been given back to the
+// donor. Refunds still waiting on the gateway are left out until they go
+// through.
+func refundedAmount(refundRepo repository.RefundRepo, transaction model.Transaction) (int, error) {
+	if transaction.Status == model.TransactionStatusChargeback {
+		return transaction.Amount, nil
+	}
+	refunds, err := refundRepo.FindByTransactionID(transaction.ID)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, refund := range refunds {
+		if refund.Status == model.RefundStatusSucceeded {
+			total += refund.Amount
+		}
+	}
+	return min(total, transaction.Amount), nil
+}
+
+// statementKey names the cached file of a statement after what is printed on
+// it.
+func (s *statementUseCase) statementKey(userID int, statement model.DonationStatement, format string) (string, error) {
+	content, err := json.Marshal(struct {
+		Issuer    string
+		Statement model.DonationStatement
+	}{s.issuer, statement})
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(content)
+	return fmt.Sprintf("statements/%d/%d-%s.%s", userID, statement.Year, hex.EncodeToString(digest[:8]), format), nil
+}
+
+type StatementUseCase interface {
+	GetStatement(user model.User, year int, format string) ([]byte, error)
+}
+
+func NewStatementUseCase(transactionRepo repository.TransactionRepo, refundRepo repository.RefundRepo, campaignRepo repository.CampaignsRepo,
+	userRepo repository.UserRepo, blobStorage service.BlobStorage, issuer string, fiscalYearStart time.Month) StatementUseCase {
+	return &statementUseCase{
+		transactionRepo: transactionRepo,
+		refundRepo:      refundRepo,
+		campaignRepo:    campaignRepo,
+		userRepo:        userRepo,
+		blobStorage:     blobStorage,
+		issuer:          issuer,
+		fiscalYearStart: fiscalYearStart,
+	}
+}
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"bytes"
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"eternal-fund/usecase/service"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type StatementUseCaseTestSuite struct {
+	suite.Suite
+	suc             *statementUseCase
+	transactionRepo *mocking.TransactionRepoMock
+	refundRepo      *mocking.RefundRepoMock
+	campaignRepo    *mocking.CampaignRepoMock
+	userRepo        *mocking.UserRepoMock
+	blobStorage     *mocking.BlobStorageMock
+}
+
+var statementDonations = []model.Transaction{
+	{ID: 1, CampaignID: 2, UserID: 4, Amount: 100000, Status: model.TransactionStatusPaid, Code: "TRX-1",
+		CreatedAt: time.Date(2024, time.May, 1, 9, 0, 0, 0, time.Local)},
+	{ID: 2, CampaignID: 3, UserID: 4, Amount: 5000, Currency: model.CurrencyUSD, Status: model.TransactionStatusRefunded, Code: "TRX-2",
+		CreatedAt: time.Date(2024, time.June, 1, 9, 0, 0, 0, time.Local)},
+	{ID: 3, CampaignID: 2, UserID: 4, Amount: 75000, Status: model.TransactionStatusPaid, Code: "TRX-3",
+		CreatedAt: time.Date(2025, time.February, 1, 9, 0, 0, 0, time.Local)},
+	{ID: 4, CampaignID: 2, UserID: 4, Amount: 20000, Status: model.TransactionStatusPending, Code: "TRX-4",
+		CreatedAt: time.Date(2024, time.July, 1, 9, 0, 0, 0, time.Local)},
+	{ID: 5, CampaignID: 2, UserID: 4, Amount: 30000, Status: model.TransactionStatusPaid, Code: "TRX-5",
+		CreatedAt: time.Date(2024, time.March, 31, 9, 0, 0, 0, time.Local), UpdatedAt: time.Date(2024, time.March, 31, 9, 5, 0, 0, time.Local)},
+}
+
+// statementPaidTimes holds the status log's paid times. TRX-1 was paid the
+// day after it was made and TRX-5 predates the log.
+var statementPaidTimes = map[int]time.Time{
+	1: time.Date(2024, time.May, 2, 9, 0, 0, 0, time.Local),
+	2: time.Date(2024, time.June, 1, 9, 5, 0, 0, time.Local),
+	3: time.Date(2025, time.February, 1, 9, 5, 0, 0, time.Local),
+}
+
+func (suite *StatementUseCaseTestSuite) SetupTest() {
+	suite.transactionRepo = new(mocking.TransactionRepoMock)
+	suite.refundRepo = new(mocking.RefundRepoMock)
+	suite.campaignRepo = new(mocking.CampaignRepoMock)
+	suite.userRepo = new(mocking.UserRepoMock)
+	suite.blobStorage = new(mocking.BlobStorageMock)
+	suite.suc = &statementUseCase{
+		transactionRepo: suite.transactionRepo,
+		refundRepo:      suite.refundRepo,
+		campaignRepo:    suite.campaignRepo,
+		userRepo:        suite.userRepo,
+		blobStorage:     suite.blobStorage,
+		issuer:          "Eternal Fund",
+		fiscalYearStart: time.April,
+	}
+
+	suite.userRepo.On("FindById", 4).Return(receiptDonor, nil)
+	suite.transactionRepo.On("GetTransactionsByUserID", 4).Return(append([]model.Transaction(nil), statementDonations...), nil)
+	suite.transactionRepo.On("FindPaidTimes", []int{1, 2, 3, 4, 5}).Return(copyPaidTimes(statementPaidTimes), nil)
+	suite.refundRepo.On("FindByTransactionID", 1).Return([]model.Refund{
+		{Amount: 10000, Status: model.RefundStatusSucceeded},
+		{Amount: 5000, Status: model.RefundStatusFailed},
+	}, nil)
+	suite.refundRepo.On("FindByTransactionID", 2).Return([]model.Refund{{Amount: 5000, Status: model.RefundStatusSucceeded}}, nil)
+	suite.refundRepo.On("FindByTransactionID", 3).Return([]model.Refund{}, nil)
+	suite.campaignRepo.On("FindByIdCampaigns", 2).Return(receiptCampaign, nil)
+	suite.campaignRepo.On("FindByIdCampaigns", 3).Return(model.Campaigns{ID: 3, Name: "=Books for Flores"}, nil)
+}
+
+func (suite *StatementUseCaseTestSuite) TestBuildStatement_GroupsByCampaignAndNetsRefunds() {
+	statement, err := suite.suc.buildStatement(4, 2024)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), time.Date(2024, time.April, 1, 0, 0, 0, 0, time.Local), statement.PeriodStart)
+	assert.Equal(suite.T(), time.Date(2025, time.March, 31, 0, 0, 0, 0, time.Local), statement.PeriodEnd)
+	assert.Len(suite.T(), statement.Campaigns, 2)
+	assert.Equal(suite.T(), "Clean Water for Sumba", statement.Campaigns[0].CampaignName)
+	assert.Len(suite.T(), statement.Campaigns[0].Donations, 2)
+	assert.Equal(suite.T(), 165000, statement.Campaigns[0].Net)
+	assert.Equal(suite.T(), 0, statement.Campaigns[1].Net)
+	assert.Equal(suite.T(), []model.StatementTotal{
+		{Currency: model.CurrencyIDR, Donated: 175000, Refunded: 10000, Net: 165000},
+		{Currency: model.CurrencyUSD, Donated: 5000, Refunded: 5000, Net: 0},
+	}, statement.Totals)
+	assert.Equal(suite.T(), statementPaidTimes[1], statement.Campaigns[0].Donations[0].DonatedAt)
+}
+
+func (suite *StatementUseCaseTestSuite) TestBuildStatement_DatesDonationsByPaidTime() {
+	paidTimes := copyPaidTimes(statementPaidTimes)
+	paidTimes[3] = time.Date(2025, time.April, 1, 0, 30, 0, 0, time.Local)
+	paidTimes[5] = time.Date(2024, time.April, 1, 8, 0, 0, 0, time.Local)
+	suite.transactionRepo.ExpectedCalls = nil
+	suite.transactionRepo.On("GetTransactionsByUserID", 4).Return(append([]model.Transaction(nil), statementDonations...), nil)
+	suite.transactionRepo.On("FindPaidTimes", []int{1, 2, 3, 4, 5}).Return(paidTimes, nil)
+	suite.refundRepo.On("FindByTransactionID", 5).Return([]model.Refund{}, nil)
+
+	statement, err := suite.suc.buildStatement(4, 2024)
+
+	assert.NoError(suite.T(), err)
+	donations := statement.Campaigns[0].Donations
+	assert.Len(suite.T(), donations, 2)
+	assert.Equal(suite.T(), "TRX-5", donations[0].Code)
+	assert.Equal(suite.T(), paidTimes[5], donations[0].DonatedAt)
+	assert.Equal(suite.T(), "TRX-1", donations[1].Code)
+}
+
+func copyPaidTimes(paidTimes map[int]time.Time) map[int]time.Time {
+	copied := make(map[int]time.Time, len(paidTimes))
+	for id, paidAt := range paidTimes {
+		copied[id] = paidAt
+	}
+	return copied
+}
+
+func (suite *StatementUseCaseTestSuite) TestGetStatement_RendersCSVAndCaches() {
+	suite.blobStorage.On("Get", mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "statements/4/2024-") && strings.HasSuffix(key, ".csv")
+	})).Return([]byte(nil), service.ErrBlobNotFound)
+	suite.blobStorage.On("Put", mock.Anything, mock.Anything).Return(nil)
+
+	file, err := suite.suc.GetStatement(receiptDonor, 2024, StatementFormatCSV)
+
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), string(file), "2,Clean Water for Sumba,TRX-1,2024-05-02,IDR,100000,10000,90000\n")
+	assert.Contains(suite.T(), string(file), "3,'=Books for Flores,subtotal,,USD,5000,5000,0\n")
+	assert.Contains(suite.T(), string(file), ",,total,,IDR,175000,10000,165000\n")
+	assert.Contains(suite.T(), string(file), ",,total,,USD,5000,5000,0\n")
+	suite.blobStorage.AssertCalled(suite.T(), "Put", mock.Anything, file)
+}
+
+func (suite *StatementUseCaseTestSuite) TestGetStatement_ServesCachedFile() {
+	suite.blobStorage.On("Get", mock.Anything).Return([]byte("%PDF-cached"), nil)
+
+	file, err := suite.suc.GetStatement(receiptDonor, 2024, StatementFormatPDF)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []byte("%PDF-cached"), file)
+	suite.blobStorage.AssertNotCalled(suite.T(), "Put", mock.Anything, mock.Anything)
+}
+
+func (suite *StatementUseCaseTestSuite) TestGetStatement_RendersPDF() {
+	suite.blobStorage.On("Get", mock.Anything).Return([]byte(nil), service.ErrBlobNotFound)
+	suite.blobStorage.On("Put", mock.Anything, mock.Anything).Return(assert.AnError)
+
+	file, err := suite.suc.GetStatement(receiptDonor, 2024, StatementFormatPDF)
+
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), bytes.HasPrefix(file, []byte("%PDF-")))
+}
+
+func (suite *StatementUseCaseTestSuite) TestGetStatement_InvalidFormat() {
+	_, err := suite.suc.GetStatement(receiptDonor, 2024, "xlsx")
+
+	assert.ErrorIs(suite.T(), err, ErrInvalidStatementFormat)
+}
+
+func TestStatementUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(StatementUseCaseTestSuite))
+}
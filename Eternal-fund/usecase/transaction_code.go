@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+)
+
+const transactionCodeAttempts = 3
+
+var transactionCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newTransactionCode returns a random code such as TRX-7KQ2M4XB9WJ3TDHF. It
+// carries 80 bits of randomness, so codes cannot be guessed from one another
+// and collisions are rare enough to be resolved by retrying the insert.
+func newTransactionCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "TRX-" + transactionCodeEncoding.EncodeToString(buf), nil
+}
@@ -1,6 +1,10 @@
 package usecase
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
 	"eternal-fund/repository"
@@ -8,51 +12,221 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 )
 
+var (
+	ErrInvalidSignature = errors.New("invalid notification signature")
+	ErrAmountMismatch   = errors.New("notification gross_amount does not match the transaction amount")
+
+	ErrCoverFeesDisabled = errors.New("covering the fees of a donation is not enabled")
+
+	ErrDuplicateNotification    = errors.New("notification has already been processed")
+	ErrInvalidTransactionStatus = errors.New("invalid transaction status")
+
+	ErrTransactionNotRefundable = errors.New("only paid transactions can be refunded")
+	ErrRefundExceedsAmount      = errors.New("refund amount exceeds the refundable amount of the transaction")
+	ErrRefundFailed             = errors.New("payment provider rejected the refund")
+
+	ErrEmailNotVerified = errors.New("verify your email before claiming guest donations")
+
+	ErrTransactionForbidden = errors.New("only the donor, the campaign team and admins can see this donation")
+)
+
 type transactionUseCase struct {
-	transactionRepo repository.TransactionRepo
-	campaignRepo    repository.CampaignsRepo
-	paymentService  service.PaymentService
+	transactionRepo  repository.TransactionRepo
+	campaignRepo     repository.CampaignsRepo
+	notificationRepo repository.PaymentNotificationRepo
+	refundRepo       repository.RefundRepo
+	memberRepo       repository.CampaignMemberRepo
+	userRepo         repository.UserRepo
+	paymentProvider  service.PaymentProvider
+	mailService      service.MailService
+	fees             model.FeeSchedule
+	jwtService       service.JwtService
+	exchangeRates    ExchangeRateUseCase
+	riskEngine       RiskEngine
+	baseURL          string
 }
 
-func (uc *transactionUseCase) GetTransactionsByCampaignID(campaignID int) ([]model.Transaction, error) {
+// GetTransactionsByCampaignID lists every donation of a campaign, with who
+// made it, to the campaign's team and to admins.
+func (uc *transactionUseCase) GetTransactionsByCampaignID(campaignID int, user model.User) ([]model.Transaction, error) {
+	err := authorizeCampaign(uc.memberRepo, campaignID, user, model.CampaignRoleOwner, model.CampaignRoleEditor, model.CampaignRoleViewer)
+	if err != nil {
+		return nil, err
+	}
 	return uc.transactionRepo.GetTransactionsByCampaignID(campaignID)
 }
-func (uc *transactionUseCase) GetTransactionsByUserID(userID int) ([]model.Transaction, error) {
+
+// GetTransactionsByUserID lists a donor's donations to the donor and to
+// admins. Others can only see the donor's public donations.
+func (uc *transactionUseCase) GetTransactionsByUserID(userID int, user model.User) ([]model.Transaction, error) {
+	if user.ID != userID && user.Role != "admin" {
+		return nil, ErrTransactionForbidden
+	}
 	return uc.transactionRepo.GetTransactionsByUserID(userID)
 }
 
-func (u *transactionUseCase) GetTransactionByID(id int) (model.Transaction, error) {
-	return u.transactionRepo.GetByID(id)
+// GetTransactionByID shows a donation to its donor, the campaign's team and
+// admins.
+func (u *transactionUseCase) GetTransactionByID(id int, user model.User) (model.Transaction, error) {
+	transaction, err := u.transactionRepo.GetByID(id)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if transaction.UserID == 0 || transaction.UserID != user.ID {
+		err := authorizeCampaign(u.memberRepo, transaction.CampaignID, user, model.CampaignRoleOwner, model.CampaignRoleEditor, model.CampaignRoleViewer)
+		if errors.Is(err, ErrCampaignForbidden) {
+			return model.Transaction{}, ErrTransactionForbidden
+		}
+		if err != nil {
+			return model.Transaction{}, err
+		}
+	}
+	return transaction, nil
+}
+
+// GetSupporter shows a single donation the way the supporters feed does.
+func (uc *transactionUseCase) GetSupporter(transactionID int) (model.Supporter, error) {
+	return uc.transactionRepo.FindSupporter(transactionID)
+}
+
+// GetPublicDonations lists the donations a user made without asking to be
+// anonymous, the way the supporters feed shows them.
+func (uc *transactionUseCase) GetPublicDonations(userID int) ([]model.Supporter, error) {
+	return uc.transactionRepo.FindPublicDonations(userID)
+}
+
+// saveWithUniqueCode stores the transaction under a fresh random code, drawing
+// a new one if the database reports that the code is already taken. A
+// velocity limit, when given, is enforced as the transaction is stored.
+func (uc *transactionUseCase) saveWithUniqueCode(transaction model.Transaction, limit *model.VelocityLimit) (model.Transaction, error) {
+	var err error
+	for attempt := 0; attempt < transactionCodeAttempts; attempt++ {
+		transaction.Code, err = newTransactionCode()
+		if err != nil {
+			return model.Transaction{}, err
+		}
+
+		var saved model.Transaction
+		if limit != nil {
+			saved, err = uc.transactionRepo.SaveWithinLimit(transaction, *limit)
+		} else {
+			saved, err = uc.transactionRepo.Save(transaction)
+		}
+		if !errors.Is(err, repository.ErrDuplicateTransactionCode) {
+			return saved, err
+		}
+	}
+	return model.Transaction{}, err
 }
 
 func (uc *transactionUseCase) GetPaymentURL(transaction model.Transaction, user model.User) (string, error) {
-	return uc.paymentService.GetPaymentURL(transaction, user)
+	charge, err := uc.paymentProvider.CreateCharge(transaction, user)
+	if err != nil {
+		return "", err
+	}
+	return charge.RedirectURL, nil
 }
 
 func (uc *transactionUseCase) CreateTransaction(input model.CreateTransactionInput) (model.Transaction, error) {
+	// Deleted campaigns are not found here, so they cannot receive donations.
+	campaign, err := uc.campaignRepo.FindByIdCampaigns(input.CampaignID)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	// The donor may give in another currency than the campaign's. The
+	// donation is converted once, here, and the rate is kept with it.
+	currency := campaign.Currency.OrDefault()
+	if !uc.paymentProvider.SupportsCurrency(currency) {
+		return model.Transaction{}, service.ErrUnsupportedCurrency
+	}
+	donorCurrency := currency
+	if input.Currency != "" {
+		if donorCurrency, err = model.ParseCurrency(input.Currency); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+	amount, rate := input.Amount, "1"
+	if donorCurrency != currency {
+		if amount, rate, err = uc.exchangeRates.Convert(input.Amount, donorCurrency, currency); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+	if err := campaign.CheckDonation(amount); err != nil {
+		return model.Transaction{}, err
+	}
+
 	transaction := model.Transaction{
-		CampaignID: input.CampaignID,
-		UserID:     input.User.ID,
-		Amount:     input.Amount,
-		Status:     "pending",
-		Code:       "TRX-" + strconv.Itoa(int(time.Now().Unix())),
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		CampaignID:      input.CampaignID,
+		UserID:          input.User.ID,
+		Amount:          amount,
+		Currency:        currency,
+		DonorAmount:     input.Amount,
+		DonorCurrency:   donorCurrency,
+		ExchangeRate:    rate,
+		Status:          model.TransactionStatusPending,
+		RecurringPlanID: input.RecurringPlanID,
+		ClientIP:        input.ClientIP,
+		DonorChoices: model.DonorChoices{
+			Message:       strings.TrimSpace(input.Message),
+			Anonymous:     input.Anonymous,
+			DisplayName:   strings.TrimSpace(input.DisplayName),
+			ContactOptOut: input.ContactOptOut,
+		},
+	}
+	if input.User.ID == 0 {
+		// Without an account the donation is a guest donation, made in
+		// the name and email the guest gave.
+		transaction.GuestName = input.User.Name
+		transaction.GuestEmail = input.User.Email
+	}
+	if input.CoverFees {
+		if !uc.fees.DonorCoversFees {
+			return model.Transaction{}, ErrCoverFeesDisabled
+		}
+		transaction.Amount = uc.fees.GrossUp(amount, currency)
+		transaction.CoverFees = true
 	}
 
-	fmt.Printf("Creating transaction: %+v\n", transaction)
+	// A recurring plan is assessed on its first charge, so its renewals
+	// are not scored again.
+	var limit *model.VelocityLimit
+	if !input.Renewal {
+		assessment, err := uc.riskEngine.Assess(transaction, campaign)
+		if err != nil {
+			return model.Transaction{}, err
+		}
+		limit = assessment.Limit
+		transaction.RiskScore = assessment.Score
+		transaction.RiskReasons = assessment.Reasons
+		if assessment.Review {
+			transaction.Status = model.TransactionStatusReview
+		}
+	}
 
-	savedTransaction, err := uc.transactionRepo.Save(transaction)
+	savedTransaction, err := uc.saveWithUniqueCode(transaction, limit)
 	if err != nil {
 		fmt.Printf("Error saving transaction: %v\n", err)
 		return model.Transaction{}, err
 	}
 	fmt.Printf("Transaction saved: %+v\n", savedTransaction)
+	if savedTransaction.Status == model.TransactionStatusReview {
+		// The payment link is issued once an admin approves the donation.
+		return savedTransaction, nil
+	}
 
-	paymentURL, err := uc.paymentService.GetPaymentURL(savedTransaction, input.User)
+	var paymentURL string
+	if input.CardToken != "" {
+		var charge model.PaymentCharge
+		charge, err = uc.paymentProvider.ChargeToken(savedTransaction, input.User, input.CardToken)
+		paymentURL = charge.RedirectURL
+	} else {
+		paymentURL, err = uc.GetPaymentURL(savedTransaction, input.User)
+	}
 	if err != nil {
 		fmt.Printf("Error getting payment URL: %v\n", err)
 		return model.Transaction{}, err
@@ -69,103 +243,516 @@ func (uc *transactionUseCase) CreateTransaction(input model.CreateTransactionInp
 	return updatedTransaction, nil
 }
 
-func (u *transactionUseCase) ProcessPayment(input model.TransactionNotificationInput) error {
-	transaction_id, _ := strconv.Atoi(input.OrderID)
+// CreateGuestTransaction takes a donation from someone without an account.
+// The guest is emailed a signed link to follow the donation, which is also
+// returned so the checkout can show it.
+func (uc *transactionUseCase) CreateGuestTransaction(input model.CreateGuestTransactionInput) (model.Transaction, string, error) {
+	guest := model.User{Name: strings.TrimSpace(input.Name), Email: strings.ToLower(strings.TrimSpace(input.Email))}
+	transaction, err := uc.CreateTransaction(model.CreateTransactionInput{
+		CampaignID:   input.CampaignID,
+		Amount:       input.Amount,
+		Currency:     input.Currency,
+		CoverFees:    input.CoverFees,
+		DonorChoices: input.DonorChoices,
+		User:         guest,
+		ClientIP:     input.ClientIP,
+	})
+	if err != nil {
+		return model.Transaction{}, "", err
+	}
 
-	transaction, err := u.transactionRepo.GetByID(transaction_id)
+	token, err := uc.jwtService.CreateLinkToken(service.LinkPurposeGuestDonation, strconv.Itoa(transaction.ID))
 	if err != nil {
-		return err
+		return model.Transaction{}, "", err
 	}
+	claimURL := fmt.Sprintf("%s/api/v1/guest-donations/%s", uc.baseURL, token)
 
-	if input.PaymentType == "credit_card" && input.TransactionStatus == "capture" && input.FraudStatus == "accept" {
-		transaction.Status = "paid"
-	} else if input.TransactionStatus == "settlement" {
-		transaction.Status = "paid"
-	} else if input.TransactionStatus == "deny" || input.TransactionStatus == "expire" || input.TransactionStatus == "cancel" {
-		transaction.Status = "cancelled"
+	payment := fmt.Sprintf("Complete the payment here:\n%s\n\n", transaction.PaymentURL)
+	if transaction.Status == model.TransactionStatusReview {
+		payment = "Your donation is being reviewed. We will email you the payment link once it is approved.\n\n"
+	}
+	body := fmt.Sprintf("Hi %s,\n\n"+
+		"Thank you for your donation of %s (%s).\n"+
+		"%s"+
+		"You can follow the status of your donation at:\n%s\n\n"+
+		"Register with this email and verify it to add the donation to your account.\n",
+		guest.Name, transaction.AmountFormat(), transaction.Code, payment, claimURL)
+	if err := uc.mailService.Send(guest.Email, "Your donation", body); err != nil {
+		log.Printf("Error sending guest donation email for transaction %d: %v", transaction.ID, err)
 	}
+	return transaction, claimURL, nil
+}
 
-	updatedTransaction, err := u.transactionRepo.Update(transaction)
+// GetGuestTransaction returns the guest donation a signed link was issued
+// for. The link keeps working after the donation has been claimed.
+func (uc *transactionUseCase) GetGuestTransaction(token string) (model.Transaction, error) {
+	subject, err := uc.jwtService.ValidateLinkToken(service.LinkPurposeGuestDonation, token)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	id, err := strconv.Atoi(subject)
 	if err != nil {
-		return err
+		return model.Transaction{}, service.ErrInvalidLinkToken
 	}
+	transaction, err := uc.transactionRepo.GetByID(id)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if transaction.GuestEmail == "" {
+		return model.Transaction{}, service.ErrInvalidLinkToken
+	}
+	return transaction, nil
+}
 
-	campaign, err := u.campaignRepo.FindByIdCampaigns(updatedTransaction.CampaignID)
+// ClaimGuestTransactions attaches the guest donations made with the user's
+// email to their account. The email must be verified first, so nobody can
+// claim donations by registering with someone else's address.
+func (uc *transactionUseCase) ClaimGuestTransactions(user model.User) ([]model.Transaction, error) {
+	donor, err := uc.userRepo.FindById(user.ID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if donor.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
 	}
+	return uc.transactionRepo.ClaimGuestTransactions(donor.ID, donor.Email)
+}
 
-	if updatedTransaction.Status == "paid" {
-		campaign.Backer_count = campaign.Backer_count + 1
-		campaign.Current_amount = campaign.Current_amount + updatedTransaction.Amount
+// ProcessPayment applies a gateway notification to its transaction. Status
+// mapping and campaign totals are handled here for every notification path so
+// a settled donation is counted exactly once. The gateway order id is the
+// transaction code.
+func (u *transactionUseCase) ProcessPayment(input model.TransactionNotificationInput) (model.Transaction, error) {
+	transaction, err := u.transactionRepo.GetByCode(input.OrderID)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	return u.applyGatewayStatus(*transaction, input)
+}
 
-		_, err := u.campaignRepo.UpdateCampaigns(campaign.ID)
-		if err != nil {
-			return err
-		}
+func (u *transactionUseCase) applyGatewayStatus(transaction model.Transaction, input model.TransactionNotificationInput) (model.Transaction, error) {
+	status := input.InternalStatus()
+	if status == "" {
+		log.Printf("Ignoring %q notification for transaction %s", input.TransactionStatus, transaction.Code)
+		return transaction, nil
+	}
+
+	updated, err := u.transactionRepo.ApplyStatus(model.TransactionStatusChange{
+		TransactionID: transaction.ID,
+		ToStatus:      status,
+		Source:        model.StatusSourceGateway,
+		Note:          "gateway status " + input.TransactionStatus,
+		Fees:          u.feesFor(transaction, status, input.PaymentType),
+	})
+	if errors.Is(err, model.ErrIllegalTransition) {
+		// Gateways retry and reorder notifications, so a status the
+		// transaction cannot move to is stale rather than an error.
+		log.Printf("Ignoring %q notification for transaction %s in status %s", input.TransactionStatus, transaction.Code, updated.Status)
+		return updated, nil
 	}
+	return updated, err
+}
 
-	return nil
+// feesFor works out the fees of a transaction moving to status. Fees are only
+// taken from donations that count towards the campaign total.
+func (uc *transactionUseCase) feesFor(transaction model.Transaction, status model.TransactionStatus, method string) model.TransactionFees {
+	if !status.CountsTowardsTotal() {
+		return model.TransactionFees{}
+	}
+	return uc.fees.Calculate(transaction.Amount, transaction.Currency, method)
 }
 
+// UpdateTransaction lets an admin move a transaction to another status. Only
+// transitions allowed by the status state machine are accepted. The payment
+// method is unknown here, so a transaction marked paid is charged the default
+// gateway fee.
 func (uc *transactionUseCase) UpdateTransaction(transactionID int, input model.UpdateTransactionInput) (model.Transaction, error) {
+	if !input.Status.IsValid() {
+		return model.Transaction{}, ErrInvalidTransactionStatus
+	}
+
 	transaction, err := uc.transactionRepo.GetByID(transactionID)
 	if err != nil {
 		return model.Transaction{}, err
 	}
-	transaction.Status = input.Status
-	updatedTransaction, err := uc.transactionRepo.Update(transaction)
-	if err != nil {
-		return model.Transaction{}, err
+	if !transaction.Status.CanTransitionTo(input.Status) {
+		return model.Transaction{}, fmt.Errorf("%w from %s to %s", model.ErrIllegalTransition, transaction.Status, input.Status)
 	}
 
-	return updatedTransaction, nil
+	return uc.transactionRepo.ApplyStatus(model.TransactionStatusChange{
+		TransactionID: transactionID,
+		ToStatus:      input.Status,
+		Source:        model.StatusSourceAdmin,
+		ActorID:       input.User.ID,
+		Note:          input.Note,
+		Fees:          uc.feesFor(transaction, input.Status, ""),
+	})
+}
+
+func (uc *transactionUseCase) GetStatusLog(transactionID int) ([]model.TransactionStatusChange, error) {
+	if _, err := uc.transactionRepo.GetByID(transactionID); err != nil {
+		return nil, err
+	}
+	return uc.transactionRepo.FindStatusLog(transactionID)
 }
 
 func (u *transactionUseCase) GetAllTransactions(page int, size int) ([]model.Transaction, dto.Paging, error) {
 	return u.transactionRepo.FindAll(page, size)
 }
 
-func (uc *transactionUseCase) UpdateTransactionStatus(orderID, status string) (model.Transaction, error) {
-	log.Println("Fetching transaction with order ID:", orderID)
-	transaction, err := uc.transactionRepo.GetByCode(orderID)
+// HandleNotification stores a received notification and applies it unless an
+// identical notification has already been processed. Gateways retry until
+// they get a success response, so repeats are expected.
+func (uc *transactionUseCase) HandleNotification(notification model.PaymentNotification) (model.Transaction, error) {
+	notification.Result = model.NotificationResultReceived
+	notification, err := uc.notificationRepo.Save(notification)
 	if err != nil {
-		log.Println("Error fetching transaction by code:", err)
 		return model.Transaction{}, err
 	}
 
-	log.Println("Fetched transaction:", transaction)
+	processed, err := uc.notificationRepo.IsProcessed(notification)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if processed {
+		uc.recordNotificationResult(notification.ID, model.NotificationResultDuplicate, nil)
+		return model.Transaction{}, ErrDuplicateNotification
+	}
 
-	transaction.Status = status
-	log.Println("Updating transaction status to:", status)
-	updatedTransaction, err := uc.transactionRepo.Update(*transaction)
+	return uc.processNotification(notification)
+}
+
+// ReplayNotification applies a stored notification again, skipping the
+// duplicate check. Status transitions are monotonic so a replay never undoes
+// a later update.
+func (uc *transactionUseCase) ReplayNotification(id int) (model.Transaction, error) {
+	notification, err := uc.notificationRepo.FindByID(id)
 	if err != nil {
 		return model.Transaction{}, err
 	}
+	return uc.processNotification(notification)
+}
 
-	return updatedTransaction, nil
+func (uc *transactionUseCase) FindNotifications(page int, size int) ([]model.PaymentNotification, dto.Paging, error) {
+	return uc.notificationRepo.FindAll(page, size)
+}
+
+func (uc *transactionUseCase) processNotification(notification model.PaymentNotification) (model.Transaction, error) {
+	var input model.TransactionNotificationInput
+	if err := json.Unmarshal(notification.Payload, &input); err != nil {
+		uc.recordNotificationResult(notification.ID, model.NotificationResultRejected, err)
+		return model.Transaction{}, err
+	}
+
+	transaction, err := uc.applyNotification(input)
+	switch {
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrAmountMismatch):
+		uc.recordNotificationResult(notification.ID, model.NotificationResultRejected, err)
+	case err != nil:
+		uc.recordNotificationResult(notification.ID, model.NotificationResultFailed, err)
+	default:
+		uc.recordNotificationResult(notification.ID, model.NotificationResultProcessed, nil)
+	}
+	return transaction, err
+}
+
+func (uc *transactionUseCase) recordNotificationResult(id int, result string, cause error) {
+	message := ""
+	if cause != nil {
+		message = cause.Error()
+	}
+	if err := uc.notificationRepo.UpdateResult(id, result, message); err != nil {
+		log.Printf("Error recording result of notification %d: %v", id, err)
+	}
+}
+
+// applyNotification verifies a payment notification and applies its status.
+// The signature proves the payload came from the gateway and the amount check
+// stops a valid signature for another amount from settling this transaction.
+func (uc *transactionUseCase) applyNotification(input model.TransactionNotificationInput) (model.Transaction, error) {
+	if !uc.paymentProvider.VerifyWebhook(input) {
+		return model.Transaction{}, ErrInvalidSignature
+	}
+
+	transaction, err := uc.transactionRepo.GetByCode(input.OrderID)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	if !grossAmountMatches(input.GrossAmount, transaction.Amount) {
+		return model.Transaction{}, ErrAmountMismatch
+	}
+
+	return uc.applyGatewayStatus(*transaction, input)
+}
+
+// RefundTransaction returns all or part of a paid donation through the
+// payment provider. The transaction is refund_pending while the gateway call
+// is in flight, which also stops a second refund from starting meanwhile.
+func (uc *transactionUseCase) RefundTransaction(transactionID int, input model.RefundInput) (model.Refund, error) {
+	transaction, err := uc.transactionRepo.GetByID(transactionID)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	if err := authorizeCampaign(uc.memberRepo, transaction.CampaignID, input.User, model.CampaignRoleOwner); err != nil {
+		return model.Refund{}, err
+	}
+	if transaction.Status != model.TransactionStatusPaid {
+		return model.Refund{}, ErrTransactionNotRefundable
+	}
+
+	_, err = uc.transactionRepo.ApplyStatus(model.TransactionStatusChange{
+		TransactionID: transactionID,
+		ToStatus:      model.TransactionStatusRefundPending,
+		Source:        model.StatusSourceAdmin,
+		ActorID:       input.User.ID,
+		Note:          "refund requested: " + input.Reason,
+	})
+	if errors.Is(err, model.ErrIllegalTransition) {
+		return model.Refund{}, ErrTransactionNotRefundable
+	}
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	refund, err := uc.startRefund(transaction, input)
+	if err != nil {
+		uc.endRefund(transactionID, model.TransactionStatusPaid, input.User, "refund not started: "+err.Error())
+		return model.Refund{}, err
+	}
+
+	refund.RefundKey = refund.GatewayKey(transaction.Code)
+	_, err = uc.paymentProvider.Refund(transaction.Code, refund.RefundKey, refund.Amount, refund.Reason)
+	if err != nil {
+		refund.Error = err.Error()
+		if _, markErr := uc.refundRepo.MarkFailed(refund); markErr != nil {
+			log.Printf("Error marking refund %d as failed: %v", refund.ID, markErr)
+		}
+		uc.endRefund(transactionID, model.TransactionStatusPaid, input.User, "refund failed: "+err.Error())
+		return model.Refund{}, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+
+	refund, err = uc.refundRepo.MarkSucceeded(refund, transaction)
+	if err != nil {
+		// The gateway has paid the refund out. The transaction stays
+		// refund_pending so no other refund starts until the books are
+		// put right by hand.
+		log.Printf("[RECONCILE] refund %d (%s) of %s succeeded at the gateway but was not recorded: %v",
+			refund.ID, refund.RefundKey, transaction.Code, err)
+		return model.Refund{}, err
+	}
+
+	refunded, err := uc.refundRepo.SumRefunded(transactionID)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	next := model.TransactionStatusPaid
+	if refunded >= transaction.Amount {
+		next = model.TransactionStatusRefunded
+	}
+	uc.endRefund(transactionID, next, input.User, fmt.Sprintf("refund %d completed", refund.ID))
+
+	uc.notifyRefund(transaction, refund)
+	return refund, nil
+}
+
+// startRefund works out the refund amount and stores the pending refund. It
+// runs after the transaction is refund_pending so the refunded sum it reads
+// cannot change underneath it.
+func (uc *transactionUseCase) startRefund(transaction model.Transaction, input model.RefundInput) (model.Refund, error) {
+	refunded, err := uc.refundRepo.SumRefunded(transaction.ID)
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	remaining := transaction.Amount - refunded
+	amount := input.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		return model.Refund{}, ErrRefundExceedsAmount
+	}
+
+	return uc.refundRepo.Save(model.Refund{
+		TransactionID: transaction.ID,
+		Amount:        amount,
+		Reason:        input.Reason,
+		Status:        model.RefundStatusPending,
+		RequestedBy:   input.User.ID,
+	})
+}
+
+// endRefund moves a refund_pending transaction on once the refund attempt is
+// over. Failures are only logged: the refund itself has already been decided
+// and an admin can still correct the status.
+func (uc *transactionUseCase) endRefund(transactionID int, status model.TransactionStatus, user model.User, note string) {
+	_, err := uc.transactionRepo.ApplyStatus(model.TransactionStatusChange{
+		TransactionID: transactionID,
+		ToStatus:      status,
+		Source:        model.StatusSourceAdmin,
+		ActorID:       user.ID,
+		Note:          note,
+	})
+	if err != nil {
+		log.Printf("Error moving transaction %d to %s after refund: %v", transactionID, status, err)
+	}
+}
+
+// donorOf returns the account of a transaction's donor, or the guest's name
+// and email for a guest donation.
+func donorOf(userRepo repository.UserRepo, transaction model.Transaction) (model.User, error) {
+	if transaction.UserID == 0 {
+		return model.User{Name: transaction.GuestName, Email: transaction.GuestEmail}, nil
+	}
+	return userRepo.FindById(transaction.UserID)
+}
+
+func (uc *transactionUseCase) notifyRefund(transaction model.Transaction, refund model.Refund) {
+	donor, err := donorOf(uc.userRepo, transaction)
+	if err != nil {
+		log.Printf("Error finding donor of transaction %d: %v", transaction.ID, err)
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\n"+
+		"We have refunded %s of your donation %s.\n"+
+		"Reason: %s\n\n"+
+		"The money will be returned to your original payment method.\n",
+		donor.Name, model.FormatMoney(refund.Amount, transaction.Currency), transaction.Code, refund.Reason)
+	if err := uc.mailService.Send(donor.Email, "Your donation has been refunded", body); err != nil {
+		log.Println("Error sending refund email:", err)
+	}
+}
+
+// GetRefunds lists the refunds of a transaction to its donor and to the
+// owners of the campaign it went to.
+func (uc *transactionUseCase) GetRefunds(transactionID int, user model.User) ([]model.Refund, error) {
+	transaction, err := uc.transactionRepo.GetByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.UserID != user.ID {
+		if err := authorizeCampaign(uc.memberRepo, transaction.CampaignID, user, model.CampaignRoleOwner); err != nil {
+			return nil, err
+		}
+	}
+	return uc.refundRepo.FindByTransactionID(transactionID)
+}
+
+// GetCampaignReport shows the team of a campaign how much it has raised
+// before and after fees.
+func (uc *transactionUseCase) GetCampaignReport(campaignID int, user model.User) (model.CampaignReport, error) {
+	err := authorizeCampaign(uc.memberRepo, campaignID, user, model.CampaignRoleOwner, model.CampaignRoleEditor, model.CampaignRoleViewer)
+	if err != nil {
+		return model.CampaignReport{}, err
+	}
+	return uc.transactionRepo.FindCampaignReport(campaignID)
+}
+
+// GetSupporters is the public feed of a campaign's donations. It honours
+// each donor's choice to be anonymous or to appear under a display name.
+func (uc *transactionUseCase) GetSupporters(campaignID int, page int, size int) ([]model.Supporter, dto.Paging, error) {
+	if _, err := uc.campaignRepo.FindByIdCampaigns(campaignID); err != nil {
+		return nil, dto.Paging{}, err
+	}
+	return uc.transactionRepo.FindSupporters(campaignID, page, size)
+}
+
+// ExportDonations renders every donation of a campaign as a CSV file for its
+// owner, with the donor's account details and choices.
+func (uc *transactionUseCase) ExportDonations(campaignID int, user model.User) ([]byte, error) {
+	if err := authorizeCampaign(uc.memberRepo, campaignID, user, model.CampaignRoleOwner); err != nil {
+		return nil, err
+	}
+	rows, err := uc.transactionRepo.FindDonationExport(campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"id", "code", "created_at", "status", "amount", "net_amount", "donor_id", "donor_name", "donor_email",
+		"display_name", "anonymous", "contact_opt_out", "message"})
+	for _, row := range rows {
+		donorID := ""
+		if row.UserID != 0 {
+			donorID = strconv.Itoa(row.UserID)
+		}
+		writer.Write([]string{
+			strconv.Itoa(row.ID),
+			row.Code,
+			row.CreatedAt.Format(time.RFC3339),
+			string(row.Status),
+			strconv.Itoa(row.Amount),
+			strconv.Itoa(row.NetAmount),
+			donorID,
+			csvText(row.DonorName),
+			row.DonorEmail,
+			csvText(row.DisplayName),
+			strconv.FormatBool(row.Anonymous),
+			strconv.FormatBool(row.ContactOptOut),
+			csvText(row.Message),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// csvText stops donor supplied text from being read as a formula when the
+// export is opened in a spreadsheet.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
 }
 
 type TransactionUseCase interface {
 	GetPaymentURL(transaction model.Transaction, user model.User) (string, error)
-	GetTransactionsByCampaignID(campaignID int) ([]model.Transaction, error)
-	GetTransactionsByUserID(userID int) ([]model.Transaction, error)
-	GetTransactionByID(id int) (model.Transaction, error)
+	GetTransactionsByCampaignID(campaignID int, user model.User) ([]model.Transaction, error)
+	GetTransactionsByUserID(userID int, user model.User) ([]model.Transaction, error)
+	GetTransactionByID(id int, user model.User) (model.Transaction, error)
+	GetSupporter(transactionID int) (model.Supporter, error)
+	GetPublicDonations(userID int) ([]model.Supporter, error)
 	CreateTransaction(input model.CreateTransactionInput) (model.Transaction, error)
+	CreateGuestTransaction(input model.CreateGuestTransactionInput) (model.Transaction, string, error)
+	GetGuestTransaction(token string) (model.Transaction, error)
+	ClaimGuestTransactions(user model.User) ([]model.Transaction, error)
 	UpdateTransaction(transactionID int, input model.UpdateTransactionInput) (model.Transaction, error)
-	UpdateTransactionStatus(orderID, status string) (model.Transaction, error)
-	ProcessPayment(input model.TransactionNotificationInput) error
+	GetStatusLog(transactionID int) ([]model.TransactionStatusChange, error)
+	ProcessPayment(input model.TransactionNotificationInput) (model.Transaction, error)
+	HandleNotification(notification model.PaymentNotification) (model.Transaction, error)
+	ReplayNotification(id int) (model.Transaction, error)
+	RefundTransaction(transactionID int, input model.RefundInput) (model.Refund, error)
+	GetRefunds(transactionID int, user model.User) ([]model.Refund, error)
+	GetCampaignReport(campaignID int, user model.User) (model.CampaignReport, error)
+	GetSupporters(campaignID int, page int, size int) ([]model.Supporter, dto.Paging, error)
+	ExportDonations(campaignID int, user model.User) ([]byte, error)
+	FindNotifications(page int, size int) ([]model.PaymentNotification, dto.Paging, error)
 	GetAllTransactions(page int, size int) ([]model.Transaction, dto.Paging, error)
 }
 
-type PaymentService interface {
-	GetPaymentURL(transaction model.Transaction, user model.User) (string, error)
-}
-
-func NewTransactionUseCase(transactionRepo repository.TransactionRepo, campaignRepo repository.CampaignsRepo, paymentService service.PaymentService) TransactionUseCase {
+func NewTransactionUseCase(transactionRepo repository.TransactionRepo, campaignRepo repository.CampaignsRepo, notificationRepo repository.PaymentNotificationRepo,
+	refundRepo repository.RefundRepo, memberRepo repository.CampaignMemberRepo, userRepo repository.UserRepo, paymentProvider service.PaymentProvider,
+	mailService service.MailService, fees model.FeeSchedule, jwtService service.JwtService, exchangeRates ExchangeRateUseCase, riskEngine RiskEngine,
+	baseURL string) TransactionUseCase {
 	return &transactionUseCase{
-		transactionRepo: transactionRepo,
-		campaignRepo:    campaignRepo,
-		paymentService:  paymentService,
+		transactionRepo:  transactionRepo,
+		campaignRepo:     campaignRepo,
+		notificationRepo: notificationRepo,
+		refundRepo:       refundRepo,
+		memberRepo:       memberRepo,
+		userRepo:         userRepo,
+		paymentProvider:  paymentProvider,
+		mailService:      mailService,
+		fees:             fees,
+		jwtService:       jwtService,
+		exchangeRates:    exchangeRates,
+		riskEngine:       riskEngine,
+		baseURL:          baseURL,
 	}
 }
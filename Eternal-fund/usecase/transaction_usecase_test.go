@@ -1,12 +1,20 @@
 package usecase
 
 import (
+    "database/sql"
+    "encoding/json"
+    "errors"
     "eternal-fund/mocking"
     "eternal-fund/model"
     "eternal-fund/model/dto"
+    "eternal-fund/repository"
+    "eternal-fund/usecase/service"
+    "regexp"
     "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
     "github.com/stretchr/testify/suite"
     "testing"
+    "time"
 )
 
 type TransactionUseCaseTestSuite struct {
@@ -14,17 +22,48 @@ type TransactionUseCaseTestSuite struct {
     tuc          *transactionUseCase
     transactionRepo *mocking.TransactionRepoMock
     campaignRepo *mocking.CampaignRepoMock
-    paymentService *mocking.PaymentServiceMock
+    notificationRepo *mocking.PaymentNotificationRepoMock
+    paymentProvider *mocking.PaymentProviderMock
+    refundRepo *mocking.RefundRepoMock
+    memberRepo *mocking.CampaignMemberRepoMock
+    userRepo *mocking.UserRepoMock
+    mailService *mocking.MailServiceMock
+    jwtService *mocking.JwtServiceMock
+    exchangeRates *mocking.ExchangeRateUseCaseMock
+    riskEngine *mocking.RiskEngineMock
 }
 
 func (suite *TransactionUseCaseTestSuite) SetupTest() {
     suite.transactionRepo = new(mocking.TransactionRepoMock)
     suite.campaignRepo = new(mocking.CampaignRepoMock)
-    suite.paymentService = new(mocking.PaymentServiceMock)
+    suite.notificationRepo = new(mocking.PaymentNotificationRepoMock)
+    suite.paymentProvider = new(mocking.PaymentProviderMock)
+    suite.paymentProvider.On("SupportsCurrency", mock.Anything).Return(true).Maybe()
+    suite.refundRepo = new(mocking.RefundRepoMock)
+    suite.memberRepo = new(mocking.CampaignMemberRepoMock)
+    suite.userRepo = new(mocking.UserRepoMock)
+    suite.mailService = new(mocking.MailServiceMock)
+    suite.jwtService = new(mocking.JwtServiceMock)
+    suite.exchangeRates = new(mocking.ExchangeRateUseCaseMock)
+    suite.riskEngine = new(mocking.RiskEngineMock)
+    suite.riskEngine.On("Assess", mock.Anything, mock.Anything).Return(model.RiskAssessment{}, nil)
     suite.tuc = &transactionUseCase{
-        transactionRepo: suite.transactionRepo,
-        campaignRepo:    suite.campaignRepo,
-        paymentService:  suite.paymentService,
+        transactionRepo:  suite.transactionRepo,
+        campaignRepo:     suite.campaignRepo,
+        notificationRepo: suite.notificationRepo,
+        refundRepo:       suite.refundRepo,
+        memberRepo:       suite.memberRepo,
+        userRepo:         suite.userRepo,
+        paymentProvider:  suite.paymentProvider,
+        mailService:      suite.mailService,
+        fees:             model.FeeSchedule{PlatformBasisPoints: 500, DonorCoversFees: true, GatewayFees: map[model.Currency]model.GatewayFees{
+            model.CurrencyIDR: {ByMethod: map[string]int{"gopay": 50}, Default: 100},
+            model.CurrencyUSD: {Default: 30},
+        }},
+        jwtService:       suite.jwtService,
+        exchangeRates:    suite.exchangeRates,
+        riskEngine:       suite.riskEngine,
+        baseURL:          "http://localhost:2000",
     }
 }
 
@@ -35,14 +74,23 @@ func (suite *TransactionUseCaseTestSuite) TestGetTransactionsByCampaignID() {
         {ID: 2, Amount: 2000, CampaignID: campaignID},
     }
 
+    suite.memberRepo.On("FindMembership", campaignID, 4).Return(model.CampaignMember{Role: model.CampaignRoleViewer}, nil)
     suite.transactionRepo.On("GetTransactionsByCampaignID", campaignID).Return(mockTransactions, nil)
 
-    transactions, err := suite.tuc.GetTransactionsByCampaignID(campaignID)
+    transactions, err := suite.tuc.GetTransactionsByCampaignID(campaignID, model.User{ID: 4})
     assert.NoError(suite.T(), err)
     assert.Equal(suite.T(), mockTransactions, transactions)
     suite.transactionRepo.AssertExpectations(suite.T())
 }
 
+func (suite *TransactionUseCaseTestSuite) TestGetTransactionsByCampaignID_NotMember() {
+    suite.memberRepo.On("FindMembership", 1, 4).Return(model.CampaignMember{}, sql.ErrNoRows)
+
+    _, err := suite.tuc.GetTransactionsByCampaignID(1, model.User{ID: 4})
+    assert.ErrorIs(suite.T(), err, ErrCampaignForbidden)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "GetTransactionsByCampaignID", mock.Anything)
+}
+
 func (suite *TransactionUseCaseTestSuite) TestGetTransactionsByUserID() {
     userID := 1
     mockTransactions := []model.Transaction{
@@ -52,98 +100,462 @@ func (suite *TransactionUseCaseTestSuite) TestGetTransactionsByUserID() {
 
     suite.transactionRepo.On("GetTransactionsByUserID", userID).Return(mockTransactions, nil)
 
-    transactions, err := suite.tuc.GetTransactionsByUserID(userID)
+    transactions, err := suite.tuc.GetTransactionsByUserID(userID, model.User{ID: userID})
     assert.NoError(suite.T(), err)
     assert.Equal(suite.T(), mockTransactions, transactions)
     suite.transactionRepo.AssertExpectations(suite.T())
 }
 
+func (suite *TransactionUseCaseTestSuite) TestGetTransactionsByUserID_OtherUser() {
+    _, err := suite.tuc.GetTransactionsByUserID(1, model.User{ID: 2, Role: "user"})
+    assert.ErrorIs(suite.T(), err, ErrTransactionForbidden)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "GetTransactionsByUserID", mock.Anything)
+}
+
 func (suite *TransactionUseCaseTestSuite) TestGetTransactionByID() {
     transactionID := 1
-    mockTransaction := model.Transaction{ID: transactionID, Amount: 1000}
+    mockTransaction := model.Transaction{ID: transactionID, Amount: 1000, UserID: 4}
 
     suite.transactionRepo.On("GetByID", transactionID).Return(mockTransaction, nil)
 
-    transaction, err := suite.tuc.GetTransactionByID(transactionID)
+    transaction, err := suite.tuc.GetTransactionByID(transactionID, model.User{ID: 4})
     assert.NoError(suite.T(), err)
     assert.Equal(suite.T(), mockTransaction, transaction)
     suite.transactionRepo.AssertExpectations(suite.T())
 }
 
+func (suite *TransactionUseCaseTestSuite) TestGetTransactionByID_GuestDonationHiddenFromOthers() {
+    suite.transactionRepo.On("GetByID", 1).Return(model.Transaction{ID: 1, CampaignID: 2, GuestName: "Rina"}, nil)
+    suite.memberRepo.On("FindMembership", 2, 4).Return(model.CampaignMember{}, sql.ErrNoRows)
+
+    _, err := suite.tuc.GetTransactionByID(1, model.User{ID: 4})
+    assert.ErrorIs(suite.T(), err, ErrTransactionForbidden)
+}
+
 func (suite *TransactionUseCaseTestSuite) TestCreateTransaction() {
     input := model.CreateTransactionInput{
         Amount: 1000,
         User: model.User{ID: 1},
     }
-    transaction := model.Transaction{
-        CampaignID: input.CampaignID,
-        UserID:     input.User.ID,
-        Amount:     input.Amount,
-        Status:     "pending",
-    }
-    savedTransaction := transaction
-    savedTransaction.ID = 1
+    newTransaction := mock.MatchedBy(func(t model.Transaction) bool {
+        return t.UserID == 1 && t.Amount == 1000 && t.Status == model.TransactionStatusPending &&
+            regexp.MustCompile(`^TRX-[A-Z2-7]{16}$`).MatchString(t.Code)
+    })
+    savedTransaction := model.Transaction{ID: 1, UserID: 1, Amount: 1000, Status: model.TransactionStatusPending, Code: "TRX-ABCDEFGHJKLMNPQR"}
+    updatedTransaction := savedTransaction
+    updatedTransaction.PaymentURL = "http://payment.url"
 
-    suite.transactionRepo.On("Save", transaction).Return(savedTransaction, nil)
-    suite.paymentService.On("GetPaymentURL", savedTransaction, input.User).Return("http://payment.url", nil)
-    suite.transactionRepo.On("UpdatePaymentURL", savedTransaction).Return(savedTransaction, nil)
+    suite.campaignRepo.On("FindByIdCampaigns", input.CampaignID).Return(model.Campaigns{}, nil)
+    suite.transactionRepo.On("Save", newTransaction).Return(savedTransaction, nil)
+    suite.paymentProvider.On("CreateCharge", savedTransaction, input.User).Return(model.PaymentCharge{OrderID: savedTransaction.Code, RedirectURL: "http://payment.url"}, nil)
+    suite.transactionRepo.On("UpdatePaymentURL", updatedTransaction).Return(updatedTransaction, nil)
 
     createdTransaction, err := suite.tuc.CreateTransaction(input)
     assert.NoError(suite.T(), err)
-    assert.Equal(suite.T(), savedTransaction, createdTransaction)
+    assert.Equal(suite.T(), updatedTransaction, createdTransaction)
+    suite.transactionRepo.AssertExpectations(suite.T())
+    suite.paymentProvider.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_RetriesDuplicateCode() {
+    input := model.CreateTransactionInput{Amount: 1000, User: model.User{ID: 1}}
+    saved := mock.AnythingOfType("model.Transaction")
+
+    suite.campaignRepo.On("FindByIdCampaigns", 0).Return(model.Campaigns{}, nil)
+    suite.transactionRepo.On("Save", saved).Return(model.Transaction{}, repository.ErrDuplicateTransactionCode).Once()
+    suite.transactionRepo.On("Save", saved).Return(model.Transaction{ID: 2}, nil).Once()
+    suite.paymentProvider.On("CreateCharge", mock.AnythingOfType("model.Transaction"), input.User).Return(model.PaymentCharge{RedirectURL: "http://payment.url"}, nil)
+    suite.transactionRepo.On("UpdatePaymentURL", mock.AnythingOfType("model.Transaction")).Return(model.Transaction{ID: 2}, nil)
+
+    transaction, err := suite.tuc.CreateTransaction(input)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), 2, transaction.ID)
+    suite.transactionRepo.AssertNumberOfCalls(suite.T(), "Save", 2)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_CoverFees() {
+    input := model.CreateTransactionInput{CampaignID: 2, Amount: 10000, CoverFees: true, User: model.User{ID: 1}}
+    grossedUp := mock.MatchedBy(func(t model.Transaction) bool {
+        return t.Amount == 10632 && t.CoverFees
+    })
+
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{}, nil)
+    suite.transactionRepo.On("Save", grossedUp).Return(model.Transaction{ID: 3, Amount: 10632, CoverFees: true}, nil)
+    suite.paymentProvider.On("CreateCharge", mock.AnythingOfType("model.Transaction"), input.User).Return(model.PaymentCharge{RedirectURL: "http://payment.url"}, nil)
+    suite.transactionRepo.On("UpdatePaymentURL", mock.AnythingOfType("model.Transaction")).Return(model.Transaction{ID: 3, Amount: 10632, CoverFees: true}, nil)
+
+    transaction, err := suite.tuc.CreateTransaction(input)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), 10000, suite.tuc.fees.Calculate(transaction.Amount, model.CurrencyIDR, "").NetAmount)
+    assert.Equal(suite.T(), 9999, suite.tuc.fees.Calculate(transaction.Amount-1, model.CurrencyIDR, "").NetAmount)
     suite.transactionRepo.AssertExpectations(suite.T())
-    suite.paymentService.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_CoverFeesDisabled() {
+    suite.tuc.fees.DonorCoversFees = false
+    input := model.CreateTransactionInput{CampaignID: 2, Amount: 10000, CoverFees: true, User: model.User{ID: 1}}
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{}, nil)
+
+    _, err := suite.tuc.CreateTransaction(input)
+    assert.ErrorIs(suite.T(), err, ErrCoverFeesDisabled)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_CardToken() {
+    input := model.CreateTransactionInput{CampaignID: 2, Amount: 10000, User: model.User{ID: 1}, RecurringPlanID: 3, CardToken: "saved-card", Renewal: true}
+    saved := model.Transaction{ID: 4, CampaignID: 2, UserID: 1, Amount: 10000, Status: model.TransactionStatusPending, RecurringPlanID: 3}
+
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{}, nil)
+    suite.transactionRepo.On("Save", mock.MatchedBy(func(t model.Transaction) bool {
+        return t.RecurringPlanID == 3
+    })).Return(saved, nil)
+    suite.paymentProvider.On("ChargeToken", saved, input.User, "saved-card").Return(model.PaymentCharge{OrderID: saved.Code}, nil)
+    suite.transactionRepo.On("UpdatePaymentURL", saved).Return(saved, nil)
+
+    transaction, err := suite.tuc.CreateTransaction(input)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), saved, transaction)
+    suite.paymentProvider.AssertNotCalled(suite.T(), "CreateCharge", mock.Anything, mock.Anything)
+    suite.riskEngine.AssertNotCalled(suite.T(), "Assess", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_DonorChoices() {
+    input := model.CreateTransactionInput{CampaignID: 2, Amount: 10000, User: model.User{ID: 1},
+        DonorChoices: model.DonorChoices{Message: "  Keep going!  ", Anonymous: true, DisplayName: " The Smiths ", ContactOptOut: true}}
+    choices := model.DonorChoices{Message: "Keep going!", Anonymous: true, DisplayName: "The Smiths", ContactOptOut: true}
+
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{}, nil)
+    suite.transactionRepo.On("Save", mock.MatchedBy(func(t model.Transaction) bool {
+        return t.DonorChoices == choices
+    })).Return(model.Transaction{ID: 5, DonorChoices: choices}, nil)
+    suite.paymentProvider.On("CreateCharge", mock.AnythingOfType("model.Transaction"), input.User).Return(model.PaymentCharge{RedirectURL: "http://payment.url"}, nil)
+    suite.transactionRepo.On("UpdatePaymentURL", mock.AnythingOfType("model.Transaction")).Return(model.Transaction{ID: 5, DonorChoices: choices}, nil)
+
+    transaction, err := suite.tuc.CreateTransaction(input)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), choices, transaction.DonorChoices)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_ConvertsCurrency() {
+    input := model.CreateTransactionInput{CampaignID: 2, Amount: 2500, Currency: "usd", User: model.User{ID: 1}}
+    converted := mock.MatchedBy(func(t model.Transaction) bool {
+        return t.Amount == 406263 && t.Currency == model.CurrencyIDR && t.DonorAmount == 2500 &&
+            t.DonorCurrency == model.CurrencyUSD && t.ExchangeRate == "16250.5"
+    })
+
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{ID: 2, Currency: model.CurrencyIDR}, nil)
+    suite.exchangeRates.On("Convert", 2500, model.CurrencyUSD, model.CurrencyIDR).Return(406263, "16250.5", nil)
+    suite.transactionRepo.On("Save", converted).Return(model.Transaction{ID: 6, Amount: 406263}, nil)
+    suite.paymentProvider.On("CreateCharge", mock.AnythingOfType("model.Transaction"), input.User).Return(model.PaymentCharge{RedirectURL: "http://payment.url"}, nil)
+    suite.transactionRepo.On("UpdatePaymentURL", mock.AnythingOfType("model.Transaction")).Return(model.Transaction{ID: 6, Amount: 406263}, nil)
+
+    _, err := suite.tuc.CreateTransaction(input)
+    assert.NoError(suite.T(), err)
+    suite.transactionRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_UnsupportedCurrency() {
+    input := model.CreateTransactionInput{CampaignID: 2, Amount: 2500, Currency: "EUR", User: model.User{ID: 1}}
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{ID: 2}, nil)
+
+    _, err := suite.tuc.CreateTransaction(input)
+    assert.ErrorIs(suite.T(), err, model.ErrUnsupportedCurrency)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_GatewayCannotCharge() {
+    suite.paymentProvider.ExpectedCalls = nil
+    suite.paymentProvider.On("SupportsCurrency", model.CurrencyUSD).Return(false)
+    input := model.CreateTransactionInput{CampaignID: 2, Amount: 2500, User: model.User{ID: 1}}
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{ID: 2, Currency: model.CurrencyUSD}, nil)
+
+    _, err := suite.tuc.CreateTransaction(input)
+    assert.ErrorIs(suite.T(), err, service.ErrUnsupportedCurrency)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "SaveWithinLimit", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_DonationLimits() {
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{ID: 2, Min_donation: 5000, Max_donation: 50000}, nil)
+
+    _, err := suite.tuc.CreateTransaction(model.CreateTransactionInput{CampaignID: 2, Amount: 4999, User: model.User{ID: 1}})
+    assert.ErrorIs(suite.T(), err, model.ErrDonationBelowMinimum)
+    _, err = suite.tuc.CreateTransaction(model.CreateTransactionInput{CampaignID: 2, Amount: 50001, User: model.User{ID: 1}})
+    assert.ErrorIs(suite.T(), err, model.ErrDonationAboveMaximum)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_HeldForReview() {
+    input := model.CreateTransactionInput{CampaignID: 2, Amount: 900000, User: model.User{ID: 1}, ClientIP: "203.0.113.7"}
+    assessment := model.RiskAssessment{Score: 75, Reasons: []string{"large_amount", "new_account", "donor_velocity"}, Review: true}
+    held := model.Transaction{ID: 7, CampaignID: 2, UserID: 1, Amount: 900000, Status: model.TransactionStatusReview, RiskScore: 75}
+
+    suite.riskEngine.ExpectedCalls = nil
+    suite.riskEngine.On("Assess", mock.MatchedBy(func(t model.Transaction) bool {
+        return t.ClientIP == "203.0.113.7" && t.Amount == 900000
+    }), model.Campaigns{ID: 2}).Return(assessment, nil)
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{ID: 2}, nil)
+    suite.transactionRepo.On("Save", mock.MatchedBy(func(t model.Transaction) bool {
+        return t.Status == model.TransactionStatusReview && t.RiskScore == 75 && len(t.RiskReasons) == 3
+    })).Return(held, nil)
+
+    transaction, err := suite.tuc.CreateTransaction(input)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), held, transaction)
+    suite.paymentProvider.AssertNotCalled(suite.T(), "CreateCharge", mock.Anything, mock.Anything)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "UpdatePaymentURL", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_FirstRecurringChargeAssessed() {
+    suite.riskEngine.ExpectedCalls = nil
+    suite.riskEngine.On("Assess", mock.MatchedBy(func(t model.Transaction) bool {
+        return t.RecurringPlanID == 3 && t.ClientIP == "203.0.113.7"
+    }), mock.Anything).Return(model.RiskAssessment{}, model.ErrVelocityExceeded)
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{ID: 2}, nil)
+
+    _, err := suite.tuc.CreateTransaction(model.CreateTransactionInput{
+        CampaignID: 2, Amount: 10000, User: model.User{ID: 1}, RecurringPlanID: 3, CardToken: "saved-card", ClientIP: "203.0.113.7",
+    })
+    assert.ErrorIs(suite.T(), err, model.ErrVelocityExceeded)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_VelocityExceeded() {
+    suite.riskEngine.ExpectedCalls = nil
+    suite.riskEngine.On("Assess", mock.Anything, mock.Anything).Return(model.RiskAssessment{}, model.ErrVelocityExceeded)
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{ID: 2}, nil)
+
+    _, err := suite.tuc.CreateTransaction(model.CreateTransactionInput{CampaignID: 2, Amount: 10000, User: model.User{ID: 1}})
+    assert.ErrorIs(suite.T(), err, model.ErrVelocityExceeded)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateTransaction_EnforcesVelocityOnSave() {
+    limit := &model.VelocityLimit{Since: time.Now().Add(-time.Hour), MaxPerDonor: 5, MaxPerIP: 20}
+    suite.riskEngine.ExpectedCalls = nil
+    suite.riskEngine.On("Assess", mock.Anything, mock.Anything).Return(model.RiskAssessment{Limit: limit}, nil)
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{ID: 2}, nil)
+    suite.transactionRepo.On("SaveWithinLimit", mock.AnythingOfType("model.Transaction"), *limit).Return(model.Transaction{}, model.ErrVelocityExceeded)
+
+    _, err := suite.tuc.CreateTransaction(model.CreateTransactionInput{CampaignID: 2, Amount: 10000, User: model.User{ID: 1}})
+    assert.ErrorIs(suite.T(), err, model.ErrVelocityExceeded)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+    suite.paymentProvider.AssertNotCalled(suite.T(), "CreateCharge", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestCreateGuestTransaction() {
+    input := model.CreateGuestTransactionInput{CampaignID: 2, Amount: 10000, Name: " Jane Doe ", Email: " Jane@Example.com "}
+    guest := model.User{Name: "Jane Doe", Email: "jane@example.com"}
+    saved := model.Transaction{ID: 5, CampaignID: 2, Amount: 10000, GuestName: "Jane Doe", GuestEmail: "jane@example.com", Code: "TRX-5"}
+
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{}, nil)
+    suite.transactionRepo.On("Save", mock.MatchedBy(func(t model.Transaction) bool {
+        return t.UserID == 0 && t.GuestName == "Jane Doe" && t.GuestEmail == "jane@example.com"
+    })).Return(saved, nil)
+    suite.paymentProvider.On("CreateCharge", mock.AnythingOfType("model.Transaction"), guest).Return(model.PaymentCharge{RedirectURL: "http://payment.url"}, nil)
+    suite.transactionRepo.On("UpdatePaymentURL", mock.AnythingOfType("model.Transaction")).Return(saved, nil)
+    suite.jwtService.On("CreateLinkToken", service.LinkPurposeGuestDonation, "5").Return("guest-token", nil)
+    suite.mailService.On("Send", "jane@example.com", "Your donation", mock.MatchedBy(func(body string) bool {
+        return regexp.MustCompile(`/api/v1/guest-donations/guest-token`).MatchString(body)
+    })).Return(nil)
+
+    transaction, claimURL, err := suite.tuc.CreateGuestTransaction(input)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), saved, transaction)
+    assert.Equal(suite.T(), "http://localhost:2000/api/v1/guest-donations/guest-token", claimURL)
+    suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) TestGetGuestTransaction_NotAGuestDonation() {
+    suite.jwtService.On("ValidateLinkToken", service.LinkPurposeGuestDonation, "guest-token").Return("5", nil)
+    suite.transactionRepo.On("GetByID", 5).Return(model.Transaction{ID: 5, UserID: 1}, nil)
+
+    _, err := suite.tuc.GetGuestTransaction("guest-token")
+    assert.ErrorIs(suite.T(), err, service.ErrInvalidLinkToken)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestClaimGuestTransactions() {
+    verifiedAt := time.Now()
+    claimed := []model.Transaction{{ID: 5, UserID: 1, GuestEmail: "jane@example.com"}}
+
+    suite.userRepo.On("FindById", 1).Return(model.User{ID: 1, Email: "jane@example.com", EmailVerifiedAt: &verifiedAt}, nil)
+    suite.transactionRepo.On("ClaimGuestTransactions", 1, "jane@example.com").Return(claimed, nil)
+
+    transactions, err := suite.tuc.ClaimGuestTransactions(model.User{ID: 1})
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), claimed, transactions)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestClaimGuestTransactions_UnverifiedEmail() {
+    suite.userRepo.On("FindById", 1).Return(model.User{ID: 1, Email: "jane@example.com"}, nil)
+
+    _, err := suite.tuc.ClaimGuestTransactions(model.User{ID: 1})
+    assert.ErrorIs(suite.T(), err, ErrEmailNotVerified)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "ClaimGuestTransactions", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestGetSupporters_DeletedCampaign() {
+    suite.campaignRepo.On("FindByIdCampaigns", 2).Return(model.Campaigns{}, sql.ErrNoRows)
+
+    _, _, err := suite.tuc.GetSupporters(2, 1, 10)
+    assert.ErrorIs(suite.T(), err, sql.ErrNoRows)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "FindSupporters", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestExportDonations() {
+    owner := model.User{ID: 4, Role: "user"}
+    createdAt := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+    row := model.DonationExportRow{
+        Transaction: model.Transaction{ID: 7, Code: "TRX-7", Status: model.TransactionStatusPaid, Amount: 50000, UserID: 9, CreatedAt: createdAt,
+            TransactionFees: model.TransactionFees{NetAmount: 47000},
+            DonorChoices: model.DonorChoices{Message: "=HYPERLINK(\"http://evil\")", Anonymous: true, ContactOptOut: true}},
+        DonorName:  "Jane Doe",
+        DonorEmail: "jane@example.com",
+    }
+
+    suite.memberRepo.On("FindMembership", 2, 4).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
+    suite.transactionRepo.On("FindDonationExport", 2).Return([]model.DonationExportRow{row}, nil)
+
+    file, err := suite.tuc.ExportDonations(2, owner)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), "id,code,created_at,status,amount,net_amount,donor_id,donor_name,donor_email,display_name,anonymous,contact_opt_out,message\n"+
+        "7,TRX-7,2024-03-01T10:00:00Z,paid,50000,47000,9,Jane Doe,jane@example.com,,true,true,\"'=HYPERLINK(\"\"http://evil\"\")\"\n", string(file))
+}
+
+func (suite *TransactionUseCaseTestSuite) TestExportDonations_EditorForbidden() {
+    suite.memberRepo.On("FindMembership", 2, 4).Return(model.CampaignMember{Role: model.CampaignRoleEditor}, nil)
+
+    _, err := suite.tuc.ExportDonations(2, model.User{ID: 4, Role: "user"})
+    assert.ErrorIs(suite.T(), err, ErrCampaignForbidden)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "FindDonationExport", mock.Anything)
 }
 
 func (suite *TransactionUseCaseTestSuite) TestUpdateTransaction() {
     transactionID := 1
-    input := model.UpdateTransactionInput{Status: "paid"}
-    transaction := model.Transaction{ID: transactionID, Amount: 1000, Status: "pending"}
+    input := model.UpdateTransactionInput{Status: model.TransactionStatusRefunded, Note: "donor request", User: model.User{ID: 2, Role: "admin"}}
+    transaction := model.Transaction{ID: transactionID, Amount: 1000, Status: model.TransactionStatusPaid}
+    refunded := transaction
+    refunded.Status = model.TransactionStatusRefunded
 
     suite.transactionRepo.On("GetByID", transactionID).Return(transaction, nil)
-    transaction.Status = input.Status
-    suite.transactionRepo.On("Update", transaction).Return(transaction, nil)
+    suite.transactionRepo.On("ApplyStatus", model.TransactionStatusChange{TransactionID: transactionID, ToStatus: model.TransactionStatusRefunded,
+        Source: model.StatusSourceAdmin, ActorID: 2, Note: "donor request"}).Return(refunded, nil)
 
     updatedTransaction, err := suite.tuc.UpdateTransaction(transactionID, input)
     assert.NoError(suite.T(), err)
-    assert.Equal(suite.T(), transaction, updatedTransaction)
+    assert.Equal(suite.T(), refunded, updatedTransaction)
     suite.transactionRepo.AssertExpectations(suite.T())
 }
 
+func (suite *TransactionUseCaseTestSuite) TestUpdateTransaction_IllegalTransition() {
+    input := model.UpdateTransactionInput{Status: model.TransactionStatusPending, User: model.User{ID: 2, Role: "admin"}}
+    suite.transactionRepo.On("GetByID", 1).Return(model.Transaction{ID: 1, Status: model.TransactionStatusRefunded}, nil)
+
+    _, err := suite.tuc.UpdateTransaction(1, input)
+    assert.ErrorIs(suite.T(), err, model.ErrIllegalTransition)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "ApplyStatus", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestUpdateTransaction_UnknownStatus() {
+    input := model.UpdateTransactionInput{Status: "completed", User: model.User{ID: 2, Role: "admin"}}
+
+    _, err := suite.tuc.UpdateTransaction(1, input)
+    assert.ErrorIs(suite.T(), err, ErrInvalidTransactionStatus)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "GetByID", mock.Anything)
+}
+
+func gatewayChange(id int, status model.TransactionStatus, gatewayStatus string) model.TransactionStatusChange {
+    return model.TransactionStatusChange{TransactionID: id, ToStatus: status, Source: model.StatusSourceGateway,
+        Note: "gateway status " + gatewayStatus}
+}
+
+// paidChange is a settlement of a 1000 donation with the suite's fees.
+func paidChange(method string, gatewayFee int) model.TransactionStatusChange {
+    change := gatewayChange(1, model.TransactionStatusPaid, "settlement")
+    change.Fees = model.TransactionFees{PaymentMethod: method, GrossAmount: 1000, PlatformFee: 50, GatewayFee: gatewayFee,
+        FeeAmount: 50 + gatewayFee, NetAmount: 950 - gatewayFee}
+    return change
+}
+
 func (suite *TransactionUseCaseTestSuite) TestProcessPayment() {
     input := model.TransactionNotificationInput{
-        OrderID:           "1",
+        OrderID:           "TRX-1",
         PaymentType:       "credit_card",
         TransactionStatus: "capture",
         FraudStatus:       "accept",
     }
-    transaction := model.Transaction{ID: 1, Amount: 1000, Status: "pending"}
-    campaign := model.Campaigns{ID: 1, Backer_count: 0, Current_amount: 0}
+    paid := model.Transaction{ID: 1, CampaignID: 1, Amount: 1000, Status: model.TransactionStatusPaid}
+
+    change := gatewayChange(1, model.TransactionStatusPaid, "capture")
+    change.Fees = paidChange("credit_card", 100).Fees
 
-    suite.transactionRepo.On("GetByID", 1).Return(transaction, nil)
-    suite.campaignRepo.On("FindByIdCampaigns", 1).Return(campaign, nil)
-    suite.transactionRepo.On("Update", transaction).Return(transaction, nil)
-    suite.campaignRepo.On("UpdateCampaigns", 1).Return(campaign, nil)
+    suite.transactionRepo.On("GetByCode", "TRX-1").Return(&model.Transaction{ID: 1, Code: "TRX-1", Amount: 1000, Status: model.TransactionStatusPending}, nil)
+    suite.transactionRepo.On("ApplyStatus", change).Return(paid, nil)
 
-    err := suite.tuc.ProcessPayment(input)
+    transaction, err := suite.tuc.ProcessPayment(input)
     assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), model.TransactionStatusPaid, transaction.Status)
+    assert.Equal(suite.T(), paid, transaction)
     suite.transactionRepo.AssertExpectations(suite.T())
-    suite.campaignRepo.AssertExpectations(suite.T())
+    suite.campaignRepo.AssertNotCalled(suite.T(), "UpdateCampaigns", mock.Anything, mock.Anything)
 }
 
-func (suite *TransactionUseCaseTestSuite) TestUpdateTransactionStatus() {
-    orderID := "TRX-123"
-    status := "paid"
-    transaction := model.Transaction{ID: 1, Status: "pending", Code: orderID}
+func (suite *TransactionUseCaseTestSuite) TestProcessPayment_FeesInTransactionCurrency() {
+    for currency, gatewayFee := range map[model.Currency]int{model.CurrencyUSD: 30, model.CurrencySGD: 0} {
+        suite.SetupTest()
+        paid := model.Transaction{ID: 1, Amount: 1000, Currency: currency, Status: model.TransactionStatusPaid}
+        change := gatewayChange(1, model.TransactionStatusPaid, "settlement")
+        change.Fees = paidChange("gopay", gatewayFee).Fees
 
-    suite.transactionRepo.On("GetByCode", orderID).Return(transaction, nil)
-    transaction.Status = status
-    suite.transactionRepo.On("Update", transaction).Return(transaction, nil)
+        suite.transactionRepo.On("GetByCode", "TRX-1").Return(&model.Transaction{ID: 1, Code: "TRX-1", Amount: 1000, Currency: currency,
+            Status: model.TransactionStatusPending}, nil)
+        suite.transactionRepo.On("ApplyStatus", change).Return(paid, nil)
 
-    updatedTransaction, err := suite.tuc.UpdateTransactionStatus(orderID, status)
+        _, err := suite.tuc.ProcessPayment(model.TransactionNotificationInput{OrderID: "TRX-1", PaymentType: "gopay", TransactionStatus: "settlement"})
+        assert.NoError(suite.T(), err, currency)
+        suite.transactionRepo.AssertExpectations(suite.T())
+    }
+}
+
+func (suite *TransactionUseCaseTestSuite) TestProcessPayment_StatusMapping() {
+    cases := map[model.TransactionNotificationInput]model.TransactionStatus{
+        {OrderID: "TRX-1", TransactionStatus: "settlement"}:                         model.TransactionStatusPaid,
+        {OrderID: "TRX-1", TransactionStatus: "capture", FraudStatus: "challenge"}:  model.TransactionStatusPending,
+        {OrderID: "TRX-1", TransactionStatus: "pending"}:                            model.TransactionStatusPending,
+        {OrderID: "TRX-1", TransactionStatus: "expire"}:                             model.TransactionStatusExpired,
+        {OrderID: "TRX-1", TransactionStatus: "cancel"}:                             model.TransactionStatusCancelled,
+        {OrderID: "TRX-1", TransactionStatus: "deny"}:                               model.TransactionStatusFailed,
+    }
+    suite.transactionRepo.On("GetByCode", "TRX-1").Return(&model.Transaction{ID: 1, Code: "TRX-1", Status: model.TransactionStatusPending}, nil)
+    for input, status := range cases {
+        suite.transactionRepo.On("ApplyStatus", gatewayChange(1, status, input.TransactionStatus)).Return(model.Transaction{ID: 1, Status: status}, nil).Once()
+
+        transaction, err := suite.tuc.ProcessPayment(input)
+        assert.NoError(suite.T(), err)
+        assert.Equal(suite.T(), status, transaction.Status, input.TransactionStatus)
+    }
+}
+
+func (suite *TransactionUseCaseTestSuite) TestProcessPayment_StaleStatus() {
+    input := model.TransactionNotificationInput{OrderID: "TRX-1", TransactionStatus: "pending"}
+    paid := model.Transaction{ID: 1, Status: model.TransactionStatusPaid}
+    suite.transactionRepo.On("GetByCode", "TRX-1").Return(&model.Transaction{ID: 1, Code: "TRX-1", Status: model.TransactionStatusPaid}, nil)
+    suite.transactionRepo.On("ApplyStatus", gatewayChange(1, model.TransactionStatusPending, "pending")).Return(paid, model.ErrIllegalTransition)
+
+    transaction, err := suite.tuc.ProcessPayment(input)
     assert.NoError(suite.T(), err)
-    assert.Equal(suite.T(), transaction, updatedTransaction)
-    suite.transactionRepo.AssertExpectations(suite.T())
+    assert.Equal(suite.T(), paid, transaction)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestProcessPayment_UnmappedStatus() {
+    input := model.TransactionNotificationInput{OrderID: "TRX-1", TransactionStatus: "refund"}
+    transaction := model.Transaction{ID: 1, Code: "TRX-1", Status: model.TransactionStatusPaid}
+    suite.transactionRepo.On("GetByCode", "TRX-1").Return(&transaction, nil)
+
+    result, err := suite.tuc.ProcessPayment(input)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), transaction, result)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "ApplyStatus", mock.Anything)
 }
 
 func (suite *TransactionUseCaseTestSuite) TestGetAllTransactions() {
@@ -164,6 +576,222 @@ func (suite *TransactionUseCaseTestSuite) TestGetAllTransactions() {
     suite.transactionRepo.AssertExpectations(suite.T())
 }
 
+func (suite *TransactionUseCaseTestSuite) storeNotification(input model.TransactionNotificationInput) model.PaymentNotification {
+    payload, _ := json.Marshal(input)
+    notification := model.PaymentNotification{OrderID: input.OrderID, TransactionStatus: input.TransactionStatus,
+        SignatureKey: input.SignatureKey, Payload: payload}
+    received := notification
+    received.Result = model.NotificationResultReceived
+    stored := received
+    stored.ID = 9
+
+    suite.notificationRepo.On("Save", received).Return(stored, nil)
+    return notification
+}
+
+func (suite *TransactionUseCaseTestSuite) TestHandleNotification() {
+    input := model.TransactionNotificationInput{OrderID: "TRX-1", TransactionStatus: "settlement", PaymentType: "gopay", StatusCode: "200", GrossAmount: "1000.00", SignatureKey: "sig"}
+    transaction := model.Transaction{ID: 1, Amount: 1000, Status: "pending", Code: "TRX-1"}
+    updated := transaction
+    updated.Status = model.TransactionStatusPaid
+
+    notification := suite.storeNotification(input)
+    suite.notificationRepo.On("IsProcessed", mock.AnythingOfType("model.PaymentNotification")).Return(false, nil)
+    suite.paymentProvider.On("VerifyWebhook", input).Return(true)
+    suite.transactionRepo.On("GetByCode", "TRX-1").Return(&transaction, nil)
+    suite.transactionRepo.On("ApplyStatus", paidChange("gopay", 50)).Return(updated, nil)
+    suite.notificationRepo.On("UpdateResult", 9, model.NotificationResultProcessed, "").Return(nil)
+
+    result, err := suite.tuc.HandleNotification(notification)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), updated, result)
+    suite.notificationRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) TestHandleNotification_Duplicate() {
+    input := model.TransactionNotificationInput{OrderID: "TRX-1", TransactionStatus: "settlement", GrossAmount: "1000.00", SignatureKey: "sig"}
+
+    notification := suite.storeNotification(input)
+    suite.notificationRepo.On("IsProcessed", mock.AnythingOfType("model.PaymentNotification")).Return(true, nil)
+    suite.notificationRepo.On("UpdateResult", 9, model.NotificationResultDuplicate, "").Return(nil)
+
+    _, err := suite.tuc.HandleNotification(notification)
+    assert.ErrorIs(suite.T(), err, ErrDuplicateNotification)
+    suite.paymentProvider.AssertNotCalled(suite.T(), "VerifyWebhook", mock.Anything)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "ApplyStatus", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestHandleNotification_InvalidSignature() {
+    input := model.TransactionNotificationInput{OrderID: "TRX-1", GrossAmount: "1000.00", SignatureKey: "forged"}
+
+    notification := suite.storeNotification(input)
+    suite.notificationRepo.On("IsProcessed", mock.AnythingOfType("model.PaymentNotification")).Return(false, nil)
+    suite.paymentProvider.On("VerifyWebhook", input).Return(false)
+    suite.notificationRepo.On("UpdateResult", 9, model.NotificationResultRejected, ErrInvalidSignature.Error()).Return(nil)
+
+    _, err := suite.tuc.HandleNotification(notification)
+    assert.ErrorIs(suite.T(), err, ErrInvalidSignature)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "GetByCode", mock.Anything)
+    suite.notificationRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) TestHandleNotification_AmountMismatch() {
+    input := model.TransactionNotificationInput{OrderID: "TRX-1", GrossAmount: "1.00", SignatureKey: "sig"}
+
+    notification := suite.storeNotification(input)
+    suite.notificationRepo.On("IsProcessed", mock.AnythingOfType("model.PaymentNotification")).Return(false, nil)
+    suite.paymentProvider.On("VerifyWebhook", input).Return(true)
+    suite.transactionRepo.On("GetByCode", "TRX-1").Return(&model.Transaction{ID: 1, Code: "TRX-1", Amount: 1000}, nil)
+    suite.notificationRepo.On("UpdateResult", 9, model.NotificationResultRejected, ErrAmountMismatch.Error()).Return(nil)
+
+    _, err := suite.tuc.HandleNotification(notification)
+    assert.ErrorIs(suite.T(), err, ErrAmountMismatch)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "ApplyStatus", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestReplayNotification() {
+    input := model.TransactionNotificationInput{OrderID: "TRX-1", TransactionStatus: "settlement", GrossAmount: "1000", SignatureKey: "sig"}
+    payload, _ := json.Marshal(input)
+    stored := model.PaymentNotification{ID: 4, OrderID: "TRX-1", TransactionStatus: "settlement", Payload: payload,
+        Result: model.NotificationResultFailed}
+    paid := model.Transaction{ID: 1, Amount: 1000, Status: model.TransactionStatusPaid}
+
+    suite.notificationRepo.On("FindByID", 4).Return(stored, nil)
+    suite.paymentProvider.On("VerifyWebhook", input).Return(true)
+    suite.transactionRepo.On("GetByCode", "TRX-1").Return(&model.Transaction{ID: 1, Code: "TRX-1", Amount: 1000, Status: "pending"}, nil)
+    suite.transactionRepo.On("ApplyStatus", paidChange("", 100)).Return(paid, nil)
+    suite.notificationRepo.On("UpdateResult", 4, model.NotificationResultProcessed, "").Return(nil)
+
+    transaction, err := suite.tuc.ReplayNotification(4)
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), paid, transaction)
+    suite.notificationRepo.AssertNotCalled(suite.T(), "IsProcessed", mock.Anything)
+    suite.notificationRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) expectRefundStatus(status model.TransactionStatus) {
+    suite.transactionRepo.On("ApplyStatus", mock.MatchedBy(func(change model.TransactionStatusChange) bool {
+        return change.TransactionID == 1 && change.ToStatus == status && change.Source == model.StatusSourceAdmin
+    })).Return(model.Transaction{ID: 1, Status: status}, nil).Once()
+}
+
+func (suite *TransactionUseCaseTestSuite) TestRefundTransaction_Partial() {
+    paid := model.Transaction{ID: 1, CampaignID: 2, UserID: 3, Amount: 100000, Status: model.TransactionStatusPaid, Code: "TRX-1"}
+    owner := model.User{ID: 4, Role: "user"}
+    pending := model.Refund{TransactionID: 1, Amount: 40000, Reason: "duplicate", Status: model.RefundStatusPending, RequestedBy: 4}
+    saved := pending
+    saved.ID = 7
+    succeeded := saved
+    succeeded.RefundKey = "TRX-1-refund-7"
+
+    suite.transactionRepo.On("GetByID", 1).Return(paid, nil)
+    suite.memberRepo.On("FindMembership", 2, 4).Return(model.CampaignMember{Role: model.CampaignRoleOwner}, nil)
+    suite.expectRefundStatus(model.TransactionStatusRefundPending)
+    suite.refundRepo.On("SumRefunded", 1).Return(0, nil).Once()
+    suite.refundRepo.On("Save", pending).Return(saved, nil)
+    suite.paymentProvider.On("Refund", "TRX-1", "TRX-1-refund-7", 40000, "duplicate").Return(model.PaymentRefund{RefundKey: "TRX-1-refund-7"}, nil)
+    suite.refundRepo.On("MarkSucceeded", succeeded, paid).Return(succeeded, nil)
+    suite.refundRepo.On("SumRefunded", 1).Return(40000, nil).Once()
+    suite.expectRefundStatus(model.TransactionStatusPaid)
+    suite.userRepo.On("FindById", 3).Return(model.User{ID: 3, Name: "Donor", Email: "donor@example.com"}, nil)
+    suite.mailService.On("Send", "donor@example.com", "Your donation has been refunded", mock.AnythingOfType("string")).Return(nil)
+
+    refund, err := suite.tuc.RefundTransaction(1, model.RefundInput{Amount: 40000, Reason: "duplicate", User: owner})
+    assert.NoError(suite.T(), err)
+    assert.Equal(suite.T(), succeeded, refund)
+    suite.transactionRepo.AssertExpectations(suite.T())
+    suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) TestRefundTransaction_FullRemainder() {
+    paid := model.Transaction{ID: 1, CampaignID: 2, UserID: 3, Amount: 100000, Status: model.TransactionStatusPaid, Code: "TRX-1"}
+    admin := model.User{ID: 9, Role: "admin"}
+    pending := model.Refund{TransactionID: 1, Amount: 60000, Reason: "campaign cancelled", Status: model.RefundStatusPending, RequestedBy: 9}
+    saved := pending
+    saved.ID = 8
+    succeeded := saved
+    succeeded.RefundKey = "TRX-1-refund-8"
+
+    suite.transactionRepo.On("GetByID", 1).Return(paid, nil)
+    suite.expectRefundStatus(model.TransactionStatusRefundPending)
+    suite.refundRepo.On("SumRefunded", 1).Return(40000, nil).Once()
+    suite.refundRepo.On("Save", pending).Return(saved, nil)
+    suite.paymentProvider.On("Refund", "TRX-1", "TRX-1-refund-8", 60000, "campaign cancelled").Return(model.PaymentRefund{}, nil)
+    suite.refundRepo.On("MarkSucceeded", succeeded, paid).Return(succeeded, nil)
+    suite.refundRepo.On("SumRefunded", 1).Return(100000, nil).Once()
+    suite.expectRefundStatus(model.TransactionStatusRefunded)
+    suite.userRepo.On("FindById", 3).Return(model.User{ID: 3, Email: "donor@example.com"}, nil)
+    suite.mailService.On("Send", "donor@example.com", "Your donation has been refunded", mock.AnythingOfType("string")).Return(nil)
+
+    _, err := suite.tuc.RefundTransaction(1, model.RefundInput{Reason: "campaign cancelled", User: admin})
+    assert.NoError(suite.T(), err)
+    suite.transactionRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) TestRefundTransaction_ProviderFails() {
+    paid := model.Transaction{ID: 1, CampaignID: 2, UserID: 3, Amount: 100000, Status: model.TransactionStatusPaid, Code: "TRX-1"}
+    admin := model.User{ID: 9, Role: "admin"}
+    saved := model.Refund{ID: 8, TransactionID: 1, Amount: 100000, Reason: "fraud", Status: model.RefundStatusPending, RequestedBy: 9}
+
+    suite.transactionRepo.On("GetByID", 1).Return(paid, nil)
+    suite.expectRefundStatus(model.TransactionStatusRefundPending)
+    suite.refundRepo.On("SumRefunded", 1).Return(0, nil)
+    suite.refundRepo.On("Save", mock.AnythingOfType("model.Refund")).Return(saved, nil)
+    suite.paymentProvider.On("Refund", "TRX-1", "TRX-1-refund-8", 100000, "fraud").Return(model.PaymentRefund{}, errors.New("gateway down"))
+    suite.refundRepo.On("MarkFailed", mock.MatchedBy(func(refund model.Refund) bool {
+        return refund.ID == 8 && refund.Error == "gateway down"
+    })).Return(saved, nil)
+    suite.expectRefundStatus(model.TransactionStatusPaid)
+
+    _, err := suite.tuc.RefundTransaction(1, model.RefundInput{Reason: "fraud", User: admin})
+    assert.ErrorIs(suite.T(), err, ErrRefundFailed)
+    suite.refundRepo.AssertNotCalled(suite.T(), "MarkSucceeded", mock.Anything, mock.Anything)
+    suite.mailService.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything, mock.Anything)
+    suite.transactionRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUseCaseTestSuite) TestRefundTransaction_NotRecordedStaysRefundPending() {
+    paid := model.Transaction{ID: 1, CampaignID: 2, UserID: 3, Amount: 100000, Status: model.TransactionStatusPaid, Code: "TRX-1"}
+    admin := model.User{ID: 9, Role: "admin"}
+    saved := model.Refund{ID: 8, TransactionID: 1, Amount: 100000, Reason: "fraud", Status: model.RefundStatusPending, RequestedBy: 9}
+
+    suite.transactionRepo.On("GetByID", 1).Return(paid, nil)
+    suite.expectRefundStatus(model.TransactionStatusRefundPending)
+    suite.refundRepo.On("SumRefunded", 1).Return(0, nil)
+    suite.refundRepo.On("Save", mock.AnythingOfType("model.Refund")).Return(saved, nil)
+    suite.paymentProvider.On("Refund", "TRX-1", "TRX-1-refund-8", 100000, "fraud").Return(model.PaymentRefund{}, nil)
+    suite.refundRepo.On("MarkSucceeded", mock.AnythingOfType("model.Refund"), paid).Return(model.Refund{}, errors.New("connection reset"))
+
+    _, err := suite.tuc.RefundTransaction(1, model.RefundInput{Reason: "fraud", User: admin})
+    assert.Error(suite.T(), err)
+    suite.transactionRepo.AssertNumberOfCalls(suite.T(), "ApplyStatus", 1)
+    suite.refundRepo.AssertNotCalled(suite.T(), "MarkFailed", mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestRefundTransaction_ExceedsAmount() {
+    paid := model.Transaction{ID: 1, CampaignID: 2, Amount: 100000, Status: model.TransactionStatusPaid}
+    admin := model.User{ID: 9, Role: "admin"}
+
+    suite.transactionRepo.On("GetByID", 1).Return(paid, nil)
+    suite.expectRefundStatus(model.TransactionStatusRefundPending)
+    suite.refundRepo.On("SumRefunded", 1).Return(80000, nil)
+    suite.expectRefundStatus(model.TransactionStatusPaid)
+
+    _, err := suite.tuc.RefundTransaction(1, model.RefundInput{Amount: 30000, Reason: "too much", User: admin})
+    assert.ErrorIs(suite.T(), err, ErrRefundExceedsAmount)
+    suite.paymentProvider.AssertNotCalled(suite.T(), "Refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUseCaseTestSuite) TestRefundTransaction_NotOwner() {
+    paid := model.Transaction{ID: 1, CampaignID: 2, Amount: 100000, Status: model.TransactionStatusPaid}
+    suite.transactionRepo.On("GetByID", 1).Return(paid, nil)
+    suite.memberRepo.On("FindMembership", 2, 5).Return(model.CampaignMember{Role: model.CampaignRoleEditor}, nil)
+
+    _, err := suite.tuc.RefundTransaction(1, model.RefundInput{Reason: "please", User: model.User{ID: 5, Role: "user"}})
+    assert.ErrorIs(suite.T(), err, ErrCampaignForbidden)
+    suite.transactionRepo.AssertNotCalled(suite.T(), "ApplyStatus", mock.Anything)
+}
+
 func TestTransactionUseCaseTestSuite(t *testing.T) {
     suite.Run(t, new(TransactionUseCaseTestSuite))
 }
@@ -0,0 +1,314 @@
+package usecase
+
+import (
+	"errors"
+	"eternal-fund/model"
+	"eternal-fund/repository"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	trendingBackerWeight = 2.0
+	trendingHalfLife     = 3 * 24 * time.Hour
+	trendingCacheSize    = 100
+	trendingDefaultLimit = 10
+)
+
+type trendingUseCase struct {
+	rankingRepo   repository.CampaignRankingRepo
+	campaignsRepo repository.CampaignsRepo
+	exchangeUC    ExchangeRateUseCase
+
+	mu         sync.RWMutex
+	rankings   []model.CampaignRanking
+	featured   []model.FeaturedCampaign
+	computedAt time.Time
+}
+
+// scoreRanking rates a campaign by its decayed donations, so a gift counts
+// less the longer ago it was paid and long-running campaigns do not stay on
+// top forever.
+func scoreRanking(ranking model.CampaignRanking) float64 {
+	return math.Log1p(ranking.DecayedAmount) + trendingBackerWeight*ranking.DecayedBackers
+}
+
+func (t *trendingUseCase) RefreshRankings() error {
+	now := time.Now()
+	stats, err := t.rankingRepo.FindDonationVelocity(now, trendingHalfLife)
+	if err != nil {
+		return err
+	}
+
+	stats, err = t.inDefaultCurrency(stats)
+	if err != nil {
+		return err
+	}
+	for i := range stats {
+		stats[i].Score = scoreRanking(stats[i])
+		stats[i].ComputedAt = now
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Score > stats[j].Score
+	})
+	if len(stats) > trendingCacheSize {
+		stats = stats[:trendingCacheSize]
+	}
+	for i := range stats {
+		stats[i].Rank = i + 1
+	}
+
+	if err := t.rankingRepo.SaveRankings(stats); err != nil {
+		return err
+	}
+
+	rankings, err := t.attachRankingCampaigns(stats)
+	if err != nil {
+		return err
+	}
+	featured, err := t.loadFeatured(now)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.rankings = rankings
+	t.featured = featured
+	t.computedAt = now
+	t.mu.Unlock()
+
+	return nil
+}
+
+// inDefaultCurrency converts the decayed amounts the campaigns are scored by
+// into the default currency, so campaigns raising in different currencies
+// are compared by what they raised. A campaign whose currency has no rate is
+// left out of the ranking until one is set.
+func (t *trendingUseCase) inDefaultCurrency(stats []model.CampaignRanking) ([]model.CampaignRanking, error) {
+	converted := stats[:0]
+	for _, ranking := range stats {
+		amount, _, err := t.exchangeUC.Convert(int(math.Round(ranking.DecayedAmount)), ranking.Currency.OrDefault(), model.DefaultCurrency)
+		switch {
+		case errors.Is(err, ErrDonationTooSmall):
+			amount = 0
+		case errors.Is(err, ErrExchangeRateNotFound):
+			log.Printf("[TRENDING] leaving campaign %d out: no %s rate to %s", ranking.CampaignID, ranking.Currency, model.DefaultCurrency)
+			continue
+		case err != nil:
+			return nil, err
+		}
+		ranking.DecayedAmount = float64(amount)
+		converted = append(converted, ranking)
+	}
+	return converted, nil
+}
+
+func (t *trendingUseCase) GetTrending(limit int) (model.TrendingCampaigns, error) {
+	if limit <= 0 {
+		limit = trendingDefaultLimit
+	}
+
+	t.mu.RLock()
+	loaded := !t.computedAt.IsZero()
+	t.mu.RUnlock()
+
+	if !loaded {
+		if err := t.loadFromStore(); err != nil {
+			return model.TrendingCampaigns{}, err
+		}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := time.Now()
+	result := model.TrendingCampaigns{ComputedAt: t.computedAt}
+	for _, f := range t.featured {
+		if f.IsActive(now) {
+			result.Featured = append(result.Featured, f)
+		}
+	}
+
+	result.Trending = t.rankings
+	if len(result.Trending) > limit {
+		result.Trending = result.Trending[:limit]
+	}
+
+	return result, nil
+}
+
+func (t *trendingUseCase) ListFeatured() ([]model.FeaturedCampaign, error) {
+	return t.loadFeatured(time.Now())
+}
+
+func (t *trendingUseCase) CreateFeatured(input model.FeaturedCampaignInput) (model.FeaturedCampaign, error) {
+	if !input.EndsAt.After(input.StartsAt) {
+		return model.FeaturedCampaign{}, errors.New("ends_at must be after starts_at")
+	}
+
+	campaign, err := t.campaignsRepo.FindByIdCampaigns(input.CampaignID)
+	if err != nil {
+		return model.FeaturedCampaign{}, err
+	}
+
+	overlapping, err := t.rankingRepo.CountOverlappingFeatured(input.Slot, input.StartsAt, input.EndsAt)
+	if err != nil {
+		return model.FeaturedCampaign{}, err
+	}
+	if overlapping > 0 {
+		return model.FeaturedCampaign{}, errors.New("featured slot is already scheduled in this window")
+	}
+
+	featured, err := t.rankingRepo.CreateFeatured(model.FeaturedCampaign{
+		CampaignID: input.CampaignID,
+		Slot:       input.Slot,
+		StartsAt:   input.StartsAt,
+		EndsAt:     input.EndsAt,
+		CreatedBy:  input.User.ID,
+	})
+	if err != nil {
+		return model.FeaturedCampaign{}, err
+	}
+	featured.Campaign = campaign
+
+	if err := t.reloadFeatured(); err != nil {
+		return model.FeaturedCampaign{}, err
+	}
+
+	return featured, nil
+}
+
+func (t *trendingUseCase) DeleteFeatured(id int) error {
+	if err := t.rankingRepo.DeleteFeatured(id); err != nil {
+		return err
+	}
+	return t.reloadFeatured()
+}
+
+func (t *trendingUseCase) StartRefresher(interval time.Duration) {
+	runPeriodically("trending rankings", interval, t.RefreshRankings)
+}
+
+func (t *trendingUseCase) loadFromStore() error {
+	stored, err := t.rankingRepo.FindRankings(trendingCacheSize)
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		return t.RefreshRankings()
+	}
+
+	now := time.Now()
+	rankings, err := t.attachRankingCampaigns(stored)
+	if err != nil {
+		return err
+	}
+	featured, err := t.loadFeatured(now)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.rankings = rankings
+	t.featured = featured
+	t.computedAt = stored[0].ComputedAt
+	t.mu.Unlock()
+
+	return nil
+}
+
+func (t *trendingUseCase) reloadFeatured() error {
+	featured, err := t.loadFeatured(time.Now())
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.featured = featured
+	t.mu.Unlock()
+
+	return nil
+}
+
+func (t *trendingUseCase) loadFeatured(now time.Time) ([]model.FeaturedCampaign, error) {
+	featured, err := t.rankingRepo.FindUpcomingFeatured(now)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(featured))
+	for _, f := range featured {
+		ids = append(ids, f.CampaignID)
+	}
+	campaigns, err := t.campaignsByID(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.FeaturedCampaign
+	for _, f := range featured {
+		campaign, ok := campaigns[f.CampaignID]
+		if !ok {
+			continue
+		}
+		f.Campaign = campaign
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// attachRankingCampaigns fills in campaign details and drops rankings whose
+// campaign can no longer be loaded.
+func (t *trendingUseCase) attachRankingCampaigns(rankings []model.CampaignRanking) ([]model.CampaignRanking, error) {
+	ids := make([]int, 0, len(rankings))
+	for _, ranking := range rankings {
+		ids = append(ids, ranking.CampaignID)
+	}
+	campaigns, err := t.campaignsByID(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.CampaignRanking
+	for _, ranking := range rankings {
+		campaign, ok := campaigns[ranking.CampaignID]
+		if !ok {
+			continue
+		}
+		ranking.Campaign = campaign
+		result = append(result, ranking)
+	}
+	return result, nil
+}
+
+// campaignsByID loads the campaigns with the given ids in one query.
+func (t *trendingUseCase) campaignsByID(ids []int) (map[int]model.Campaigns, error) {
+	campaigns := map[int]model.Campaigns{}
+	if len(ids) == 0 {
+		return campaigns, nil
+	}
+	found, err := t.campaignsRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, campaign := range found {
+		campaigns[campaign.ID] = campaign
+	}
+	return campaigns, nil
+}
+
+type TrendingUseCase interface {
+	RefreshRankings() error
+	GetTrending(limit int) (model.TrendingCampaigns, error)
+	ListFeatured() ([]model.FeaturedCampaign, error)
+	CreateFeatured(input model.FeaturedCampaignInput) (model.FeaturedCampaign, error)
+	DeleteFeatured(id int) error
+	StartRefresher(interval time.Duration)
+}
+
+func NewTrendingUseCase(rankingRepo repository.CampaignRankingRepo, campaignsRepo repository.CampaignsRepo, exchangeUC ExchangeRateUseCase) TrendingUseCase {
+	return &trendingUseCase{rankingRepo: rankingRepo, campaignsRepo: campaignsRepo, exchangeUC: exchangeUC}
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"eternal-fund/mocking"
+	"eternal-fund/model"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type TrendingUseCaseTestSuite struct {
+	suite.Suite
+	tuc          *trendingUseCase
+	rankingRepo  *mocking.CampaignRankingRepoMock
+	campaignRepo *mocking.CampaignRepoMock
+	exchangeUC   *mocking.ExchangeRateUseCaseMock
+}
+
+func (suite *TrendingUseCaseTestSuite) SetupTest() {
+	suite.rankingRepo = new(mocking.CampaignRankingRepoMock)
+	suite.campaignRepo = new(mocking.CampaignRepoMock)
+	suite.exchangeUC = new(mocking.ExchangeRateUseCaseMock)
+	suite.tuc = &trendingUseCase{
+		rankingRepo:   suite.rankingRepo,
+		campaignsRepo: suite.campaignRepo,
+		exchangeUC:    suite.exchangeUC,
+	}
+}
+
+func (suite *TrendingUseCaseTestSuite) TestScoreRanking_RecentDonationsWin() {
+	// Five donations of 10000 paid today against the same paid a week ago.
+	today := math.Pow(0.5, 0.5/3)
+	lastWeek := math.Pow(0.5, 7.0/3)
+	recent := model.CampaignRanking{DecayedAmount: 50000 * today, DecayedBackers: 5 * today}
+	older := model.CampaignRanking{DecayedAmount: 50000 * lastWeek, DecayedBackers: 5 * lastWeek}
+
+	assert.Greater(suite.T(), scoreRanking(recent), scoreRanking(older))
+}
+
+func (suite *TrendingUseCaseTestSuite) TestScoreRanking_CountsBackers() {
+	many := model.CampaignRanking{DecayedAmount: 10000, DecayedBackers: 10}
+	few := model.CampaignRanking{DecayedAmount: 10000, DecayedBackers: 1}
+
+	assert.Greater(suite.T(), scoreRanking(many), scoreRanking(few))
+}
+
+func (suite *TrendingUseCaseTestSuite) TestRefreshRankings() {
+	stats := []model.CampaignRanking{
+		{CampaignID: 1, Amount_30d: 1000, Backer_count_30d: 1, DecayedAmount: 10, DecayedBackers: 0.01},
+		{CampaignID: 2, Amount_24h: 9000, Backer_count_24h: 3, Amount_7d: 9000, Backer_count_7d: 3, Amount_30d: 9000, Backer_count_30d: 3,
+			DecayedAmount: 8000, DecayedBackers: 2.7},
+		{CampaignID: 3, Amount_30d: 500, Backer_count_30d: 1, DecayedAmount: 5, DecayedBackers: 0.01},
+	}
+
+	suite.rankingRepo.On("FindDonationVelocity", mock.AnythingOfType("time.Time"), trendingHalfLife).Return(stats, nil)
+	for _, amount := range []int{10, 8000, 5} {
+		suite.exchangeUC.On("Convert", amount, model.CurrencyIDR, model.CurrencyIDR).Return(amount, "1", nil)
+	}
+	suite.rankingRepo.On("SaveRankings", mock.AnythingOfType("[]model.CampaignRanking")).Return(nil)
+	suite.rankingRepo.On("FindUpcomingFeatured", mock.AnythingOfType("time.Time")).Return([]model.FeaturedCampaign{}, nil)
+	// Campaign 3 was deleted since it was paid, so it is not found.
+	suite.campaignRepo.On("FindByIDs", []int{2, 1, 3}).Return([]model.Campaigns{{ID: 1}, {ID: 2}}, nil).Once()
+
+	err := suite.tuc.RefreshRankings()
+	assert.NoError(suite.T(), err)
+
+	trending, err := suite.tuc.GetTrending(10)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trending.Trending, 2)
+	assert.Equal(suite.T(), 2, trending.Trending[0].CampaignID)
+	assert.Equal(suite.T(), 2, trending.Trending[0].Campaign.ID)
+	assert.Equal(suite.T(), 1, trending.Trending[0].Rank)
+	assert.Equal(suite.T(), 2, trending.Trending[1].Rank)
+	suite.rankingRepo.AssertExpectations(suite.T())
+	suite.campaignRepo.AssertNotCalled(suite.T(), "FindByIdCampaigns", mock.Anything)
+}
+
+func (suite *TrendingUseCaseTestSuite) TestListFeatured_LoadsCampaignsAtOnce() {
+	now := time.Now()
+	featured := []model.FeaturedCampaign{
+		{ID: 1, CampaignID: 4, StartsAt: now, EndsAt: now.Add(time.Hour)},
+		{ID: 2, CampaignID: 5, StartsAt: now, EndsAt: now.Add(time.Hour)},
+	}
+	suite.rankingRepo.On("FindUpcomingFeatured", mock.AnythingOfType("time.Time")).Return(featured, nil)
+	suite.campaignRepo.On("FindByIDs", []int{4, 5}).Return([]model.Campaigns{{ID: 5, Name: "Clean Water"}}, nil).Once()
+
+	result, err := suite.tuc.ListFeatured()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), result, 1)
+	assert.Equal(suite.T(), "Clean Water", result[0].Campaign.Name)
+}
+
+func (suite *TrendingUseCaseTestSuite) TestGetTrending_OnlyActiveFeatured() {
+	now := time.Now()
+	suite.tuc.computedAt = now
+	suite.tuc.featured = []model.FeaturedCampaign{
+		{ID: 1, CampaignID: 1, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+		{ID: 2, CampaignID: 2, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+	}
+
+	trending, err := suite.tuc.GetTrending(10)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trending.Featured, 1)
+	assert.Equal(suite.T(), 1, trending.Featured[0].ID)
+}
+
+func (suite *TrendingUseCaseTestSuite) TestCreateFeatured_OverlappingSlot() {
+	now := time.Now()
+	input := model.FeaturedCampaignInput{CampaignID: 1, Slot: 1, StartsAt: now, EndsAt: now.Add(time.Hour)}
+
+	suite.campaignRepo.On("FindByIdCampaigns", 1).Return(model.Campaigns{ID: 1}, nil)
+	suite.rankingRepo.On("CountOverlappingFeatured", 1, input.StartsAt, input.EndsAt).Return(1, nil)
+
+	_, err := suite.tuc.CreateFeatured(input)
+	assert.Error(suite.T(), err)
+	suite.rankingRepo.AssertNotCalled(suite.T(), "CreateFeatured", mock.Anything)
+}
+
+func (suite *TrendingUseCaseTestSuite) TestRefreshRankings_ComparesInDefaultCurrency() {
+	stats := []model.CampaignRanking{
+		{CampaignID: 1, Currency: model.CurrencyIDR, DecayedAmount: 500000, DecayedBackers: 1},
+		{CampaignID: 2, Currency: model.CurrencyUSD, DecayedAmount: 5000, DecayedBackers: 1},
+		{CampaignID: 3, Currency: model.CurrencySGD, DecayedAmount: 5000, DecayedBackers: 1},
+	}
+
+	suite.rankingRepo.On("FindDonationVelocity", mock.AnythingOfType("time.Time"), trendingHalfLife).Return(stats, nil)
+	suite.exchangeUC.On("Convert", 500000, model.CurrencyIDR, model.CurrencyIDR).Return(500000, "1", nil)
+	suite.exchangeUC.On("Convert", 5000, model.CurrencyUSD, model.CurrencyIDR).Return(800000, "16000", nil)
+	suite.exchangeUC.On("Convert", 5000, model.CurrencySGD, model.CurrencyIDR).Return(0, "", ErrExchangeRateNotFound)
+	suite.rankingRepo.On("SaveRankings", mock.MatchedBy(func(rankings []model.CampaignRanking) bool {
+		return len(rankings) == 2 && rankings[0].CampaignID == 2 && rankings[1].CampaignID == 1
+	})).Return(nil)
+	suite.rankingRepo.On("FindUpcomingFeatured", mock.AnythingOfType("time.Time")).Return([]model.FeaturedCampaign{}, nil)
+	suite.campaignRepo.On("FindByIDs", []int{2, 1}).Return([]model.Campaigns{{ID: 1}, {ID: 2}}, nil)
+
+	err := suite.tuc.RefreshRankings()
+	assert.NoError(suite.T(), err)
+	suite.rankingRepo.AssertExpectations(suite.T())
+}
+
+func TestTrendingUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(TrendingUseCaseTestSuite))
+}
@@ -6,12 +6,24 @@ import (
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
 	"eternal-fund/repository"
+	"eternal-fund/usecase/service"
+	"fmt"
+	"log"
+	"net/url"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrUserForbidden        = errors.New("you are not allowed to manage this user")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+)
+
 type userUseCase struct {
-	repo repository.UserRepo
+	repo        repository.UserRepo
+	mailService service.MailService
+	jwtService  service.JwtService
+	baseURL     string
 }
 
 func (u *userUseCase) RegisterUser(input model.RegisterUserInput) (model.User, error) {
@@ -28,7 +40,55 @@ func (u *userUseCase) RegisterUser(input model.RegisterUserInput) (model.User, e
 		Role:         "user",
 	}
 
-	return u.repo.Save(user)
+	user, err = u.repo.Save(user)
+	if err != nil {
+		return model.User{}, err
+	}
+	if err := u.sendVerification(user); err != nil {
+		log.Printf("Error sending verification email to user %d: %v", user.ID, err)
+	}
+	return user, nil
+}
+
+// SendVerificationEmail sends the user a new link to verify their email.
+func (u *userUseCase) SendVerificationEmail(userID int) error {
+	user, err := u.repo.FindById(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	return u.sendVerification(user)
+}
+
+func (u *userUseCase) sendVerification(user model.User) error {
+	token, err := u.jwtService.CreateLinkToken(service.LinkPurposeVerifyEmail, user.Email)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hi %s,\n\n"+
+		"Please confirm your email address by opening this link:\n%s/api/v1/users/verify-email?token=%s\n",
+		user.Name, u.baseURL, url.QueryEscape(token))
+	return u.mailService.Send(user.Email, "Verify your email", body)
+}
+
+// VerifyEmail marks the email a verification link was sent to as verified.
+// The link stops working once the account no longer uses that email.
+func (u *userUseCase) VerifyEmail(token string) (model.User, error) {
+	email, err := u.jwtService.ValidateLinkToken(service.LinkPurposeVerifyEmail, token)
+	if err != nil {
+		return model.User{}, err
+	}
+	user, err := u.repo.MarkEmailVerified(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, service.ErrInvalidLinkToken
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	user.PasswordHash = ""
+	return user, nil
 }
 
 func (u *userUseCase) UpdateUser(userId int, input model.User) (model.User, error) {
@@ -73,6 +133,35 @@ func (u *userUseCase) FindByEmail(email string) (model.User, error) {
 	return u.repo.FindByEmail(email)
 }
 
+// DeleteUser soft deletes an account. Users may delete themselves and admins
+// may delete anyone.
+func (u *userUseCase) DeleteUser(id int, actor model.User) error {
+	if actor.Role != "admin" && actor.ID != id {
+		return ErrUserForbidden
+	}
+	return u.repo.Delete(id)
+}
+
+func (u *userUseCase) FindDeleted() ([]model.User, error) {
+	users, err := u.repo.FindDeleted()
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].PasswordHash = ""
+	}
+	return users, nil
+}
+
+func (u *userUseCase) RestoreUser(id int) (model.User, error) {
+	user, err := u.repo.Restore(id)
+	if err != nil {
+		return model.User{}, err
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
+
 type UserUseCase interface {
 	RegisterUser(input model.RegisterUserInput) (model.User, error)
 	UpdateUser(userId int, input model.User) (model.User, error)
@@ -81,8 +170,13 @@ type UserUseCase interface {
 	FindAll(page int, size int) ([]model.User, dto.Paging, error)
 	FindById(id int) (model.User, error)
 	FindByEmail(email string) (model.User, error)
+	DeleteUser(id int, actor model.User) error
+	FindDeleted() ([]model.User, error)
+	RestoreUser(id int) (model.User, error)
+	SendVerificationEmail(userID int) error
+	VerifyEmail(token string) (model.User, error)
 }
 
-func NewUserUseCase(repo repository.UserRepo) UserUseCase {
-	return &userUseCase{repo: repo}
+func NewUserUseCase(repo repository.UserRepo, mailService service.MailService, jwtService service.JwtService, baseURL string) UserUseCase {
+	return &userUseCase{repo: repo, mailService: mailService, jwtService: jwtService, baseURL: baseURL}
 }
@@ -6,6 +6,8 @@ import (
 	"eternal-fund/mocking"
 	"eternal-fund/model"
 	"eternal-fund/model/dto"
+	"eternal-fund/usecase/service"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,11 +20,15 @@ type UsersUseCaseTestSuite struct {
 	suite.Suite
 	uuc          *userUseCase
 	userRepoMock *mocking.UserUseCaseMock
+	mailService  *mocking.MailServiceMock
+	jwtService   *mocking.JwtServiceMock
 }
 
 func (suite *UsersUseCaseTestSuite) SetupTest() {
 	suite.userRepoMock = new(mocking.UserUseCaseMock)
-	suite.uuc = &userUseCase{repo: suite.userRepoMock}
+	suite.mailService = new(mocking.MailServiceMock)
+	suite.jwtService = new(mocking.JwtServiceMock)
+	suite.uuc = &userUseCase{repo: suite.userRepoMock, mailService: suite.mailService, jwtService: suite.jwtService, baseURL: "http://localhost:2000"}
 }
 func (suite *UsersUseCaseTestSuite) TestIsEmailAvailable_Success() {
 	mockRepo := &mocking.UserUseCaseMock{}
@@ -121,11 +127,47 @@ func (suite *UsersUseCaseTestSuite) TestRegister_Success() {
 		UpdatedAt:    time.Now(),
 	}
 	mockRepo.On("Save", mock.AnythingOfType("model.User")).Return(expectedUser, nil)
+	suite.jwtService.On("CreateLinkToken", service.LinkPurposeVerifyEmail, input.Email).Return("verify-token", nil)
+	suite.mailService.On("Send", input.Email, "Verify your email",
+		mock.MatchedBy(func(body string) bool { return strings.Contains(body, "/api/v1/users/verify-email?token=verify-token") })).Return(nil)
 	registeredUser, err := suite.uuc.RegisterUser(input)
 
 	assert.NoError(suite.T(), err, "Expected no error")
 	assert.Equal(suite.T(), expectedUser.Name, registeredUser.Name, "Expected registered user's name to match")
 	assert.Equal(suite.T(), expectedUser.Email, registeredUser.Email, "Expected registered user's email to match")
+	suite.mailService.AssertExpectations(suite.T())
+}
+
+func (suite *UsersUseCaseTestSuite) TestVerifyEmail_Success() {
+	verifiedAt := time.Now()
+	suite.jwtService.On("ValidateLinkToken", service.LinkPurposeVerifyEmail, "verify-token").Return("john.doe@example.com", nil)
+	suite.userRepoMock.On("MarkEmailVerified", "john.doe@example.com").
+		Return(model.User{ID: 1, Email: "john.doe@example.com", PasswordHash: "hashed_password", EmailVerifiedAt: &verifiedAt}, nil)
+
+	user, err := suite.uuc.VerifyEmail("verify-token")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), &verifiedAt, user.EmailVerifiedAt)
+	assert.Empty(suite.T(), user.PasswordHash)
+}
+
+func (suite *UsersUseCaseTestSuite) TestVerifyEmail_EmailNoLongerUsed() {
+	suite.jwtService.On("ValidateLinkToken", service.LinkPurposeVerifyEmail, "verify-token").Return("old@example.com", nil)
+	suite.userRepoMock.On("MarkEmailVerified", "old@example.com").Return(model.User{}, sql.ErrNoRows)
+
+	_, err := suite.uuc.VerifyEmail("verify-token")
+
+	assert.ErrorIs(suite.T(), err, service.ErrInvalidLinkToken)
+}
+
+func (suite *UsersUseCaseTestSuite) TestSendVerificationEmail_AlreadyVerified() {
+	verifiedAt := time.Now()
+	suite.userRepoMock.On("FindById", 1).Return(model.User{ID: 1, EmailVerifiedAt: &verifiedAt}, nil)
+
+	err := suite.uuc.SendVerificationEmail(1)
+
+	assert.ErrorIs(suite.T(), err, ErrEmailAlreadyVerified)
+	suite.mailService.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (suite *UsersUseCaseTestSuite) TestRegisterUser_FailedBySaveError() {
@@ -178,6 +220,28 @@ func (suite *UsersUseCaseTestSuite) TestIsEmailAvailable_FailedByUnexpectedError
 	assert.Equal(suite.T(), "unexpected error", err.Error(), "Expected error message to match")
 }
 
+func (suite *UsersUseCaseTestSuite) TestDeleteUser_Self() {
+	suite.userRepoMock.On("Delete", 3).Return(nil)
+
+	err := suite.uuc.DeleteUser(3, model.User{ID: 3, Role: "user"})
+	assert.NoError(suite.T(), err)
+	suite.userRepoMock.AssertExpectations(suite.T())
+}
+
+func (suite *UsersUseCaseTestSuite) TestDeleteUser_OtherUserForbidden() {
+	err := suite.uuc.DeleteUser(3, model.User{ID: 4, Role: "user"})
+	assert.ErrorIs(suite.T(), err, ErrUserForbidden)
+	suite.userRepoMock.AssertNotCalled(suite.T(), "Delete", mock.Anything)
+}
+
+func (suite *UsersUseCaseTestSuite) TestRestoreUser_HidesPassword() {
+	suite.userRepoMock.On("Restore", 3).Return(model.User{ID: 3, PasswordHash: "hash"}, nil)
+
+	user, err := suite.uuc.RestoreUser(3)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), user.PasswordHash)
+}
+
 func TestUsersUseCaseTestSuite(t *testing.T) {
 	suite.Run(t, new(UsersUseCaseTestSuite))
 }
@@ -7,3 +7,10 @@ type CustomClaims struct {
 	Role     string `json:"role"`
 	UserId string `json:"userId"`
 }
+
+// LinkClaims are the claims of a token embedded in an emailed link. The
+// purpose keeps a link issued for one flow from being accepted by another.
+type LinkClaims struct {
+	jwt.RegisteredClaims
+	Purpose string `json:"purpose"`
+}